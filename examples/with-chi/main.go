@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"github.com/supertokens/supertokens-golang/framework/chiadapter"
 	"github.com/supertokens/supertokens-golang/recipe/dashboard"
 	"github.com/supertokens/supertokens-golang/recipe/emailpassword"
 	"github.com/supertokens/supertokens-golang/recipe/emailverification"
@@ -170,15 +171,15 @@ func main() {
 		AllowCredentials: true,
 	}))
 
-	r.Use(supertokens.Middleware)
+	r.Use(chiadapter.Middleware(nil))
 
-	r.Get("/sessioninfo", session.VerifySession(nil, sessioninfo))
+	r.With(chiadapter.VerifySession(nil, nil)).Get("/sessioninfo", sessioninfo)
 
 	http.ListenAndServe(":3001", r)
 }
 
 func sessioninfo(w http.ResponseWriter, r *http.Request) {
-	sessionContainer := session.GetSessionFromRequestContext(r.Context())
+	sessionContainer := chiadapter.GetSessionFromContext(r)
 
 	if sessionContainer == nil {
 		w.WriteHeader(500)
@@ -2,17 +2,15 @@ package main
 
 import (
 	"log"
-	"net/http"
 
 	"github.com/supertokens/supertokens-golang/recipe/dashboard"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/supertokens/supertokens-golang/framework/fiberadapter"
 	"github.com/supertokens/supertokens-golang/recipe/emailverification"
 	"github.com/supertokens/supertokens-golang/recipe/emailverification/evmodels"
 	"github.com/supertokens/supertokens-golang/recipe/session"
-	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
 	"github.com/supertokens/supertokens-golang/recipe/thirdparty"
 	"github.com/supertokens/supertokens-golang/recipe/thirdparty/tpmodels"
 	"github.com/supertokens/supertokens-golang/supertokens"
@@ -172,36 +170,14 @@ func main() {
 	}))
 
 	//adding the supertokens middleware
-	app.Use(adaptor.HTTPMiddleware(supertokens.Middleware))
+	app.Use(fiberadapter.Middleware(nil))
 
-	app.Get("/sessionInfo", verifySession(nil), sessioninfo)
+	app.Get("/sessionInfo", fiberadapter.VerifySession(nil, nil), sessioninfo)
 	log.Fatal(app.Listen(":3001"))
 }
 
-// wrapper of the original implementation of verify session to match the required function signature
-func verifySession(options *sessmodels.VerifySessionOptions) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		var errFromNextHandler error
-		err := adaptor.HTTPHandlerFunc(session.VerifySession(options, func(rw http.ResponseWriter, r *http.Request) {
-			c.SetUserContext(r.Context())
-			errFromNextHandler = c.Next()
-
-			if errFromNextHandler != nil {
-				// just in case a supertokens error was returned, we call the supertokens error handler
-				// also, if supertokens error was handled, we don't want to return it, hence updating errFromNextHandler
-				errFromNextHandler = supertokens.ErrorHandler(errFromNextHandler, r, rw)
-			}
-		}))(c)
-
-		if err != nil {
-			return err
-		}
-		return errFromNextHandler
-	}
-}
-
 func sessioninfo(c *fiber.Ctx) error {
-	sessionContainer := session.GetSessionFromRequestContext(c.UserContext())
+	sessionContainer := fiberadapter.GetSessionFromContext(c)
 	if sessionContainer == nil {
 		return c.Status(500).JSON("no session found")
 	}
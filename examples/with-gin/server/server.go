@@ -1,14 +1,12 @@
 package server
 
 import (
-	"net/http"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/supertokens/supertokens-golang/framework/ginadapter"
 	"github.com/supertokens/supertokens-golang/examples/with-gin/config"
-	"github.com/supertokens/supertokens-golang/recipe/session"
-	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
 	"github.com/supertokens/supertokens-golang/supertokens"
 )
 
@@ -29,16 +27,10 @@ func Init() {
 	}))
 
 	// Adding the SuperTokens middleware
-	router.Use(func(c *gin.Context) {
-		supertokens.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-			c.Next()
-		})).ServeHTTP(c.Writer, c.Request)
-		// we call Abort so that the next handler in the chain is not called, unless we call Next explicitly
-		c.Abort()
-	})
+	router.Use(ginadapter.Middleware(nil))
 
 	// Adding an API that requires session verification
-	router.GET("/sessioninfo", verifySession(nil), sessioninfo)
+	router.GET("/sessioninfo", ginadapter.VerifySession(nil, nil), sessioninfo)
 
 	// starting the server
 	err := router.Run(config.GetString("Server.Address"))
@@ -47,21 +39,8 @@ func Init() {
 	}
 }
 
-// This is a function that wraps the supertokens verification function
-// to work the gin
-func verifySession(options *sessmodels.VerifySessionOptions) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		session.VerifySession(options, func(rw http.ResponseWriter, r *http.Request) {
-			c.Request = c.Request.WithContext(r.Context())
-			c.Next()
-		})(c.Writer, c.Request)
-		// we call Abort so that the next handler in the chain is not called, unless we call Next explicitly
-		c.Abort()
-	}
-}
-
 func sessioninfo(c *gin.Context) {
-	sessionContainer := session.GetSessionFromRequestContext(c.Request.Context())
+	sessionContainer := ginadapter.GetSessionFromContext(c)
 	if sessionContainer == nil {
 		c.JSON(500, "no session found")
 		return
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/supertokens/supertokens-golang/framework/gozeroadapter"
 	"github.com/supertokens/supertokens-golang/recipe/dashboard"
 
 	"github.com/supertokens/supertokens-golang/recipe/emailverification"
@@ -167,15 +168,13 @@ func main() {
 	defer server.Stop()
 
 	server.Use(corsMiddleware)
-	server.Use(rest.ToMiddleware(supertokens.Middleware))
-	addSupertokensRoutes("/auth", server) // go-zero doesn't execute middlewares if matching routes are not found
-	server.AddRoute(rest.Route{
-		Method: http.MethodGet,
-		Path:   "/sessioninfo",
-		Handler: func(rw http.ResponseWriter, r *http.Request) {
-			session.VerifySession(nil, sessioninfo).ServeHTTP(rw, r)
-		},
-	})
+	server.Use(gozeroadapter.Middleware(nil))
+	server.AddRoutes(gozeroadapter.APIRoutes("/auth")) // go-zero doesn't execute middlewares if matching routes are not found
+	server.AddRoutes(rest.WithMiddleware(gozeroadapter.VerifySession(nil, nil), rest.Route{
+		Method:  http.MethodGet,
+		Path:    "/sessioninfo",
+		Handler: sessioninfo,
+	}))
 
 	// go-zero does not honour middlewares for CORS requests unless we explicitly add a route for it.
 	server.AddRoute(rest.Route{
@@ -189,7 +188,7 @@ func main() {
 }
 
 func sessioninfo(w http.ResponseWriter, r *http.Request) {
-	sessionContainer := session.GetSessionFromRequestContext(r.Context())
+	sessionContainer := gozeroadapter.GetSessionFromContext(r)
 
 	if sessionContainer == nil {
 		w.WriteHeader(500)
@@ -233,28 +232,3 @@ func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		}
 	}
 }
-
-func addSupertokensRoutes(prefix string, server *rest.Server) {
-	methods := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions}
-	routes := []string{
-		prefix,
-		prefix + "/:a",
-		prefix + "/:a/:b",
-		prefix + "/:a/:b/:c",
-		prefix + "/:a/:b/:c/:d",
-		prefix + "/:a/:b/:c/:d/:e",
-		prefix + "/:a/:b/:c/:d/:e/:f",
-	}
-
-	for _, method := range methods {
-		for _, route := range routes {
-			server.AddRoute(rest.Route{
-				Method: method,
-				Path:   route,
-				Handler: func(w http.ResponseWriter, r *http.Request) {
-					http.NotFound(w, r) // Return not found if not handled by middleware
-				},
-			})
-		}
-	}
-}
@@ -0,0 +1,34 @@
+// Package chiadapter integrates SuperTokens with chi (https://github.com/go-chi/chi). Since chi is based on
+// net/http, the adapters only give the SuperTokens handlers the signature of chi middlewares.
+package chiadapter
+
+import (
+	"net/http"
+
+	"github.com/supertokens/supertokens-golang/internal/instancebinding"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// Middleware returns a chi middleware that serves the APIs exposed by the SuperTokens instance (sign in,
+// session refresh, etc), or by the one initialised using supertokens.Init if instance is nil. Requests for
+// other paths are passed on to the next handler. It can be passed to chi's Router.Use.
+func Middleware(instance *supertokens.Instance) func(next http.Handler) http.Handler {
+	return instancebinding.Middleware(instance)
+}
+
+// VerifySession returns a chi middleware that verifies the session of the request, using the SuperTokens
+// instance (or the one initialised using supertokens.Init if instance is nil), before calling the next
+// handler. It can be passed to chi's Router.Use or Router.With. Use GetSessionFromContext to get the session
+// in that handler.
+func VerifySession(instance *supertokens.Instance, options *sessmodels.VerifySessionOptions) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return instancebinding.Handler(instance, session.VerifySession(options, next.ServeHTTP))
+	}
+}
+
+// GetSessionFromContext returns the session verified by VerifySession, or nil if there is none
+func GetSessionFromContext(r *http.Request) sessmodels.SessionContainer {
+	return session.GetSessionFromRequestContext(r.Context())
+}
@@ -0,0 +1,60 @@
+package chiadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/internal/adaptertesting"
+)
+
+func makeRouter() chi.Router {
+	r := chi.NewRouter()
+	r.Use(Middleware(nil))
+	r.Get("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("world"))
+	})
+	r.With(VerifySession(nil, nil)).Get("/sessioninfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(GetSessionFromContext(r).GetUserID()))
+	})
+	r.With(VerifySession(nil, adaptertesting.OptionalSessionOptions())).Get("/optional", func(w http.ResponseWriter, r *http.Request) {
+		if GetSessionFromContext(r) == nil {
+			w.Write([]byte("no session"))
+			return
+		}
+		w.Write([]byte("session"))
+	})
+	return r
+}
+
+func TestMiddlewareHandlesSuperTokensAPIs(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	r := makeRouter()
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/auth/session/refresh", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/hello", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "world", res.Body.String())
+}
+
+func TestVerifySession(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	r := makeRouter()
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/sessioninfo", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/optional", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "no session", res.Body.String())
+}
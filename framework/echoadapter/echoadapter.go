@@ -0,0 +1,56 @@
+// Package echoadapter integrates SuperTokens with echo (https://github.com/labstack/echo).
+package echoadapter
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/supertokens/supertokens-golang/internal/instancebinding"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// Middleware returns an echo middleware that serves the APIs exposed by the SuperTokens instance (sign in,
+// session refresh, etc), or by the one initialised using supertokens.Init if instance is nil. Requests for
+// other paths are passed on to the next handler.
+func Middleware(instance *supertokens.Instance) echo.MiddlewareFunc {
+	middleware := instancebinding.Middleware(instance)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var errFromNextHandler error
+			middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				errFromNextHandler = next(c)
+			})).ServeHTTP(c.Response(), c.Request())
+			return errFromNextHandler
+		}
+	}
+}
+
+// VerifySession returns an echo middleware that verifies the session of the request, using the SuperTokens
+// instance (or the one initialised using supertokens.Init if instance is nil), before calling the next
+// handler. Use GetSessionFromContext to get the session in that handler.
+//
+// SuperTokens errors returned by the handler (for example from session.ValidateClaims) are passed to the
+// error handler of the instance. Other errors are returned to echo.
+func VerifySession(instance *supertokens.Instance, options *sessmodels.VerifySessionOptions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var errFromNextHandler error
+			instancebinding.Handler(instance, session.VerifySession(options, func(rw http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				errFromNextHandler = next(c)
+				if errFromNextHandler != nil {
+					errFromNextHandler = instancebinding.ErrorHandler(instance, errFromNextHandler, r, rw)
+				}
+			}))(c.Response(), c.Request())
+			return errFromNextHandler
+		}
+	}
+}
+
+// GetSessionFromContext returns the session verified by VerifySession, or nil if there is none
+func GetSessionFromContext(c echo.Context) sessmodels.SessionContainer {
+	return session.GetSessionFromRequestContext(c.Request().Context())
+}
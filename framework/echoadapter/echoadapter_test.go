@@ -0,0 +1,81 @@
+package echoadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/internal/adaptertesting"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+)
+
+func makeServer() *echo.Echo {
+	e := echo.New()
+	e.Use(Middleware(nil))
+	e.GET("/hello", func(c echo.Context) error {
+		return c.String(http.StatusOK, "world")
+	})
+	e.GET("/sessioninfo", func(c echo.Context) error {
+		return c.String(http.StatusOK, GetSessionFromContext(c).GetUserID())
+	}, VerifySession(nil, nil))
+	e.GET("/optional", func(c echo.Context) error {
+		if GetSessionFromContext(c) == nil {
+			return c.String(http.StatusOK, "no session")
+		}
+		return c.String(http.StatusOK, "session")
+	}, VerifySession(nil, adaptertesting.OptionalSessionOptions()))
+	e.GET("/error", func(c echo.Context) error {
+		return errors.UnauthorizedError{Msg: "unauthorised"}
+	}, VerifySession(nil, adaptertesting.OptionalSessionOptions()))
+	e.GET("/othererror", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot)
+	}, VerifySession(nil, adaptertesting.OptionalSessionOptions()))
+	return e
+}
+
+func TestMiddlewareHandlesSuperTokensAPIs(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	e := makeServer()
+
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/auth/session/refresh", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+
+	res = httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/hello", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "world", res.Body.String())
+}
+
+func TestVerifySession(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	e := makeServer()
+
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/sessioninfo", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+
+	res = httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/optional", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "no session", res.Body.String())
+}
+
+func TestVerifySessionHandlesSuperTokensErrors(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	e := makeServer()
+
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+
+	// errors that are not from SuperTokens are handled by echo
+	res = httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/othererror", nil))
+	assert.Equal(t, http.StatusTeapot, res.Code)
+}
@@ -0,0 +1,63 @@
+// Package fiberadapter integrates SuperTokens with fiber (https://github.com/gofiber/fiber). Since fiber is
+// not based on net/http, requests are converted using fiber's adaptor middleware.
+package fiberadapter
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/supertokens/supertokens-golang/internal/instancebinding"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// Middleware returns a fiber handler that serves the APIs exposed by the SuperTokens instance (sign in,
+// session refresh, etc), or by the one initialised using supertokens.Init if instance is nil. Requests for
+// other paths are passed on to the next handler.
+func Middleware(instance *supertokens.Instance) fiber.Handler {
+	return adaptor.HTTPMiddleware(instancebinding.Middleware(instance))
+}
+
+// VerifySession returns a fiber handler that verifies the session of the request, using the SuperTokens
+// instance (or the one initialised using supertokens.Init if instance is nil), before calling the next
+// handler. Use GetSessionFromContext to get the session in that handler.
+//
+// SuperTokens errors returned by the handler (for example from session.ValidateClaims) are passed to the
+// error handler of the instance. Other errors are returned to fiber.
+func VerifySession(instance *supertokens.Instance, options *sessmodels.VerifySessionOptions) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var verifiedRequest *http.Request
+		err := adaptor.HTTPHandlerFunc(instancebinding.Handler(instance, session.VerifySession(options, func(rw http.ResponseWriter, r *http.Request) {
+			verifiedRequest = r
+		})))(c)
+		if err != nil {
+			return err
+		}
+		if verifiedRequest == nil {
+			// the response has already been sent by VerifySession
+			return nil
+		}
+
+		// the request context holds the session
+		c.SetUserContext(verifiedRequest.Context())
+		errFromNextHandler := c.Next()
+		if errFromNextHandler == nil {
+			return nil
+		}
+
+		err = adaptor.HTTPHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			errFromNextHandler = instancebinding.ErrorHandler(instance, errFromNextHandler, r, rw)
+		})(c)
+		if err != nil {
+			return err
+		}
+		return errFromNextHandler
+	}
+}
+
+// GetSessionFromContext returns the session verified by VerifySession, or nil if there is none
+func GetSessionFromContext(c *fiber.Ctx) sessmodels.SessionContainer {
+	return session.GetSessionFromRequestContext(c.UserContext())
+}
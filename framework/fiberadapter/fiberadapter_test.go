@@ -0,0 +1,91 @@
+package fiberadapter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/internal/adaptertesting"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+)
+
+func makeApp() *fiber.App {
+	app := fiber.New()
+	app.Use(Middleware(nil))
+	app.Get("/hello", func(c *fiber.Ctx) error {
+		return c.Status(http.StatusOK).SendString("world")
+	})
+	app.Get("/sessioninfo", VerifySession(nil, nil), func(c *fiber.Ctx) error {
+		return c.SendString(GetSessionFromContext(c).GetUserID())
+	})
+	app.Get("/optional", VerifySession(nil, adaptertesting.OptionalSessionOptions()), func(c *fiber.Ctx) error {
+		if GetSessionFromContext(c) == nil {
+			return c.Status(http.StatusTeapot).SendString("no session")
+		}
+		return c.SendString("session")
+	})
+	app.Get("/error", VerifySession(nil, adaptertesting.OptionalSessionOptions()), func(c *fiber.Ctx) error {
+		return errors.UnauthorizedError{Msg: "unauthorised"}
+	})
+	app.Get("/othererror", VerifySession(nil, adaptertesting.OptionalSessionOptions()), func(c *fiber.Ctx) error {
+		return fiber.NewError(http.StatusConflict)
+	})
+	return app
+}
+
+func readBody(t *testing.T, res *http.Response) string {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return string(body)
+}
+
+func TestMiddlewareHandlesSuperTokensAPIs(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	app := makeApp()
+
+	res, err := app.Test(httptest.NewRequest(http.MethodPost, "/auth/session/refresh", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.StatusCode)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/hello", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "world", readBody(t, res))
+}
+
+func TestVerifySession(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	app := makeApp()
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/sessioninfo", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.StatusCode)
+
+	// the status code set by the handler is kept
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/optional", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, res.StatusCode)
+	assert.Equal(t, "no session", readBody(t, res))
+}
+
+func TestVerifySessionHandlesSuperTokensErrors(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	app := makeApp()
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.StatusCode)
+
+	// errors that are not from SuperTokens are handled by fiber
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/othererror", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
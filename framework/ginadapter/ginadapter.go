@@ -0,0 +1,56 @@
+// Package ginadapter integrates SuperTokens with gin (https://github.com/gin-gonic/gin).
+package ginadapter
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/supertokens/supertokens-golang/internal/instancebinding"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// Middleware returns a gin middleware that serves the APIs exposed by the SuperTokens instance (sign in,
+// session refresh, etc), or by the one initialised using supertokens.Init if instance is nil. Requests for
+// other paths are passed on to the next handler in the chain.
+func Middleware(instance *supertokens.Instance) gin.HandlerFunc {
+	middleware := instancebinding.Middleware(instance)
+	return func(c *gin.Context) {
+		middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			c.Request = r
+			c.Next()
+		})).ServeHTTP(c.Writer, c.Request)
+		// we call Abort so that the next handler in the chain is not called, unless we call Next explicitly
+		c.Abort()
+	}
+}
+
+// VerifySession returns a gin middleware that verifies the session of the request, using the SuperTokens
+// instance (or the one initialised using supertokens.Init if instance is nil), before calling the next
+// handler in the chain. Use GetSessionFromContext to get the session in that handler.
+//
+// If the handler adds a SuperTokens error (for example from session.ValidateClaims) using c.Error and has
+// not written a response, the error is passed to the error handler of the instance.
+func VerifySession(instance *supertokens.Instance, options *sessmodels.VerifySessionOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instancebinding.Handler(instance, session.VerifySession(options, func(rw http.ResponseWriter, r *http.Request) {
+			c.Request = r
+			c.Next()
+
+			lastErr := c.Errors.Last()
+			if lastErr != nil && !c.Writer.Written() {
+				if instancebinding.ErrorHandler(instance, lastErr.Err, r, rw) == nil {
+					c.Errors = c.Errors[:len(c.Errors)-1]
+				}
+			}
+		}))(c.Writer, c.Request)
+		// we call Abort so that the next handler in the chain is not called, unless we call Next explicitly
+		c.Abort()
+	}
+}
+
+// GetSessionFromContext returns the session verified by VerifySession, or nil if there is none
+func GetSessionFromContext(c *gin.Context) sessmodels.SessionContainer {
+	return session.GetSessionFromRequestContext(c.Request.Context())
+}
@@ -0,0 +1,96 @@
+package ginadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/internal/adaptertesting"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+)
+
+func makeRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(nil))
+	router.GET("/hello", func(c *gin.Context) {
+		c.String(http.StatusOK, "world")
+	})
+	router.GET("/sessioninfo", VerifySession(nil, nil), func(c *gin.Context) {
+		c.String(http.StatusOK, GetSessionFromContext(c).GetUserID())
+	})
+	router.GET("/optional", VerifySession(nil, adaptertesting.OptionalSessionOptions()), func(c *gin.Context) {
+		if GetSessionFromContext(c) == nil {
+			c.String(http.StatusOK, "no session")
+			return
+		}
+		c.String(http.StatusOK, "session")
+	})
+	router.GET("/error", VerifySession(nil, adaptertesting.OptionalSessionOptions()), func(c *gin.Context) {
+		c.Error(errors.UnauthorizedError{Msg: "unauthorised"})
+	})
+	return router
+}
+
+func TestMiddlewareHandlesSuperTokensAPIs(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	router := makeRouter()
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/auth/session/refresh", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/hello", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "world", res.Body.String())
+}
+
+func TestVerifySession(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	router := makeRouter()
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/sessioninfo", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/optional", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "no session", res.Body.String())
+}
+
+func TestVerifySessionHandlesSuperTokensErrors(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	router := makeRouter()
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+}
+
+func TestAdaptersUseTheGivenInstance(t *testing.T) {
+	instance, cleanup := adaptertesting.NewInstanceForTest(t, 401)
+	defer cleanup()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(instance))
+	router.GET("/sessioninfo", VerifySession(instance, nil), func(c *gin.Context) {
+		c.String(http.StatusOK, GetSessionFromContext(c).GetUserID())
+	})
+
+	// supertokens.Init has not been called, so the instance must be used for both
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/auth/session/refresh", nil))
+	assert.Equal(t, 401, res.Code)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/sessioninfo", nil))
+	assert.Equal(t, 401, res.Code)
+}
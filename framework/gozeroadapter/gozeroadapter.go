@@ -0,0 +1,66 @@
+// Package gozeroadapter integrates SuperTokens with go-zero (https://github.com/zeromicro/go-zero).
+package gozeroadapter
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/supertokens/supertokens-golang/internal/instancebinding"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+	"github.com/zeromicro/go-zero/rest"
+)
+
+// maxAPIPathSegments is the number of path segments after the API base path that APIRoutes matches. It is
+// enough for all the APIs exposed by SuperTokens, including the ones prefixed with a tenant ID.
+const maxAPIPathSegments = 6
+
+// Middleware returns a go-zero middleware that serves the APIs exposed by the SuperTokens instance (sign in,
+// session refresh, etc), or by the one initialised using supertokens.Init if instance is nil. Requests for
+// other paths are passed on to the next handler.
+//
+// go-zero only runs middlewares for requests that match a route, so the routes returned by APIRoutes have
+// to be added to the server as well.
+func Middleware(instance *supertokens.Instance) rest.Middleware {
+	return rest.ToMiddleware(instancebinding.Middleware(instance))
+}
+
+// APIRoutes returns routes that match all the paths under apiBasePath. They respond with a 404 status code,
+// since the requests they match are expected to be served by Middleware.
+func APIRoutes(apiBasePath string) []rest.Route {
+	apiBasePath = "/" + strings.Trim(apiBasePath, "/")
+	methods := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions}
+	paths := []string{apiBasePath}
+	path := strings.TrimSuffix(apiBasePath, "/")
+	for i := 0; i < maxAPIPathSegments; i++ {
+		path += "/:" + string(rune('a'+i))
+		paths = append(paths, path)
+	}
+
+	routes := []rest.Route{}
+	for _, method := range methods {
+		for _, path := range paths {
+			routes = append(routes, rest.Route{
+				Method:  method,
+				Path:    path,
+				Handler: http.NotFound,
+			})
+		}
+	}
+	return routes
+}
+
+// VerifySession returns a go-zero middleware that verifies the session of the request, using the SuperTokens
+// instance (or the one initialised using supertokens.Init if instance is nil), before calling the next
+// handler. It can be used with rest.WithMiddleware. Use GetSessionFromContext to get the session in that handler.
+func VerifySession(instance *supertokens.Instance, options *sessmodels.VerifySessionOptions) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return instancebinding.Handler(instance, session.VerifySession(options, next))
+	}
+}
+
+// GetSessionFromContext returns the session verified by VerifySession, or nil if there is none
+func GetSessionFromContext(r *http.Request) sessmodels.SessionContainer {
+	return session.GetSessionFromRequestContext(r.Context())
+}
@@ -0,0 +1,65 @@
+package gozeroadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/internal/adaptertesting"
+)
+
+// go-zero does not expose the handler of a server without starting it, so the middlewares are chained the
+// way the server chains them for a route
+func hello(w http.ResponseWriter, r *http.Request) {
+	if GetSessionFromContext(r) == nil {
+		w.Write([]byte("no session"))
+		return
+	}
+	w.Write([]byte("session"))
+}
+
+func TestMiddlewareHandlesSuperTokensAPIs(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+	handler := Middleware(nil)(hello)
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodPost, "/auth/session/refresh", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, "/hello", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "no session", res.Body.String())
+}
+
+func TestVerifySession(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+
+	res := httptest.NewRecorder()
+	Middleware(nil)(VerifySession(nil, nil)(hello))(res, httptest.NewRequest(http.MethodGet, "/hello", nil))
+	assert.Equal(t, adaptertesting.SessionExpiredStatusCode, res.Code)
+
+	res = httptest.NewRecorder()
+	Middleware(nil)(VerifySession(nil, adaptertesting.OptionalSessionOptions())(hello))(res, httptest.NewRequest(http.MethodGet, "/hello", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "no session", res.Body.String())
+}
+
+func TestAPIRoutes(t *testing.T) {
+	routes := APIRoutes("/auth/")
+
+	paths := map[string]bool{}
+	for _, route := range routes {
+		if route.Method == http.MethodPost {
+			paths[route.Path] = true
+		}
+	}
+	assert.Equal(t, maxAPIPathSegments+1, len(paths))
+	assert.True(t, paths["/auth"])
+	assert.True(t, paths["/auth/:a"])
+	assert.True(t, paths["/auth/:a/:b/:c/:d/:e/:f"])
+	assert.Equal(t, 5*(maxAPIPathSegments+1), len(routes))
+}
@@ -0,0 +1,105 @@
+// Package grpcadapter verifies SuperTokens sessions in gRPC servers (https://github.com/grpc/grpc-go).
+//
+// The access token is read from the "authorization" metadata (as a bearer token), or from the access token
+// cookie in the "cookie" metadata. Requests that fail session verification are rejected with a gRPC status:
+// Unauthenticated if the session is missing, expired (the client should refresh it) or invalid, and
+// PermissionDenied if a claim validator fails.
+package grpcadapter
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/supertokens/supertokens-golang/internal/rpcsession"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	sessionErrors "github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns an interceptor that verifies the session of each unary call, using the
+// SuperTokens instance (or the one initialised using supertokens.Init if instance is nil), before calling
+// the handler. Use GetSessionFromContext to get the session in the handler. Errors returned by the handler
+// are converted using ToStatusError, so the handler can return the errors of session functions as is.
+func UnaryServerInterceptor(instance *supertokens.Instance, options *sessmodels.VerifySessionOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		sessionCtx, err := verifySession(instance, ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		res, err := handler(sessionCtx, req)
+		return res, ToStatusError(err)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that verifies the session of each stream, using the
+// SuperTokens instance (or the one initialised using supertokens.Init if instance is nil), before calling the
+// handler. Use GetSessionFromContext with the context of the stream to get the session in the handler. Like
+// for unary calls, errors returned by the handler are converted using ToStatusError.
+func StreamServerInterceptor(instance *supertokens.Instance, options *sessmodels.VerifySessionOptions) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		sessionCtx, err := verifySession(instance, stream.Context(), options)
+		if err != nil {
+			return err
+		}
+		return ToStatusError(handler(srv, &serverStreamWithContext{ServerStream: stream, ctx: sessionCtx}))
+	}
+}
+
+// GetSessionFromContext returns the session verified by the interceptors, or nil if there is none
+func GetSessionFromContext(ctx context.Context) sessmodels.SessionContainer {
+	return session.GetSessionFromRequestContext(ctx)
+}
+
+// ToStatusError converts errors returned by session functions (like SessionContainer.AssertClaims) to gRPC
+// status errors. Other errors are returned as is.
+func ToStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.As(err, &sessionErrors.TryRefreshTokenError{}) {
+		return status.Error(codes.Unauthenticated, "try refresh token")
+	}
+	if errors.As(err, &sessionErrors.UnauthorizedError{}) {
+		return status.Error(codes.Unauthenticated, "unauthorised")
+	}
+	if errors.As(err, &sessionErrors.TokenTheftDetectedError{}) {
+		return status.Error(codes.Unauthenticated, "token theft detected")
+	}
+	var invalidClaimError sessionErrors.InvalidClaimError
+	if errors.As(err, &invalidClaimError) {
+		claimIds := []string{}
+		for _, claimError := range invalidClaimError.InvalidClaims {
+			claimIds = append(claimIds, claimError.ID)
+		}
+		return status.Error(codes.PermissionDenied, "invalid claim: "+strings.Join(claimIds, ", "))
+	}
+	return err
+}
+
+func verifySession(instance *supertokens.Instance, ctx context.Context, options *sessmodels.VerifySessionOptions) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	sessionCtx, err := rpcsession.VerifySession(instance, ctx, md.Get, options)
+	if err != nil {
+		err = ToStatusError(err)
+		if _, ok := status.FromError(err); !ok {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return nil, err
+	}
+	return sessionCtx, nil
+}
+
+type serverStreamWithContext struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStreamWithContext) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,139 @@
+package grpcadapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/internal/adaptertesting"
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	sessionErrors "github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func hasSession(ctx context.Context) bool {
+	return GetSessionFromContext(ctx) != nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+
+	handlerCalled := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerCalled = true
+		assert.False(t, hasSession(ctx))
+		return "response", nil
+	}
+
+	_, err := UnaryServerInterceptor(nil, nil)(context.Background(), "request", &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.False(t, handlerCalled)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer invalid"))
+	_, err = UnaryServerInterceptor(nil, nil)(ctx, "request", &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.False(t, handlerCalled)
+
+	res, err := UnaryServerInterceptor(nil, adaptertesting.OptionalSessionOptions())(context.Background(), "request", &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "response", res)
+	assert.True(t, handlerCalled)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+
+	handlerCalled := false
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		handlerCalled = true
+		assert.False(t, hasSession(stream.Context()))
+		return nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("cookie", "sAccessToken=invalid"))
+	err := StreamServerInterceptor(nil, nil)(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.False(t, handlerCalled)
+
+	err = StreamServerInterceptor(nil, adaptertesting.OptionalSessionOptions())(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.True(t, handlerCalled)
+}
+
+func TestThatAccessTokensBoundToADPoPKeyAreRejected(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.True(t, hasSession(ctx))
+		return "response", nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+adaptertesting.MakeAccessTokenForTest(t, nil)))
+	res, err := UnaryServerInterceptor(nil, nil)(ctx, "request", &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "response", res)
+
+	// the interceptors can't verify a DPoP proof, so the token can't be used as a bearer token
+	boundAccessToken := adaptertesting.MakeAccessTokenForTest(t, map[string]interface{}{"cnf": map[string]interface{}{"jkt": "thumbprint"}})
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+boundAccessToken))
+	_, err = UnaryServerInterceptor(nil, nil)(ctx, "request", &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	err = StreamServerInterceptor(nil, nil)(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestInterceptorsUseTheGivenInstance(t *testing.T) {
+	instance, cleanup := adaptertesting.NewInstanceForTest(t, 401)
+	defer cleanup()
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "response", nil
+	}
+
+	// supertokens.Init has not been called, so this fails unless the instance is used
+	res, err := UnaryServerInterceptor(instance, adaptertesting.OptionalSessionOptions())(context.Background(), "request", &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "response", res)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer invalid"))
+	_, err = UnaryServerInterceptor(instance, nil)(ctx, "request", &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestToStatusError(t *testing.T) {
+	assert.Nil(t, ToStatusError(nil))
+	assert.Equal(t, codes.Unauthenticated, status.Code(ToStatusError(sessionErrors.TryRefreshTokenError{Msg: "expired"})))
+	assert.Equal(t, codes.Unauthenticated, status.Code(ToStatusError(sessionErrors.UnauthorizedError{Msg: "unauthorised"})))
+
+	err := ToStatusError(sessionErrors.InvalidClaimError{
+		Msg: "invalid claim",
+		InvalidClaims: []claims.ClaimValidationError{
+			{ID: "st-role"},
+			{ID: "st-ev"},
+		},
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "invalid claim: st-role, st-ev", status.Convert(err).Message())
+
+	otherErr := errors.New("other")
+	assert.Equal(t, otherErr, ToStatusError(otherErr))
+}
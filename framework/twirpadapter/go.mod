@@ -0,0 +1,24 @@
+module github.com/supertokens/supertokens-golang/framework/twirpadapter
+
+go 1.18
+
+require (
+	github.com/stretchr/testify v1.8.0
+	github.com/supertokens/supertokens-golang v0.0.0-20210909070424-b13c10ce5994
+	github.com/twitchtv/twirp v8.1.0+incompatible
+)
+
+require (
+	github.com/MicahParks/keyfunc/v2 v2.1.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang-jwt/jwt/v5 v5.0.0 // indirect
+	github.com/h2non/parth v0.0.0-20190131123155-b4df798d6542 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.2.0 // indirect
+	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
+	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df // indirect
+	gopkg.in/h2non/gock.v1 v1.1.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/supertokens/supertokens-golang => ../../
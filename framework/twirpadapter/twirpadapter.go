@@ -0,0 +1,88 @@
+// Package twirpadapter verifies SuperTokens sessions in Twirp servers (https://github.com/twitchtv/twirp).
+//
+// Twirp interceptors do not have access to the HTTP request, so the Twirp server has to be wrapped with
+// WithRequestHeaders for Interceptor to read the access token from the "Authorization" header (as a bearer
+// token) or from the access token cookie.
+package twirpadapter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/supertokens/supertokens-golang/internal/rpcsession"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	sessionErrors "github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+	"github.com/twitchtv/twirp"
+)
+
+type requestHeadersContextKey struct{}
+
+// WithRequestHeaders makes the headers of each request available to Interceptor
+func WithRequestHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), requestHeadersContextKey{}, r.Header)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// Interceptor returns an interceptor that verifies the session of each call, using the SuperTokens instance
+// (or the one initialised using supertokens.Init if instance is nil), before calling the method. Use
+// GetSessionFromContext to get the session in the method. Errors returned by the method are converted
+// using ToTwirpError, so the method can return the errors of session functions as is.
+func Interceptor(instance *supertokens.Instance, options *sessmodels.VerifySessionOptions) twirp.Interceptor {
+	return func(next twirp.Method) twirp.Method {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			header, _ := ctx.Value(requestHeadersContextKey{}).(http.Header)
+			sessionCtx, err := rpcsession.VerifySession(instance, ctx, func(key string) []string {
+				return header.Values(key)
+			}, options)
+			if err != nil {
+				return nil, ToTwirpError(err)
+			}
+			res, err := next(sessionCtx, req)
+			if err != nil {
+				return res, ToTwirpError(err)
+			}
+			return res, nil
+		}
+	}
+}
+
+// GetSessionFromContext returns the session verified by Interceptor, or nil if there is none
+func GetSessionFromContext(ctx context.Context) sessmodels.SessionContainer {
+	return session.GetSessionFromRequestContext(ctx)
+}
+
+// ToTwirpError converts errors returned by session functions (like SessionContainer.AssertClaims) to Twirp
+// errors. Other errors are wrapped as internal errors.
+func ToTwirpError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.As(err, &sessionErrors.TryRefreshTokenError{}) {
+		return twirp.NewError(twirp.Unauthenticated, "try refresh token")
+	}
+	if errors.As(err, &sessionErrors.UnauthorizedError{}) {
+		return twirp.NewError(twirp.Unauthenticated, "unauthorised")
+	}
+	if errors.As(err, &sessionErrors.TokenTheftDetectedError{}) {
+		return twirp.NewError(twirp.Unauthenticated, "token theft detected")
+	}
+	var invalidClaimError sessionErrors.InvalidClaimError
+	if errors.As(err, &invalidClaimError) {
+		claimIds := []string{}
+		for _, claimError := range invalidClaimError.InvalidClaims {
+			claimIds = append(claimIds, claimError.ID)
+		}
+		return twirp.NewError(twirp.PermissionDenied, "invalid claim: "+strings.Join(claimIds, ", "))
+	}
+	var twirpErr twirp.Error
+	if errors.As(err, &twirpErr) {
+		return twirpErr
+	}
+	return twirp.InternalErrorWith(err)
+}
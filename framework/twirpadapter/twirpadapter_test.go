@@ -0,0 +1,89 @@
+package twirpadapter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/internal/adaptertesting"
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	sessionErrors "github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/twitchtv/twirp"
+)
+
+// callWithHeader calls method through the interceptor the way a Twirp server wrapped with WithRequestHeaders does
+func callWithHeader(header http.Header, interceptor twirp.Interceptor, method twirp.Method) (interface{}, error) {
+	var res interface{}
+	var err error
+	WithRequestHeaders(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		res, err = interceptor(method)(r.Context(), "request")
+	})).ServeHTTP(httptest.NewRecorder(), &http.Request{Header: header})
+	return res, err
+}
+
+func errorCode(err error) twirp.ErrorCode {
+	var twirpErr twirp.Error
+	if errors.As(err, &twirpErr) {
+		return twirpErr.Code()
+	}
+	return twirp.NoError
+}
+
+func TestInterceptor(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+
+	methodCalled := false
+	method := func(ctx context.Context, req interface{}) (interface{}, error) {
+		methodCalled = true
+		assert.Nil(t, GetSessionFromContext(ctx))
+		return "response", nil
+	}
+
+	_, err := callWithHeader(http.Header{}, Interceptor(nil, nil), method)
+	assert.Equal(t, twirp.Unauthenticated, errorCode(err))
+	assert.False(t, methodCalled)
+
+	_, err = callWithHeader(http.Header{"Authorization": []string{"Bearer invalid"}}, Interceptor(nil, nil), method)
+	assert.Equal(t, twirp.Unauthenticated, errorCode(err))
+	assert.False(t, methodCalled)
+
+	res, err := callWithHeader(http.Header{}, Interceptor(nil, adaptertesting.OptionalSessionOptions()), method)
+	assert.NoError(t, err)
+	assert.Equal(t, "response", res)
+	assert.True(t, methodCalled)
+}
+
+func TestThatAccessTokensBoundToADPoPKeyAreRejected(t *testing.T) {
+	cleanup := adaptertesting.InitForTest(t)
+	defer cleanup()
+
+	method := func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.NotNil(t, GetSessionFromContext(ctx))
+		return "response", nil
+	}
+
+	res, err := callWithHeader(http.Header{"Authorization": []string{"Bearer " + adaptertesting.MakeAccessTokenForTest(t, nil)}}, Interceptor(nil, nil), method)
+	assert.NoError(t, err)
+	assert.Equal(t, "response", res)
+
+	// the interceptor can't verify a DPoP proof, so the token can't be used as a bearer token
+	boundAccessToken := adaptertesting.MakeAccessTokenForTest(t, map[string]interface{}{"cnf": map[string]interface{}{"jkt": "thumbprint"}})
+	_, err = callWithHeader(http.Header{"Authorization": []string{"Bearer " + boundAccessToken}}, Interceptor(nil, nil), method)
+	assert.Equal(t, twirp.Unauthenticated, errorCode(err))
+}
+
+func TestToTwirpError(t *testing.T) {
+	assert.Nil(t, ToTwirpError(nil))
+	assert.Equal(t, twirp.Unauthenticated, errorCode(ToTwirpError(sessionErrors.TryRefreshTokenError{Msg: "expired"})))
+	assert.Equal(t, twirp.Unauthenticated, errorCode(ToTwirpError(sessionErrors.UnauthorizedError{Msg: "unauthorised"})))
+	assert.Equal(t, twirp.PermissionDenied, errorCode(ToTwirpError(sessionErrors.InvalidClaimError{
+		Msg:           "invalid claim",
+		InvalidClaims: []claims.ClaimValidationError{{ID: "st-role"}},
+	})))
+	assert.Equal(t, twirp.NotFound, errorCode(ToTwirpError(twirp.NotFoundError("not found"))))
+	assert.Equal(t, twirp.Internal, errorCode(ToTwirpError(errors.New("other"))))
+}
@@ -20,6 +20,7 @@ import (
 	"crypto/tls"
 	"fmt"
 
+	"github.com/supertokens/supertokens-golang/supertokens"
 	"gopkg.in/gomail.v2"
 )
 
@@ -37,6 +38,20 @@ func MakeIngredient(config TypeInputWithService) Ingredient {
 		result.IngredientInterfaceImpl = config.Override(result.IngredientInterfaceImpl)
 	}
 
+	// sends are tracked so that supertokens.Shutdown can wait for them to finish
+	if result.IngredientInterfaceImpl.SendEmail != nil {
+		originalSendEmail := *result.IngredientInterfaceImpl.SendEmail
+		trackedSendEmail := func(input EmailType, userContext supertokens.UserContext) error {
+			done, err := supertokens.StartInFlightOperation()
+			if err != nil {
+				return err
+			}
+			defer done()
+			return originalSendEmail(input, userContext)
+		}
+		result.IngredientInterfaceImpl.SendEmail = &trackedSendEmail
+	}
+
 	return result
 }
 
@@ -19,6 +19,7 @@ package smsdelivery
 import (
 	"errors"
 
+	"github.com/supertokens/supertokens-golang/supertokens"
 	"github.com/twilio/twilio-go"
 	openapi "github.com/twilio/twilio-go/rest/api/v2010"
 )
@@ -36,6 +37,20 @@ func MakeIngredient(config TypeInputWithService) Ingredient {
 		result.IngredientInterfaceImpl = config.Override(result.IngredientInterfaceImpl)
 	}
 
+	// sends are tracked so that supertokens.Shutdown can wait for them to finish
+	if result.IngredientInterfaceImpl.SendSms != nil {
+		originalSendSms := *result.IngredientInterfaceImpl.SendSms
+		trackedSendSms := func(input SmsType, userContext supertokens.UserContext) error {
+			done, err := supertokens.StartInFlightOperation()
+			if err != nil {
+				return err
+			}
+			defer done()
+			return originalSendSms(input, userContext)
+		}
+		result.IngredientInterfaceImpl.SendSms = &trackedSendSms
+	}
+
 	return result
 }
 
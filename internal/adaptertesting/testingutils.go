@@ -0,0 +1,153 @@
+package adaptertesting
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// SessionExpiredStatusCode is the status code that the SDK initialised by InitForTest responds with
+// when a session is required but missing
+const SessionExpiredStatusCode = 440
+
+// InitForTest initialises SuperTokens with the session recipe against a fake core, so that the adapters can
+// be tested without running the SuperTokens core. The returned function resets SuperTokens and stops the
+// fake core.
+func InitForTest(t *testing.T) func() {
+	core := startFakeCore()
+	supertokens.ResetForTest()
+	err := supertokens.Init(makeConfigForTest(core.URL, SessionExpiredStatusCode))
+	if err != nil {
+		core.Close()
+		t.Fatal(err.Error())
+	}
+
+	return func() {
+		// this stops the background refresh of the JWKS
+		supertokens.Shutdown(context.Background())
+		supertokens.ResetForTest()
+		core.Close()
+	}
+}
+
+// NewInstanceForTest is like InitForTest, but creates an instance using supertokens.New, which responds with
+// sessionExpiredStatusCode when a session is required but missing. Init is not called.
+func NewInstanceForTest(t *testing.T, sessionExpiredStatusCode int) (*supertokens.Instance, func()) {
+	core := startFakeCore()
+	supertokens.ResetForTest()
+	instance, err := supertokens.New(makeConfigForTest(core.URL, sessionExpiredStatusCode))
+	if err != nil {
+		core.Close()
+		t.Fatal(err.Error())
+	}
+
+	return instance, func() {
+		instance.Shutdown(context.Background())
+		supertokens.ResetForTest()
+		core.Close()
+	}
+}
+
+var signingKey *rsa.PrivateKey
+var signingKeyOnce sync.Once
+
+// getSigningKey returns the key that the fake core signs access tokens with
+func getSigningKey() *rsa.PrivateKey {
+	signingKeyOnce.Do(func() {
+		var err error
+		signingKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+	})
+	return signingKey
+}
+
+func startFakeCore() *httptest.Server {
+	publicKey := getSigningKey().PublicKey
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apiversion":
+			json.NewEncoder(rw).Encode(map[string]interface{}{"versions": []string{"3.1"}})
+		case "/.well-known/jwks.json":
+			json.NewEncoder(rw).Encode(map[string]interface{}{"keys": []interface{}{map[string]interface{}{
+				"kty": "RSA",
+				"kid": "d-test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			}}})
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// MakeAccessTokenForTest creates an access token for the user "userId", signed by the fake core of InitForTest
+// and NewInstanceForTest, with the claims in payload added to it
+func MakeAccessTokenForTest(t *testing.T, payload map[string]interface{}) string {
+	claimsToSign := jwt.MapClaims{
+		"sub":               "userId",
+		"sessionHandle":     "handle",
+		"refreshTokenHash1": "hash",
+		"tId":               "public",
+		"rsub":              "userId",
+		"exp":               time.Now().Add(time.Hour).Unix(),
+		"iat":               time.Now().Unix(),
+	}
+	for key, value := range payload {
+		claimsToSign[key] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claimsToSign)
+	token.Header["kid"] = "d-test"
+	token.Header["version"] = "5"
+	signed, err := token.SignedString(getSigningKey())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return signed
+}
+
+func makeConfigForTest(connectionURI string, sessionExpiredStatusCode int) supertokens.TypeInput {
+	apiBasePath := "/auth"
+	return supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: connectionURI,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "http://localhost:3000",
+			APIDomain:     "http://localhost:3001",
+			APIBasePath:   &apiBasePath,
+		},
+		RecipeList: []supertokens.Recipe{
+			session.Init(&sessmodels.TypeInput{
+				SessionExpiredStatusCode: &sessionExpiredStatusCode,
+			}),
+		},
+	}
+}
+
+// OptionalSessionOptions returns options for VerifySession that do not require a session, so that
+// requests without tokens reach the handler
+func OptionalSessionOptions() *sessmodels.VerifySessionOptions {
+	sessionRequired := false
+	return &sessmodels.VerifySessionOptions{
+		SessionRequired: &sessionRequired,
+	}
+}
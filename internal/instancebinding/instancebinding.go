@@ -0,0 +1,45 @@
+// Package instancebinding lets the adapters use a SuperTokens instance created using supertokens.New, or the
+// one initialised using supertokens.Init if the instance is nil.
+package instancebinding
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// Middleware returns the middleware that serves the APIs exposed by instance
+func Middleware(instance *supertokens.Instance) func(next http.Handler) http.Handler {
+	if instance == nil {
+		return supertokens.Middleware
+	}
+	return instance.Middleware
+}
+
+// Context returns a copy of ctx that recipe functions resolve to instance
+func Context(instance *supertokens.Instance, ctx context.Context) context.Context {
+	if instance == nil {
+		return ctx
+	}
+	return instance.BindContext(ctx)
+}
+
+// Handler calls next with a request whose context is bound to instance, so that session.VerifySession uses
+// the session recipe of instance
+func Handler(instance *supertokens.Instance, next http.HandlerFunc) http.HandlerFunc {
+	if instance == nil {
+		return next
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		next(rw, r.WithContext(instance.BindContext(r.Context())))
+	}
+}
+
+// ErrorHandler passes err to the error handler of instance
+func ErrorHandler(instance *supertokens.Instance, err error, r *http.Request, rw http.ResponseWriter) error {
+	if instance == nil {
+		return supertokens.ErrorHandler(err, r, rw)
+	}
+	return instance.ErrorHandler(err, r, rw)
+}
@@ -0,0 +1,78 @@
+// Package rpcsession verifies sessions for RPC frameworks, where the access token is read from request
+// metadata instead of an http.Request.
+package rpcsession
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/supertokens/supertokens-golang/internal/instancebinding"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	sessionErrors "github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+const (
+	authorizationHeaderKey = "authorization"
+	cookieHeaderKey        = "cookie"
+	antiCsrfHeaderKey      = "anti-csrf"
+)
+
+// Metadata returns the values of a (lower case) header of the request
+type Metadata func(key string) []string
+
+// VerifySession verifies the session whose access token is in the Authorization header (as a bearer token)
+// or in the access token cookie, using the SuperTokens instance (or the one initialised using
+// supertokens.Init if instance is nil), and returns a context that holds the session and is bound to the
+// instance. If there is no access token and options.SessionRequired is false, the bound context is returned
+// without a session. Errors are the ones returned by session.GetSessionWithoutRequestResponse.
+func VerifySession(instance *supertokens.Instance, ctx context.Context, metadata Metadata, options *sessmodels.VerifySessionOptions) (context.Context, error) {
+	ctx = instancebinding.Context(instance, ctx)
+	userContext := supertokens.SetContextInUserContext(nil, ctx)
+	accessTokenCookieName, err := session.GetCookieName(sessmodels.AccessToken, userContext)
+	if err != nil {
+		return nil, err
+	}
+	accessToken := getAccessToken(metadata, accessTokenCookieName)
+	if accessToken == "" {
+		if options != nil && options.SessionRequired != nil && !*options.SessionRequired {
+			return ctx, nil
+		}
+		return nil, sessionErrors.UnauthorizedError{Msg: "Session does not exist. Are you sending the session tokens in the request with the appropriate token transfer method?"}
+	}
+
+	var antiCsrfToken *string = nil
+	if values := metadata(antiCsrfHeaderKey); len(values) > 0 {
+		antiCsrfToken = &values[0]
+	}
+
+	sessionContainer, err := session.GetSessionWithoutRequestResponse(accessToken, antiCsrfToken, options, userContext)
+	if err != nil {
+		return nil, err
+	}
+	if sessionContainer == nil {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, sessmodels.SessionContext, sessionContainer), nil
+}
+
+func getAccessToken(metadata Metadata, accessTokenCookieName string) string {
+	for _, value := range metadata(authorizationHeaderKey) {
+		if len(value) > len("bearer ") && strings.EqualFold(value[:len("bearer ")], "bearer ") {
+			return strings.TrimSpace(value[len("bearer "):])
+		}
+	}
+
+	// the cookie header is parsed using net/http so that quoting is handled the same way as for HTTP requests
+	header := http.Header{}
+	for _, value := range metadata(cookieHeaderKey) {
+		header.Add("Cookie", value)
+	}
+	cookie, err := (&http.Request{Header: header}).Cookie(accessTokenCookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
@@ -0,0 +1,46 @@
+package rpcsession
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func metadataFromHeader(header http.Header) Metadata {
+	return func(key string) []string {
+		return header.Values(key)
+	}
+}
+
+func TestGetAccessToken(t *testing.T) {
+	assert.Equal(t, "", getAccessToken(metadataFromHeader(http.Header{}), "sAccessToken"))
+
+	assert.Equal(t, "token", getAccessToken(metadataFromHeader(http.Header{
+		"Authorization": []string{"Bearer token"},
+	}), "sAccessToken"))
+	assert.Equal(t, "token", getAccessToken(metadataFromHeader(http.Header{
+		"Authorization": []string{"bearer token"},
+	}), "sAccessToken"))
+	assert.Equal(t, "", getAccessToken(metadataFromHeader(http.Header{
+		"Authorization": []string{"Basic dXNlcjpwYXNz"},
+	}), "sAccessToken"))
+
+	assert.Equal(t, "token", getAccessToken(metadataFromHeader(http.Header{
+		"Cookie": []string{"other=value; sAccessToken=token"},
+	}), "sAccessToken"))
+	assert.Equal(t, "", getAccessToken(metadataFromHeader(http.Header{
+		"Cookie": []string{"other=value"},
+	}), "sAccessToken"))
+
+	// the authorization header takes precedence over the cookie
+	assert.Equal(t, "header", getAccessToken(metadataFromHeader(http.Header{
+		"Authorization": []string{"Bearer header"},
+		"Cookie":        []string{"sAccessToken=cookie"},
+	}), "sAccessToken"))
+
+	// the name of the cookie depends on the session recipe config
+	assert.Equal(t, "token", getAccessToken(metadataFromHeader(http.Header{
+		"Cookie": []string{"sAccessToken=other; __Host-app1_sAccessToken=token"},
+	}), "__Host-app1_sAccessToken"))
+}
@@ -0,0 +1,98 @@
+/* Copyright (c) 2022, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package testingutils contains helpers that are shared by the tests of the recipes in this module. It is
+// internal so that the helpers are not part of the public API.
+package testingutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var malformedRequestBodies = []string{
+	"",
+	"null",
+	"[]",
+	"\"body\"",
+	"1",
+	"{",
+	"{}{}",
+	"{} []",
+	"{\"a\": 1, \"a\": 2}",
+	"{\"a\": {\"b\": 1, \"b\": 2}}",
+	"{\"a\": [{\"b\": 1, \"b\": 2}]}",
+	"{\"formFields\": {}}",
+	"{\"formFields\": [1, \"a\", null]}",
+	"{\"formFields\": [{\"id\": 1, \"value\": []}]}",
+	"{\"email\": 1, \"phoneNumber\": null}",
+	"{\"token\": {}, \"method\": []}",
+	"{\"preAuthSessionId\": 1, \"deviceId\": true, \"userInputCode\": []}",
+	"{\"thirdPartyId\": 1, \"redirectURIInfo\": []}",
+	"{\"email\": [], \"password\": {}}",
+}
+
+// StartFakeCore starts a server that can be used as the core for tests that don't need a real core. It
+// answers the API version request, and requests to the paths in responses with the given JSON.
+func StartFakeCore(responses map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/apiversion" {
+			rw.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(rw).Encode(map[string]interface{}{"versions": []string{"3.1"}})
+			return
+		}
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(response)
+	}))
+}
+
+// AddRequestBodySeeds adds the given valid bodies, along with bodies that are malformed for every API, to
+// the seed corpus of f
+func AddRequestBodySeeds(f *testing.F, validBodies ...string) {
+	for _, body := range validBodies {
+		f.Add([]byte(body))
+	}
+	for _, body := range malformedRequestBodies {
+		f.Add([]byte(body))
+	}
+}
+
+// AssertAPIHandlesRequestBody sends body to the API at path, and checks that it is rejected with a 400 if it
+// is not a JSON object, and that it never causes an error other than a bad input error
+func AssertAPIHandlesRequestBody(t *testing.T, handler http.Handler, path string, body []byte) {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	var object map[string]interface{}
+	if json.Unmarshal(body, &object) != nil || object == nil {
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s to reject %q with a 400, got %d: %s", path, body, res.Code, res.Body.String())
+		}
+		return
+	}
+	if res.Code != http.StatusOK && res.Code != http.StatusBadRequest {
+		t.Fatalf("expected %s to respond to %q with a 200 or 400, got %d: %s", path, body, res.Code, res.Body.String())
+	}
+}
@@ -19,7 +19,7 @@ type analyticsPostRequestBody struct {
 }
 
 func AnalyticsPost(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) (analyticsPostResponse, error) {
-	supertokensInstance, instanceError := supertokens.GetInstanceOrThrowError()
+	supertokensInstance, instanceError := supertokens.GetInstanceOrThrowError(userContext)
 
 	if supertokens.IsRunningInTestMode() {
 		return analyticsPostResponse{
@@ -37,14 +37,8 @@ func AnalyticsPost(apiInterface dashboardmodels.APIInterface, tenantId string, o
 		}, nil
 	}
 
-	body, err := supertokens.ReadFromRequest(options.Req)
-
-	if err != nil {
-		return analyticsPostResponse{}, err
-	}
-
 	var readBody analyticsPostRequestBody
-	err = json.Unmarshal(body, &readBody)
+	err := supertokens.ReadJSONFromRequest(options.Req, &readBody)
 	if err != nil {
 		return analyticsPostResponse{}, err
 	}
@@ -76,7 +70,7 @@ func AnalyticsPost(apiInterface dashboardmodels.APIInterface, tenantId string, o
 		"dashboardVersion": *readBody.DashboardVersion,
 	}
 
-	querier, err := supertokens.GetNewQuerierInstanceOrThrowError("")
+	querier, err := supertokens.GetNewQuerierInstanceOrThrowError("", userContext)
 	if err != nil {
 		return analyticsPostResponse{}, err
 	}
@@ -95,7 +89,7 @@ func AnalyticsPost(apiInterface dashboardmodels.APIInterface, tenantId string, o
 		data["telemetryId"] = response["telemetryId"].(string)
 	}
 
-	numberOfUsers, err := supertokens.GetUserCount(nil, nil)
+	numberOfUsers, err := supertokens.GetUserCount(nil, nil, userContext)
 	if err != nil {
 		// We don't send telemetry events if this fails
 		return analyticsPostResponse{
@@ -110,8 +104,17 @@ func AnalyticsPost(apiInterface dashboardmodels.APIInterface, tenantId string, o
 		return analyticsPostResponse{}, err
 	}
 
+	// the telemetry event is tracked so that supertokens.Shutdown waits for it to be sent
+	done, err := supertokens.StartInFlightOperation()
+	if err != nil {
+		return analyticsPostResponse{
+			Status: "OK",
+		}, nil
+	}
+	defer done()
+
 	url := "https://api.supertokens.com/0/st/telemetry"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(supertokens.GetContextFromUserContext(userContext), "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return analyticsPostResponse{
 			Status: "OK",
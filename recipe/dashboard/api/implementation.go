@@ -42,7 +42,7 @@ func MakeAPIImplementation() dashboardmodels.APIInterface {
 
 		bundleDomain := normalizedDomain.GetAsStringDangerous() + normalizedPath.GetAsStringDangerous()
 
-		stInstance, err := supertokens.GetInstanceOrThrowError()
+		stInstance, err := supertokens.GetInstanceOrThrowError(userContext)
 		if err != nil {
 			return "", err
 		}
@@ -72,7 +72,7 @@ func MakeAPIImplementation() dashboardmodels.APIInterface {
 		authMode := string(options.Config.AuthMode)
 
 		isSearchEnabled := false
-		querier, err := supertokens.GetNewQuerierInstanceOrThrowError(options.RecipeID)
+		querier, err := supertokens.GetNewQuerierInstanceOrThrowError(options.RecipeID, userContext)
 		if err != nil {
 			return "", err
 		}
@@ -26,7 +26,7 @@ type searchTagsResponse struct {
 }
 
 func SearchTagsGet(apiImplementation dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) (searchTagsResponse, error) {
-	querier, querierErr := supertokens.GetNewQuerierInstanceOrThrowError("dashboard")
+	querier, querierErr := supertokens.GetNewQuerierInstanceOrThrowError("dashboard", userContext)
 
 	if querierErr != nil {
 		return searchTagsResponse{}, querierErr
@@ -1,8 +1,6 @@
 package api
 
 import (
-	"encoding/json"
-
 	"github.com/supertokens/supertokens-golang/recipe/dashboard/dashboardmodels"
 	"github.com/supertokens/supertokens-golang/supertokens"
 )
@@ -19,14 +17,8 @@ type signInRequestBody struct {
 }
 
 func SignInPost(apiInterface dashboardmodels.APIInterface, options dashboardmodels.APIOptions, userContext supertokens.UserContext) error {
-	body, err := supertokens.ReadFromRequest(options.Req)
-
-	if err != nil {
-		return err
-	}
-
 	var readBody signInRequestBody
-	err = json.Unmarshal(body, &readBody)
+	err := supertokens.ReadJSONFromRequest(options.Req, &readBody)
 	if err != nil {
 		return err
 	}
@@ -43,7 +35,7 @@ func SignInPost(apiInterface dashboardmodels.APIInterface, options dashboardmode
 		}
 	}
 
-	querier, querierErr := supertokens.GetNewQuerierInstanceOrThrowError("dashboard")
+	querier, querierErr := supertokens.GetNewQuerierInstanceOrThrowError("dashboard", userContext)
 
 	if querierErr != nil {
 		return querierErr
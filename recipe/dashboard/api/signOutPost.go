@@ -24,7 +24,7 @@ func SignOutPost(apiInterface dashboardmodels.APIInterface, tenantId string, opt
 	keyParts := strings.Split(sessionIdFromHeader, " ")
 	sessionIdFromHeader = keyParts[len(keyParts)-1]
 
-	querier, querierErr := supertokens.GetNewQuerierInstanceOrThrowError("dashboard")
+	querier, querierErr := supertokens.GetNewQuerierInstanceOrThrowError("dashboard", userContext)
 
 	if querierErr != nil {
 		return signOutPostResponse{}, querierErr
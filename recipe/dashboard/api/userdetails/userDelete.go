@@ -34,7 +34,7 @@ func UserDelete(apiInterface dashboardmodels.APIInterface, tenantId string, opti
 		}
 	}
 
-	deleteError := supertokens.DeleteUser(userId)
+	deleteError := supertokens.DeleteUser(userId, userContext)
 
 	if deleteError != nil {
 		return userDeleteResponse{}, deleteError
@@ -36,7 +36,7 @@ func UserEmailVerifyGet(apiImplementation dashboardmodels.APIInterface, tenantId
 		}
 	}
 
-	emailverificationInstance := emailverification.GetRecipeInstance()
+	emailverificationInstance := emailverification.GetRecipeInstance(userContext)
 
 	if emailverificationInstance == nil {
 		return userEmailVerifyGetResponse{
@@ -16,7 +16,6 @@
 package userdetails
 
 import (
-	"encoding/json"
 	"errors"
 
 	"github.com/supertokens/supertokens-golang/recipe/dashboard/dashboardmodels"
@@ -34,14 +33,8 @@ type userEmailVerifyPutRequestBody struct {
 }
 
 func UserEmailVerifyPut(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) (userEmailVerifyPutResponse, error) {
-	body, err := supertokens.ReadFromRequest(options.Req)
-
-	if err != nil {
-		return userEmailVerifyPutResponse{}, err
-	}
-
 	var readBody userEmailVerifyPutRequestBody
-	err = json.Unmarshal(body, &readBody)
+	err := supertokens.ReadJSONFromRequest(options.Req, &readBody)
 	if err != nil {
 		return userEmailVerifyPutResponse{}, err
 	}
@@ -16,8 +16,6 @@
 package userdetails
 
 import (
-	"encoding/json"
-
 	"github.com/supertokens/supertokens-golang/recipe/dashboard/dashboardmodels"
 	"github.com/supertokens/supertokens-golang/recipe/emailverification"
 	"github.com/supertokens/supertokens-golang/supertokens"
@@ -32,14 +30,8 @@ type userEmailverifyTokenPostRequestBody struct {
 }
 
 func UserEmailVerifyTokenPost(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) (userEmailVerifyTokenPost, error) {
-	body, err := supertokens.ReadFromRequest(options.Req)
-
-	if err != nil {
-		return userEmailVerifyTokenPost{}, err
-	}
-
 	var readBody userEmailverifyTokenPostRequestBody
-	err = json.Unmarshal(body, &readBody)
+	err := supertokens.ReadJSONFromRequest(options.Req, &readBody)
 	if err != nil {
 		return userEmailVerifyTokenPost{}, err
 	}
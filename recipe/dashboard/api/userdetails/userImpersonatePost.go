@@ -0,0 +1,127 @@
+/* Copyright (c) 2022, VRAI Labs and/or its affiliates. All rights reserved.
+*
+* This software is licensed under the Apache License, Version 2.0 (the
+* "License") as published by the Apache Software Foundation.
+*
+* You may not use this file except in compliance with the License. You may
+* obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+* WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+* License for the specific language governing permissions and limitations
+* under the License.
+ */
+
+package userdetails
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/supertokens/supertokens-golang/recipe/dashboard/api"
+	"github.com/supertokens/supertokens-golang/recipe/dashboard/dashboardmodels"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+type userImpersonatePostResponse struct {
+	Status        string  `json:"status"`
+	SessionHandle string  `json:"sessionHandle,omitempty"`
+	AccessToken   string  `json:"accessToken,omitempty"`
+	FrontToken    string  `json:"frontToken,omitempty"`
+	AntiCsrfToken *string `json:"antiCsrfToken,omitempty"`
+}
+
+type userImpersonatePostRequestBody struct {
+	UserId   *string `json:"userId"`
+	RecipeId *string `json:"recipeId"`
+}
+
+// UserImpersonatePost creates an impersonation session for a user on behalf of the dashboard user, and returns its
+// access token in the response body. The tokens are not set as cookies, since that would replace the session of
+// the dashboard user's browser on the website with the impersonation session. The refresh token is not
+// returned, since refreshing an impersonation session revokes it.
+func UserImpersonatePost(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) (userImpersonatePostResponse, error) {
+	var readBody userImpersonatePostRequestBody
+	err := supertokens.ReadJSONFromRequest(options.Req, &readBody)
+	if err != nil {
+		return userImpersonatePostResponse{}, err
+	}
+
+	if readBody.UserId == nil || *readBody.UserId == "" {
+		return userImpersonatePostResponse{}, supertokens.BadInputError{
+			Msg: "Required parameter 'userId' is missing or has an invalid type",
+		}
+	}
+
+	if readBody.RecipeId == nil || !api.IsValidRecipeId(*readBody.RecipeId) {
+		return userImpersonatePostResponse{}, supertokens.BadInputError{
+			Msg: "Required parameter 'recipeId' is missing or is not a valid recipe id",
+		}
+	}
+
+	if !api.IsRecipeInitialised(*readBody.RecipeId, userContext) {
+		return userImpersonatePostResponse{
+			Status: "RECIPE_NOT_INITIALISED",
+		}, nil
+	}
+
+	userForRecipeId, _ := api.GetUserForRecipeId(*readBody.UserId, *readBody.RecipeId, userContext)
+	if reflect.DeepEqual(userForRecipeId, dashboardmodels.UserType{}) {
+		return userImpersonatePostResponse{
+			Status: "NO_USER_FOUND_ERROR",
+		}, nil
+	}
+
+	adminUserId, err := getDashboardUserId(options, userContext)
+	if err != nil {
+		return userImpersonatePostResponse{}, err
+	}
+
+	sessionContainer, err := session.CreateImpersonationSessionWithoutRequestResponse(adminUserId, *readBody.UserId, tenantId, options.Config.Impersonation.TTL, userContext)
+	if err != nil {
+		return userImpersonatePostResponse{}, err
+	}
+
+	tokens := sessionContainer.GetAllSessionTokensDangerously()
+	return userImpersonatePostResponse{
+		Status:        "OK",
+		SessionHandle: sessionContainer.GetHandleWithContext(userContext),
+		AccessToken:   tokens.AccessToken,
+		FrontToken:    tokens.FrontToken,
+		AntiCsrfToken: tokens.AntiCsrfToken,
+	}, nil
+}
+
+// getDashboardUserId returns the email of the dashboard user who made the request, or "api-key" if the
+// dashboard is protected by an API key
+func getDashboardUserId(options dashboardmodels.APIOptions, userContext supertokens.UserContext) (string, error) {
+	if options.Config.AuthMode == dashboardmodels.AuthModeAPIKey {
+		return string(dashboardmodels.AuthModeAPIKey), nil
+	}
+
+	// We receive the session id as `Bearer SESSION_ID`, this retrieves just the id
+	keyParts := strings.Split(options.Req.Header.Get("authorization"), " ")
+	sessionId := keyParts[len(keyParts)-1]
+
+	querier, err := supertokens.GetNewQuerierInstanceOrThrowError("dashboard", userContext)
+	if err != nil {
+		return "", err
+	}
+
+	verifyResponse, err := querier.SendPostRequest("/recipe/dashboard/session/verify", map[string]interface{}{
+		"sessionId": sessionId,
+	}, userContext)
+	if err != nil {
+		return "", err
+	}
+
+	email, _ := verifyResponse["email"].(string)
+	if email == "" {
+		return "", supertokens.BadInputError{
+			Msg: "Could not find the email of the dashboard user",
+		}
+	}
+	return email, nil
+}
@@ -33,14 +33,8 @@ type userMetaDataRequestBody struct {
 }
 
 func UserMetaDataPut(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) (userMetadataPutResponse, error) {
-	body, err := supertokens.ReadFromRequest(options.Req)
-
-	if err != nil {
-		return userMetadataPutResponse{}, err
-	}
-
 	var readBody userMetaDataRequestBody
-	err = json.Unmarshal(body, &readBody)
+	err := supertokens.ReadJSONFromRequest(options.Req, &readBody)
 	if err != nil {
 		return userMetadataPutResponse{}, err
 	}
@@ -57,7 +51,7 @@ func UserMetaDataPut(apiInterface dashboardmodels.APIInterface, tenantId string,
 		}
 	}
 
-	_, instanceError := usermetadata.GetRecipeInstanceOrThrowError()
+	_, instanceError := usermetadata.GetRecipeInstanceOrThrowError(userContext)
 
 	// This is so that the API exists early if the recipe has not been initialised
 	if instanceError != nil {
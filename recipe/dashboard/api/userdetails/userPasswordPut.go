@@ -16,7 +16,6 @@
 package userdetails
 
 import (
-	"encoding/json"
 	"errors"
 
 	"github.com/supertokens/supertokens-golang/recipe/dashboard/dashboardmodels"
@@ -32,18 +31,12 @@ type userPasswordPutResponse struct {
 
 type userPasswordPutRequestBody struct {
 	UserId      *string `json:"userId"`
-	NewPassword *string `json:"newPassword`
+	NewPassword *string `json:"newPassword"`
 }
 
 func UserPasswordPut(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) (userPasswordPutResponse, error) {
-	body, err := supertokens.ReadFromRequest(options.Req)
-
-	if err != nil {
-		return userPasswordPutResponse{}, err
-	}
-
 	var readBody userPasswordPutRequestBody
-	err = json.Unmarshal(body, &readBody)
+	err := supertokens.ReadJSONFromRequest(options.Req, &readBody)
 	if err != nil {
 		return userPasswordPutResponse{}, err
 	}
@@ -62,7 +55,7 @@ func UserPasswordPut(apiInterface dashboardmodels.APIInterface, tenantId string,
 
 	recipeToUse := "none"
 
-	emailPasswordInstance := emailpassword.GetRecipeInstance()
+	emailPasswordInstance := emailpassword.GetRecipeInstance(userContext)
 
 	if emailPasswordInstance != nil {
 		recipeToUse = "emailpassword"
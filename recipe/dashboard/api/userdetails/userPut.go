@@ -16,7 +16,6 @@
 package userdetails
 
 import (
-	"encoding/json"
 	"errors"
 	"strings"
 
@@ -57,7 +56,7 @@ func updateEmailForRecipeId(recipeId string, userId string, email string, tenant
 	if recipeId == "emailpassword" {
 		var emailField epmodels.NormalisedFormField
 
-		for _, value := range emailpassword.GetRecipeInstance().Config.SignUpFeature.FormFields {
+		for _, value := range emailpassword.GetRecipeInstance(userContext).Config.SignUpFeature.FormFields {
 			if value.ID == "email" {
 				emailField = value
 			}
@@ -97,7 +96,7 @@ func updateEmailForRecipeId(recipeId string, userId string, email string, tenant
 		isValidEmail := true
 		validationError := ""
 
-		passwordlessConfig := passwordless.GetRecipeInstance().Config
+		passwordlessConfig := passwordless.GetRecipeInstance(userContext).Config
 
 		if passwordlessConfig.ContactMethodPhone.Enabled {
 			validationResult := passwordless.DefaultValidateEmailAddress(email, tenantId)
@@ -158,7 +157,7 @@ func updatePhoneForRecipeId(recipeId string, userId string, phone string, tenant
 		isValidPhone := true
 		validationError := ""
 
-		passwordlessConfig := passwordless.GetRecipeInstance().Config
+		passwordlessConfig := passwordless.GetRecipeInstance(userContext).Config
 
 		if passwordlessConfig.ContactMethodEmail.Enabled {
 			validationResult := passwordless.DefaultValidatePhoneNumber(phone, tenantId)
@@ -218,14 +217,8 @@ func updatePhoneForRecipeId(recipeId string, userId string, phone string, tenant
 }
 
 func UserPut(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) (userPutResponse, error) {
-	body, err := supertokens.ReadFromRequest(options.Req)
-
-	if err != nil {
-		return userPutResponse{}, err
-	}
-
 	var readBody userPutRequestBody
-	err = json.Unmarshal(body, &readBody)
+	err := supertokens.ReadJSONFromRequest(options.Req, &readBody)
 	if err != nil {
 		return userPutResponse{}, err
 	}
@@ -271,7 +264,7 @@ func UserPut(apiInterface dashboardmodels.APIInterface, tenantId string, options
 	if *readBody.FirstName != "" || *readBody.LastName != "" {
 		isRecipeInitialised := false
 
-		_, err = usermetadata.GetRecipeInstanceOrThrowError()
+		_, err = usermetadata.GetRecipeInstanceOrThrowError(userContext)
 
 		if err == nil {
 			isRecipeInitialised = true
@@ -16,8 +16,6 @@
 package userdetails
 
 import (
-	"encoding/json"
-
 	"github.com/supertokens/supertokens-golang/recipe/dashboard/dashboardmodels"
 	"github.com/supertokens/supertokens-golang/recipe/session"
 	"github.com/supertokens/supertokens-golang/supertokens"
@@ -32,14 +30,8 @@ type userSessionsPostRequestBody struct {
 }
 
 func UserSessionsRevoke(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) (userSessionsPostResponse, error) {
-	body, err := supertokens.ReadFromRequest(options.Req)
-
-	if err != nil {
-		return userSessionsPostResponse{}, err
-	}
-
 	var readBody userSessionsPostRequestBody
-	err = json.Unmarshal(body, &readBody)
+	err := supertokens.ReadJSONFromRequest(options.Req, &readBody)
 	if err != nil {
 		return userSessionsPostResponse{}, err
 	}
@@ -28,5 +28,5 @@ func apiKeyProtector(apiImpl dashboardmodels.APIInterface, tenantId string, opti
 		return err
 	}
 
-	return supertokens.Send200Response(options.Res, resp)
+	return supertokens.Send200ResponseForAPIResult(options.Res, resp, resp)
 }
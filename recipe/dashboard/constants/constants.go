@@ -25,6 +25,7 @@ const UserAPI = "/api/user"
 const UserEmailVerifyAPI = "/api/user/email/verify"
 const UserMetadataAPI = "/api/user/metadata"
 const UserSessionsAPI = "/api/user/sessions"
+const UserImpersonateAPI = "/api/user/impersonate"
 const UserPasswordAPI = "/api/user/password"
 const UserEmailVerifyTokenAPI = "/api/user/email/verify/token"
 const SearchTagsAPI = "/api/search/tags"
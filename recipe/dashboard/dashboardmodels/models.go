@@ -15,10 +15,21 @@
 
 package dashboardmodels
 
+import "time"
+
 type TypeInput struct {
-	ApiKey   string
-	Admins   *[]string
-	Override *OverrideStruct
+	ApiKey        string
+	Admins        *[]string
+	Override      *OverrideStruct
+	Impersonation *ImpersonationConfig
+}
+
+// ImpersonationConfig enables the API that lets dashboard users sign in as a user from the user details page,
+// using session.CreateImpersonationSession. The email of the dashboard user (or "api-key") is the admin user
+// ID of the session.
+type ImpersonationConfig struct {
+	// TTL is how long impersonation sessions last. Defaults to 15 minutes.
+	TTL time.Duration
 }
 
 type TypeAuthMode string
@@ -29,10 +40,11 @@ const (
 )
 
 type TypeNormalisedInput struct {
-	ApiKey   string
-	Admins   *[]string
-	AuthMode TypeAuthMode
-	Override OverrideStruct
+	ApiKey        string
+	Admins        *[]string
+	AuthMode      TypeAuthMode
+	Override      OverrideStruct
+	Impersonation *ImpersonationConfig
 }
 
 type OverrideStruct struct {
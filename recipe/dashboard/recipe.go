@@ -43,7 +43,7 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 	verifiedConfig := validateAndNormaliseUserInput(appInfo, config)
 	r.Config = verifiedConfig
 
-	querierInstance, err := supertokens.GetNewQuerierInstanceOrThrowError(recipeId)
+	querierInstance, err := supertokens.GetNewQuerierInstanceForAppInfoOrThrowError(appInfo, recipeId)
 	if err != nil {
 		return Recipe{}, err
 	}
@@ -63,13 +63,20 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 
 func recipeInit(config *dashboardmodels.TypeInput) supertokens.Recipe {
 	return func(appInfo supertokens.NormalisedAppinfo, onSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)) (*supertokens.RecipeModule, error) {
-		if singletonInstance == nil {
+		if singletonInstance == nil || !supertokens.IsDefaultInstance(appInfo) {
 			recipe, err := MakeRecipe(RECIPE_ID, appInfo, config, onSuperTokensAPIError)
 			if err != nil {
 				return nil, err
 			}
-			singletonInstance = &recipe
-			return &singletonInstance.RecipeModule, nil
+			if supertokens.IsDefaultInstance(appInfo) {
+				singletonInstance = &recipe
+			} else {
+				err = supertokens.RegisterRecipeInstance(appInfo, RECIPE_ID, &recipe)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return &recipe.RecipeModule, nil
 		}
 		return nil, errors.New("Dashboard recipe has already been initialised. Please check your code for bugs.")
 	}
@@ -120,6 +127,10 @@ func (r *Recipe) getAPIsHandled() ([]supertokens.APIHandled, error) {
 	if err != nil {
 		return nil, err
 	}
+	userImpersonateAPI, err := supertokens.NewNormalisedURLPath(constants.UserImpersonateAPI)
+	if err != nil {
+		return nil, err
+	}
 	userPasswordAPI, err := supertokens.NewNormalisedURLPath(constants.UserPasswordAPI)
 	if err != nil {
 		return nil, err
@@ -238,6 +249,12 @@ func (r *Recipe) getAPIsHandled() ([]supertokens.APIHandled, error) {
 			Method:                 http.MethodPost,
 			Disabled:               false,
 		},
+		{
+			ID:                     constants.UserImpersonateAPI,
+			PathWithoutAPIBasePath: dashboardApiBasePath.AppendPath(userImpersonateAPI),
+			Method:                 http.MethodPost,
+			Disabled:               r.Config.Impersonation == nil,
+		},
 		{
 			ID:                     constants.UserPasswordAPI,
 			PathWithoutAPIBasePath: dashboardApiBasePath.AppendPath(userPasswordAPI),
@@ -323,6 +340,8 @@ func (r *Recipe) handleAPIRequest(id string, tenantId string, req *http.Request,
 			if req.Method == http.MethodPost {
 				return userdetails.UserSessionsRevoke(r.APIImpl, tenantId, options, userContext)
 			}
+		} else if id == constants.UserImpersonateAPI {
+			return userdetails.UserImpersonatePost(r.APIImpl, tenantId, options, userContext)
 		} else if id == constants.UserMetadataAPI {
 			if req.Method == http.MethodGet {
 				return userdetails.UserMetaDataGet(r.APIImpl, tenantId, options, userContext)
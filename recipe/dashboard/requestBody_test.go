@@ -0,0 +1,100 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/supertokens/supertokens-golang/internal/testingutils"
+	"github.com/supertokens/supertokens-golang/recipe/dashboard/api"
+	"github.com/supertokens/supertokens-golang/recipe/dashboard/api/userdetails"
+	"github.com/supertokens/supertokens-golang/recipe/dashboard/dashboardmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func FuzzDashboardAPIRequestBodies(f *testing.F) {
+	testingutils.AddRequestBodySeeds(f,
+		`{"email":"test@example.com","password":"password1"}`,
+		`{"userId":"user","verified":true}`,
+		`{"userId":"user","newPassword":"password1"}`,
+		`{"userId":"user","data":"{\"key\":\"value\"}"}`,
+		`{"userId":"user","recipeId":"emailpassword","firstName":"","lastName":"","email":"test@example.com","phone":""}`,
+		`{"sessionHandles":["handle"]}`,
+		`{"userId":[],"sessionHandles":"handle","verified":"true"}`,
+	)
+	core := testingutils.StartFakeCore(map[string]interface{}{
+		"/recipe/dashboard/signin": map[string]interface{}{"status": "INVALID_CREDENTIALS_ERROR"},
+	})
+	defer core.Close()
+
+	apiBasePath := "/auth"
+	instance, err := supertokens.New(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: core.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+			APIBasePath:   &apiBasePath,
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(&dashboardmodels.TypeInput{
+				ApiKey: "test",
+			}),
+		},
+	})
+	if err != nil {
+		f.Fatal(err.Error())
+	}
+	defer instance.Close()
+	handler := instance.Middleware(nil)
+
+	handlers := []func(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) error{
+		func(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) error {
+			_, err := userdetails.UserEmailVerifyPut(apiInterface, tenantId, options, userContext)
+			return err
+		},
+		func(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) error {
+			_, err := userdetails.UserEmailVerifyTokenPost(apiInterface, tenantId, options, userContext)
+			return err
+		},
+		func(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) error {
+			_, err := userdetails.UserMetaDataPut(apiInterface, tenantId, options, userContext)
+			return err
+		},
+		func(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) error {
+			_, err := userdetails.UserPasswordPut(apiInterface, tenantId, options, userContext)
+			return err
+		},
+		func(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) error {
+			_, err := userdetails.UserPut(apiInterface, tenantId, options, userContext)
+			return err
+		},
+		func(apiInterface dashboardmodels.APIInterface, tenantId string, options dashboardmodels.APIOptions, userContext supertokens.UserContext) error {
+			_, err := userdetails.UserSessionsRevoke(apiInterface, tenantId, options, userContext)
+			return err
+		},
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		testingutils.AssertAPIHandlesRequestBody(t, handler, "/auth/dashboard/api/signin", body)
+
+		var object map[string]interface{}
+		isObject := json.Unmarshal(body, &object) == nil && object != nil
+		for _, handle := range handlers {
+			options := dashboardmodels.APIOptions{
+				RecipeID: RECIPE_ID,
+				Req:      httptest.NewRequest(http.MethodPut, "/auth/dashboard/api/user", bytes.NewReader(body)),
+				Res:      httptest.NewRecorder(),
+			}
+			err := handle(api.MakeAPIImplementation(), "public", options, instance.BindUserContext(nil))
+			if !isObject && !errors.As(err, &supertokens.BadInputError{}) {
+				t.Fatalf("expected %q to be rejected with a bad input error, got %v", body, err)
+			}
+		}
+	})
+}
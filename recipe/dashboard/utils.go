@@ -19,6 +19,7 @@ import (
 	"github.com/supertokens/supertokens-golang/recipe/dashboard/dashboardmodels"
 	"github.com/supertokens/supertokens-golang/supertokens"
 	"strings"
+	"time"
 )
 
 func validateAndNormaliseUserInput(appInfo supertokens.NormalisedAppinfo, config *dashboardmodels.TypeInput) dashboardmodels.TypeNormalisedInput {
@@ -54,6 +55,14 @@ func validateAndNormaliseUserInput(appInfo supertokens.NormalisedAppinfo, config
 
 	typeNormalisedInput.Admins = admins
 
+	if _config.Impersonation != nil {
+		impersonation := *_config.Impersonation
+		if impersonation.TTL <= 0 {
+			impersonation.TTL = 15 * time.Minute
+		}
+		typeNormalisedInput.Impersonation = &impersonation
+	}
+
 	return typeNormalisedInput
 }
 
@@ -34,12 +34,12 @@ func EmailExists(apiImplementation epmodels.APIInterface, tenantId string, optio
 		return err
 	}
 	if result.OK != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, map[string]interface{}{
 			"status": "OK",
 			"exists": result.OK.Exists,
 		})
 	} else if result.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
 	}
 
 	return supertokens.ErrorIfNoResponse(options.Res)
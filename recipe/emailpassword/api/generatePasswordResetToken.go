@@ -16,8 +16,6 @@
 package api
 
 import (
-	"encoding/json"
-
 	"github.com/supertokens/supertokens-golang/recipe/emailpassword/epmodels"
 	"github.com/supertokens/supertokens-golang/supertokens"
 )
@@ -29,12 +27,7 @@ func GeneratePasswordResetToken(apiImplementation epmodels.APIInterface, tenantI
 		return nil
 	}
 
-	body, err := supertokens.ReadFromRequest(options.Req)
-	if err != nil {
-		return err
-	}
-	var formFieldsRaw map[string]interface{}
-	err = json.Unmarshal(body, &formFieldsRaw)
+	formFieldsRaw, err := supertokens.ReadJSONObjectFromRequest(options.Req, "formFields")
 	if err != nil {
 		return err
 	}
@@ -49,11 +42,11 @@ func GeneratePasswordResetToken(apiImplementation epmodels.APIInterface, tenantI
 		return err
 	}
 	if resp.OK != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, map[string]interface{}{
 			"status": "OK",
 		})
 	} else if resp.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*resp.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, supertokens.ConvertGeneralErrorToJsonResponse(*resp.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
@@ -176,7 +176,7 @@ func MakeAPIImplementation() epmodels.APIInterface {
 		}
 
 		user := response.OK.User
-		session, err := session.CreateNewSession(options.Req, options.Res, tenantId, user.ID, map[string]interface{}{}, map[string]interface{}{}, userContext)
+		session, err := session.CreateNewSessionForSignIn(options.Req, options.Res, tenantId, user.ID, map[string]interface{}{}, map[string]interface{}{}, userContext)
 		if err != nil {
 			return epmodels.SignInPOSTResponse{}, err
 		}
@@ -227,7 +227,7 @@ func MakeAPIImplementation() epmodels.APIInterface {
 
 		user := response.OK.User
 
-		session, err := session.CreateNewSession(options.Req, options.Res, tenantId, user.ID, map[string]interface{}{}, map[string]interface{}{}, userContext)
+		session, err := session.CreateNewSessionForSignIn(options.Req, options.Res, tenantId, user.ID, map[string]interface{}{}, map[string]interface{}{}, userContext)
 		if err != nil {
 			return epmodels.SignUpPOSTResponse{}, err
 		}
@@ -16,7 +16,6 @@
 package api
 
 import (
-	"encoding/json"
 	"reflect"
 
 	"github.com/supertokens/supertokens-golang/recipe/emailpassword/epmodels"
@@ -29,12 +28,7 @@ func PasswordReset(apiImplementation epmodels.APIInterface, tenantId string, opt
 		return nil
 	}
 
-	body, err := supertokens.ReadFromRequest(options.Req)
-	if err != nil {
-		return err
-	}
-	var formFieldsRaw map[string]interface{}
-	err = json.Unmarshal(body, &formFieldsRaw)
+	formFieldsRaw, err := supertokens.ReadJSONObjectFromRequest(options.Req, "method", "formFields", "token")
 	if err != nil {
 		return err
 	}
@@ -57,15 +51,15 @@ func PasswordReset(apiImplementation epmodels.APIInterface, tenantId string, opt
 		return err
 	}
 	if result.OK != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, map[string]interface{}{
 			"status": "OK",
 		})
 	} else if result.ResetPasswordInvalidTokenError != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, map[string]interface{}{
 			"status": "RESET_PASSWORD_INVALID_TOKEN_ERROR",
 		})
 	} else if result.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
@@ -16,8 +16,6 @@
 package api
 
 import (
-	"encoding/json"
-
 	"github.com/supertokens/supertokens-golang/recipe/emailpassword/epmodels"
 	"github.com/supertokens/supertokens-golang/supertokens"
 )
@@ -28,12 +26,7 @@ func SignInAPI(apiImplementation epmodels.APIInterface, tenantId string, options
 		return nil
 	}
 
-	body, err := supertokens.ReadFromRequest(options.Req)
-	if err != nil {
-		return err
-	}
-	var formFieldsRaw map[string]interface{}
-	err = json.Unmarshal(body, &formFieldsRaw)
+	formFieldsRaw, err := supertokens.ReadJSONObjectFromRequest(options.Req, "formFields", "shouldTryLinkingWithSessionUser")
 	if err != nil {
 		return err
 	}
@@ -48,16 +41,21 @@ func SignInAPI(apiImplementation epmodels.APIInterface, tenantId string, options
 		return err
 	}
 	if result.WrongCredentialsError != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, map[string]interface{}{
 			"status": "WRONG_CREDENTIALS_ERROR",
 		})
 	} else if result.OK != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		supertokens.AddToCounter(userContext, supertokens.MetricSignIns, 1, map[string]interface{}{
+			"recipeId":       options.RecipeID,
+			"tenantId":       tenantId,
+			"createdNewUser": false,
+		})
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, map[string]interface{}{
 			"status": "OK",
 			"user":   result.OK.User,
 		})
 	} else if result.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
@@ -16,8 +16,6 @@
 package api
 
 import (
-	"encoding/json"
-
 	"github.com/supertokens/supertokens-golang/recipe/emailpassword/epmodels"
 	"github.com/supertokens/supertokens-golang/recipe/emailpassword/errors"
 	"github.com/supertokens/supertokens-golang/supertokens"
@@ -29,12 +27,7 @@ func SignUpAPI(apiImplementation epmodels.APIInterface, tenantId string, options
 		return nil
 	}
 
-	body, err := supertokens.ReadFromRequest(options.Req)
-	if err != nil {
-		return err
-	}
-	var formFieldsRaw map[string]interface{}
-	err = json.Unmarshal(body, &formFieldsRaw)
+	formFieldsRaw, err := supertokens.ReadJSONObjectFromRequest(options.Req, "formFields", "shouldTryLinkingWithSessionUser")
 	if err != nil {
 		return err
 	}
@@ -49,7 +42,7 @@ func SignUpAPI(apiImplementation epmodels.APIInterface, tenantId string, options
 		return err
 	}
 	if result.OK != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, map[string]interface{}{
 			"status": "OK",
 			"user":   result.OK.User,
 		})
@@ -62,7 +55,7 @@ func SignUpAPI(apiImplementation epmodels.APIInterface, tenantId string, options
 			}},
 		}
 	} else if result.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
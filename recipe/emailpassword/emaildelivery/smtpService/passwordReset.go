@@ -933,8 +933,8 @@ const passwordResetTemplate = `<!doctype html>
 
 </html>`
 
-func getPasswordResetEmailContent(input emaildelivery.PasswordResetType) (emaildelivery.EmailContent, error) {
-	stInstance, err := supertokens.GetInstanceOrThrowError()
+func getPasswordResetEmailContent(input emaildelivery.PasswordResetType, userContext supertokens.UserContext) (emaildelivery.EmailContent, error) {
+	stInstance, err := supertokens.GetInstanceOrThrowError(userContext)
 	if err != nil {
 		panic("Please call supertokens.Init function before using the Middleware")
 	}
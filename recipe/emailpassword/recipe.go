@@ -46,7 +46,7 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 	r := &Recipe{}
 	r.RecipeModule = supertokens.MakeRecipeModule(recipeId, appInfo, r.handleAPIRequest, r.getAllCORSHeaders, r.getAPIsHandled, nil, r.handleError, onSuperTokensAPIError)
 
-	querierInstance, err := supertokens.GetNewQuerierInstanceOrThrowError(recipeId)
+	querierInstance, err := supertokens.GetNewQuerierInstanceForAppInfoOrThrowError(appInfo, recipeId)
 	if err != nil {
 		return Recipe{}, err
 	}
@@ -64,8 +64,8 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 		r.EmailDelivery = emaildelivery.MakeIngredient(verifiedConfig.GetEmailDeliveryConfig(r.RecipeImpl))
 	}
 
-	supertokens.AddPostInitCallback(func() error {
-		emailVerificationRecipe := emailverification.GetRecipeInstance()
+	supertokens.AddPostInitCallbackForAppInfo(appInfo, func(userContext supertokens.UserContext) error {
+		emailVerificationRecipe := emailverification.GetRecipeInstance(userContext)
 		if emailVerificationRecipe != nil {
 			emailVerificationRecipe.AddGetEmailForUserIdFunc(r.getEmailForUserId)
 		}
@@ -80,26 +80,41 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 
 func recipeInit(config *epmodels.TypeInput) supertokens.Recipe {
 	return func(appInfo supertokens.NormalisedAppinfo, onSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)) (*supertokens.RecipeModule, error) {
-		if singletonInstance == nil {
+		if singletonInstance == nil || !supertokens.IsDefaultInstance(appInfo) {
 			recipe, err := MakeRecipe(RECIPE_ID, appInfo, config, nil, onSuperTokensAPIError)
 			if err != nil {
 				return nil, err
 			}
-			singletonInstance = &recipe
-			return &singletonInstance.RecipeModule, nil
+			if supertokens.IsDefaultInstance(appInfo) {
+				singletonInstance = &recipe
+			} else {
+				err = supertokens.RegisterRecipeInstance(appInfo, RECIPE_ID, &recipe)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return &recipe.RecipeModule, nil
 		}
 		return nil, defaultErrors.New("emailpassword recipe has already been initialised. Please check your code for bugs.")
 	}
 }
 
-func GetRecipeInstanceOrThrowError() (*Recipe, error) {
-	if singletonInstance != nil {
+func GetRecipeInstanceOrThrowError(userContext ...supertokens.UserContext) (*Recipe, error) {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		if recipe, ok := instance.(*Recipe); ok {
+			return recipe, nil
+		}
+	} else if singletonInstance != nil {
 		return singletonInstance, nil
 	}
 	return nil, defaultErrors.New("initialisation not done. Did you forget to call the init function?")
 }
 
-func GetRecipeInstance() *Recipe {
+func GetRecipeInstance(userContext ...supertokens.UserContext) *Recipe {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		recipe, _ := instance.(*Recipe)
+		return recipe
+	}
 	return singletonInstance
 }
 
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package emailpassword
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/internal/testingutils"
+	"github.com/supertokens/supertokens-golang/recipe/emailpassword/epmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+	"github.com/supertokens/supertokens-golang/test/unittesting"
+)
+
+func newInstanceWithStubbedAPIsForTest(t testing.TB, coreURL string, requestBody *supertokens.RequestBodyConfig) *supertokens.Instance {
+	apiBasePath := "/auth"
+	instance, err := supertokens.New(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: coreURL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+			APIBasePath:   &apiBasePath,
+		},
+		RequestBody: requestBody,
+		RecipeList: []supertokens.Recipe{
+			Init(&epmodels.TypeInput{
+				Override: &epmodels.OverrideStruct{
+					APIs: func(originalImplementation epmodels.APIInterface) epmodels.APIInterface {
+						signUpPOST := func(formFields []epmodels.TypeFormField, tenantId string, options epmodels.APIOptions, userContext supertokens.UserContext) (epmodels.SignUpPOSTResponse, error) {
+							return epmodels.SignUpPOSTResponse{EmailAlreadyExistsError: &struct{}{}}, nil
+						}
+						signInPOST := func(formFields []epmodels.TypeFormField, tenantId string, options epmodels.APIOptions, userContext supertokens.UserContext) (epmodels.SignInPOSTResponse, error) {
+							return epmodels.SignInPOSTResponse{WrongCredentialsError: &struct{}{}}, nil
+						}
+						generatePasswordResetTokenPOST := func(formFields []epmodels.TypeFormField, tenantId string, options epmodels.APIOptions, userContext supertokens.UserContext) (epmodels.GeneratePasswordResetTokenPOSTResponse, error) {
+							return epmodels.GeneratePasswordResetTokenPOSTResponse{OK: &struct{}{}}, nil
+						}
+						passwordResetPOST := func(formFields []epmodels.TypeFormField, token string, tenantId string, options epmodels.APIOptions, userContext supertokens.UserContext) (epmodels.ResetPasswordPOSTResponse, error) {
+							return epmodels.ResetPasswordPOSTResponse{ResetPasswordInvalidTokenError: &struct{}{}}, nil
+						}
+						originalImplementation.SignUpPOST = &signUpPOST
+						originalImplementation.SignInPOST = &signInPOST
+						originalImplementation.GeneratePasswordResetTokenPOST = &generatePasswordResetTokenPOST
+						originalImplementation.PasswordResetPOST = &passwordResetPOST
+						return originalImplementation
+					},
+				},
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return instance
+}
+
+func TestThatMalformedRequestBodiesAreRejectedWithBadInputErrors(t *testing.T) {
+	core := testingutils.StartFakeCore(nil)
+	defer core.Close()
+	instance := newInstanceWithStubbedAPIsForTest(t, core.URL, &supertokens.RequestBodyConfig{MaxSize: 100})
+	defer instance.Close()
+	handler := instance.Middleware(nil)
+
+	send := func(body string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "/auth/signin", strings.NewReader(body))
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		return *unittesting.HttpResponseToConsumableInformation(res.Result().Body)
+	}
+
+	assert.Equal(t, "Request body must be a valid JSON object", send("not json")["message"])
+	assert.Equal(t, "Request body must be a valid JSON object", send("[]")["message"])
+	assert.Equal(t, "Request body must contain a single JSON object", send(`{"formFields":[]} {}`)["message"])
+	assert.Equal(t, "Duplicate field 'formFields' in request body", send(`{"formFields":[],"formFields":[]}`)["message"])
+	assert.Equal(t, "Request body must not be larger than 100 bytes", send(`{"formFields":[{"id":"email","value":"` + strings.Repeat("a", 100) + `"}]}`)["message"])
+}
+
+func TestThatUnknownFieldsAreOnlyRejectedIfConfigured(t *testing.T) {
+	core := testingutils.StartFakeCore(nil)
+	defer core.Close()
+	body := `{"formFields":[{"id":"email","value":"test@example.com"},{"id":"password","value":"password1"}],"shouldTryLinkingWithSessionUser":false,"extra":true}`
+
+	instance := newInstanceWithStubbedAPIsForTest(t, core.URL, nil)
+	defer instance.Close()
+	req := httptest.NewRequest(http.MethodPost, "/auth/signin", strings.NewReader(body))
+	res := httptest.NewRecorder()
+	instance.Middleware(nil).ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "WRONG_CREDENTIALS_ERROR", (*unittesting.HttpResponseToConsumableInformation(res.Result().Body))["status"])
+
+	strictInstance := newInstanceWithStubbedAPIsForTest(t, core.URL, &supertokens.RequestBodyConfig{RejectUnknownFields: true})
+	defer strictInstance.Close()
+	req = httptest.NewRequest(http.MethodPost, "/auth/signin", strings.NewReader(body))
+	res = httptest.NewRecorder()
+	strictInstance.Middleware(nil).ServeHTTP(res, req)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, "Unknown field 'extra' in request body", (*unittesting.HttpResponseToConsumableInformation(res.Result().Body))["message"])
+}
+
+func FuzzEmailPasswordAPIRequestBodies(f *testing.F) {
+	testingutils.AddRequestBodySeeds(f,
+		`{"formFields":[{"id":"email","value":"test@example.com"},{"id":"password","value":"password1"}]}`,
+		`{"formFields":[{"id":"email","value":"test@example.com"}]}`,
+		`{"method":"token","token":"token","formFields":[{"id":"password","value":"password1"}]}`,
+	)
+	core := testingutils.StartFakeCore(nil)
+	defer core.Close()
+	instance := newInstanceWithStubbedAPIsForTest(f, core.URL, nil)
+	defer instance.Close()
+	handler := instance.Middleware(nil)
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		for _, path := range []string{"/auth/signup", "/auth/signin", "/auth/user/password/reset/token", "/auth/user/password/reset"} {
+			testingutils.AssertAPIHandlesRequestBody(t, handler, path, body)
+		}
+	})
+}
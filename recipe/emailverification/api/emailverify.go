@@ -16,7 +16,6 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 	"reflect"
 
@@ -29,6 +28,7 @@ import (
 
 func EmailVerify(apiImplementation evmodels.APIInterface, tenantId string, options evmodels.APIOptions, userContext supertokens.UserContext) error {
 	var result map[string]interface{}
+	var typedResult interface{}
 	if options.Req.Method == http.MethodPost {
 		if apiImplementation.VerifyEmailPOST == nil ||
 			(*apiImplementation.VerifyEmailPOST) == nil {
@@ -42,7 +42,8 @@ func EmailVerify(apiImplementation evmodels.APIInterface, tenantId string, optio
 			&sessmodels.VerifySessionOptions{
 				SessionRequired: &sessionRequired,
 				OverrideGlobalClaimValidators: func(globalClaimValidators []claims.SessionClaimValidator, sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) ([]claims.SessionClaimValidator, error) {
-					validators := []claims.SessionClaimValidator{}
+					// an admin must not verify emails on behalf of a user
+					validators := []claims.SessionClaimValidator{session.RequireNoImpersonation()}
 					return validators, nil
 				},
 			},
@@ -51,13 +52,7 @@ func EmailVerify(apiImplementation evmodels.APIInterface, tenantId string, optio
 			return err
 		}
 
-		body, err := supertokens.ReadFromRequest(options.Req)
-
-		if err != nil {
-			return err
-		}
-		var readBody map[string]interface{}
-		err = json.Unmarshal(body, &readBody)
+		readBody, err := supertokens.ReadJSONObjectFromRequest(options.Req, "method", "token")
 		if err != nil {
 			return err
 		}
@@ -73,6 +68,7 @@ func EmailVerify(apiImplementation evmodels.APIInterface, tenantId string, optio
 		if err != nil {
 			return err
 		}
+		typedResult = response
 		if response.EmailVerificationInvalidTokenError != nil {
 			result = map[string]interface{}{
 				"status": "EMAIL_VERIFICATION_INVALID_TOKEN_ERROR",
@@ -113,6 +109,7 @@ func EmailVerify(apiImplementation evmodels.APIInterface, tenantId string, optio
 		if err != nil {
 			return err
 		}
+		typedResult = isVerified
 
 		if isVerified.OK != nil {
 			result = map[string]interface{}{
@@ -126,5 +123,5 @@ func EmailVerify(apiImplementation evmodels.APIInterface, tenantId string, optio
 		}
 	}
 
-	return supertokens.Send200Response(options.Res, result)
+	return supertokens.Send200ResponseForAPIResult(options.Res, typedResult, result)
 }
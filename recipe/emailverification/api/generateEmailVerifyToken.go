@@ -34,7 +34,8 @@ func GenerateEmailVerifyToken(apiImplementation evmodels.APIInterface, options e
 		options.Req, options.Res,
 		&sessmodels.VerifySessionOptions{
 			OverrideGlobalClaimValidators: func(globalClaimValidators []claims.SessionClaimValidator, sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) ([]claims.SessionClaimValidator, error) {
-				validators := []claims.SessionClaimValidator{}
+				// an admin must not send verification emails on behalf of a user
+				validators := []claims.SessionClaimValidator{session.RequireNoImpersonation()}
 				return validators, nil
 			},
 		},
@@ -49,15 +50,15 @@ func GenerateEmailVerifyToken(apiImplementation evmodels.APIInterface, options e
 		return err
 	}
 	if response.EmailAlreadyVerifiedError != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, response, map[string]interface{}{
 			"status": "EMAIL_ALREADY_VERIFIED_ERROR",
 		})
 	} else if response.OK != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, response, map[string]interface{}{
 			"status": "OK",
 		})
 	} else if response.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*response.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, response, supertokens.ConvertGeneralErrorToJsonResponse(*response.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
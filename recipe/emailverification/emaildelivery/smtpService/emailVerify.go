@@ -941,8 +941,8 @@ const emailVerificationTemplate = `<!doctype html>
 
 </html>`
 
-func getEmailVerifyEmailContent(input emaildelivery.EmailVerificationType) (emaildelivery.EmailContent, error) {
-	stInstance, err := supertokens.GetInstanceOrThrowError()
+func getEmailVerifyEmailContent(input emaildelivery.EmailVerificationType, userContext supertokens.UserContext) (emaildelivery.EmailContent, error) {
+	stInstance, err := supertokens.GetInstanceOrThrowError(userContext)
 	if err != nil {
 		panic("Please call supertokens.Init function before using the Middleware")
 	}
@@ -30,7 +30,7 @@ func Init(config evmodels.TypeInput) supertokens.Recipe {
 }
 
 func CreateEmailVerificationToken(tenantId string, userID string, email *string, userContext ...supertokens.UserContext) (evmodels.CreateEmailVerificationTokenResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return evmodels.CreateEmailVerificationTokenResponse{}, err
 	}
@@ -56,7 +56,7 @@ func CreateEmailVerificationToken(tenantId string, userID string, email *string,
 }
 
 func VerifyEmailUsingToken(tenantId string, token string, userContext ...supertokens.UserContext) (evmodels.VerifyEmailUsingTokenResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return evmodels.VerifyEmailUsingTokenResponse{}, err
 	}
@@ -67,7 +67,7 @@ func VerifyEmailUsingToken(tenantId string, token string, userContext ...superto
 }
 
 func IsEmailVerified(userID string, email *string, userContext ...supertokens.UserContext) (bool, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return false, err
 	}
@@ -91,7 +91,7 @@ func IsEmailVerified(userID string, email *string, userContext ...supertokens.Us
 }
 
 func RevokeEmailVerificationTokens(tenantId string, userID string, email *string, userContext ...supertokens.UserContext) (evmodels.RevokeEmailVerificationTokensResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return evmodels.RevokeEmailVerificationTokensResponse{}, err
 	}
@@ -117,7 +117,7 @@ func RevokeEmailVerificationTokens(tenantId string, userID string, email *string
 }
 
 func UnverifyEmail(userID string, email *string, userContext ...supertokens.UserContext) (evmodels.UnverifyEmailResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return evmodels.UnverifyEmailResponse{}, err
 	}
@@ -143,7 +143,7 @@ func UnverifyEmail(userID string, email *string, userContext ...supertokens.User
 }
 
 func SendEmail(input emaildelivery.EmailType, userContext ...supertokens.UserContext) error {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return err
 	}
@@ -154,7 +154,7 @@ func SendEmail(input emaildelivery.EmailType, userContext ...supertokens.UserCon
 }
 
 func CreateEmailVerificationLink(tenantId string, userID string, email *string, userContext ...supertokens.UserContext) (evmodels.CreateEmailVerificationLinkResponse, error) {
-	st, err := supertokens.GetInstanceOrThrowError()
+	st, err := supertokens.GetInstanceOrThrowError(userContext...)
 	if err != nil {
 		return evmodels.CreateEmailVerificationLinkResponse{}, err
 	}
@@ -191,7 +191,7 @@ func SendEmailVerificationEmail(tenantId string, userID string, email *string, u
 		userContext = append(userContext, &map[string]interface{}{})
 	}
 	if email == nil {
-		instance, err := getRecipeInstanceOrThrowError()
+		instance, err := getRecipeInstanceOrThrowError(userContext...)
 		if err != nil {
 			return evmodels.SendEmailVerificationLinkResponse{}, err
 		}
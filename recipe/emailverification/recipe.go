@@ -54,7 +54,7 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config e
 	r.Config = verifiedConfig
 	r.APIImpl = verifiedConfig.Override.APIs(api.MakeAPIImplementation())
 
-	querierInstance, err := supertokens.GetNewQuerierInstanceOrThrowError(recipeId)
+	querierInstance, err := supertokens.GetNewQuerierInstanceForAppInfoOrThrowError(appInfo, recipeId)
 	if err != nil {
 		return Recipe{}, err
 	}
@@ -106,28 +106,43 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config e
 	return *r, nil
 }
 
-func getRecipeInstanceOrThrowError() (*Recipe, error) {
-	if singletonInstance != nil {
+func getRecipeInstanceOrThrowError(userContext ...supertokens.UserContext) (*Recipe, error) {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		if recipe, ok := instance.(*Recipe); ok {
+			return recipe, nil
+		}
+	} else if singletonInstance != nil {
 		return singletonInstance, nil
 	}
 	return nil, errors.New("Initialisation not done. Did you forget to call the init function?")
 }
 
-func GetRecipeInstance() *Recipe {
+func GetRecipeInstance(userContext ...supertokens.UserContext) *Recipe {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		recipe, _ := instance.(*Recipe)
+		return recipe
+	}
 	return singletonInstance
 }
 
 func recipeInit(config evmodels.TypeInput) supertokens.Recipe {
 	return func(appInfo supertokens.NormalisedAppinfo, onSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)) (*supertokens.RecipeModule, error) {
-		if singletonInstance == nil {
+		if singletonInstance == nil || !supertokens.IsDefaultInstance(appInfo) {
 			recipe, err := MakeRecipe(RECIPE_ID, appInfo, config, nil, onSuperTokensAPIError)
 			if err != nil {
 				return nil, err
 			}
-			singletonInstance = &recipe
+			if supertokens.IsDefaultInstance(appInfo) {
+				singletonInstance = &recipe
+			} else {
+				err = supertokens.RegisterRecipeInstance(appInfo, RECIPE_ID, &recipe)
+				if err != nil {
+					return nil, err
+				}
+			}
 
-			supertokens.AddPostInitCallback(func() error {
-				sessionRecipe, err := session.GetRecipeInstanceOrThrowError()
+			supertokens.AddPostInitCallbackForAppInfo(appInfo, func(userContext supertokens.UserContext) error {
+				sessionRecipe, err := session.GetRecipeInstanceOrThrowError(userContext)
 
 				if err != nil {
 					return err
@@ -142,7 +157,7 @@ func recipeInit(config evmodels.TypeInput) supertokens.Recipe {
 				}
 				return nil
 			})
-			return &singletonInstance.RecipeModule, nil
+			return &recipe.RecipeModule, nil
 		}
 		return nil, errors.New("Emailverification recipe has already been initialised. Please check your code for bugs.")
 	}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2024, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package emailverification
+
+import (
+	"testing"
+
+	"github.com/supertokens/supertokens-golang/internal/testingutils"
+	"github.com/supertokens/supertokens-golang/recipe/emailverification/evmodels"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func FuzzVerifyEmailRequestBodies(f *testing.F) {
+	testingutils.AddRequestBodySeeds(f,
+		`{"method":"token","token":"token"}`,
+		`{"token":"token"}`,
+	)
+	core := testingutils.StartFakeCore(nil)
+	defer core.Close()
+
+	apiBasePath := "/auth"
+	instance, err := supertokens.New(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: core.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+			APIBasePath:   &apiBasePath,
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(evmodels.TypeInput{
+				Mode: evmodels.ModeOptional,
+				Override: &evmodels.OverrideStruct{
+					APIs: func(originalImplementation evmodels.APIInterface) evmodels.APIInterface {
+						verifyEmailPOST := func(token string, sessionContainer sessmodels.SessionContainer, tenantId string, options evmodels.APIOptions, userContext supertokens.UserContext) (evmodels.VerifyEmailPOSTResponse, error) {
+							return evmodels.VerifyEmailPOSTResponse{EmailVerificationInvalidTokenError: &struct{}{}}, nil
+						}
+						originalImplementation.VerifyEmailPOST = &verifyEmailPOST
+						return originalImplementation
+					},
+				},
+			}),
+			session.Init(nil),
+		},
+	})
+	if err != nil {
+		f.Fatal(err.Error())
+	}
+	defer instance.Close()
+	handler := instance.Middleware(nil)
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		testingutils.AssertAPIHandlesRequestBody(t, handler, "/auth/user/email/verify", body)
+	})
+}
@@ -32,10 +32,10 @@ func GetJWKS(apiImplementation jwtmodels.APIInterface, options jwtmodels.APIOpti
 	}
 
 	if response.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*response.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, response, supertokens.ConvertGeneralErrorToJsonResponse(*response.GeneralError))
 	} else if response.OK != nil {
 		options.Res.Header().Set("Access-Control-Allow-Origin", "*")
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, response, map[string]interface{}{
 			"keys": response.OK.Keys,
 		})
 	}
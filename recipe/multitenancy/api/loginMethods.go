@@ -24,9 +24,9 @@ func LoginMethodsAPI(apiImplementation multitenancymodels.APIInterface, tenantId
 	}
 
 	if result.OK != nil {
-		return supertokens.Send200Response(options.Res, result.OK)
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, result.OK)
 	} else if result.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
@@ -26,7 +26,7 @@ func Init(config *multitenancymodels.TypeInput) supertokens.Recipe {
 }
 
 func CreateOrUpdateTenant(tenantId string, config multitenancymodels.TenantConfig, userContext ...supertokens.UserContext) (multitenancymodels.CreateOrUpdateTenantResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return multitenancymodels.CreateOrUpdateTenantResponse{}, err
 	}
@@ -37,7 +37,7 @@ func CreateOrUpdateTenant(tenantId string, config multitenancymodels.TenantConfi
 }
 
 func DeleteTenant(tenantId string, userContext ...supertokens.UserContext) (multitenancymodels.DeleteTenantResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return multitenancymodels.DeleteTenantResponse{}, err
 	}
@@ -48,7 +48,7 @@ func DeleteTenant(tenantId string, userContext ...supertokens.UserContext) (mult
 }
 
 func GetTenant(tenantId string, userContext ...supertokens.UserContext) (*multitenancymodels.Tenant, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +59,7 @@ func GetTenant(tenantId string, userContext ...supertokens.UserContext) (*multit
 }
 
 func ListAllTenants(userContext ...supertokens.UserContext) (multitenancymodels.ListAllTenantsResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return multitenancymodels.ListAllTenantsResponse{}, err
 	}
@@ -71,7 +71,7 @@ func ListAllTenants(userContext ...supertokens.UserContext) (multitenancymodels.
 
 // Third party provider management
 func CreateOrUpdateThirdPartyConfig(tenantId string, config tpmodels.ProviderConfig, skipValidation *bool, userContext ...supertokens.UserContext) (multitenancymodels.CreateOrUpdateThirdPartyConfigResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return multitenancymodels.CreateOrUpdateThirdPartyConfigResponse{}, err
 	}
@@ -82,7 +82,7 @@ func CreateOrUpdateThirdPartyConfig(tenantId string, config tpmodels.ProviderCon
 }
 
 func DeleteThirdPartyConfig(tenantId string, thirdPartyId string, userContext ...supertokens.UserContext) (multitenancymodels.DeleteThirdPartyConfigResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return multitenancymodels.DeleteThirdPartyConfigResponse{}, err
 	}
@@ -93,7 +93,7 @@ func DeleteThirdPartyConfig(tenantId string, thirdPartyId string, userContext ..
 }
 
 func AssociateUserToTenant(tenantId string, userId string, userContext ...supertokens.UserContext) (multitenancymodels.AssociateUserToTenantResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return multitenancymodels.AssociateUserToTenantResponse{}, err
 	}
@@ -104,7 +104,7 @@ func AssociateUserToTenant(tenantId string, userId string, userContext ...supert
 }
 
 func DisassociateUserFromTenant(tenantId string, userId string, userContext ...supertokens.UserContext) (multitenancymodels.DisassociateUserFromTenantResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return multitenancymodels.DisassociateUserFromTenantResponse{}, err
 	}
@@ -46,7 +46,7 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 	verifiedConfig := validateAndNormaliseUserInput(config)
 	r.Config = verifiedConfig
 
-	querierInstance, err := supertokens.GetNewQuerierInstanceOrThrowError(recipeId)
+	querierInstance, err := supertokens.GetNewQuerierInstanceForAppInfoOrThrowError(appInfo, recipeId)
 	if err != nil {
 		return nil, err
 	}
@@ -67,29 +67,37 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 	return r, nil
 }
 
-func GetRecipeInstanceOrThrowError() (*Recipe, error) {
-	if singletonInstance != nil {
+func GetRecipeInstanceOrThrowError(userContext ...supertokens.UserContext) (*Recipe, error) {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		if recipe, ok := instance.(*Recipe); ok {
+			return recipe, nil
+		}
+	} else if singletonInstance != nil {
 		return singletonInstance, nil
 	}
 
 	return nil, errors.New("Initialisation not done. Did you forget to call the init function?")
 }
 
-func GetRecipeInstance() *Recipe {
+func GetRecipeInstance(userContext ...supertokens.UserContext) *Recipe {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		recipe, _ := instance.(*Recipe)
+		return recipe
+	}
 	return singletonInstance
 }
 
 func recipeInit(config *multitenancymodels.TypeInput) supertokens.Recipe {
 	return func(appInfo supertokens.NormalisedAppinfo, onSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)) (*supertokens.RecipeModule, error) {
-		if singletonInstance == nil {
+		if singletonInstance == nil || !supertokens.IsDefaultInstance(appInfo) {
 			recipe, err := MakeRecipe(RECIPE_ID, appInfo, config, onSuperTokensAPIError)
 			if err != nil {
 				return nil, err
 			}
 
 			if recipe.GetAllowedDomainsForTenantId != nil {
-				supertokens.AddPostInitCallback(func() error {
-					sessionRecipe, err := session.GetRecipeInstanceOrThrowError()
+				supertokens.AddPostInitCallbackForAppInfo(appInfo, func(userContext supertokens.UserContext) error {
+					sessionRecipe, err := session.GetRecipeInstanceOrThrowError(userContext)
 
 					if err != nil {
 						return nil // skip adding claims if session recipe is not initialised
@@ -101,8 +109,15 @@ func recipeInit(config *multitenancymodels.TypeInput) supertokens.Recipe {
 				})
 			}
 
-			singletonInstance = recipe
-			return &singletonInstance.RecipeModule, nil
+			if supertokens.IsDefaultInstance(appInfo) {
+				singletonInstance = recipe
+			} else {
+				err = supertokens.RegisterRecipeInstance(appInfo, RECIPE_ID, recipe)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return &recipe.RecipeModule, nil
 		}
 		return nil, errors.New("Multitenancy recipe has already been initialised. Please check your code for bugs.")
 	}
@@ -172,7 +187,7 @@ func init() {
 	multitenancyclaims.AllowedDomainsClaim, multitenancyclaims.AllowedDomainsClaimValidators = NewAllowedDomainsClaim()
 
 	supertokens.GetTenantIdFuncFromUsingMultitenancyRecipe = func(tenantIdFromFrontend string, userContext supertokens.UserContext) (string, error) {
-		mtRecipe := GetRecipeInstance()
+		mtRecipe := GetRecipeInstance(userContext)
 		return (*mtRecipe.RecipeImpl.GetTenantId)(tenantIdFromFrontend, userContext)
 	}
 }
@@ -33,12 +33,12 @@ func GetOpenIdDiscoveryConfiguration(apiImplementation openidmodels.APIInterface
 
 	if response.OK != nil {
 		options.Res.Header().Set("Access-Control-Allow-Origin", "*")
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, response, map[string]interface{}{
 			"issuer":   response.OK.Issuer,
 			"jwks_uri": response.OK.Jwks_uri,
 		})
 	} else if response.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*response.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, response, supertokens.ConvertGeneralErrorToJsonResponse(*response.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
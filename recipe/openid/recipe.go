@@ -65,8 +65,12 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 	return *r, nil
 }
 
-func getRecipeInstanceOrThrowError() (*Recipe, error) {
-	if singletonInstance != nil {
+func getRecipeInstanceOrThrowError(userContext ...supertokens.UserContext) (*Recipe, error) {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		if recipe, ok := instance.(*Recipe); ok {
+			return recipe, nil
+		}
+	} else if singletonInstance != nil {
 		return singletonInstance, nil
 	}
 	return nil, defaultErrors.New("Initialisation not done. Did you forget to call the init function?")
@@ -74,13 +78,20 @@ func getRecipeInstanceOrThrowError() (*Recipe, error) {
 
 func recipeInit(config *openidmodels.TypeInput) supertokens.Recipe {
 	return func(appInfo supertokens.NormalisedAppinfo, onSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)) (*supertokens.RecipeModule, error) {
-		if singletonInstance == nil {
+		if singletonInstance == nil || !supertokens.IsDefaultInstance(appInfo) {
 			recipe, err := MakeRecipe(RECIPE_ID, appInfo, config, onSuperTokensAPIError)
 			if err != nil {
 				return nil, err
 			}
-			singletonInstance = &recipe
-			return &singletonInstance.RecipeModule, nil
+			if supertokens.IsDefaultInstance(appInfo) {
+				singletonInstance = &recipe
+			} else {
+				err = supertokens.RegisterRecipeInstance(appInfo, RECIPE_ID, &recipe)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return &recipe.RecipeModule, nil
 		}
 		return nil, defaultErrors.New("OpenID recipe has already been initialised. Please check your code for bugs.")
 	}
@@ -16,7 +16,6 @@
 package api
 
 import (
-	"encoding/json"
 	"reflect"
 
 	"github.com/supertokens/supertokens-golang/recipe/passwordless/plessmodels"
@@ -30,12 +29,7 @@ func ConsumeCode(apiImplementation plessmodels.APIInterface, tenantId string, op
 		return nil
 	}
 
-	body, err := supertokens.ReadFromRequest(options.Req)
-	if err != nil {
-		return err
-	}
-	var readBody map[string]interface{}
-	err = json.Unmarshal(body, &readBody)
+	readBody, err := supertokens.ReadJSONObjectFromRequest(options.Req, "preAuthSessionId", "linkCode", "deviceId", "userInputCode", "shouldTryLinkingWithSessionUser")
 	if err != nil {
 		return err
 	}
@@ -97,6 +91,11 @@ func ConsumeCode(apiImplementation plessmodels.APIInterface, tenantId string, op
 	var result map[string]interface{}
 
 	if response.OK != nil {
+		supertokens.AddToCounter(userContext, supertokens.MetricSignIns, 1, map[string]interface{}{
+			"recipeId":       options.RecipeID,
+			"tenantId":       tenantId,
+			"createdNewUser": response.OK.CreatedNewUser,
+		})
 		result = map[string]interface{}{
 			"status":         "OK",
 			"createdNewUser": response.OK.CreatedNewUser,
@@ -124,5 +123,5 @@ func ConsumeCode(apiImplementation plessmodels.APIInterface, tenantId string, op
 		return supertokens.ErrorIfNoResponse(options.Res)
 	}
 
-	return supertokens.Send200Response(options.Res, result)
+	return supertokens.Send200ResponseForAPIResult(options.Res, response, result)
 }
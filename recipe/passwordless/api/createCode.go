@@ -16,7 +16,6 @@
 package api
 
 import (
-	"encoding/json"
 	"reflect"
 	"strings"
 
@@ -31,12 +30,7 @@ func CreateCode(apiImplementation plessmodels.APIInterface, tenantId string, opt
 		return nil
 	}
 
-	body, err := supertokens.ReadFromRequest(options.Req)
-	if err != nil {
-		return err
-	}
-	var readBody map[string]interface{}
-	err = json.Unmarshal(body, &readBody)
+	readBody, err := supertokens.ReadJSONObjectFromRequest(options.Req, "email", "phoneNumber", "shouldTryLinkingWithSessionUser")
 	if err != nil {
 		return err
 	}
@@ -134,5 +128,5 @@ func CreateCode(apiImplementation plessmodels.APIInterface, tenantId string, opt
 		return supertokens.ErrorIfNoResponse(options.Res)
 	}
 
-	return supertokens.Send200Response(options.Res, result)
+	return supertokens.Send200ResponseForAPIResult(options.Res, response, result)
 }
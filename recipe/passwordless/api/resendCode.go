@@ -16,7 +16,6 @@
 package api
 
 import (
-	"encoding/json"
 	"reflect"
 
 	"github.com/supertokens/supertokens-golang/recipe/passwordless/plessmodels"
@@ -29,12 +28,7 @@ func ResendCode(apiImplementation plessmodels.APIInterface, tenantId string, opt
 		return nil
 	}
 
-	body, err := supertokens.ReadFromRequest(options.Req)
-	if err != nil {
-		return err
-	}
-	var readBody map[string]interface{}
-	err = json.Unmarshal(body, &readBody)
+	readBody, err := supertokens.ReadJSONObjectFromRequest(options.Req, "preAuthSessionId", "deviceId", "shouldTryLinkingWithSessionUser")
 	if err != nil {
 		return err
 	}
@@ -82,5 +76,5 @@ func ResendCode(apiImplementation plessmodels.APIInterface, tenantId string, opt
 		return supertokens.ErrorIfNoResponse(options.Res)
 	}
 
-	return supertokens.Send200Response(options.Res, result)
+	return supertokens.Send200ResponseForAPIResult(options.Res, response, result)
 }
@@ -30,7 +30,7 @@ func Init(config plessmodels.TypeInput) supertokens.Recipe {
 }
 
 func CreateCodeWithEmail(tenantId string, email string, userInputCode *string, userContext ...supertokens.UserContext) (plessmodels.CreateCodeResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return plessmodels.CreateCodeResponse{}, err
 	}
@@ -41,7 +41,7 @@ func CreateCodeWithEmail(tenantId string, email string, userInputCode *string, u
 }
 
 func CreateCodeWithPhoneNumber(tenantId string, phoneNumber string, userInputCode *string, userContext ...supertokens.UserContext) (plessmodels.CreateCodeResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return plessmodels.CreateCodeResponse{}, err
 	}
@@ -52,7 +52,7 @@ func CreateCodeWithPhoneNumber(tenantId string, phoneNumber string, userInputCod
 }
 
 func CreateNewCodeForDevice(tenantId string, deviceID string, userInputCode *string, userContext ...supertokens.UserContext) (plessmodels.ResendCodeResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return plessmodels.ResendCodeResponse{}, err
 	}
@@ -63,7 +63,7 @@ func CreateNewCodeForDevice(tenantId string, deviceID string, userInputCode *str
 }
 
 func ConsumeCodeWithUserInputCode(tenantId string, deviceID string, userInputCode string, preAuthSessionID string, userContext ...supertokens.UserContext) (plessmodels.ConsumeCodeResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return plessmodels.ConsumeCodeResponse{}, err
 	}
@@ -77,7 +77,7 @@ func ConsumeCodeWithUserInputCode(tenantId string, deviceID string, userInputCod
 }
 
 func ConsumeCodeWithLinkCode(tenantId string, linkCode string, preAuthSessionID string, userContext ...supertokens.UserContext) (plessmodels.ConsumeCodeResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return plessmodels.ConsumeCodeResponse{}, err
 	}
@@ -88,7 +88,7 @@ func ConsumeCodeWithLinkCode(tenantId string, linkCode string, preAuthSessionID
 }
 
 func GetUserByID(userID string, userContext ...supertokens.UserContext) (*plessmodels.User, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +99,7 @@ func GetUserByID(userID string, userContext ...supertokens.UserContext) (*plessm
 }
 
 func GetUserByEmail(tenantId string, email string, userContext ...supertokens.UserContext) (*plessmodels.User, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -110,7 +110,7 @@ func GetUserByEmail(tenantId string, email string, userContext ...supertokens.Us
 }
 
 func GetUserByPhoneNumber(tenantId string, phoneNumber string, userContext ...supertokens.UserContext) (*plessmodels.User, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +121,7 @@ func GetUserByPhoneNumber(tenantId string, phoneNumber string, userContext ...su
 }
 
 func UpdateUser(userID string, email *string, phoneNumber *string, userContext ...supertokens.UserContext) (plessmodels.UpdateUserResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return plessmodels.UpdateUserResponse{}, err
 	}
@@ -132,7 +132,7 @@ func UpdateUser(userID string, email *string, phoneNumber *string, userContext .
 }
 
 func RevokeAllCodesByEmail(tenantId string, email string, userContext ...supertokens.UserContext) error {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return err
 	}
@@ -143,7 +143,7 @@ func RevokeAllCodesByEmail(tenantId string, email string, userContext ...superto
 }
 
 func RevokeAllCodesByPhoneNumber(tenantId string, phoneNumber string, userContext ...supertokens.UserContext) error {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return err
 	}
@@ -154,7 +154,7 @@ func RevokeAllCodesByPhoneNumber(tenantId string, phoneNumber string, userContex
 }
 
 func RevokeCode(tenantId string, codeID string, userContext ...supertokens.UserContext) error {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return err
 	}
@@ -165,7 +165,7 @@ func RevokeCode(tenantId string, codeID string, userContext ...supertokens.UserC
 }
 
 func ListCodesByEmail(tenantId string, email string, userContext ...supertokens.UserContext) ([]plessmodels.DeviceType, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return []plessmodels.DeviceType{}, err
 	}
@@ -176,7 +176,7 @@ func ListCodesByEmail(tenantId string, email string, userContext ...supertokens.
 }
 
 func ListCodesByPhoneNumber(tenantId string, phoneNumber string, userContext ...supertokens.UserContext) ([]plessmodels.DeviceType, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return []plessmodels.DeviceType{}, err
 	}
@@ -187,7 +187,7 @@ func ListCodesByPhoneNumber(tenantId string, phoneNumber string, userContext ...
 }
 
 func ListCodesByDeviceID(tenantId string, deviceID string, userContext ...supertokens.UserContext) (*plessmodels.DeviceType, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -198,7 +198,7 @@ func ListCodesByDeviceID(tenantId string, deviceID string, userContext ...supert
 }
 
 func ListCodesByPreAuthSessionID(tenantId string, preAuthSessionID string, userContext ...supertokens.UserContext) (*plessmodels.DeviceType, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -209,7 +209,7 @@ func ListCodesByPreAuthSessionID(tenantId string, preAuthSessionID string, userC
 }
 
 func CreateMagicLinkByEmail(tenantId string, email string, userContext ...supertokens.UserContext) (string, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return "", err
 	}
@@ -220,7 +220,7 @@ func CreateMagicLinkByEmail(tenantId string, email string, userContext ...supert
 }
 
 func CreateMagicLinkByPhoneNumber(tenantId string, phoneNumber string, userContext ...supertokens.UserContext) (string, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return "", err
 	}
@@ -235,7 +235,7 @@ func SignInUpByEmail(tenantId string, email string, userContext ...supertokens.U
 	CreatedNewUser   bool
 	User             plessmodels.User
 }, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return struct {
 			PreAuthSessionID string
@@ -254,7 +254,7 @@ func SignInUpByPhoneNumber(tenantId string, phoneNumber string, userContext ...s
 	CreatedNewUser   bool
 	User             plessmodels.User
 }, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return struct {
 			PreAuthSessionID string
@@ -269,7 +269,7 @@ func SignInUpByPhoneNumber(tenantId string, phoneNumber string, userContext ...s
 }
 
 func DeleteEmailForUser(userID string, userContext ...supertokens.UserContext) (plessmodels.DeleteUserResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return plessmodels.DeleteUserResponse{}, err
 	}
@@ -280,7 +280,7 @@ func DeleteEmailForUser(userID string, userContext ...supertokens.UserContext) (
 }
 
 func DeletePhoneNumberForUser(userID string, userContext ...supertokens.UserContext) (plessmodels.DeleteUserResponse, error) {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return plessmodels.DeleteUserResponse{}, err
 	}
@@ -291,7 +291,7 @@ func DeletePhoneNumberForUser(userID string, userContext ...supertokens.UserCont
 }
 
 func SendEmail(input emaildelivery.EmailType, userContext ...supertokens.UserContext) error {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return err
 	}
@@ -302,7 +302,7 @@ func SendEmail(input emaildelivery.EmailType, userContext ...supertokens.UserCon
 }
 
 func SendSms(input smsdelivery.SmsType, userContext ...supertokens.UserContext) error {
-	instance, err := GetRecipeInstanceOrThrowError()
+	instance, err := GetRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return err
 	}
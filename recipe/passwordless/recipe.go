@@ -48,7 +48,7 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config p
 
 	r.APIImpl = verifiedConfig.Override.APIs(api.MakeAPIImplementation())
 
-	querierInstance, err := supertokens.GetNewQuerierInstanceOrThrowError(recipeId)
+	querierInstance, err := supertokens.GetNewQuerierInstanceForAppInfoOrThrowError(appInfo, recipeId)
 	if err != nil {
 		return Recipe{}, err
 	}
@@ -70,8 +70,8 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config p
 		r.SmsDelivery = smsdelivery.MakeIngredient(verifiedConfig.GetSmsDeliveryConfig())
 	}
 
-	supertokens.AddPostInitCallback(func() error {
-		emailVerificationRecipe := emailverification.GetRecipeInstance()
+	supertokens.AddPostInitCallbackForAppInfo(appInfo, func(userContext supertokens.UserContext) error {
+		emailVerificationRecipe := emailverification.GetRecipeInstance(userContext)
 		if emailVerificationRecipe != nil {
 			emailVerificationRecipe.AddGetEmailForUserIdFunc(r.getEmailForUserId)
 		}
@@ -104,26 +104,41 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config p
 	return *r, nil
 }
 
-func GetRecipeInstanceOrThrowError() (*Recipe, error) {
-	if singletonInstance != nil {
+func GetRecipeInstanceOrThrowError(userContext ...supertokens.UserContext) (*Recipe, error) {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		if recipe, ok := instance.(*Recipe); ok {
+			return recipe, nil
+		}
+	} else if singletonInstance != nil {
 		return singletonInstance, nil
 	}
 	return nil, errors.New("initialisation not done. Did you forget to call the init function?")
 }
 
-func GetRecipeInstance() *Recipe {
+func GetRecipeInstance(userContext ...supertokens.UserContext) *Recipe {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		recipe, _ := instance.(*Recipe)
+		return recipe
+	}
 	return singletonInstance
 }
 
 func recipeInit(config plessmodels.TypeInput) supertokens.Recipe {
 	return func(appInfo supertokens.NormalisedAppinfo, onSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)) (*supertokens.RecipeModule, error) {
-		if singletonInstance == nil {
+		if singletonInstance == nil || !supertokens.IsDefaultInstance(appInfo) {
 			recipe, err := MakeRecipe(RECIPE_ID, appInfo, config, nil, nil, onSuperTokensAPIError)
 			if err != nil {
 				return nil, err
 			}
-			singletonInstance = &recipe
-			return &singletonInstance.RecipeModule, nil
+			if supertokens.IsDefaultInstance(appInfo) {
+				singletonInstance = &recipe
+			} else {
+				err = supertokens.RegisterRecipeInstance(appInfo, RECIPE_ID, &recipe)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return &recipe.RecipeModule, nil
 		}
 		return nil, errors.New("passwordless recipe has already been initialised. Please check your code for bugs")
 	}
@@ -233,7 +248,7 @@ func (r *Recipe) handleError(err error, req *http.Request, res http.ResponseWrit
 }
 
 func (r *Recipe) CreateMagicLink(email *string, phoneNumber *string, tenantId string, userContext supertokens.UserContext) (string, error) {
-	stInstance, err := supertokens.GetInstanceOrThrowError()
+	stInstance, err := supertokens.GetInstanceOrThrowError(userContext)
 	if err != nil {
 		return "", err
 	}
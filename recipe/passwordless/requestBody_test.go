@@ -0,0 +1,85 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package passwordless
+
+import (
+	"testing"
+
+	"github.com/supertokens/supertokens-golang/internal/testingutils"
+	"github.com/supertokens/supertokens-golang/recipe/passwordless/plessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func FuzzPasswordlessAPIRequestBodies(f *testing.F) {
+	testingutils.AddRequestBodySeeds(f,
+		`{"email":"test@example.com"}`,
+		`{"phoneNumber":"+919876543210"}`,
+		`{"deviceId":"device","preAuthSessionId":"session"}`,
+		`{"deviceId":"device","preAuthSessionId":"session","userInputCode":"123456"}`,
+		`{"linkCode":"code","preAuthSessionId":"session"}`,
+	)
+	core := testingutils.StartFakeCore(nil)
+	defer core.Close()
+
+	apiBasePath := "/auth"
+	instance, err := supertokens.New(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: core.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+			APIBasePath:   &apiBasePath,
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(plessmodels.TypeInput{
+				FlowType: "USER_INPUT_CODE_AND_MAGIC_LINK",
+				ContactMethodEmailOrPhone: plessmodels.ContactMethodEmailOrPhoneConfig{
+					Enabled: true,
+				},
+				Override: &plessmodels.OverrideStruct{
+					APIs: func(originalImplementation plessmodels.APIInterface) plessmodels.APIInterface {
+						createCodePOST := func(email *string, phoneNumber *string, tenantId string, options plessmodels.APIOptions, userContext supertokens.UserContext) (plessmodels.CreateCodePOSTResponse, error) {
+							return plessmodels.CreateCodePOSTResponse{GeneralError: &supertokens.GeneralErrorResponse{Message: "stubbed"}}, nil
+						}
+						resendCodePOST := func(deviceID string, preAuthSessionID string, tenantId string, options plessmodels.APIOptions, userContext supertokens.UserContext) (plessmodels.ResendCodePOSTResponse, error) {
+							return plessmodels.ResendCodePOSTResponse{ResetFlowError: &struct{}{}}, nil
+						}
+						consumeCodePOST := func(userInput *plessmodels.UserInputCodeWithDeviceID, linkCode *string, preAuthSessionID string, tenantId string, options plessmodels.APIOptions, userContext supertokens.UserContext) (plessmodels.ConsumeCodePOSTResponse, error) {
+							return plessmodels.ConsumeCodePOSTResponse{RestartFlowError: &struct{}{}}, nil
+						}
+						originalImplementation.CreateCodePOST = &createCodePOST
+						originalImplementation.ResendCodePOST = &resendCodePOST
+						originalImplementation.ConsumeCodePOST = &consumeCodePOST
+						return originalImplementation
+					},
+				},
+			}),
+		},
+	})
+	if err != nil {
+		f.Fatal(err.Error())
+	}
+	defer instance.Close()
+	handler := instance.Middleware(nil)
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		for _, path := range []string{"/auth/signinup/code", "/auth/signinup/code/resend", "/auth/signinup/code/consume"} {
+			testingutils.AssertAPIHandlesRequestBody(t, handler, path, body)
+		}
+	})
+}
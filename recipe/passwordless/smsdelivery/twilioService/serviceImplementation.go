@@ -27,7 +27,7 @@ func MakeServiceImplementation(config smsdelivery.TwilioSettings) smsdelivery.Tw
 	}
 
 	getContent := func(input smsdelivery.SmsType, userContext supertokens.UserContext) (smsdelivery.SMSContent, error) {
-		result := getPasswordlessLoginSmsContent(*input.PasswordlessLogin)
+		result := getPasswordlessLoginSmsContent(*input.PasswordlessLogin, userContext)
 		return result, nil
 	}
 
@@ -17,11 +17,15 @@ package session
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
 	"github.com/supertokens/supertokens-golang/supertokens"
 )
 
+// sessionsGETMaxConcurrentFetches is how many sessions SessionsGET fetches from the core at the same time
+const sessionsGETMaxConcurrentFetches = 10
+
 func MakeAPIImplementation() sessmodels.APIInterface {
 	refreshPOST := func(options sessmodels.APIOptions, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
 		return RefreshSessionInRequest(options.Req, options.Res, options.Config, options.RecipeImplementation, userContext)
@@ -80,9 +84,126 @@ func MakeAPIImplementation() sessmodels.APIInterface {
 		}, nil
 	}
 
+	sessionsGET := func(sessionContainer sessmodels.SessionContainer, options sessmodels.APIOptions, userContext supertokens.UserContext) (sessmodels.SessionsGETResponse, error) {
+		currentSessionHandle := sessionContainer.GetHandleWithContext(userContext)
+		sessionHandles, err := (*options.RecipeImplementation.GetAllSessionHandlesForUser)(sessionContainer.GetUserIDWithContext(userContext), sessionContainer.GetTenantIdWithContext(userContext), nil, userContext)
+		if err != nil {
+			return sessmodels.SessionsGETResponse{}, err
+		}
+
+		sessionInfos := make([]*sessmodels.SessionInformation, len(sessionHandles))
+		errs := make([]error, len(sessionHandles))
+		var wg sync.WaitGroup
+		fetchSlots := make(chan struct{}, sessionsGETMaxConcurrentFetches)
+		for i, sessionHandle := range sessionHandles {
+			wg.Add(1)
+			fetchSlots <- struct{}{}
+			// each fetch gets its own copy of the user context, since the core call cache in it is not safe
+			// for concurrent use
+			go func(i int, sessionHandle string, userContext supertokens.UserContext) {
+				defer wg.Done()
+				defer func() { <-fetchSlots }()
+				sessionInfos[i], errs[i] = (*options.RecipeImplementation.GetSessionInformation)(sessionHandle, userContext)
+			}(i, sessionHandle, copyUserContextWithoutCoreCallCache(userContext))
+		}
+		wg.Wait()
+
+		sessions := []sessmodels.SessionWithDeviceInfo{}
+		for i, sessionHandle := range sessionHandles {
+			if errs[i] != nil {
+				return sessmodels.SessionsGETResponse{}, errs[i]
+			}
+			sessionInfo := sessionInfos[i]
+			if sessionInfo == nil {
+				// the session expired or was revoked after the handles were fetched
+				continue
+			}
+			sessions = append(sessions, sessmodels.SessionWithDeviceInfo{
+				SessionHandle: sessionHandle,
+				TenantId:      sessionInfo.TenantId,
+				IsCurrent:     sessionHandle == currentSessionHandle,
+				TimeCreated:   sessionInfo.TimeCreated,
+				Expiry:        sessionInfo.Expiry,
+				Device:        GetDeviceInfoFromSessionData(sessionInfo.SessionDataInDatabase),
+			})
+		}
+
+		return sessmodels.SessionsGETResponse{
+			OK: &struct {
+				Sessions []sessmodels.SessionWithDeviceInfo
+			}{Sessions: sessions},
+		}, nil
+	}
+
+	revokeSessionPOST := func(sessionHandle string, sessionContainer sessmodels.SessionContainer, options sessmodels.APIOptions, userContext supertokens.UserContext) (sessmodels.RevokeSessionPOSTResponse, error) {
+		if sessionHandle == sessionContainer.GetHandleWithContext(userContext) {
+			// this also clears the tokens of the current session
+			err := sessionContainer.RevokeSessionWithContext(userContext)
+			if err != nil {
+				return sessmodels.RevokeSessionPOSTResponse{}, err
+			}
+			return sessmodels.RevokeSessionPOSTResponse{
+				OK: &struct{}{},
+			}, nil
+		}
+
+		sessionInfo, err := (*options.RecipeImplementation.GetSessionInformation)(sessionHandle, userContext)
+		if err != nil {
+			return sessmodels.RevokeSessionPOSTResponse{}, err
+		}
+		if sessionInfo == nil || sessionInfo.UserId != sessionContainer.GetUserIDWithContext(userContext) || sessionInfo.TenantId != sessionContainer.GetTenantIdWithContext(userContext) {
+			return sessmodels.RevokeSessionPOSTResponse{
+				UnknownSessionError: &struct{}{},
+			}, nil
+		}
+
+		setSessionInfoToRevokeInUserContext(sessionHandle, sessionToRevoke{
+			userID:             sessionInfo.UserId,
+			tenantId:           sessionInfo.TenantId,
+			impersonatorUserID: getImpersonatorUserID(sessionInfo.CustomClaimsInAccessTokenPayload),
+		}, userContext)
+		_, err = (*options.RecipeImplementation.RevokeSession)(sessionHandle, userContext)
+		if err != nil {
+			return sessmodels.RevokeSessionPOSTResponse{}, err
+		}
+		return sessmodels.RevokeSessionPOSTResponse{
+			OK: &struct{}{},
+		}, nil
+	}
+
+	revokeOtherSessionsPOST := func(sessionContainer sessmodels.SessionContainer, options sessmodels.APIOptions, userContext supertokens.UserContext) (sessmodels.RevokeOtherSessionsPOSTResponse, error) {
+		currentSessionHandle := sessionContainer.GetHandleWithContext(userContext)
+		sessionHandles, err := (*options.RecipeImplementation.GetAllSessionHandlesForUser)(sessionContainer.GetUserIDWithContext(userContext), sessionContainer.GetTenantIdWithContext(userContext), nil, userContext)
+		if err != nil {
+			return sessmodels.RevokeOtherSessionsPOSTResponse{}, err
+		}
+
+		otherSessionHandles := []string{}
+		for _, sessionHandle := range sessionHandles {
+			if sessionHandle != currentSessionHandle {
+				otherSessionHandles = append(otherSessionHandles, sessionHandle)
+			}
+		}
+
+		revokedSessionHandles := []string{}
+		if len(otherSessionHandles) > 0 {
+			revokedSessionHandles, err = (*options.RecipeImplementation.RevokeMultipleSessions)(otherSessionHandles, userContext)
+			if err != nil {
+				return sessmodels.RevokeOtherSessionsPOSTResponse{}, err
+			}
+		}
+
+		return sessmodels.RevokeOtherSessionsPOSTResponse{
+			OK: &struct{ RevokedSessionHandles []string }{RevokedSessionHandles: revokedSessionHandles},
+		}, nil
+	}
+
 	return sessmodels.APIInterface{
-		RefreshPOST:   &refreshPOST,
-		VerifySession: &verifySession,
-		SignOutPOST:   &signOutPOST,
+		RefreshPOST:             &refreshPOST,
+		VerifySession:           &verifySession,
+		SignOutPOST:             &signOutPOST,
+		SessionsGET:             &sessionsGET,
+		RevokeSessionPOST:       &revokeSessionPOST,
+		RevokeOtherSessionsPOST: &revokeOtherSessionsPOST,
 	}
 }
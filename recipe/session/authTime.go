@@ -0,0 +1,124 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	defaultErrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// AuthTimeClaim holds the time at which the user last signed in (or re-authenticated) in the session. It is
+// only set by the sign in APIs of the emailpassword, passwordless and thirdparty recipes (see
+// CreateNewSessionForSignIn). Fetching it never returns a value, so that refetching the claim can't make a
+// session look like it was recently authenticated.
+var AuthTimeClaim, AuthTimeClaimValidators = claims.TimestampClaim(authTimeKey, func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
+	return nil, nil
+}, nil)
+
+// MaxAuthAge returns a claim validator that fails if the user signed in more than maxAgeInSeconds seconds
+// ago, or if the session has no AuthTimeClaim. Use it to protect sensitive APIs, and ask the user to sign in
+// again if it fails.
+func MaxAuthAge(maxAgeInSeconds int64) claims.SessionClaimValidator {
+	id := "st-max-auth-age"
+	validator := AuthTimeClaimValidators.NotOlderThan(maxAgeInSeconds, nil, &id)
+	// the claim must only change when the user signs in, so it is never refetched
+	validator.ShouldRefetch = nil
+	return validator
+}
+
+// CreateNewSessionForSignIn creates a session for a user who has just signed in, with the AuthTimeClaim set
+// to the current time. If ReauthenticateOnSignIn is set in the session recipe config and the request already
+// has a valid session of the same user and tenant, the AuthTimeClaim of that session is updated instead, and
+// accessTokenPayload is merged into its access token payload, so the session handle does not change.
+func CreateNewSessionForSignIn(req *http.Request, res http.ResponseWriter, tenantId string, userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, userContext ...supertokens.UserContext) (sessmodels.SessionContainer, error) {
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
+	if err != nil {
+		return nil, err
+	}
+	if len(userContext) == 0 || userContext[0] == nil {
+		// the session is fetched and created with the same user context, so that a DPoP proof verified while
+		// fetching it is not treated as replayed when it is verified again while creating it
+		userContext = []supertokens.UserContext{supertokens.SetContextInUserContext(nil, req.Context())}
+	}
+
+	authTimePayload := AuthTimeClaim.AddToPayload_internal(map[string]interface{}{}, time.Now(), userContext[0])
+
+	if instance.Config.ReauthenticateOnSignIn {
+		existingSession, err := getSessionToReauthenticate(req, res, instance, tenantId, userID, userContext[0])
+		if err != nil {
+			return nil, err
+		}
+		if existingSession != nil {
+			accessTokenPayloadUpdate := map[string]interface{}{}
+			for key, value := range accessTokenPayload {
+				accessTokenPayloadUpdate[key] = value
+			}
+			for _, protectedProp := range protectedProps {
+				delete(accessTokenPayloadUpdate, protectedProp)
+			}
+			setSessionManagedPropsInUserContext(authTimePayload, userContext[0])
+			err = existingSession.MergeIntoAccessTokenPayloadWithContext(accessTokenPayloadUpdate, userContext[0])
+			if err != nil {
+				return nil, err
+			}
+			supertokens.LogMessage(supertokens.LogLevelDebug, userContext[0], "createNewSessionForSignIn: Re-authenticated the existing session", "recipeId", RECIPE_ID, "tenantId", tenantId, "session", supertokens.HashForLogging(existingSession.GetHandleWithContext(userContext[0])))
+			return existingSession, nil
+		}
+	}
+
+	finalAccessTokenPayload := map[string]interface{}{}
+	for key, value := range accessTokenPayload {
+		finalAccessTokenPayload[key] = value
+	}
+	setSessionManagedPropsInUserContext(authTimePayload, userContext[0])
+
+	return CreateNewSessionInRequest(req, res, tenantId, instance.Config, instance.RecipeModule.GetAppInfo(), *instance, instance.RecipeImpl, userID, finalAccessTokenPayload, sessionDataInDatabase, userContext[0])
+}
+
+// getSessionToReauthenticate returns the session of the request if it is valid, belongs to userID in tenantId
+// and is not an impersonation session, or nil otherwise. The result of verifying the DPoP proof of the request
+// is kept in userContext, so it must be the one the new session is created with if nil is returned.
+func getSessionToReauthenticate(req *http.Request, res http.ResponseWriter, instance *Recipe, tenantId string, userID string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+	sessionRequired := false
+	existingSession, err := GetSessionFromRequest(req, res, instance.Config, &sessmodels.VerifySessionOptions{
+		SessionRequired: &sessionRequired,
+		OverrideGlobalClaimValidators: func(globalClaimValidators []claims.SessionClaimValidator, sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) ([]claims.SessionClaimValidator, error) {
+			// the user is signing in again, so claims like the auth time are expected to be invalid
+			return []claims.SessionClaimValidator{}, nil
+		},
+	}, instance.RecipeImpl, userContext)
+	if err != nil {
+		if defaultErrors.As(err, &errors.UnauthorizedError{}) || defaultErrors.As(err, &errors.TryRefreshTokenError{}) || defaultErrors.As(err, &errors.InvalidClaimError{}) {
+			// a new session is created instead
+			return nil, nil
+		}
+		return nil, err
+	}
+	if existingSession == nil || existingSession.GetUserIDWithContext(userContext) != userID || existingSession.GetTenantIdWithContext(userContext) != tenantId {
+		return nil, nil
+	}
+	// signing in must not turn an impersonation session into a recently authenticated session of the user
+	if getImpersonatorUserID(existingSession.GetAccessTokenPayloadWithContext(userContext)) != "" {
+		return nil, nil
+	}
+	return existingSession, nil
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	defaultErrors "errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func TestMaxAuthAge(t *testing.T) {
+	validator := MaxAuthAge(300)
+	assert.Equal(t, "st-max-auth-age", validator.ID)
+	assert.Nil(t, validator.ShouldRefetch)
+
+	userContext := &map[string]interface{}{}
+	now := time.Now()
+
+	payload := AuthTimeClaim.AddToPayload_internal(map[string]interface{}{}, now.Add(-time.Minute), userContext)
+	assert.True(t, validator.Validate(payload, userContext).IsValid)
+
+	payload = AuthTimeClaim.AddToPayload_internal(map[string]interface{}{}, now.Add(-time.Hour), userContext)
+	result := validator.Validate(payload, userContext)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, int64(300), result.Reason.(map[string]interface{})["expectedToBeNotOlderThanInSeconds"])
+
+	result = validator.Validate(map[string]interface{}{}, userContext)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, "value does not exist", result.Reason.(map[string]interface{})["message"])
+
+	// refetching the claim never sets the auth time
+	value, err := AuthTimeClaim.FetchValue("userId", "public", userContext)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	payload, err = AuthTimeClaim.Build("userId", "public", map[string]interface{}{}, userContext)
+	assert.NoError(t, err)
+	assert.False(t, AuthTimeClaimValidators.NotOlderThan(300, nil, nil).Validate(payload, userContext).IsValid)
+}
+
+func TestThatSignInReauthenticatesTheExistingSession(t *testing.T) {
+	errCreateNewSession := defaultErrors.New("create new session")
+	var regeneratedPayload map[string]interface{}
+	var createdPayload map[string]interface{}
+	privateKey, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+		ReauthenticateOnSignIn: true,
+		Override: &sessmodels.OverrideStruct{
+			Functions: func(originalImplementation sessmodels.RecipeInterface) sessmodels.RecipeInterface {
+				(*originalImplementation.RegenerateAccessToken) = func(accessToken string, newAccessTokenPayload *map[string]interface{}, userContext supertokens.UserContext) (*sessmodels.RegenerateAccessTokenResponse, error) {
+					regeneratedPayload = *newAccessTokenPayload
+					return &sessmodels.RegenerateAccessTokenResponse{}, nil
+				}
+				(*originalImplementation.CreateNewSession) = func(userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCsrf *bool, tenantId string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+					createdPayload = accessTokenPayload
+					return nil, errCreateNewSession
+				}
+				return originalImplementation
+			},
+		},
+	})
+	defer cleanup()
+
+	accessToken := makeAccessTokenForTest(t, privateKey, map[string]interface{}{"custom": "value"})
+
+	req := httptest.NewRequest("POST", "/auth/signin", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	sessionContainer, err := CreateNewSessionForSignIn(req, httptest.NewRecorder(), "public", "userId", map[string]interface{}{"extra": "value"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "handle", sessionContainer.GetHandle())
+	assert.Nil(t, createdPayload)
+	assert.Equal(t, "value", regeneratedPayload["custom"])
+	assert.Equal(t, "value", regeneratedPayload["extra"])
+	assert.NotNil(t, regeneratedPayload["st-auth-time"])
+
+	// a different user signs in
+	regeneratedPayload = nil
+	req = httptest.NewRequest("POST", "/auth/signin", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	_, err = CreateNewSessionForSignIn(req, httptest.NewRecorder(), "public", "otherUserId", nil, nil)
+	assert.Equal(t, errCreateNewSession, err)
+	assert.Nil(t, regeneratedPayload)
+	assert.NotNil(t, createdPayload["st-auth-time"])
+
+	// impersonation sessions are not re-authenticated
+	regeneratedPayload, createdPayload = nil, nil
+	impersonationPayload, err := makeImpersonationAccessTokenPayload("admin", time.Minute)
+	assert.NoError(t, err)
+	req = httptest.NewRequest("POST", "/auth/signin", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessTokenForTest(t, privateKey, impersonationPayload))
+	_, err = CreateNewSessionForSignIn(req, httptest.NewRecorder(), "public", "userId", nil, nil)
+	assert.Equal(t, errCreateNewSession, err)
+	assert.Nil(t, regeneratedPayload)
+	assert.NotNil(t, createdPayload["st-auth-time"])
+
+	// the request has no session
+	createdPayload = nil
+	req = httptest.NewRequest("POST", "/auth/signin", nil)
+	_, err = CreateNewSessionForSignIn(req, httptest.NewRecorder(), "public", "userId", nil, nil)
+	assert.Equal(t, errCreateNewSession, err)
+	assert.NotNil(t, createdPayload["st-auth-time"])
+}
+
+func TestThatSignInCreatesANewSessionByDefault(t *testing.T) {
+	errCreateNewSession := defaultErrors.New("create new session")
+	var createdPayload map[string]interface{}
+	privateKey, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+		Override: &sessmodels.OverrideStruct{
+			Functions: func(originalImplementation sessmodels.RecipeInterface) sessmodels.RecipeInterface {
+				(*originalImplementation.CreateNewSession) = func(userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCsrf *bool, tenantId string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+					createdPayload = accessTokenPayload
+					return nil, errCreateNewSession
+				}
+				return originalImplementation
+			},
+		},
+	})
+	defer cleanup()
+
+	req := httptest.NewRequest("POST", "/auth/signin", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessTokenForTest(t, privateKey, nil))
+	_, err := CreateNewSessionForSignIn(req, httptest.NewRecorder(), "public", "userId", nil, nil)
+	assert.Equal(t, errCreateNewSession, err)
+	assert.NotNil(t, createdPayload["st-auth-time"])
+}
+
+func TestThatSignInCreatesANewSessionWithTheSameDPoPProofIfTheExistingOneIsOfAnotherUser(t *testing.T) {
+	errCreateNewSession := defaultErrors.New("create new session")
+	var createdPayload map[string]interface{}
+	signingKey, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+		DPoP:                   &sessmodels.DPoPConfig{},
+		ReauthenticateOnSignIn: true,
+		Override: &sessmodels.OverrideStruct{
+			Functions: func(originalImplementation sessmodels.RecipeInterface) sessmodels.RecipeInterface {
+				(*originalImplementation.CreateNewSession) = func(userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCsrf *bool, tenantId string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+					createdPayload = accessTokenPayload
+					return nil, errCreateNewSession
+				}
+				return originalImplementation
+			},
+		},
+	})
+	defer cleanup()
+
+	privateKey, jwk := makeDPoPKeyForTest(t)
+	thumbprint, err := computeJWKThumbprint(jwk)
+	assert.NoError(t, err)
+	accessToken := makeAccessTokenForTest(t, signingKey, map[string]interface{}{
+		dpopConfirmationKey: map[string]interface{}{"jkt": thumbprint},
+	})
+
+	// the proof is verified while fetching the existing session, and again while creating the new one
+	req := httptest.NewRequest("POST", "/auth/signin", nil)
+	req.Header.Set("Authorization", "DPoP "+accessToken)
+	req.Header.Set("DPoP", makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{
+		"htm": "POST",
+		"htu": "https://api.supertokens.io/auth/signin",
+		"ath": getDPoPAccessTokenHash(accessToken),
+	}))
+	_, err = CreateNewSessionForSignIn(req, httptest.NewRecorder(), "public", "otherUserId", nil, nil, nil)
+	assert.Equal(t, errCreateNewSession, err)
+	assert.Equal(t, map[string]interface{}{"jkt": thumbprint}, createdPayload[dpopConfirmationKey])
+}
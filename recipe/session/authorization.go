@@ -0,0 +1,192 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/recipe/userroles/userrolesclaims"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// RequireRoles returns a claim validator that passes if the user has all of roles. The userroles recipe must
+// be imported (and initialised) to use it, otherwise the validator always fails.
+func RequireRoles(roles ...string) claims.SessionClaimValidator {
+	if userrolesclaims.UserRoleClaim == nil {
+		return failingClaimValidator("RequireRoles", "the userroles recipe needs to be imported to use RequireRoles")
+	}
+	return userrolesclaims.UserRoleClaimValidators.IncludesAll(toInterfaceSlice(roles), nil, nil)
+}
+
+// RequireAnyRole returns a claim validator that passes if the user has at least one of roles. The userroles
+// recipe must be imported (and initialised) to use it, otherwise the validator always fails.
+func RequireAnyRole(roles ...string) claims.SessionClaimValidator {
+	if userrolesclaims.UserRoleClaim == nil {
+		return failingClaimValidator("RequireAnyRole", "the userroles recipe needs to be imported to use RequireAnyRole")
+	}
+	return userrolesclaims.UserRoleClaimValidators.IncludesAny(toInterfaceSlice(roles), nil, nil)
+}
+
+// RequirePermissions returns a claim validator that passes if the user has all of permissions (through their
+// roles). The userroles recipe must be imported (and initialised) to use it, otherwise the validator always
+// fails.
+func RequirePermissions(permissions ...string) claims.SessionClaimValidator {
+	if userrolesclaims.PermissionClaim == nil {
+		return failingClaimValidator("RequirePermissions", "the userroles recipe needs to be imported to use RequirePermissions")
+	}
+	return userrolesclaims.PermissionClaimValidators.IncludesAll(toInterfaceSlice(permissions), nil, nil)
+}
+
+// failingClaimValidator returns a validator that always fails with message as the reason. It is used instead
+// of the validators of claims that are not available, so that routes that need them are never allowed.
+func failingClaimValidator(id string, message string) claims.SessionClaimValidator {
+	return claims.SessionClaimValidator{
+		ID: id,
+		Validate: func(payload map[string]interface{}, userContext supertokens.UserContext) claims.ClaimValidationResult {
+			return claims.ClaimValidationResult{
+				IsValid: false,
+				Reason: map[string]interface{}{
+					"message": message,
+				},
+			}
+		},
+	}
+}
+
+// WithClaimValidators returns a copy of options that also checks claimValidators, after the validators
+// returned by options.OverrideGlobalClaimValidators (or the global claim validators if it is not set). The
+// result can be passed to VerifySession, GetSession or the VerifySession function of a framework adapter,
+// for example:
+//
+//	session.VerifySession(session.WithClaimValidators(nil, session.RequireRoles("admin")), handler)
+func WithClaimValidators(options *sessmodels.VerifySessionOptions, claimValidators ...claims.SessionClaimValidator) *sessmodels.VerifySessionOptions {
+	result := sessmodels.VerifySessionOptions{}
+	if options != nil {
+		result = *options
+	}
+	overrideGlobalClaimValidators := result.OverrideGlobalClaimValidators
+	result.OverrideGlobalClaimValidators = func(globalClaimValidators []claims.SessionClaimValidator, sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) ([]claims.SessionClaimValidator, error) {
+		validators := globalClaimValidators
+		if overrideGlobalClaimValidators != nil {
+			var err error
+			validators, err = overrideGlobalClaimValidators(globalClaimValidators, sessionContainer, userContext)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(append([]claims.SessionClaimValidator{}, validators...), claimValidators...), nil
+	}
+	return &result
+}
+
+// VerifySessionWithAuthorizationRules verifies the session of requests that match one of rules, and checks
+// the claim validators of the first rule that matches. Requests that fail a claim validator are rejected
+// using ErrorHandlers.OnInvalidClaim (a 403 by default). Requests that match a rule with Public set are
+// passed on to otherHandler without verifying their session, and requests that match no rule are rejected
+// with a 403. The path of the request is cleaned (see path.Clean) before it is matched.
+func VerifySessionWithAuthorizationRules(rules []sessmodels.AuthorizationRule, options *sessmodels.VerifySessionOptions, otherHandler http.HandlerFunc) http.HandlerFunc {
+	// the options are created up front, since they are the same for every request that matches a rule
+	optionsForRules := make([]*sessmodels.VerifySessionOptions, len(rules))
+	for i, rule := range rules {
+		optionsForRules[i] = WithClaimValidators(options, rule.ClaimValidators...)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		i := getAuthorizationRuleIndex(rules, r)
+		if i < 0 {
+			supertokens.LogDebugMessage("VerifySessionWithAuthorizationRules: Rejecting request to " + r.URL.Path + " because no rule matches it")
+			err := supertokens.SendNon200ResponseWithMessage(w, "no authorization rule matches the request", http.StatusForbidden)
+			if err != nil {
+				supertokens.LogDebugMessage("VerifySessionWithAuthorizationRules: Failed to send the response: " + err.Error())
+			}
+			return
+		}
+		if rules[i].Public {
+			otherHandler(w, r)
+			return
+		}
+		VerifySession(optionsForRules[i], otherHandler).ServeHTTP(w, r)
+	}
+}
+
+// GetAuthorizationRuleForRequest returns the first of rules that matches the method and path of req, or nil
+// if there is none. It can be used to apply the rules in frameworks that don't use net/http handlers, in
+// which case requests for which it returns nil should be rejected, and requests that match a rule with
+// Public set should be let through without verifying their session.
+func GetAuthorizationRuleForRequest(rules []sessmodels.AuthorizationRule, req *http.Request) *sessmodels.AuthorizationRule {
+	i := getAuthorizationRuleIndex(rules, req)
+	if i < 0 {
+		return nil
+	}
+	return &rules[i]
+}
+
+func getAuthorizationRuleIndex(rules []sessmodels.AuthorizationRule, req *http.Request) int {
+	// cleaning the path makes sure that paths like "/public/../admin" or "//admin" match the same rules as
+	// "/admin"
+	requestPath := path.Clean("/" + req.URL.Path)
+	for i := range rules {
+		if doesAuthorizationRuleMatch(rules[i], req.Method, requestPath) {
+			return i
+		}
+	}
+	return -1
+}
+
+func doesAuthorizationRuleMatch(rule sessmodels.AuthorizationRule, method string, path string) bool {
+	if rule.Method != "" && rule.Method != "*" && !strings.EqualFold(rule.Method, method) {
+		return false
+	}
+	return doesPathPatternMatch(rule.Path, path)
+}
+
+func doesPathPatternMatch(pattern string, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pathSegments) == 1 && pathSegments[0] == "" {
+		pathSegments = []string{}
+	}
+	if len(patternSegments) == 1 && patternSegments[0] == "" {
+		patternSegments = []string{}
+	}
+
+	for i, patternSegment := range patternSegments {
+		if patternSegment == "**" && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if patternSegment == "*" || (strings.HasPrefix(patternSegment, ":") && pathSegments[i] != "") {
+			continue
+		}
+		if patternSegment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(pathSegments)
+}
+
+func toInterfaceSlice(values []string) []interface{} {
+	result := []interface{}{}
+	for _, value := range values {
+		result = append(result, value)
+	}
+	return result
+}
@@ -0,0 +1,270 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/recipe/userroles/userrolesclaims"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// initWithSigningKeyForTest initialises the session recipe with a fake core that serves the public key of the
+// returned private key, so that access tokens signed with it can be verified without the core
+func initWithSigningKeyForTest(t *testing.T) (*rsa.PrivateKey, func()) {
+	return initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{})
+}
+
+func initWithSigningKeyAndConfigForTest(t *testing.T, config *sessmodels.TypeInput) (*rsa.PrivateKey, func()) {
+	return initWithSigningKeyAndCoreHandlerForTest(t, config, nil)
+}
+
+// initWithSigningKeyAndCoreHandlerForTest is like initWithSigningKeyAndConfigForTest, and passes the requests to
+// the core that are not for the API version or the JWKS to coreHandler
+func initWithSigningKeyAndCoreHandlerForTest(t *testing.T, config *sessmodels.TypeInput, coreHandler http.HandlerFunc) (*rsa.PrivateKey, func()) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	core := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apiversion":
+			json.NewEncoder(rw).Encode(map[string]interface{}{"versions": []string{"3.1"}})
+		case "/.well-known/jwks.json":
+			json.NewEncoder(rw).Encode(map[string]interface{}{"keys": []interface{}{map[string]interface{}{
+				"kty": "RSA",
+				"kid": "d-test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+			}}})
+		default:
+			if coreHandler == nil {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			coreHandler(rw, r)
+		}
+	}))
+
+	resetAll()
+	err = supertokens.Init(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: core.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{Init(config)},
+	})
+	if err != nil {
+		core.Close()
+		t.Fatal(err.Error())
+	}
+	return privateKey, func() {
+		// this stops the background refresh of the JWKS, which resetAll does not
+		supertokens.Shutdown(context.Background())
+		resetAll()
+		core.Close()
+	}
+}
+
+// makeAccessTokenForTest creates an access token with the structure of the v5 tokens created by the core
+func makeAccessTokenForTest(t *testing.T, privateKey *rsa.PrivateKey, payload map[string]interface{}) string {
+	claimsToSign := jwt.MapClaims{
+		"sub":               "userId",
+		"sessionHandle":     "handle",
+		"refreshTokenHash1": "hash",
+		"tId":               "public",
+		"rsub":              "userId",
+		"exp":               time.Now().Add(time.Hour).Unix(),
+		"iat":               time.Now().Unix(),
+	}
+	for key, value := range payload {
+		claimsToSign[key] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claimsToSign)
+	token.Header["kid"] = "d-test"
+	token.Header["version"] = "5"
+	signed, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return signed
+}
+
+func TestPathPatternMatching(t *testing.T) {
+	matches := map[[2]string]bool{
+		{"/admin", "/admin"}:                       true,
+		{"/admin", "/admin/"}:                      true,
+		{"/admin", "/admin/users"}:                 false,
+		{"/admin/*", "/admin/users"}:               true,
+		{"/admin/*", "/admin/users/1"}:             false,
+		{"/admin/*", "/admin"}:                     false,
+		{"/admin/**", "/admin"}:                    true,
+		{"/admin/**", "/admin/users/1"}:            true,
+		{"/admin/**", "/administrator"}:            false,
+		{"/users/:userId", "/users/1"}:             true,
+		{"/users/:userId/roles", "/users/1"}:       false,
+		{"/users/:userId/roles", "/users/1/roles"}: true,
+		{"/users/:userId", "/users//"}:             false,
+		{"/**", "/"}:                               true,
+		{"/**", "/anything/at/all"}:                true,
+		{"/", "/"}:                                 true,
+		{"/", "/a"}:                                false,
+	}
+	for patternAndPath, expected := range matches {
+		assert.Equal(t, expected, doesPathPatternMatch(patternAndPath[0], patternAndPath[1]), patternAndPath)
+	}
+
+	rule := sessmodels.AuthorizationRule{Method: "post", Path: "/admin/**"}
+	assert.True(t, doesAuthorizationRuleMatch(rule, http.MethodPost, "/admin/users"))
+	assert.False(t, doesAuthorizationRuleMatch(rule, http.MethodGet, "/admin/users"))
+	rule.Method = "*"
+	assert.True(t, doesAuthorizationRuleMatch(rule, http.MethodGet, "/admin/users"))
+}
+
+func TestVerifySessionWithAuthorizationRules(t *testing.T) {
+	privateKey, cleanup := initWithSigningKeyForTest(t)
+	defer cleanup()
+
+	// the userroles recipe sets these when it is imported
+	userRoleClaim, permissionClaim := userrolesclaims.UserRoleClaim, userrolesclaims.PermissionClaim
+	userRoleClaimValidators, permissionClaimValidators := userrolesclaims.UserRoleClaimValidators, userrolesclaims.PermissionClaimValidators
+	defer func() {
+		userrolesclaims.UserRoleClaim, userrolesclaims.PermissionClaim = userRoleClaim, permissionClaim
+		userrolesclaims.UserRoleClaimValidators, userrolesclaims.PermissionClaimValidators = userRoleClaimValidators, permissionClaimValidators
+	}()
+	maxAge := int64(300)
+	userrolesclaims.UserRoleClaim, userrolesclaims.UserRoleClaimValidators = claims.PrimitiveArrayClaim("st-role", nil, &maxAge)
+	userrolesclaims.PermissionClaim, userrolesclaims.PermissionClaimValidators = claims.PrimitiveArrayClaim("st-perm", nil, &maxAge)
+
+	handler := VerifySessionWithAuthorizationRules([]sessmodels.AuthorizationRule{
+		{Method: http.MethodGet, Path: "/users/:userId", ClaimValidators: []claims.SessionClaimValidator{RequirePermissions("read:users")}},
+		{Path: "/users/**", ClaimValidators: []claims.SessionClaimValidator{RequireAnyRole("admin", "support")}},
+		{Path: "/admin/**", ClaimValidators: []claims.SessionClaimValidator{RequireRoles("admin")}},
+		{Path: "/public/**", Public: true},
+	}, nil, func(w http.ResponseWriter, r *http.Request) {
+		if GetSessionFromRequestContext(r.Context()) == nil {
+			w.Write([]byte("no session"))
+			return
+		}
+		w.Write([]byte("session"))
+	})
+
+	makeToken := func(roles []interface{}, permissions []interface{}) string {
+		now := time.Now().UnixNano() / 1000000
+		return makeAccessTokenForTest(t, privateKey, map[string]interface{}{
+			"st-role": map[string]interface{}{"v": roles, "t": now},
+			"st-perm": map[string]interface{}{"v": permissions, "t": now},
+		})
+	}
+	adminToken := makeToken([]interface{}{"admin"}, []interface{}{"read:users"})
+	supportToken := makeToken([]interface{}{"support"}, []interface{}{})
+
+	doRequest := func(method string, path string, accessToken string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		res := httptest.NewRecorder()
+		handler(res, req)
+		return res
+	}
+
+	res := doRequest(http.MethodGet, "/admin/settings", adminToken)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "session", res.Body.String())
+
+	res = doRequest(http.MethodGet, "/admin/settings", supportToken)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Equal(t, "invalid claim", body["message"])
+	assert.Equal(t, "st-role", body["claimValidationErrors"].([]interface{})[0].(map[string]interface{})["id"])
+
+	res = doRequest(http.MethodGet, "/admin/settings", "")
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	// the first rule that matches is used
+	res = doRequest(http.MethodGet, "/users/1", supportToken)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	res = doRequest(http.MethodGet, "/users/1", adminToken)
+	assert.Equal(t, http.StatusOK, res.Code)
+	res = doRequest(http.MethodDelete, "/users/1", supportToken)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	// requests that match a public rule don't need a session
+	res = doRequest(http.MethodGet, "/public/hello", "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "no session", res.Body.String())
+
+	// requests that match no rule are rejected
+	res = doRequest(http.MethodGet, "/hello", adminToken)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+
+	// the path is cleaned before it is matched
+	res = doRequest(http.MethodGet, "/public/../admin/settings", supportToken)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	res = doRequest(http.MethodGet, "//admin/settings", "")
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	rule := GetAuthorizationRuleForRequest([]sessmodels.AuthorizationRule{{Path: "/admin/**"}}, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	assert.NotNil(t, rule)
+	assert.Nil(t, GetAuthorizationRuleForRequest([]sessmodels.AuthorizationRule{{Path: "/admin/**"}}, httptest.NewRequest(http.MethodGet, "/hello", nil)))
+}
+
+func TestAuthorizationValidatorsFailWithoutTheUserRolesRecipe(t *testing.T) {
+	userRoleClaim, permissionClaim := userrolesclaims.UserRoleClaim, userrolesclaims.PermissionClaim
+	defer func() {
+		userrolesclaims.UserRoleClaim, userrolesclaims.PermissionClaim = userRoleClaim, permissionClaim
+	}()
+	userrolesclaims.UserRoleClaim, userrolesclaims.PermissionClaim = nil, nil
+
+	for _, validator := range []claims.SessionClaimValidator{RequireRoles("admin"), RequireAnyRole("admin"), RequirePermissions("read:users")} {
+		result := validator.Validate(map[string]interface{}{}, nil)
+		assert.False(t, result.IsValid)
+		assert.Equal(t, "the userroles recipe needs to be imported to use "+validator.ID, result.Reason.(map[string]interface{})["message"])
+	}
+}
+
+func TestWithClaimValidatorsKeepsTheOverriddenValidators(t *testing.T) {
+	_, trueValidators := TrueClaim()
+	_, nilValidators := NilClaim()
+	sessionRequired := false
+	options := &sessmodels.VerifySessionOptions{
+		SessionRequired: &sessionRequired,
+		OverrideGlobalClaimValidators: func(globalClaimValidators []claims.SessionClaimValidator, sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) ([]claims.SessionClaimValidator, error) {
+			return append(globalClaimValidators, trueValidators.IsTrue(nil, nil)), nil
+		},
+	}
+
+	result := WithClaimValidators(options, nilValidators.HasValue(true, nil, nil))
+	assert.Equal(t, &sessionRequired, result.SessionRequired)
+	validators, err := result.OverrideGlobalClaimValidators([]claims.SessionClaimValidator{{ID: "global"}}, nil, nil)
+	assert.NoError(t, err)
+	ids := []string{}
+	for _, validator := range validators {
+		ids = append(ids, validator.ID)
+	}
+	assert.Equal(t, []string{"global", "st-true", "st-nil"}, ids)
+
+	validators, err = WithClaimValidators(nil, nilValidators.HasValue(true, nil, nil)).OverrideGlobalClaimValidators([]claims.SessionClaimValidator{{ID: "global"}}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(validators))
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/internal/testingutils"
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func TestThatValidateClaimsRefetchesTheClaimsOfCombinedValidators(t *testing.T) {
+	core := testingutils.StartFakeCore(map[string]interface{}{})
+	defer core.Close()
+	BeforeEach()
+	defer AfterEach()
+	err := supertokens.Init(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: core.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{Init(&sessmodels.TypeInput{})},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	recipe, err := getRecipeInstanceOrThrowError()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	fetchedClaims := []string{}
+	roleClaim, roleValidators := claims.PrimitiveArrayClaim("st-role", func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
+		fetchedClaims = append(fetchedClaims, "st-role")
+		return []interface{}{"support"}, nil
+	}, nil)
+	_, evValidators := claims.BooleanClaim("st-ev", func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
+		fetchedClaims = append(fetchedClaims, "st-ev")
+		return true, nil
+	}, nil)
+
+	// admin OR (support AND email verified)
+	policy := claims.AnyOf([]claims.SessionClaimValidator{
+		roleValidators.Includes("admin", nil, nil),
+		claims.AllOf([]claims.SessionClaimValidator{
+			roleValidators.Includes("support", nil, nil),
+			evValidators.IsTrue(nil, nil),
+		}, nil),
+	}, nil)
+
+	result, err := (*recipe.RecipeImpl.ValidateClaims)("userId", map[string]interface{}{}, []claims.SessionClaimValidator{policy}, &map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Empty(t, result.InvalidClaims)
+	// the role claim is only fetched once, since it is up to date when the second validator checks it
+	assert.Equal(t, []string{"st-role", "st-ev"}, fetchedClaims)
+	assert.Equal(t, []interface{}{"support"}, roleClaim.GetValueFromPayload(result.AccessTokenPayloadUpdate, nil))
+	assert.Equal(t, true, result.AccessTokenPayloadUpdate["st-ev"].(map[string]interface{})["v"])
+}
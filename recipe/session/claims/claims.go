@@ -49,6 +49,9 @@ type SessionClaimValidator struct {
 	Claim         *TypeSessionClaim
 	ShouldRefetch func(payload map[string]interface{}, userContext supertokens.UserContext) bool
 	Validate      func(payload map[string]interface{}, userContext supertokens.UserContext) ClaimValidationResult
+	// Validators is set for validators that combine other validators (see AllOf, AnyOf and Not). Claim is
+	// nil for these, and the claims of the Validators are refetched instead.
+	Validators []SessionClaimValidator
 }
 
 type ClaimValidationResult struct {
@@ -0,0 +1,153 @@
+package claims
+
+import (
+	"strings"
+
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// AllOf creates a validator that is valid if all of validators are valid. If it fails, the reason contains
+// the ClaimValidationError of each validator that failed in "invalidClaims".
+func AllOf(validators []SessionClaimValidator, id *string) SessionClaimValidator {
+	validatorId := combinedValidatorId("allOf", validators)
+	if id != nil {
+		validatorId = *id
+	}
+	return SessionClaimValidator{
+		ID:            validatorId,
+		ShouldRefetch: shouldRefetchAny(validators),
+		Validate: func(payload map[string]interface{}, userContext supertokens.UserContext) ClaimValidationResult {
+			invalidClaims := validateAll(validators, payload, userContext)
+			if len(invalidClaims) > 0 {
+				return ClaimValidationResult{
+					IsValid: false,
+					Reason: map[string]interface{}{
+						"message":       "all of the validators must pass",
+						"invalidClaims": invalidClaims,
+					},
+				}
+			}
+			return ClaimValidationResult{
+				IsValid: true,
+			}
+		},
+		Validators: validators,
+	}
+}
+
+// AnyOf creates a validator that is valid if at least one of validators is valid. If it fails, the reason
+// contains the ClaimValidationError of every validator in "invalidClaims".
+func AnyOf(validators []SessionClaimValidator, id *string) SessionClaimValidator {
+	validatorId := combinedValidatorId("anyOf", validators)
+	if id != nil {
+		validatorId = *id
+	}
+	return SessionClaimValidator{
+		ID:            validatorId,
+		ShouldRefetch: shouldRefetchAny(validators),
+		Validate: func(payload map[string]interface{}, userContext supertokens.UserContext) ClaimValidationResult {
+			invalidClaims := validateAll(validators, payload, userContext)
+			if len(invalidClaims) == len(validators) {
+				return ClaimValidationResult{
+					IsValid: false,
+					Reason: map[string]interface{}{
+						"message":       "at least one of the validators must pass",
+						"invalidClaims": invalidClaims,
+					},
+				}
+			}
+			return ClaimValidationResult{
+				IsValid: true,
+			}
+		},
+		Validators: validators,
+	}
+}
+
+// Not creates a validator that is valid if validator is not valid. It is never valid if the value of a
+// claim checked by validator is not in the payload (for example because it could not be fetched), so that
+// a missing claim can't make Not(validator) pass.
+func Not(validator SessionClaimValidator, id *string) SessionClaimValidator {
+	validatorId := "not(" + validator.ID + ")"
+	if id != nil {
+		validatorId = *id
+	}
+	validators := []SessionClaimValidator{validator}
+	return SessionClaimValidator{
+		ID:            validatorId,
+		ShouldRefetch: shouldRefetchAny(validators),
+		Validate: func(payload map[string]interface{}, userContext supertokens.UserContext) ClaimValidationResult {
+			for _, claimValidator := range GetValidatorsToRefetch(validators) {
+				if claimValidator.Claim != nil && claimValidator.Claim.GetValueFromPayload(payload, userContext) == nil {
+					return ClaimValidationResult{
+						IsValid: false,
+						Reason: map[string]interface{}{
+							"message":     "value does not exist",
+							"validatorId": claimValidator.ID,
+						},
+					}
+				}
+			}
+			if validator.Validate(payload, userContext).IsValid {
+				return ClaimValidationResult{
+					IsValid: false,
+					Reason: map[string]interface{}{
+						"message":     "the validator must not pass",
+						"validatorId": validator.ID,
+					},
+				}
+			}
+			return ClaimValidationResult{
+				IsValid: true,
+			}
+		},
+		Validators: validators,
+	}
+}
+
+// GetValidatorsToRefetch returns the validators whose claims may need to be refetched before validators
+// are checked, replacing the validators created by AllOf, AnyOf and Not with the validators they combine
+func GetValidatorsToRefetch(validators []SessionClaimValidator) []SessionClaimValidator {
+	result := []SessionClaimValidator{}
+	for _, validator := range validators {
+		if validator.Validators != nil {
+			result = append(result, GetValidatorsToRefetch(validator.Validators)...)
+		} else {
+			result = append(result, validator)
+		}
+	}
+	return result
+}
+
+func combinedValidatorId(name string, validators []SessionClaimValidator) string {
+	ids := []string{}
+	for _, validator := range validators {
+		ids = append(ids, validator.ID)
+	}
+	return name + "(" + strings.Join(ids, ",") + ")"
+}
+
+func shouldRefetchAny(validators []SessionClaimValidator) func(payload map[string]interface{}, userContext supertokens.UserContext) bool {
+	return func(payload map[string]interface{}, userContext supertokens.UserContext) bool {
+		for _, validator := range validators {
+			if validator.ShouldRefetch != nil && validator.ShouldRefetch(payload, userContext) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func validateAll(validators []SessionClaimValidator, payload map[string]interface{}, userContext supertokens.UserContext) []ClaimValidationError {
+	invalidClaims := []ClaimValidationError{}
+	for _, validator := range validators {
+		result := validator.Validate(payload, userContext)
+		if !result.IsValid {
+			invalidClaims = append(invalidClaims, ClaimValidationError{
+				ID:     validator.ID,
+				Reason: result.Reason,
+			})
+		}
+	}
+	return invalidClaims
+}
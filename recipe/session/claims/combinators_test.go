@@ -0,0 +1,133 @@
+package claims
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func TestCombinatorsValidate(t *testing.T) {
+	roleClaim, roleValidators := PrimitiveArrayClaim("st-role", nil, nil)
+	evClaim, evValidators := BooleanClaim("st-ev", nil, nil)
+
+	isAdmin := roleValidators.Includes("admin", nil, nil)
+	isSupport := roleValidators.Includes("support", nil, nil)
+	isVerified := evValidators.IsTrue(nil, nil)
+	// admin OR (support AND email verified)
+	policy := AnyOf([]SessionClaimValidator{isAdmin, AllOf([]SessionClaimValidator{isSupport, isVerified}, nil)}, nil)
+	assert.Equal(t, "anyOf(st-role,allOf(st-role,st-ev))", policy.ID)
+
+	makePayload := func(roles []interface{}, verified bool) map[string]interface{} {
+		payload := roleClaim.AddToPayload_internal(map[string]interface{}{}, roles, nil)
+		return evClaim.AddToPayload_internal(payload, verified, nil)
+	}
+
+	assert.True(t, policy.Validate(makePayload([]interface{}{"admin"}, false), nil).IsValid)
+	assert.True(t, policy.Validate(makePayload([]interface{}{"support"}, true), nil).IsValid)
+	assert.False(t, policy.Validate(makePayload([]interface{}{"support"}, false), nil).IsValid)
+	assert.False(t, policy.Validate(makePayload([]interface{}{}, true), nil).IsValid)
+	assert.False(t, policy.Validate(map[string]interface{}{}, nil).IsValid)
+
+	notAdmin := Not(isAdmin, nil)
+	assert.Equal(t, "not(st-role)", notAdmin.ID)
+	assert.True(t, notAdmin.Validate(makePayload([]interface{}{"support"}, false), nil).IsValid)
+	assert.False(t, notAdmin.Validate(makePayload([]interface{}{"admin"}, false), nil).IsValid)
+
+	customId := "custom"
+	assert.Equal(t, "custom", AllOf(nil, &customId).ID)
+	assert.Equal(t, "custom", AnyOf(nil, &customId).ID)
+	assert.Equal(t, "custom", Not(isAdmin, &customId).ID)
+
+	// like listing no validators, AllOf of no validators is valid, and AnyOf of no validators is not
+	assert.True(t, AllOf([]SessionClaimValidator{}, nil).Validate(map[string]interface{}{}, nil).IsValid)
+	assert.False(t, AnyOf([]SessionClaimValidator{}, nil).Validate(map[string]interface{}{}, nil).IsValid)
+}
+
+func TestCombinatorsValidateReasons(t *testing.T) {
+	roleClaim, roleValidators := PrimitiveArrayClaim("st-role", nil, nil)
+	_, evValidators := BooleanClaim("st-ev", nil, nil)
+
+	isAdmin := roleValidators.Includes("admin", nil, nil)
+	isVerified := evValidators.IsTrue(nil, nil)
+	payload := roleClaim.AddToPayload_internal(map[string]interface{}{}, []interface{}{"support"}, nil)
+
+	validationResult := AnyOf([]SessionClaimValidator{isAdmin, AllOf([]SessionClaimValidator{isVerified}, nil)}, nil).Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"message": "at least one of the validators must pass",
+		"invalidClaims": []ClaimValidationError{
+			{
+				ID: "st-role",
+				Reason: map[string]interface{}{
+					"message":           "wrong value",
+					"expectedToInclude": "admin",
+					"actualValue":       []interface{}{"support"},
+				},
+			},
+			{
+				ID: "allOf(st-ev)",
+				Reason: map[string]interface{}{
+					"message": "all of the validators must pass",
+					"invalidClaims": []ClaimValidationError{
+						{
+							ID: "st-ev",
+							Reason: map[string]interface{}{
+								"message":       "value does not exist",
+								"expectedValue": true,
+								"actualValue":   nil,
+							},
+						},
+					},
+				},
+			},
+		},
+	}, validationResult.Reason)
+
+	validationResult = Not(roleValidators.Includes("support", nil, nil), nil).Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"message":     "the validator must not pass",
+		"validatorId": "st-role",
+	}, validationResult.Reason)
+
+	// a missing claim never makes Not pass, even though the validator it negates fails
+	notVerified := Not(evValidators.IsTrue(nil, nil), nil)
+	validationResult = notVerified.Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"message":     "value does not exist",
+		"validatorId": "st-ev",
+	}, validationResult.Reason)
+	assert.False(t, Not(AnyOf([]SessionClaimValidator{roleValidators.Includes("admin", nil, nil), evValidators.IsTrue(nil, nil)}, nil), nil).Validate(payload, nil).IsValid)
+}
+
+func TestCombinatorsShouldRefetch(t *testing.T) {
+	roleClaim, roleValidators := PrimitiveArrayClaim("st-role", nil, nil)
+	_, evValidators := BooleanClaim("st-ev", nil, nil)
+	payload := roleClaim.AddToPayload_internal(map[string]interface{}{}, []interface{}{"admin"}, nil)
+
+	isAdmin := roleValidators.Includes("admin", nil, nil)
+	isVerified := evValidators.IsTrue(nil, nil)
+	stub := SessionClaimValidator{
+		ID: "stub",
+		Validate: func(payload map[string]interface{}, userContext supertokens.UserContext) ClaimValidationResult {
+			return ClaimValidationResult{IsValid: true}
+		},
+	}
+
+	// a combined validator needs to refetch if any of the validators it combines does
+	assert.False(t, AllOf([]SessionClaimValidator{isAdmin, stub}, nil).ShouldRefetch(payload, nil))
+	assert.True(t, AllOf([]SessionClaimValidator{isAdmin, isVerified}, nil).ShouldRefetch(payload, nil))
+	assert.True(t, AnyOf([]SessionClaimValidator{isAdmin, Not(isVerified, nil)}, nil).ShouldRefetch(payload, nil))
+	assert.False(t, Not(isAdmin, nil).ShouldRefetch(payload, nil))
+
+	policy := AnyOf([]SessionClaimValidator{isAdmin, AllOf([]SessionClaimValidator{Not(isVerified, nil), stub}, nil)}, nil)
+	assert.Nil(t, policy.Claim)
+	validatorsToRefetch := GetValidatorsToRefetch([]SessionClaimValidator{policy, isVerified})
+	ids := []string{}
+	for _, validator := range validatorsToRefetch {
+		ids = append(ids, validator.ID)
+	}
+	assert.Equal(t, []string{"st-role", "st-ev", "stub", "st-ev"}, ids)
+}
@@ -0,0 +1,76 @@
+package claims
+
+import (
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func NumberClaim(key string, fetchValue FetchValueFunc, defaultMaxAgeInSeconds *int64) (*TypeSessionClaim, NumberClaimValidators) {
+	// Claim functions are identical to primitive claim, except that numbers are always read as float64
+	sessionClaim, _ := PrimitiveClaim(key, fetchValue, defaultMaxAgeInSeconds)
+	getPrimitiveValue := sessionClaim.GetValueFromPayload
+
+	sessionClaim.GetValueFromPayload = func(payload map[string]interface{}, userContext supertokens.UserContext) interface{} {
+		if value, ok := toFloat64(getPrimitiveValue(payload, userContext)); ok {
+			return value
+		}
+		return nil
+	}
+
+	compare := func(expectation map[string]interface{}, isValid func(claimVal float64) bool) func(maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+		return func(maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			if maxAgeInSeconds == nil {
+				maxAgeInSeconds = defaultMaxAgeInSeconds
+			}
+			return valueValidator(sessionClaim, maxAgeInSeconds, id, expectation, func(claimVal interface{}) bool {
+				return isValid(claimVal.(float64))
+			})
+		}
+	}
+
+	validators := NumberClaimValidators{
+		PrimitiveClaimValidators: PrimitiveClaimValidators{
+			HasValue: func(val interface{}, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+				return compare(map[string]interface{}{"expectedValue": val}, func(claimVal float64) bool {
+					return valuesEqual(claimVal, val)
+				})(maxAgeInSeconds, id)
+			},
+		},
+		GreaterThan: func(val float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return compare(map[string]interface{}{"expectedToBeGreaterThan": val}, func(claimVal float64) bool {
+				return claimVal > val
+			})(maxAgeInSeconds, id)
+		},
+		GreaterThanOrEqual: func(val float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return compare(map[string]interface{}{"expectedToBeGreaterThanOrEqual": val}, func(claimVal float64) bool {
+				return claimVal >= val
+			})(maxAgeInSeconds, id)
+		},
+		LessThan: func(val float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return compare(map[string]interface{}{"expectedToBeLessThan": val}, func(claimVal float64) bool {
+				return claimVal < val
+			})(maxAgeInSeconds, id)
+		},
+		LessThanOrEqual: func(val float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return compare(map[string]interface{}{"expectedToBeLessThanOrEqual": val}, func(claimVal float64) bool {
+				return claimVal <= val
+			})(maxAgeInSeconds, id)
+		},
+		Between: func(min float64, max float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return compare(map[string]interface{}{"expectedToBeBetween": []float64{min, max}}, func(claimVal float64) bool {
+				return claimVal >= min && claimVal <= max
+			})(maxAgeInSeconds, id)
+		},
+	}
+
+	return sessionClaim, validators
+}
+
+type NumberClaimValidators struct {
+	PrimitiveClaimValidators
+	GreaterThan        func(val float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	GreaterThanOrEqual func(val float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	LessThan           func(val float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	LessThanOrEqual    func(val float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	// Between checks that the value is in the range [min, max]
+	Between func(min float64, max float64, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+}
@@ -0,0 +1,123 @@
+package claims
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func TestNumberClaimBuild(t *testing.T) {
+	numberClaim, _ := NumberClaim(
+		"test",
+		func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
+			return 2, nil
+		},
+		nil,
+	)
+
+	payload, err := numberClaim.Build("userId", "public", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, payload["test"].(map[string]interface{})["v"])
+	assert.Equal(t, float64(2), numberClaim.GetValueFromPayload(payload, nil))
+
+	assert.Equal(t, nil, numberClaim.GetValueFromPayload(map[string]interface{}{}, nil))
+	payload = numberClaim.AddToPayload_internal(map[string]interface{}{}, "two", nil)
+	assert.Equal(t, nil, numberClaim.GetValueFromPayload(payload, nil))
+}
+
+func TestNumberClaimBuildWithFetchError(t *testing.T) {
+	numberClaim, _ := NumberClaim(
+		"test",
+		func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
+			return nil, errors.New("fetch failed")
+		},
+		nil,
+	)
+
+	_, err := numberClaim.Build("userId", "public", nil, nil)
+	assert.EqualError(t, err, "fetch failed")
+}
+
+func TestNumberClaimValidators(t *testing.T) {
+	numberClaim, validators := NumberClaim("test", nil, nil)
+	payload := numberClaim.AddToPayload_internal(map[string]interface{}{}, 2, nil)
+	// values read from a serialised payload are float64
+	serialisedPayload := numberClaim.AddToPayload_internal(map[string]interface{}{}, float64(2), nil)
+
+	for _, p := range []map[string]interface{}{payload, serialisedPayload} {
+		assert.True(t, validators.HasValue(2, nil, nil).Validate(p, nil).IsValid)
+		assert.True(t, validators.HasValue(2.0, nil, nil).Validate(p, nil).IsValid)
+		assert.False(t, validators.HasValue(3, nil, nil).Validate(p, nil).IsValid)
+		assert.False(t, validators.HasValue("2", nil, nil).Validate(p, nil).IsValid)
+
+		assert.True(t, validators.GreaterThan(1, nil, nil).Validate(p, nil).IsValid)
+		assert.False(t, validators.GreaterThan(2, nil, nil).Validate(p, nil).IsValid)
+		assert.True(t, validators.GreaterThanOrEqual(2, nil, nil).Validate(p, nil).IsValid)
+		assert.False(t, validators.GreaterThanOrEqual(2.5, nil, nil).Validate(p, nil).IsValid)
+
+		assert.True(t, validators.LessThan(3, nil, nil).Validate(p, nil).IsValid)
+		assert.False(t, validators.LessThan(2, nil, nil).Validate(p, nil).IsValid)
+		assert.True(t, validators.LessThanOrEqual(2, nil, nil).Validate(p, nil).IsValid)
+		assert.False(t, validators.LessThanOrEqual(1.5, nil, nil).Validate(p, nil).IsValid)
+
+		assert.True(t, validators.Between(2, 3, nil, nil).Validate(p, nil).IsValid)
+		assert.True(t, validators.Between(1, 2, nil, nil).Validate(p, nil).IsValid)
+		assert.False(t, validators.Between(3, 4, nil, nil).Validate(p, nil).IsValid)
+	}
+}
+
+func TestNumberClaimValidateReasons(t *testing.T) {
+	numberClaim, validators := NumberClaim("test", nil, nil)
+
+	validationResult := validators.GreaterThan(1, nil, nil).Validate(map[string]interface{}{}, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"actualValue":             nil,
+		"expectedToBeGreaterThan": float64(1),
+		"message":                 "value does not exist",
+	}, validationResult.Reason)
+
+	payload := numberClaim.AddToPayload_internal(map[string]interface{}{}, 1, nil)
+	validationResult = validators.Between(2, 3, nil, nil).Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"actualValue":         float64(1),
+		"expectedToBeBetween": []float64{2, 3},
+		"message":             "wrong value",
+	}, validationResult.Reason)
+
+	validator := validators.LessThan(3, nil, nil)
+	assert.Equal(t, "test", validator.ID)
+	customId := "plan-tier"
+	assert.Equal(t, "plan-tier", validators.LessThan(3, nil, &customId).ID)
+}
+
+func TestNumberClaimValidateExpiry(t *testing.T) {
+	defaultMaxAgeInSec := int64(60)
+	numberClaim, validators := NumberClaim("test", nil, &defaultMaxAgeInSec)
+
+	payload := numberClaim.AddToPayload_internal(map[string]interface{}{}, 2, nil)
+	assert.False(t, validators.GreaterThan(1, nil, nil).ShouldRefetch(payload, nil))
+	assert.True(t, validators.GreaterThan(1, nil, nil).ShouldRefetch(map[string]interface{}{}, nil))
+
+	// the value was fetched 120 seconds ago
+	payload["test"].(map[string]interface{})["t"] = time.Now().UnixNano()/1000000 - 120000
+
+	validator := validators.GreaterThan(1, nil, nil)
+	assert.True(t, validator.ShouldRefetch(payload, nil))
+	validationResult := validator.Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"ageInSeconds":    int64(120),
+		"maxAgeInSeconds": int64(60),
+		"message":         "expired",
+	}, validationResult.Reason)
+
+	maxAgeInSec := int64(600)
+	validator = validators.GreaterThan(1, &maxAgeInSec, nil)
+	assert.False(t, validator.ShouldRefetch(payload, nil))
+	assert.True(t, validator.Validate(payload, nil).IsValid)
+}
@@ -0,0 +1,135 @@
+package claims
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// ObjectClaim creates a claim whose value is a JSON object. fetchValue can return a map or any value that
+// encodes to a JSON object, it is stored in the payload in its JSON form.
+//
+// The validators select fields using paths like "org.plan" or "orgs[0].id". A leading "$." is optional.
+func ObjectClaim(key string, fetchValue FetchValueFunc, defaultMaxAgeInSeconds *int64) (*TypeSessionClaim, ObjectClaimValidators) {
+	sessionClaim, _ := PrimitiveClaim(key, fetchValue, defaultMaxAgeInSeconds)
+	addPrimitiveToPayload := sessionClaim.AddToPayload_internal
+	getPrimitiveValue := sessionClaim.GetValueFromPayload
+
+	sessionClaim.AddToPayload_internal = func(payload map[string]interface{}, value interface{}, userContext supertokens.UserContext) map[string]interface{} {
+		// This makes sure the validators see the same value before and after the payload is serialised
+		if jsonValue, err := json.Marshal(value); err == nil {
+			var object map[string]interface{}
+			if json.Unmarshal(jsonValue, &object) == nil {
+				value = object
+			}
+		}
+		return addPrimitiveToPayload(payload, value, userContext)
+	}
+
+	sessionClaim.GetValueFromPayload = func(payload map[string]interface{}, userContext supertokens.UserContext) interface{} {
+		if value, ok := getPrimitiveValue(payload, userContext).(map[string]interface{}); ok {
+			return value
+		}
+		return nil
+	}
+
+	fieldValidator := func(path string, expectation map[string]interface{}, isValid func(fieldVal interface{}) bool) func(maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+		return func(maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			if maxAgeInSeconds == nil {
+				maxAgeInSeconds = defaultMaxAgeInSeconds
+			}
+			expectation["path"] = path
+			return valueValidator(sessionClaim, maxAgeInSeconds, id, expectation, func(claimVal interface{}) bool {
+				fieldVal, ok := getValueAtPath(claimVal, path)
+				return ok && isValid(fieldVal)
+			})
+		}
+	}
+
+	validators := ObjectClaimValidators{
+		HasField: func(path string, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return fieldValidator(path, map[string]interface{}{"expectedToHaveField": true}, func(fieldVal interface{}) bool {
+				return fieldVal != nil
+			})(maxAgeInSeconds, id)
+		},
+		FieldEquals: func(path string, val interface{}, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return fieldValidator(path, map[string]interface{}{"expectedValue": val}, func(fieldVal interface{}) bool {
+				return valuesEqual(fieldVal, val)
+			})(maxAgeInSeconds, id)
+		},
+		FieldIncludes: func(path string, val interface{}, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return fieldValidator(path, map[string]interface{}{"expectedToInclude": val}, func(fieldVal interface{}) bool {
+				fieldArr, ok := fieldVal.([]interface{})
+				if !ok {
+					return false
+				}
+				for _, item := range fieldArr {
+					if valuesEqual(item, val) {
+						return true
+					}
+				}
+				return false
+			})(maxAgeInSeconds, id)
+		},
+	}
+
+	return sessionClaim, validators
+}
+
+type ObjectClaimValidators struct {
+	// HasField checks that the field at path exists and is not null
+	HasField    func(path string, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	FieldEquals func(path string, val interface{}, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	// FieldIncludes checks that the field at path is an array that includes val
+	FieldIncludes func(path string, val interface{}, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+}
+
+// getValueAtPath returns the value at a path like "a.b[0].c" in value. The second return value is false if
+// the path is invalid or does not exist in value.
+func getValueAtPath(value interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return value, true
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		field := segment
+		indexes := ""
+		if bracket := strings.Index(segment, "["); bracket != -1 {
+			field = segment[:bracket]
+			indexes = segment[bracket:]
+		}
+
+		if field != "" {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if value, ok = object[field]; !ok {
+				return nil, false
+			}
+		} else if indexes == "" {
+			return nil, false
+		}
+
+		for indexes != "" {
+			end := strings.Index(indexes, "]")
+			if !strings.HasPrefix(indexes, "[") || end == -1 {
+				return nil, false
+			}
+			index, err := strconv.Atoi(indexes[1:end])
+			if err != nil {
+				return nil, false
+			}
+			arr, ok := value.([]interface{})
+			if !ok || index < 0 || index >= len(arr) {
+				return nil, false
+			}
+			value = arr[index]
+			indexes = indexes[end+1:]
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,130 @@
+package claims
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+type testOrganisation struct {
+	ID       string   `json:"id"`
+	Plan     string   `json:"plan"`
+	Seats    int      `json:"seats"`
+	Features []string `json:"features"`
+}
+
+func TestObjectClaimBuild(t *testing.T) {
+	objectClaim, _ := ObjectClaim(
+		"test",
+		func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
+			return testOrganisation{ID: "org", Plan: "pro", Seats: 5, Features: []string{"sso"}}, nil
+		},
+		nil,
+	)
+
+	payload, err := objectClaim.Build("userId", "public", nil, nil)
+	assert.NoError(t, err)
+	// the value is stored in its JSON form
+	assert.Equal(t, map[string]interface{}{
+		"id":       "org",
+		"plan":     "pro",
+		"seats":    float64(5),
+		"features": []interface{}{"sso"},
+	}, objectClaim.GetValueFromPayload(payload, nil))
+
+	assert.Equal(t, nil, objectClaim.GetValueFromPayload(map[string]interface{}{}, nil))
+	payload = objectClaim.AddToPayload_internal(map[string]interface{}{}, []string{"a"}, nil)
+	assert.Equal(t, nil, objectClaim.GetValueFromPayload(payload, nil))
+}
+
+func TestObjectClaimValidators(t *testing.T) {
+	objectClaim, validators := ObjectClaim("test", nil, nil)
+	payload := objectClaim.AddToPayload_internal(map[string]interface{}{}, map[string]interface{}{
+		"org": map[string]interface{}{
+			"plan":     "pro",
+			"seats":    5,
+			"features": []string{"sso", "audit-log"},
+			"parent":   nil,
+		},
+		"orgs": []interface{}{
+			map[string]interface{}{"id": "a"},
+			map[string]interface{}{"id": "b", "admins": []interface{}{[]interface{}{"user"}}},
+		},
+	}, nil)
+
+	assert.True(t, validators.HasField("org.plan", nil, nil).Validate(payload, nil).IsValid)
+	assert.True(t, validators.HasField("$.org.plan", nil, nil).Validate(payload, nil).IsValid)
+	assert.True(t, validators.HasField("orgs[1].id", nil, nil).Validate(payload, nil).IsValid)
+	assert.True(t, validators.HasField("orgs[1].admins[0][0]", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.HasField("org.parent", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.HasField("org.owner", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.HasField("org.plan.name", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.HasField("orgs[2].id", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.HasField("orgs[-1].id", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.HasField("orgs[a].id", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.HasField("orgs[0.id", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.HasField("org..plan", nil, nil).Validate(payload, nil).IsValid)
+
+	assert.True(t, validators.FieldEquals("org.plan", "pro", nil, nil).Validate(payload, nil).IsValid)
+	assert.True(t, validators.FieldEquals("org.seats", 5, nil, nil).Validate(payload, nil).IsValid)
+	assert.True(t, validators.FieldEquals("orgs[0]", map[string]interface{}{"id": "a"}, nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.FieldEquals("org.plan", "free", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.FieldEquals("org.seats", "5", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.FieldEquals("org.owner", nil, nil, nil).Validate(payload, nil).IsValid)
+
+	assert.True(t, validators.FieldIncludes("org.features", "sso", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.FieldIncludes("org.features", "scim", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.FieldIncludes("org.plan", "pro", nil, nil).Validate(payload, nil).IsValid)
+}
+
+func TestObjectClaimValidateReasons(t *testing.T) {
+	objectClaim, validators := ObjectClaim("test", nil, nil)
+
+	validationResult := validators.FieldEquals("org.plan", "pro", nil, nil).Validate(map[string]interface{}{}, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"actualValue":   nil,
+		"expectedValue": "pro",
+		"path":          "org.plan",
+		"message":       "value does not exist",
+	}, validationResult.Reason)
+
+	payload := objectClaim.AddToPayload_internal(map[string]interface{}{}, map[string]interface{}{"org": map[string]interface{}{"plan": "free"}}, nil)
+	validationResult = validators.FieldEquals("org.plan", "pro", nil, nil).Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"actualValue":   map[string]interface{}{"org": map[string]interface{}{"plan": "free"}},
+		"expectedValue": "pro",
+		"path":          "org.plan",
+		"message":       "wrong value",
+	}, validationResult.Reason)
+}
+
+func TestObjectClaimValidateExpiry(t *testing.T) {
+	defaultMaxAgeInSec := int64(60)
+	objectClaim, validators := ObjectClaim("test", nil, &defaultMaxAgeInSec)
+
+	payload := objectClaim.AddToPayload_internal(map[string]interface{}{}, map[string]interface{}{"plan": "pro"}, nil)
+	assert.False(t, validators.HasField("plan", nil, nil).ShouldRefetch(payload, nil))
+	assert.True(t, validators.HasField("plan", nil, nil).ShouldRefetch(map[string]interface{}{}, nil))
+
+	// the value was fetched 120 seconds ago
+	payload["test"].(map[string]interface{})["t"] = time.Now().UnixNano()/1000000 - 120000
+
+	validator := validators.HasField("plan", nil, nil)
+	assert.True(t, validator.ShouldRefetch(payload, nil))
+	validationResult := validator.Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"ageInSeconds":    int64(120),
+		"maxAgeInSeconds": int64(60),
+		"message":         "expired",
+	}, validationResult.Reason)
+
+	maxAgeInSec := int64(600)
+	validator = validators.HasField("plan", &maxAgeInSec, nil)
+	assert.False(t, validator.ShouldRefetch(payload, nil))
+	assert.True(t, validator.Validate(payload, nil).IsValid)
+}
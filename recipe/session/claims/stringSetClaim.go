@@ -0,0 +1,133 @@
+package claims
+
+import (
+	"sort"
+
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// StringSetClaim creates a claim whose value is a set of strings, like a list of scopes or feature flags.
+// fetchValue can return a []string or a []interface{} of strings. Duplicates are removed and the values are
+// sorted before they are added to the payload, and GetValueFromPayload returns a []string.
+func StringSetClaim(key string, fetchValue FetchValueFunc, defaultMaxAgeInSeconds *int64) (*TypeSessionClaim, StringSetClaimValidators) {
+	sessionClaim, _ := PrimitiveClaim(key, fetchValue, defaultMaxAgeInSeconds)
+	addPrimitiveToPayload := sessionClaim.AddToPayload_internal
+	getPrimitiveValue := sessionClaim.GetValueFromPayload
+
+	sessionClaim.AddToPayload_internal = func(payload map[string]interface{}, value interface{}, userContext supertokens.UserContext) map[string]interface{} {
+		if set, ok := toStringSet(value); ok {
+			values := []interface{}{}
+			for _, v := range set {
+				values = append(values, v)
+			}
+			value = values
+		}
+		return addPrimitiveToPayload(payload, value, userContext)
+	}
+
+	sessionClaim.GetValueFromPayload = func(payload map[string]interface{}, userContext supertokens.UserContext) interface{} {
+		if set, ok := toStringSet(getPrimitiveValue(payload, userContext)); ok {
+			return set
+		}
+		return nil
+	}
+
+	setValidator := func(expectation map[string]interface{}, isValid func(claimVal map[string]bool) bool) func(maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+		return func(maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			if maxAgeInSeconds == nil {
+				maxAgeInSeconds = defaultMaxAgeInSeconds
+			}
+			return valueValidator(sessionClaim, maxAgeInSeconds, id, expectation, func(claimVal interface{}) bool {
+				set := map[string]bool{}
+				for _, v := range claimVal.([]string) {
+					set[v] = true
+				}
+				return isValid(set)
+			})
+		}
+	}
+
+	validators := StringSetClaimValidators{
+		Includes: func(val string, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return setValidator(map[string]interface{}{"expectedToInclude": val}, func(claimVal map[string]bool) bool {
+				return claimVal[val]
+			})(maxAgeInSeconds, id)
+		},
+		Excludes: func(val string, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return setValidator(map[string]interface{}{"expectedToExclude": val}, func(claimVal map[string]bool) bool {
+				return !claimVal[val]
+			})(maxAgeInSeconds, id)
+		},
+		IncludesAll: func(vals []string, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return setValidator(map[string]interface{}{"expectedToInclude": vals}, func(claimVal map[string]bool) bool {
+				for _, v := range vals {
+					if !claimVal[v] {
+						return false
+					}
+				}
+				return true
+			})(maxAgeInSeconds, id)
+		},
+		IncludesAny: func(vals []string, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return setValidator(map[string]interface{}{"expectedToIncludeAtLeastOneOf": vals}, func(claimVal map[string]bool) bool {
+				for _, v := range vals {
+					if claimVal[v] {
+						return true
+					}
+				}
+				return false
+			})(maxAgeInSeconds, id)
+		},
+		ExcludesAll: func(vals []string, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return setValidator(map[string]interface{}{"expectedToNotInclude": vals}, func(claimVal map[string]bool) bool {
+				for _, v := range vals {
+					if claimVal[v] {
+						return false
+					}
+				}
+				return true
+			})(maxAgeInSeconds, id)
+		},
+	}
+
+	return sessionClaim, validators
+}
+
+type StringSetClaimValidators struct {
+	Includes    func(val string, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	Excludes    func(val string, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	IncludesAll func(vals []string, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	IncludesAny func(vals []string, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	ExcludesAll func(vals []string, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+}
+
+// toStringSet returns the sorted, unique strings in value. The second return value is false if value is not
+// a []string or a []interface{} that only contains strings.
+func toStringSet(value interface{}) ([]string, bool) {
+	var values []string
+	switch v := value.(type) {
+	case []string:
+		values = v
+	case []interface{}:
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, str)
+		}
+	default:
+		return nil, false
+	}
+
+	seen := map[string]bool{}
+	set := []string{}
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			set = append(set, v)
+		}
+	}
+	sort.Strings(set)
+	return set, true
+}
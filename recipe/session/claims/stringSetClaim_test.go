@@ -0,0 +1,105 @@
+package claims
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func TestStringSetClaimBuild(t *testing.T) {
+	stringSetClaim, _ := StringSetClaim(
+		"test",
+		func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
+			return []string{"write", "read", "write"}, nil
+		},
+		nil,
+	)
+
+	payload, err := stringSetClaim.Build("userId", "public", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"read", "write"}, payload["test"].(map[string]interface{})["v"])
+	assert.Equal(t, []string{"read", "write"}, stringSetClaim.GetValueFromPayload(payload, nil))
+
+	payload = stringSetClaim.AddToPayload_internal(map[string]interface{}{}, []interface{}{"b", "a"}, nil)
+	assert.Equal(t, []string{"a", "b"}, stringSetClaim.GetValueFromPayload(payload, nil))
+
+	assert.Equal(t, nil, stringSetClaim.GetValueFromPayload(map[string]interface{}{}, nil))
+	payload = stringSetClaim.AddToPayload_internal(map[string]interface{}{}, []interface{}{"a", 1}, nil)
+	assert.Equal(t, nil, stringSetClaim.GetValueFromPayload(payload, nil))
+}
+
+func TestStringSetClaimValidators(t *testing.T) {
+	stringSetClaim, validators := StringSetClaim("test", nil, nil)
+	payload := stringSetClaim.AddToPayload_internal(map[string]interface{}{}, []string{"read", "write"}, nil)
+
+	assert.True(t, validators.Includes("read", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.Includes("delete", nil, nil).Validate(payload, nil).IsValid)
+
+	assert.True(t, validators.Excludes("delete", nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.Excludes("read", nil, nil).Validate(payload, nil).IsValid)
+
+	assert.True(t, validators.IncludesAll([]string{"read", "write"}, nil, nil).Validate(payload, nil).IsValid)
+	assert.True(t, validators.IncludesAll([]string{}, nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.IncludesAll([]string{"read", "delete"}, nil, nil).Validate(payload, nil).IsValid)
+
+	assert.True(t, validators.IncludesAny([]string{"read", "delete"}, nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.IncludesAny([]string{"delete", "admin"}, nil, nil).Validate(payload, nil).IsValid)
+
+	assert.True(t, validators.ExcludesAll([]string{"delete", "admin"}, nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.ExcludesAll([]string{"delete", "write"}, nil, nil).Validate(payload, nil).IsValid)
+
+	// an empty set exists, so it does not need to be refetched
+	payload = stringSetClaim.AddToPayload_internal(map[string]interface{}{}, []string{}, nil)
+	assert.False(t, validators.Includes("read", nil, nil).ShouldRefetch(payload, nil))
+	assert.True(t, validators.Excludes("read", nil, nil).Validate(payload, nil).IsValid)
+}
+
+func TestStringSetClaimValidateReasons(t *testing.T) {
+	stringSetClaim, validators := StringSetClaim("test", nil, nil)
+
+	validationResult := validators.Includes("read", nil, nil).Validate(map[string]interface{}{}, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"actualValue":       nil,
+		"expectedToInclude": "read",
+		"message":           "value does not exist",
+	}, validationResult.Reason)
+
+	payload := stringSetClaim.AddToPayload_internal(map[string]interface{}{}, []string{"read"}, nil)
+	validationResult = validators.IncludesAll([]string{"read", "write"}, nil, nil).Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"actualValue":       []string{"read"},
+		"expectedToInclude": []string{"read", "write"},
+		"message":           "wrong value",
+	}, validationResult.Reason)
+}
+
+func TestStringSetClaimValidateExpiry(t *testing.T) {
+	defaultMaxAgeInSec := int64(60)
+	stringSetClaim, validators := StringSetClaim("test", nil, &defaultMaxAgeInSec)
+
+	payload := stringSetClaim.AddToPayload_internal(map[string]interface{}{}, []string{"read"}, nil)
+	assert.False(t, validators.Includes("read", nil, nil).ShouldRefetch(payload, nil))
+	assert.True(t, validators.Includes("read", nil, nil).ShouldRefetch(map[string]interface{}{}, nil))
+
+	// the value was fetched 120 seconds ago
+	payload["test"].(map[string]interface{})["t"] = time.Now().UnixNano()/1000000 - 120000
+
+	validator := validators.Includes("read", nil, nil)
+	assert.True(t, validator.ShouldRefetch(payload, nil))
+	validationResult := validator.Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"ageInSeconds":    int64(120),
+		"maxAgeInSeconds": int64(60),
+		"message":         "expired",
+	}, validationResult.Reason)
+
+	maxAgeInSec := int64(600)
+	validator = validators.Includes("read", &maxAgeInSec, nil)
+	assert.False(t, validator.ShouldRefetch(payload, nil))
+	assert.True(t, validator.Validate(payload, nil).IsValid)
+}
@@ -0,0 +1,73 @@
+package claims
+
+import (
+	"time"
+
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// TimestampClaim creates a claim whose value is a point in time, stored in the payload in milliseconds since
+// epoch. fetchValue can return a time.Time or a number of milliseconds since epoch.
+func TimestampClaim(key string, fetchValue FetchValueFunc, defaultMaxAgeInSeconds *int64) (*TypeSessionClaim, TimestampClaimValidators) {
+	sessionClaim, _ := NumberClaim(key, fetchValue, defaultMaxAgeInSeconds)
+	addNumberToPayload := sessionClaim.AddToPayload_internal
+	getNumberValue := sessionClaim.GetValueFromPayload
+
+	sessionClaim.AddToPayload_internal = func(payload map[string]interface{}, value interface{}, userContext supertokens.UserContext) map[string]interface{} {
+		switch t := value.(type) {
+		case time.Time:
+			value = t.UnixNano() / 1000000
+		case *time.Time:
+			value = t.UnixNano() / 1000000
+		}
+		return addNumberToPayload(payload, value, userContext)
+	}
+
+	sessionClaim.GetValueFromPayload = func(payload map[string]interface{}, userContext supertokens.UserContext) interface{} {
+		if value, ok := getNumberValue(payload, userContext).(float64); ok {
+			return int64(value)
+		}
+		return nil
+	}
+
+	compare := func(expectation map[string]interface{}, isValid func(claimVal int64) bool) func(maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+		return func(maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			if maxAgeInSeconds == nil {
+				maxAgeInSeconds = defaultMaxAgeInSeconds
+			}
+			return valueValidator(sessionClaim, maxAgeInSeconds, id, expectation, func(claimVal interface{}) bool {
+				return isValid(claimVal.(int64))
+			})
+		}
+	}
+
+	validators := TimestampClaimValidators{
+		NotOlderThan: func(maxAgeOfValueInSeconds int64, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			return compare(map[string]interface{}{"expectedToBeNotOlderThanInSeconds": maxAgeOfValueInSeconds}, func(claimVal int64) bool {
+				return claimVal >= time.Now().UnixNano()/1000000-maxAgeOfValueInSeconds*1000
+			})(maxAgeInSeconds, id)
+		},
+		After: func(t time.Time, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			timeInMS := t.UnixNano() / 1000000
+			return compare(map[string]interface{}{"expectedToBeAfter": timeInMS}, func(claimVal int64) bool {
+				return claimVal > timeInMS
+			})(maxAgeInSeconds, id)
+		},
+		Before: func(t time.Time, maxAgeInSeconds *int64, id *string) SessionClaimValidator {
+			timeInMS := t.UnixNano() / 1000000
+			return compare(map[string]interface{}{"expectedToBeBefore": timeInMS}, func(claimVal int64) bool {
+				return claimVal < timeInMS
+			})(maxAgeInSeconds, id)
+		},
+	}
+
+	return sessionClaim, validators
+}
+
+type TimestampClaimValidators struct {
+	// NotOlderThan checks that the value is at most maxAgeOfValueInSeconds seconds in the past (or is in the
+	// future). Unlike maxAgeInSeconds, this is about the value of the claim, not about when it was fetched.
+	NotOlderThan func(maxAgeOfValueInSeconds int64, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	After        func(t time.Time, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+	Before       func(t time.Time, maxAgeInSeconds *int64, id *string) SessionClaimValidator
+}
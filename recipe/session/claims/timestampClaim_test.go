@@ -0,0 +1,99 @@
+package claims
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func TestTimestampClaimBuild(t *testing.T) {
+	passwordChangedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	timestampClaim, _ := TimestampClaim(
+		"test",
+		func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
+			return passwordChangedAt, nil
+		},
+		nil,
+	)
+
+	payload, err := timestampClaim.Build("userId", "public", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, passwordChangedAt.UnixNano()/1000000, payload["test"].(map[string]interface{})["v"])
+	assert.Equal(t, passwordChangedAt.UnixNano()/1000000, timestampClaim.GetValueFromPayload(payload, nil))
+
+	// the value can also be fetched as milliseconds since epoch
+	payload = timestampClaim.AddToPayload_internal(map[string]interface{}{}, float64(1704164645000), nil)
+	assert.Equal(t, int64(1704164645000), timestampClaim.GetValueFromPayload(payload, nil))
+
+	assert.Equal(t, nil, timestampClaim.GetValueFromPayload(map[string]interface{}{}, nil))
+}
+
+func TestTimestampClaimValidators(t *testing.T) {
+	timestampClaim, validators := TimestampClaim("test", nil, nil)
+	passwordChangedAt := time.Now().Add(-time.Hour)
+	payload := timestampClaim.AddToPayload_internal(map[string]interface{}{}, passwordChangedAt, nil)
+
+	assert.True(t, validators.NotOlderThan(2*60*60, nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.NotOlderThan(30*60, nil, nil).Validate(payload, nil).IsValid)
+
+	assert.True(t, validators.After(passwordChangedAt.Add(-time.Minute), nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.After(passwordChangedAt.Add(time.Minute), nil, nil).Validate(payload, nil).IsValid)
+
+	assert.True(t, validators.Before(passwordChangedAt.Add(time.Minute), nil, nil).Validate(payload, nil).IsValid)
+	assert.False(t, validators.Before(passwordChangedAt.Add(-time.Minute), nil, nil).Validate(payload, nil).IsValid)
+
+	// a value in the future is never too old
+	payload = timestampClaim.AddToPayload_internal(map[string]interface{}{}, time.Now().Add(time.Hour), nil)
+	assert.True(t, validators.NotOlderThan(0, nil, nil).Validate(payload, nil).IsValid)
+}
+
+func TestTimestampClaimValidateReasons(t *testing.T) {
+	timestampClaim, validators := TimestampClaim("test", nil, nil)
+	after := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	validationResult := validators.After(after, nil, nil).Validate(map[string]interface{}{}, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"actualValue":       nil,
+		"expectedToBeAfter": after.UnixNano() / 1000000,
+		"message":           "value does not exist",
+	}, validationResult.Reason)
+
+	payload := timestampClaim.AddToPayload_internal(map[string]interface{}{}, after, nil)
+	validationResult = validators.NotOlderThan(60, nil, nil).Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"actualValue":                       after.UnixNano() / 1000000,
+		"expectedToBeNotOlderThanInSeconds": int64(60),
+		"message":                           "wrong value",
+	}, validationResult.Reason)
+}
+
+func TestTimestampClaimValidateExpiry(t *testing.T) {
+	defaultMaxAgeInSec := int64(60)
+	timestampClaim, validators := TimestampClaim("test", nil, &defaultMaxAgeInSec)
+
+	payload := timestampClaim.AddToPayload_internal(map[string]interface{}{}, time.Now(), nil)
+	assert.False(t, validators.NotOlderThan(60, nil, nil).ShouldRefetch(payload, nil))
+	assert.True(t, validators.NotOlderThan(60, nil, nil).ShouldRefetch(map[string]interface{}{}, nil))
+
+	// the value was fetched 120 seconds ago
+	payload["test"].(map[string]interface{})["t"] = time.Now().UnixNano()/1000000 - 120000
+
+	validator := validators.After(time.Now().Add(-time.Hour), nil, nil)
+	assert.True(t, validator.ShouldRefetch(payload, nil))
+	validationResult := validator.Validate(payload, nil)
+	assert.Equal(t, false, validationResult.IsValid)
+	assert.Equal(t, map[string]interface{}{
+		"ageInSeconds":    int64(120),
+		"maxAgeInSeconds": int64(60),
+		"message":         "expired",
+	}, validationResult.Reason)
+
+	maxAgeInSec := int64(600)
+	validator = validators.After(time.Now().Add(-time.Hour), &maxAgeInSec, nil)
+	assert.False(t, validator.ShouldRefetch(payload, nil))
+	assert.True(t, validator.Validate(payload, nil).IsValid)
+}
@@ -1,5 +1,13 @@
 package claims
 
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
 func includes(s []interface{}, e interface{}) bool {
 	for _, a := range s {
 		if a == e {
@@ -34,3 +42,116 @@ func excludesAll(s []interface{}, e []interface{}) bool {
 	}
 	return true
 }
+
+// valueValidator creates a validator that refetches the claim if its value is missing or older than
+// maxAgeInSeconds, and that is valid if isValid returns true for the value. expectation is added to the
+// reason of failed validations, to explain what value was expected.
+func valueValidator(sessionClaim *TypeSessionClaim, maxAgeInSeconds *int64, id *string, expectation map[string]interface{}, isValid func(claimVal interface{}) bool) SessionClaimValidator {
+	validatorId := sessionClaim.Key
+	if id != nil {
+		validatorId = *id
+	}
+	getAgeInSeconds := func(payload map[string]interface{}, userContext supertokens.UserContext) int64 {
+		lastRefetchTime := sessionClaim.GetLastRefetchTime(payload, userContext)
+		if lastRefetchTime == nil {
+			return 0
+		}
+		return (time.Now().UnixNano()/1000000 - *lastRefetchTime) / 1000
+	}
+	getReason := func(message string, claimVal interface{}) map[string]interface{} {
+		reason := map[string]interface{}{
+			"message":     message,
+			"actualValue": claimVal,
+		}
+		for key, value := range expectation {
+			reason[key] = value
+		}
+		return reason
+	}
+
+	return SessionClaimValidator{
+		ID:    validatorId,
+		Claim: sessionClaim,
+		ShouldRefetch: func(payload map[string]interface{}, userContext supertokens.UserContext) bool {
+			if sessionClaim.GetValueFromPayload(payload, userContext) == nil || sessionClaim.GetLastRefetchTime(payload, userContext) == nil {
+				return true
+			}
+			return maxAgeInSeconds != nil && *sessionClaim.GetLastRefetchTime(payload, userContext) < time.Now().UnixNano()/1000000-*maxAgeInSeconds*1000
+		},
+		Validate: func(payload map[string]interface{}, userContext supertokens.UserContext) ClaimValidationResult {
+			claimVal := sessionClaim.GetValueFromPayload(payload, userContext)
+			if claimVal == nil {
+				return ClaimValidationResult{
+					IsValid: false,
+					Reason:  getReason("value does not exist", claimVal),
+				}
+			}
+			ageInSeconds := getAgeInSeconds(payload, userContext)
+			if maxAgeInSeconds != nil && ageInSeconds > *maxAgeInSeconds {
+				return ClaimValidationResult{
+					IsValid: false,
+					Reason: map[string]interface{}{
+						"message":         "expired",
+						"ageInSeconds":    ageInSeconds,
+						"maxAgeInSeconds": *maxAgeInSeconds,
+					},
+				}
+			}
+			if !isValid(claimVal) {
+				return ClaimValidationResult{
+					IsValid: false,
+					Reason:  getReason("wrong value", claimVal),
+				}
+			}
+			return ClaimValidationResult{
+				IsValid: true,
+			}
+		},
+	}
+}
+
+// toFloat64 converts the numeric types a claim value can have (before and after the payload is serialised)
+// to a float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// valuesEqual compares two claim values, treating numbers of different types as equal if they have the
+// same value
+func valuesEqual(a interface{}, b interface{}) bool {
+	aNum, aIsNum := toFloat64(a)
+	bNum, bIsNum := toFloat64(b)
+	if aIsNum && bIsNum {
+		return aNum == bNum
+	}
+	return reflect.DeepEqual(a, b)
+}
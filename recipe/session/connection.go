@@ -0,0 +1,389 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	"encoding/json"
+	defaultErrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// SessionConnection is the session of a long-lived connection, like a WebSocket or a Server-Sent Events stream.
+// It is created by VerifyConnection, and ends when the session is revoked, when its claims are no longer valid,
+// or when its access token expires without being replaced using UpdateAccessToken.
+type SessionConnection struct {
+	options      sessmodels.VerifyConnectionOptions
+	userContext  supertokens.UserContext
+	lock         sync.Mutex
+	session      sessmodels.SessionContainer
+	endErr       *errors.SessionEndedError
+	closed       bool
+	done         chan struct{}
+	tokenUpdated chan struct{}
+}
+
+// VerifyConnection verifies the session of the handshake of a long-lived connection, and then keeps checking it
+// until the session ends or Close is called. The access token is read from the query parameter or the WebSocket
+// subprotocol if they are enabled in options, or else like in GetSession.
+//
+// Since browsers send cookies with WebSocket handshakes from any website, and the anti-csrf checks don't apply
+// to GET requests, handshakes that use cookies must come from the website domain if they have an Origin header.
+//
+// It returns nil if there is no session and options.VerifySessionOptions.SessionRequired is false.
+func VerifyConnection(req *http.Request, res http.ResponseWriter, options *sessmodels.VerifyConnectionOptions, userContext ...supertokens.UserContext) (*SessionConnection, error) {
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
+	if err != nil {
+		return nil, err
+	}
+	if len(userContext) == 0 {
+		userContext = append(userContext, supertokens.SetContextInUserContext(nil, req.Context()))
+	}
+
+	normalisedOptions := sessmodels.VerifyConnectionOptions{}
+	if options != nil {
+		normalisedOptions = *options
+	}
+	if normalisedOptions.RecheckInterval == 0 {
+		normalisedOptions.RecheckInterval = time.Minute
+	}
+
+	var sessionContainer sessmodels.SessionContainer
+	if accessToken := getConnectionAccessToken(req, normalisedOptions); accessToken != nil {
+		// the browser doesn't send these tokens by itself, so there is no anti-csrf token to check
+		verifySessionOptions := sessmodels.VerifySessionOptions{}
+		if normalisedOptions.VerifySessionOptions != nil {
+			verifySessionOptions = *normalisedOptions.VerifySessionOptions
+		}
+		antiCsrfCheck := false
+		verifySessionOptions.AntiCsrfCheck = &antiCsrfCheck
+		sessionContainer, err = GetSessionWithoutRequestResponse(*accessToken, nil, &verifySessionOptions, userContext[0])
+		if err != nil {
+			return nil, err
+		}
+		// GetSession checks the fingerprint of the handshake for sessions read from the request, so it is
+		// only checked here for tokens sent in a query parameter or a subprotocol
+		if sessionContainer != nil && instance.Config.FingerprintBinding != nil {
+			fingerprintValidator := makeFingerprintValidator(instance.Config, req, sessionContainer.GetHandleWithContext(userContext[0]))
+			err = sessionContainer.AssertClaimsWithContext([]claims.SessionClaimValidator{fingerprintValidator}, userContext[0])
+			if err != nil {
+				return nil, handleFingerprintValidationError(err, instance.Config, sessionContainer, instance.RecipeImpl, userContext[0])
+			}
+		}
+	} else {
+		err = checkConnectionOrigin(instance, req, userContext[0])
+		if err != nil {
+			return nil, err
+		}
+		sessionContainer, err = GetSession(req, res, normalisedOptions.VerifySessionOptions, userContext[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if sessionContainer == nil {
+		return nil, nil
+	}
+
+	connection := &SessionConnection{
+		options:      normalisedOptions,
+		userContext:  copyUserContextWithoutCoreCallCache(userContext[0]),
+		session:      sessionContainer,
+		done:         make(chan struct{}),
+		tokenUpdated: make(chan struct{}, 1),
+	}
+	go connection.monitor()
+	return connection, nil
+}
+
+// WriteSessionEndedEvent writes a Server-Sent Event named "session-ended" to res, with the reason why the session
+// ended in its data, and flushes it
+func WriteSessionEndedEvent(res http.ResponseWriter, err errors.SessionEndedError) error {
+	data, jsonErr := json.Marshal(map[string]string{
+		"reason":  err.Reason,
+		"message": err.Msg,
+	})
+	if jsonErr != nil {
+		return jsonErr
+	}
+	_, writeErr := fmt.Fprintf(res, "event: session-ended\ndata: %s\n\n", data)
+	if writeErr != nil {
+		return writeErr
+	}
+	if flusher, ok := res.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// GetSession returns the session of the connection, which changes when the access token is updated
+func (c *SessionConnection) GetSession() sessmodels.SessionContainer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.session
+}
+
+// Done returns a channel that is closed when the session ends or the connection is closed
+func (c *SessionConnection) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the errors.SessionEndedError saying why the session ended, or nil if it hasn't ended
+func (c *SessionConnection) Err() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.endErr == nil {
+		return nil
+	}
+	return *c.endErr
+}
+
+// Recheck checks that the session hasn't been revoked and that its claims are still valid, and ends the session
+// if not. It is called every RecheckInterval, and can also be called before handling a sensitive message.
+func (c *SessionConnection) Recheck() error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	sessionContainer := c.GetSession()
+	userContext := copyUserContextWithoutCoreCallCache(c.userContext)
+
+	if time.Now().After(getConnectionAccessTokenExpiry(sessionContainer, userContext)) {
+		return c.end(errors.SessionEndedError{
+			Msg:    "the access token of the session has expired",
+			Reason: errors.SessionEndedAccessTokenExpired,
+		})
+	}
+
+	sessionInfo, err := GetSessionInformation(sessionContainer.GetHandleWithContext(userContext), userContext)
+	if err != nil {
+		return err
+	}
+	if sessionInfo == nil {
+		return c.end(errors.SessionEndedError{
+			Msg:    "the session has been revoked",
+			Reason: errors.SessionEndedRevoked,
+		})
+	}
+
+	var overrideGlobalClaimValidators func(globalClaimValidators []claims.SessionClaimValidator, sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) ([]claims.SessionClaimValidator, error) = nil
+	if c.options.VerifySessionOptions != nil {
+		overrideGlobalClaimValidators = c.options.VerifySessionOptions.OverrideGlobalClaimValidators
+	}
+	claimValidators, err := GetRequiredClaimValidators(sessionContainer, overrideGlobalClaimValidators, userContext)
+	if err != nil {
+		return err
+	}
+	err = sessionContainer.AssertClaimsWithContext(claimValidators, userContext)
+	if err != nil {
+		var invalidClaimError errors.InvalidClaimError
+		if defaultErrors.As(err, &invalidClaimError) {
+			return c.end(errors.SessionEndedError{
+				Msg:           "the claims of the session are no longer valid",
+				Reason:        errors.SessionEndedInvalidClaims,
+				InvalidClaims: invalidClaimError.InvalidClaims,
+			})
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateAccessToken replaces the access token of the connection with a new one of the same session, so that the
+// connection doesn't end when the old one expires. Clients can send it over the connection after refreshing the
+// session.
+func (c *SessionConnection) UpdateAccessToken(accessToken string) error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	sessionRequired := true
+	verifySessionOptions := sessmodels.VerifySessionOptions{}
+	if c.options.VerifySessionOptions != nil {
+		verifySessionOptions = *c.options.VerifySessionOptions
+	}
+	verifySessionOptions.SessionRequired = &sessionRequired
+	// the token is sent over the connection, not by the browser by itself, so there is no anti-csrf token to check
+	antiCsrfCheck := false
+	verifySessionOptions.AntiCsrfCheck = &antiCsrfCheck
+
+	userContext := copyUserContextWithoutCoreCallCache(c.userContext)
+	sessionContainer, err := GetSessionWithoutRequestResponse(accessToken, nil, &verifySessionOptions, userContext)
+	if err != nil {
+		return err
+	}
+	if sessionContainer.GetHandleWithContext(userContext) != c.GetSession().GetHandleWithContext(userContext) {
+		clearTokens := false
+		return errors.UnauthorizedError{
+			Msg:         "the access token belongs to another session",
+			ClearTokens: &clearTokens,
+		}
+	}
+
+	c.lock.Lock()
+	c.session = sessionContainer
+	c.lock.Unlock()
+	select {
+	case c.tokenUpdated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close stops checking the session, without calling OnSessionEnded. It should be called when the connection is
+// closed.
+func (c *SessionConnection) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed || c.endErr != nil {
+		return
+	}
+	c.closed = true
+	close(c.done)
+}
+
+// end ends the session with err and calls OnSessionEnded, unless the session has already ended or the
+// connection has been closed
+func (c *SessionConnection) end(err errors.SessionEndedError) error {
+	c.lock.Lock()
+	if c.closed || c.endErr != nil {
+		c.lock.Unlock()
+		return err
+	}
+	c.endErr = &err
+	close(c.done)
+	c.lock.Unlock()
+
+	userContext := copyUserContextWithoutCoreCallCache(c.userContext)
+	supertokens.LogMessage(supertokens.LogLevelDebug, userContext, "session of long-lived connection ended", "recipeId", RECIPE_ID, "reason", err.Reason, "session", supertokens.HashForLogging(c.GetSession().GetHandleWithContext(userContext)))
+	if c.options.OnSessionEnded != nil {
+		c.options.OnSessionEnded(err)
+	}
+	return err
+}
+
+// monitor rechecks the session every RecheckInterval and ends it when its access token expires
+func (c *SessionConnection) monitor() {
+	var recheck <-chan time.Time
+	if c.options.RecheckInterval > 0 {
+		ticker := time.NewTicker(c.options.RecheckInterval)
+		defer ticker.Stop()
+		recheck = ticker.C
+	}
+
+	for {
+		expiryTimer := time.NewTimer(time.Until(getConnectionAccessTokenExpiry(c.GetSession(), copyUserContextWithoutCoreCallCache(c.userContext))))
+		select {
+		case <-c.done:
+			expiryTimer.Stop()
+			return
+		case <-c.tokenUpdated:
+			expiryTimer.Stop()
+		case <-recheck:
+			expiryTimer.Stop()
+			err := c.Recheck()
+			var sessionEndedError errors.SessionEndedError
+			if err != nil && !defaultErrors.As(err, &sessionEndedError) {
+				// the session is checked again at the next interval
+				supertokens.LogMessage(supertokens.LogLevelWarn, copyUserContextWithoutCoreCallCache(c.userContext), "could not recheck the session of long-lived connection: "+err.Error(), "recipeId", RECIPE_ID)
+			}
+		case <-expiryTimer.C:
+			c.end(errors.SessionEndedError{
+				Msg:    "the access token of the session has expired",
+				Reason: errors.SessionEndedAccessTokenExpired,
+			})
+		}
+	}
+}
+
+func getConnectionAccessToken(req *http.Request, options sessmodels.VerifyConnectionOptions) *string {
+	if options.AccessTokenQueryParam != "" {
+		if accessToken := req.URL.Query().Get(options.AccessTokenQueryParam); accessToken != "" {
+			return &accessToken
+		}
+	}
+	if options.AllowAccessTokenInSubprotocol {
+		for _, header := range req.Header.Values("Sec-WebSocket-Protocol") {
+			for _, protocol := range strings.Split(header, ",") {
+				protocol = strings.TrimSpace(protocol)
+				if strings.HasPrefix(protocol, accessTokenSubprotocolPrefix) {
+					accessToken := strings.TrimPrefix(protocol, accessTokenSubprotocolPrefix)
+					return &accessToken
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkConnectionOrigin prevents cross-site WebSocket hijacking, for handshakes that don't send the access token
+// in the authorization header
+func checkConnectionOrigin(instance *Recipe, req *http.Request, userContext supertokens.UserContext) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" || getHeader(req, authorizationHeaderKey) != nil {
+		return nil
+	}
+	websiteOrigin, err := instance.RecipeModule.GetAppInfo().GetOrigin(req, userContext)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(strings.TrimSuffix(origin, "/"), websiteOrigin.GetAsStringDangerous()) {
+		clearTokens := false
+		return errors.UnauthorizedError{
+			Msg:         "the origin of the request is not the website domain",
+			ClearTokens: &clearTokens,
+		}
+	}
+	return nil
+}
+
+// copyUserContextWithoutCoreCallCache copies userContext without the responses of the core that are cached in
+// it, for calls that run after (or concurrently with) the call userContext was made for. The checks of a
+// connection use their own copy, since the cached responses would be stale when the session is rechecked.
+func copyUserContextWithoutCoreCallCache(userContext supertokens.UserContext) supertokens.UserContext {
+	copied := map[string]interface{}{}
+	if userContext == nil {
+		return &copied
+	}
+	for key, value := range *userContext {
+		copied[key] = value
+	}
+	if defaultObj, ok := (*userContext)["_default"].(map[string]interface{}); ok {
+		copiedDefaultObj := map[string]interface{}{}
+		for key, value := range defaultObj {
+			if key != "coreCallCache" {
+				copiedDefaultObj[key] = value
+			}
+		}
+		copied["_default"] = copiedDefaultObj
+	}
+	return &copied
+}
+
+func getConnectionAccessTokenExpiry(sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) time.Time {
+	exp, ok := sessionContainer.GetAccessTokenPayloadWithContext(userContext)["exp"].(float64)
+	if !ok {
+		// this can only happen if GetSession is overridden, in which case the session is only rechecked
+		return time.Now().Add(100 * 365 * 24 * time.Hour)
+	}
+	return time.Unix(int64(exp), 0)
+}
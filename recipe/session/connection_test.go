@@ -0,0 +1,221 @@
+package session
+
+import (
+	"encoding/json"
+	defaultErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// initWithSessionInformationForConnectionTest starts a core that returns the information of the session with
+// the handle "handle", until revoked is set to 1
+func initWithSessionInformationForConnectionTest(t *testing.T, revoked *int32) (string, func(payload map[string]interface{}) string, func()) {
+	return initWithConfigAndSessionInformationForConnectionTest(t, &sessmodels.TypeInput{}, revoked)
+}
+
+func initWithConfigAndSessionInformationForConnectionTest(t *testing.T, config *sessmodels.TypeInput, revoked *int32) (string, func(payload map[string]interface{}) string, func()) {
+	privateKey, cleanup := initWithSigningKeyAndCoreHandlerForTest(t, config, func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/recipe/session" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if atomic.LoadInt32(revoked) == 1 || r.URL.Query().Get("sessionHandle") != "handle" {
+			json.NewEncoder(rw).Encode(map[string]interface{}{"status": "UNAUTHORISED", "message": "Session does not exist."})
+			return
+		}
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"status":             "OK",
+			"sessionHandle":      "handle",
+			"userId":             "userId",
+			"userDataInDatabase": map[string]interface{}{},
+			"expiry":             time.Now().Add(time.Hour).UnixMilli(),
+			"timeCreated":        time.Now().UnixMilli(),
+			"userDataInJWT":      map[string]interface{}{},
+			"tenantId":           "public",
+		})
+	})
+	makeAccessToken := func(payload map[string]interface{}) string {
+		return makeAccessTokenForTest(t, privateKey, payload)
+	}
+	return makeAccessToken(nil), makeAccessToken, cleanup
+}
+
+func TestGetConnectionAccessToken(t *testing.T) {
+	req := httptest.NewRequest("GET", "/ws?token=query-token", nil)
+	req.Header.Set("Sec-WebSocket-Protocol", "chat, st-access-token.subprotocol-token")
+
+	assert.Nil(t, getConnectionAccessToken(req, sessmodels.VerifyConnectionOptions{}))
+	assert.Equal(t, "query-token", *getConnectionAccessToken(req, sessmodels.VerifyConnectionOptions{AccessTokenQueryParam: "token"}))
+	assert.Equal(t, "subprotocol-token", *getConnectionAccessToken(req, sessmodels.VerifyConnectionOptions{AllowAccessTokenInSubprotocol: true}))
+	assert.Nil(t, getConnectionAccessToken(req, sessmodels.VerifyConnectionOptions{AccessTokenQueryParam: "other"}))
+}
+
+func TestThatCookieHandshakesMustComeFromTheWebsiteDomain(t *testing.T) {
+	revoked := int32(0)
+	accessToken, _, cleanup := initWithSessionInformationForConnectionTest(t, &revoked)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Cookie", "sAccessToken="+accessToken)
+	req.Header.Set("Origin", "https://attacker.example")
+	_, err := VerifyConnection(req, httptest.NewRecorder(), nil)
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+
+	req.Header.Set("Origin", "https://supertokens.io")
+	connection, err := VerifyConnection(req, httptest.NewRecorder(), nil)
+	assert.NoError(t, err)
+	defer connection.Close()
+	assert.Equal(t, "userId", connection.GetSession().GetUserID())
+
+	// the origin is not checked for tokens that are not sent automatically by browsers
+	req = httptest.NewRequest("GET", "/ws?token="+accessToken, nil)
+	req.Header.Set("Origin", "https://attacker.example")
+	queryConnection, err := VerifyConnection(req, httptest.NewRecorder(), &sessmodels.VerifyConnectionOptions{AccessTokenQueryParam: "token"})
+	assert.NoError(t, err)
+	queryConnection.Close()
+}
+
+func TestThatTheConnectionEndsWhenTheSessionIsRevoked(t *testing.T) {
+	revoked := int32(0)
+	accessToken, _, cleanup := initWithSessionInformationForConnectionTest(t, &revoked)
+	defer cleanup()
+
+	ended := make(chan errors.SessionEndedError, 2)
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	connection, err := VerifyConnection(req, httptest.NewRecorder(), &sessmodels.VerifyConnectionOptions{
+		RecheckInterval: 20 * time.Millisecond,
+		OnSessionEnded: func(err errors.SessionEndedError) {
+			ended <- err
+		},
+	})
+	assert.NoError(t, err)
+	defer connection.Close()
+
+	assert.NoError(t, connection.Recheck())
+	assert.NoError(t, connection.Err())
+
+	atomic.StoreInt32(&revoked, 1)
+	select {
+	case err := <-ended:
+		assert.Equal(t, errors.SessionEndedRevoked, err.Reason)
+		assert.Equal(t, 4401, err.WebSocketCloseCode())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the session did not end")
+	}
+	<-connection.Done()
+	assert.Equal(t, errors.SessionEndedRevoked, connection.Err().(errors.SessionEndedError).Reason)
+
+	// OnSessionEnded is only called once
+	connection.Recheck()
+	assert.Len(t, ended, 0)
+}
+
+func TestThatTheConnectionEndsWhenItsClaimsAreNoLongerValid(t *testing.T) {
+	revoked := int32(0)
+	accessToken, _, cleanup := initWithSessionInformationForConnectionTest(t, &revoked)
+	defer cleanup()
+
+	isValid := int32(1)
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	connection, err := VerifyConnection(req, httptest.NewRecorder(), &sessmodels.VerifyConnectionOptions{
+		VerifySessionOptions: &sessmodels.VerifySessionOptions{
+			OverrideGlobalClaimValidators: func(globalClaimValidators []claims.SessionClaimValidator, sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) ([]claims.SessionClaimValidator, error) {
+				return append(globalClaimValidators, claims.SessionClaimValidator{
+					ID: "test",
+					Validate: func(payload map[string]interface{}, userContext supertokens.UserContext) claims.ClaimValidationResult {
+						return claims.ClaimValidationResult{IsValid: atomic.LoadInt32(&isValid) == 1}
+					},
+				}), nil
+			},
+		},
+		RecheckInterval: -1,
+	})
+	assert.NoError(t, err)
+	defer connection.Close()
+
+	atomic.StoreInt32(&isValid, 0)
+	err = connection.Recheck()
+	sessionEndedError := errors.SessionEndedError{}
+	assert.True(t, defaultErrors.As(err, &sessionEndedError))
+	assert.Equal(t, errors.SessionEndedInvalidClaims, sessionEndedError.Reason)
+	assert.Equal(t, "test", sessionEndedError.InvalidClaims[0].ID)
+	assert.Equal(t, 4403, sessionEndedError.WebSocketCloseCode())
+	<-connection.Done()
+}
+
+func TestThatTheConnectionEndsWhenTheAccessTokenExpiresUnlessItIsUpdated(t *testing.T) {
+	revoked := int32(0)
+	_, makeAccessToken, cleanup := initWithSessionInformationForConnectionTest(t, &revoked)
+	defer cleanup()
+
+	expiresSoon := map[string]interface{}{"exp": time.Now().Add(time.Second).Unix()}
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessToken(expiresSoon))
+	connection, err := VerifyConnection(req, httptest.NewRecorder(), &sessmodels.VerifyConnectionOptions{RecheckInterval: -1})
+	assert.NoError(t, err)
+	defer connection.Close()
+
+	// tokens of other sessions are rejected
+	err = connection.UpdateAccessToken(makeAccessToken(map[string]interface{}{"sessionHandle": "other"}))
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+
+	assert.NoError(t, connection.UpdateAccessToken(makeAccessToken(nil)))
+	select {
+	case <-connection.Done():
+		t.Fatal("the connection ended although its access token was updated")
+	case <-time.After(2 * time.Second):
+	}
+
+	otherReq := httptest.NewRequest("GET", "/ws", nil)
+	otherReq.Header.Set("Authorization", "Bearer "+makeAccessToken(map[string]interface{}{"exp": time.Now().Add(time.Second).Unix()}))
+	otherConnection, err := VerifyConnection(otherReq, httptest.NewRecorder(), &sessmodels.VerifyConnectionOptions{RecheckInterval: -1})
+	assert.NoError(t, err)
+	defer otherConnection.Close()
+	select {
+	case <-otherConnection.Done():
+		assert.Equal(t, errors.SessionEndedAccessTokenExpired, otherConnection.Err().(errors.SessionEndedError).Reason)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection did not end when its access token expired")
+	}
+}
+
+func TestThatTokensSentOutsideOfCookiesAreNotCheckedForAnAntiCsrfToken(t *testing.T) {
+	for _, antiCsrf := range []string{AntiCSRF_VIA_TOKEN, AntiCSRF_VIA_CUSTOM_HEADER} {
+		t.Run(antiCsrf, func(t *testing.T) {
+			revoked := int32(0)
+			_, makeAccessToken, cleanup := initWithConfigAndSessionInformationForConnectionTest(t, &sessmodels.TypeInput{AntiCsrf: &antiCsrf}, &revoked)
+			defer cleanup()
+			accessToken := makeAccessToken(map[string]interface{}{"antiCsrfToken": "anti-csrf-token"})
+
+			connection, err := VerifyConnection(httptest.NewRequest("GET", "/ws?token="+accessToken, nil), httptest.NewRecorder(), &sessmodels.VerifyConnectionOptions{AccessTokenQueryParam: "token", RecheckInterval: -1})
+			assert.NoError(t, err)
+			defer connection.Close()
+			assert.NoError(t, connection.UpdateAccessToken(accessToken))
+
+			req := httptest.NewRequest("GET", "/ws", nil)
+			req.Header.Set("Sec-WebSocket-Protocol", "st-access-token."+accessToken)
+			otherConnection, err := VerifyConnection(req, httptest.NewRecorder(), &sessmodels.VerifyConnectionOptions{AllowAccessTokenInSubprotocol: true, RecheckInterval: -1})
+			assert.NoError(t, err)
+			defer otherConnection.Close()
+		})
+	}
+}
+
+func TestWriteSessionEndedEvent(t *testing.T) {
+	res := httptest.NewRecorder()
+	err := WriteSessionEndedEvent(res, errors.SessionEndedError{Msg: "the session has been revoked", Reason: errors.SessionEndedRevoked})
+	assert.NoError(t, err)
+	assert.Equal(t, "event: session-ended\ndata: {\"message\":\"the session has been revoked\",\"reason\":\"SESSION_REVOKED\"}\n\n", res.Body.String())
+	assert.True(t, res.Flushed)
+}
@@ -20,8 +20,24 @@ import "github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
 var AvailableTokenTransferMethods = []sessmodels.TokenTransferMethod{sessmodels.CookieTransferMethod, sessmodels.HeaderTransferMethod}
 
 const (
-	RefreshAPIPath = "/session/refresh"
-	SignoutAPIPath = "/signout"
+	RefreshAPIPath             = "/session/refresh"
+	SignoutAPIPath             = "/signout"
+	SessionsAPIPath            = "/sessions"
+	RevokeSessionAPIPath       = "/sessions/revoke"
+	RevokeOtherSessionsAPIPath = "/sessions/revoke-others"
+
+	deviceInfoKey  = "st-device"
+	fingerprintKey = "st-fp"
+
+	impersonationActorKey  = "act"
+	impersonationExpiryKey = "st-imp-exp"
+
+	authTimeKey = "st-auth-time"
+
+	dpopHeaderKey       = "DPoP"
+	dpopConfirmationKey = "cnf"
+
+	accessTokenSubprotocolPrefix = "st-access-token."
 
 	AntiCSRF_VIA_TOKEN         = "VIA_TOKEN"
 	AntiCSRF_VIA_CUSTOM_HEADER = "VIA_CUSTOM_HEADER"
@@ -30,6 +46,9 @@ const (
 	CookieSameSite_NONE   = "none"
 	CookieSameSite_LAX    = "lax"
 	CookieSameSite_STRICT = "strict"
+
+	CookiePrefix_HOST   = "__Host-"
+	CookiePrefix_SECURE = "__Secure-"
 )
 
 var JWKRefreshRateLimit = 500
@@ -43,4 +62,19 @@ var protectedProps = []string{
 	"antiCsrfToken",
 	"rsub",
 	"tId",
+	fingerprintKey,
+	impersonationActorKey,
+	impersonationExpiryKey,
+	dpopConfirmationKey,
+	authTimeKey,
+}
+
+// sessionManagedProps are the protectedProps that are set by this recipe instead of the core. They are kept when
+// the access token payload is updated, and can only be changed using setSessionManagedPropsInUserContext.
+var sessionManagedProps = []string{
+	fingerprintKey,
+	impersonationActorKey,
+	impersonationExpiryKey,
+	dpopConfirmationKey,
+	authTimeKey,
 }
@@ -123,12 +123,17 @@ func GetCORSAllowedHeaders() []string {
 	}
 }
 
-func getCookieNameFromTokenType(tokenType sessmodels.TokenType) (string, error) {
+func getCookieNameFromTokenType(config sessmodels.TypeNormalisedInput, tokenType sessmodels.TokenType) (string, error) {
 	if tokenType == sessmodels.AccessToken {
-		return accessTokenCookieKey, nil
+		return config.CookieSecurityPrefix + config.CookieNamePrefix + accessTokenCookieKey, nil
 	}
 	if tokenType == sessmodels.RefreshToken {
-		return refreshTokenCookieKey, nil
+		securityPrefix := config.CookieSecurityPrefix
+		// __Host- cookies must have the path "/", which the refresh token cookie doesn't have
+		if securityPrefix == CookiePrefix_HOST {
+			securityPrefix = CookiePrefix_SECURE
+		}
+		return securityPrefix + config.CookieNamePrefix + refreshTokenCookieKey, nil
 	}
 	return "", errors.New("Unknown token type, should never happen.")
 }
@@ -143,16 +148,37 @@ func getResponseHeaderNameForTokenType(tokenType sessmodels.TokenType) (string,
 	return "", errors.New("Unknown token type, should never happen.")
 }
 
+// GetToken returns the token of tokenType sent in req using transferMethod, reading the cookie names from the
+// config of the session recipe that handled req. If the session recipe is not initialised, the default cookie
+// names (sAccessToken and sRefreshToken) are used.
 func GetToken(req *http.Request, tokenType sessmodels.TokenType, transferMethod sessmodels.TokenTransferMethod) (*string, error) {
+	config := sessmodels.TypeNormalisedInput{}
+	if instance, err := getRecipeInstanceForRequestOrThrowError(req); err == nil {
+		config = instance.Config
+	}
+	return GetTokenWithConfig(config, req, tokenType, transferMethod)
+}
+
+// GetTokenWithConfig is like GetToken, but reads the cookie names from config
+func GetTokenWithConfig(config sessmodels.TypeNormalisedInput, req *http.Request, tokenType sessmodels.TokenType, transferMethod sessmodels.TokenTransferMethod) (*string, error) {
 	if transferMethod == sessmodels.CookieTransferMethod {
-		cookieName, err := getCookieNameFromTokenType(tokenType)
+		cookieName, err := getCookieNameFromTokenType(config, tokenType)
 		if err != nil {
 			return nil, err
 		}
 		return GetCookieValue(req, cookieName), nil
 	} else if transferMethod == sessmodels.HeaderTransferMethod {
 		headerValue := getHeader(req, authorizationHeaderKey)
-		if headerValue == nil || !strings.HasPrefix(*headerValue, "Bearer ") {
+		if headerValue == nil {
+			return nil, nil
+		}
+
+		// tokens bound to a key are sent using the DPoP scheme (RFC 9449)
+		if config.DPoP != nil && strings.HasPrefix(*headerValue, "DPoP ") {
+			token := strings.TrimSpace(strings.TrimPrefix(*headerValue, "DPoP "))
+			return &token, nil
+		}
+		if !strings.HasPrefix(*headerValue, "Bearer ") {
 			return nil, nil
 		}
 
@@ -165,7 +191,7 @@ func GetToken(req *http.Request, tokenType sessmodels.TokenType, transferMethod
 func setToken(config sessmodels.TypeNormalisedInput, res http.ResponseWriter, tokenType sessmodels.TokenType, value string, expires uint64, transferMethod sessmodels.TokenTransferMethod, request *http.Request, userContext supertokens.UserContext) error {
 	supertokens.LogDebugMessage(fmt.Sprint("setToken: Setting ", tokenType, " token as ", transferMethod))
 	if transferMethod == sessmodels.CookieTransferMethod {
-		cookieName, err := getCookieNameFromTokenType(tokenType)
+		cookieName, err := getCookieNameFromTokenType(config, tokenType)
 		if err != nil {
 			return err
 		}
@@ -245,7 +271,12 @@ func setCookie(config sessmodels.TypeNormalisedInput, res http.ResponseWriter, n
 		Path:     path,
 		SameSite: sameSiteField,
 	}
-	setCookieValue(res, cookie)
+	cookieString := cookie.String()
+	// http.Cookie doesn't have the Partitioned attribute in the versions of Go we support
+	if config.CookiePartitioned {
+		cookieString += "; Partitioned"
+	}
+	setCookieValue(res, cookieString)
 	return nil
 }
 
@@ -285,10 +316,10 @@ func GetCookieValue(request *http.Request, key string) *string {
 }
 
 // setCookieValue replaces cookie.go SetCookie, it replaces the cookie values instead of appending them
-func setCookieValue(w http.ResponseWriter, cookie *http.Cookie) {
+func setCookieValue(w http.ResponseWriter, cookie string) {
 	cookieHeader := w.Header().Values("Set-Cookie")
 	if len(cookieHeader) == 0 {
-		w.Header().Set("Set-Cookie", cookie.String())
+		w.Header().Set("Set-Cookie", cookie)
 		return
 	}
 	existingCookies := make(map[string]string, len(cookieHeader))
@@ -297,7 +328,7 @@ func setCookieValue(w http.ResponseWriter, cookie *http.Cookie) {
 		existingCookies[getCookieName(ch)] = ch
 	}
 	// replace if already existing
-	existingCookies[getCookieName(cookie.String())] = cookie.String()
+	existingCookies[getCookieName(cookie)] = cookie
 	// clear previous cookies from the headers
 	w.Header().Del("Set-Cookie")
 	// and add them back
@@ -341,7 +372,7 @@ func ClearSessionCookiesFromOlderCookieDomain(req *http.Request, res http.Respon
 
 	tokenTypes := []sessmodels.TokenType{sessmodels.AccessToken, sessmodels.RefreshToken}
 	for _, token := range tokenTypes {
-		if hasMultipleCookiesForTokenType(req, token) {
+		if hasMultipleCookiesForTokenType(config, req, token) {
 			// If a request has multiple session cookies and 'olderCookieDomain' is
 			// unset, we can't identify the correct cookie for refreshing the session.
 			// Using the wrong cookie can cause an infinite refresh loop. To avoid this,
@@ -367,14 +398,14 @@ func ClearSessionCookiesFromOlderCookieDomain(req *http.Request, res http.Respon
 	return nil
 }
 
-func hasMultipleCookiesForTokenType(req *http.Request, tokenType sessmodels.TokenType) bool {
+func hasMultipleCookiesForTokenType(config sessmodels.TypeNormalisedInput, req *http.Request, tokenType sessmodels.TokenType) bool {
 	// Count of cookies with the specified token type
 	count := 0
 
 	// Loop through each cookie in the request
 	for _, cookie := range req.Cookies() {
 		// Check if the cookie's name matches the token type
-		cookieName, _ := getCookieNameFromTokenType(tokenType)
+		cookieName, _ := getCookieNameFromTokenType(config, tokenType)
 		if cookie.Name == cookieName {
 			count++
 		}
@@ -0,0 +1,207 @@
+package session
+
+import (
+	defaultErrors "errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func normaliseConfigForCookieNamesTest(t *testing.T, config *sessmodels.TypeInput) (sessmodels.TypeNormalisedInput, error) {
+	appInfo, err := supertokens.NormaliseInputAppInfoOrThrowError(supertokens.AppInfo{
+		AppName:       "SuperTokens",
+		WebsiteDomain: "https://supertokens.io",
+		APIDomain:     "https://api.supertokens.io",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return ValidateAndNormaliseUserInput(appInfo, config)
+}
+
+func TestThatCookieConfigIsValidated(t *testing.T) {
+	False := false
+	host := CookiePrefix_HOST
+	secure := CookiePrefix_SECURE
+	invalidPrefix := "__Other-"
+	domain := ".supertokens.io"
+	namePrefix := "app1_"
+	invalidNamePrefix := "app 1;"
+
+	_, err := normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieSecurityPrefix: &host, CookieNamePrefix: &namePrefix, CookiePartitioned: true})
+	assert.NoError(t, err)
+
+	_, err = normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieSecurityPrefix: &secure, CookieDomain: &domain})
+	assert.NoError(t, err)
+
+	_, err = normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieSecurityPrefix: &invalidPrefix})
+	assert.EqualError(t, err, "CookieSecurityPrefix must be one of '__Host-' or '__Secure-'")
+
+	_, err = normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieSecurityPrefix: &secure, CookieSecure: &False})
+	assert.EqualError(t, err, "CookieSecurityPrefix can only be used if CookieSecure is true")
+
+	_, err = normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieSecurityPrefix: &host, CookieDomain: &domain})
+	assert.EqualError(t, err, "the '__Host-' CookieSecurityPrefix can't be used if CookieDomain or OlderCookieDomain is set")
+
+	_, err = normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieSecurityPrefix: &host, OlderCookieDomain: &domain})
+	assert.Error(t, err)
+
+	_, err = normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookiePartitioned: true, CookieSecure: &False})
+	assert.EqualError(t, err, "CookiePartitioned can only be used if CookieSecure is true")
+
+	_, err = normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieNamePrefix: &invalidNamePrefix})
+	assert.Error(t, err)
+
+	_, err = normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieNamePrefix: &host})
+	assert.Error(t, err)
+}
+
+func TestCookieNamesWithPrefixes(t *testing.T) {
+	host := CookiePrefix_HOST
+	namePrefix := "app1_"
+	config, err := normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieSecurityPrefix: &host, CookieNamePrefix: &namePrefix, CookiePartitioned: true})
+	assert.NoError(t, err)
+
+	accessTokenCookieName, err := getCookieNameFromTokenType(config, sessmodels.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "__Host-app1_sAccessToken", accessTokenCookieName)
+	refreshTokenCookieName, err := getCookieNameFromTokenType(config, sessmodels.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "__Secure-app1_sRefreshToken", refreshTokenCookieName)
+
+	req := httptest.NewRequest("GET", "https://api.supertokens.io/", nil)
+	res := httptest.NewRecorder()
+	err = setToken(config, res, sessmodels.AccessToken, "access", 0, sessmodels.CookieTransferMethod, req, &map[string]interface{}{})
+	assert.NoError(t, err)
+	err = setToken(config, res, sessmodels.RefreshToken, "refresh", 0, sessmodels.CookieTransferMethod, req, &map[string]interface{}{})
+	assert.NoError(t, err)
+
+	setCookies := res.Header().Values("Set-Cookie")
+	assert.Len(t, setCookies, 2)
+	for _, cookie := range setCookies {
+		assert.True(t, strings.HasSuffix(cookie, "; Partitioned"))
+		assert.Contains(t, cookie, "; Secure")
+		if strings.HasPrefix(cookie, "__Host-app1_sAccessToken=access;") {
+			assert.Contains(t, cookie, "; Path=/;")
+			assert.NotContains(t, cookie, "Domain=")
+		} else {
+			assert.True(t, strings.HasPrefix(cookie, "__Secure-app1_sRefreshToken=refresh;"))
+			assert.Contains(t, cookie, "; Path=/auth/session/refresh;")
+		}
+	}
+
+	// cookies without the prefixes belong to other apps
+	req.Header.Set("Cookie", "sAccessToken=other; __Host-app1_sAccessToken=access")
+	token, err := GetTokenWithConfig(config, req, sessmodels.AccessToken, sessmodels.CookieTransferMethod)
+	assert.NoError(t, err)
+	assert.Equal(t, "access", *token)
+	token, err = GetTokenWithConfig(config, req, sessmodels.RefreshToken, sessmodels.CookieTransferMethod)
+	assert.NoError(t, err)
+	assert.Nil(t, token)
+
+	res = httptest.NewRecorder()
+	err = ClearSession(config, res, sessmodels.CookieTransferMethod, req, &map[string]interface{}{})
+	assert.NoError(t, err)
+	for _, cookie := range res.Header().Values("Set-Cookie") {
+		assert.True(t, strings.HasPrefix(cookie, "__Host-app1_sAccessToken=;") || strings.HasPrefix(cookie, "__Secure-app1_sRefreshToken=;"))
+		assert.True(t, strings.HasSuffix(cookie, "; Partitioned"))
+	}
+}
+
+func TestThatDuplicateCookiesAreDetectedWithACookieNamePrefix(t *testing.T) {
+	namePrefix := "app1_"
+	olderCookieDomain := ".supertokens.io"
+	config, err := normaliseConfigForCookieNamesTest(t, &sessmodels.TypeInput{CookieNamePrefix: &namePrefix, OlderCookieDomain: &olderCookieDomain})
+	assert.NoError(t, err)
+
+	// the cookies of another app with the same token name are not duplicates
+	req := httptest.NewRequest("GET", "https://api.supertokens.io/", nil)
+	req.Header.Set("Cookie", "sAccessToken=other; app1_sAccessToken=access")
+	err = ClearSessionCookiesFromOlderCookieDomain(req, httptest.NewRecorder(), config, &map[string]interface{}{})
+	assert.NoError(t, err)
+
+	req.Header.Set("Cookie", "app1_sAccessToken=old; app1_sAccessToken=access")
+	res := httptest.NewRecorder()
+	err = ClearSessionCookiesFromOlderCookieDomain(req, res, config, &map[string]interface{}{})
+	assert.True(t, defaultErrors.As(err, &errors.ClearDuplicateSessionCookiesError{}))
+	assert.True(t, strings.HasPrefix(res.Header().Get("Set-Cookie"), "app1_sAccessToken=;"))
+	assert.Contains(t, res.Header().Get("Set-Cookie"), "Domain=supertokens.io")
+}
+
+func TestThatGetTokenReadsTheCookieNamesFromTheRecipeConfig(t *testing.T) {
+	namePrefix := "app1_"
+	_, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{CookieNamePrefix: &namePrefix})
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "https://api.supertokens.io/", nil)
+	req.Header.Set("Cookie", "sAccessToken=other; app1_sAccessToken=access")
+	token, err := GetToken(req, sessmodels.AccessToken, sessmodels.CookieTransferMethod)
+	assert.NoError(t, err)
+	assert.Equal(t, "access", *token)
+}
+
+func TestThatGetTokenUsesTheRecipeOfTheInstanceThatHandledTheRequest(t *testing.T) {
+	namePrefix := "app1_"
+	_, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{CookieNamePrefix: &namePrefix})
+	defer cleanup()
+	core := newFakeCore("handle")
+	defer core.server.Close()
+
+	otherNamePrefix := "app2_"
+	instance, err := supertokens.New(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: core.server.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(&sessmodels.TypeInput{CookieNamePrefix: &otherNamePrefix}),
+		},
+	})
+	assert.NoError(t, err)
+	defer instance.Close()
+
+	req := httptest.NewRequest("GET", "https://api.supertokens.io/", nil)
+	req.Header.Set("Cookie", "app1_sAccessToken=access1; app2_sAccessToken=access2")
+	token, err := GetToken(req, sessmodels.AccessToken, sessmodels.CookieTransferMethod)
+	assert.NoError(t, err)
+	assert.Equal(t, "access1", *token)
+
+	req = req.WithContext(instance.BindContext(req.Context()))
+	token, err = GetToken(req, sessmodels.AccessToken, sessmodels.CookieTransferMethod)
+	assert.NoError(t, err)
+	assert.Equal(t, "access2", *token)
+}
+
+func TestThatGetTokenUsesTheDefaultCookieNamesWithoutTheSessionRecipe(t *testing.T) {
+	resetAll()
+	defer resetAll()
+
+	req := httptest.NewRequest("GET", "https://api.supertokens.io/", nil)
+	req.Header.Set("Cookie", "sAccessToken=access; app1_sAccessToken=other")
+	token, err := GetToken(req, sessmodels.AccessToken, sessmodels.CookieTransferMethod)
+	assert.NoError(t, err)
+	assert.Equal(t, "access", *token)
+
+	req.Header.Set("Authorization", "Bearer access")
+	token, err = GetToken(req, sessmodels.AccessToken, sessmodels.HeaderTransferMethod)
+	assert.NoError(t, err)
+	assert.Equal(t, "access", *token)
+
+	// the DPoP scheme is only accepted if DPoP is enabled
+	req.Header.Set("Authorization", "DPoP access")
+	token, err = GetToken(req, sessmodels.AccessToken, sessmodels.HeaderTransferMethod)
+	assert.NoError(t, err)
+	assert.Nil(t, token)
+	token, err = GetTokenWithConfig(sessmodels.TypeNormalisedInput{DPoP: &sessmodels.DPoPConfig{}}, req, sessmodels.AccessToken, sessmodels.HeaderTransferMethod)
+	assert.NoError(t, err)
+	assert.Equal(t, "access", *token)
+}
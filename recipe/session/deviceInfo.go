@@ -0,0 +1,220 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// deviceInfoUpdateInterval is how often the LastSeen of a device is updated when a session is refreshed
+// from the same device. Since the session data in the database can only be replaced as a whole, updating it
+// less often also makes it less likely to overwrite changes made to it at the same time by other processes.
+const deviceInfoUpdateInterval = time.Hour
+
+// deviceInfoRecorderMaxSessions is the number of sessions whose last recorded device is remembered by a
+// process, so that refreshes from the same device don't need to fetch the session data
+const deviceInfoRecorderMaxSessions = 10000
+
+// deviceInfoRecorder serialises the updates of the session data made by this process per session, so that
+// recording the device info never overwrites a concurrent call to UpdateSessionDataInDatabase, and remembers
+// the device info it last saw for each session
+type deviceInfoRecorder struct {
+	sessionLocks [64]sync.Mutex
+	lock         sync.Mutex
+	recorded     map[string]sessmodels.DeviceInfo
+}
+
+func (r *deviceInfoRecorder) getSessionLock(sessionHandle string) *sync.Mutex {
+	hash := fnv.New32a()
+	hash.Write([]byte(sessionHandle))
+	return &r.sessionLocks[hash.Sum32()%uint32(len(r.sessionLocks))]
+}
+
+func (r *deviceInfoRecorder) getRecorded(sessionHandle string) (sessmodels.DeviceInfo, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	deviceInfo, ok := r.recorded[sessionHandle]
+	return deviceInfo, ok
+}
+
+func (r *deviceInfoRecorder) setRecorded(sessionHandle string, deviceInfo *sessmodels.DeviceInfo) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if deviceInfo == nil {
+		delete(r.recorded, sessionHandle)
+		return
+	}
+	if _, ok := r.recorded[sessionHandle]; !ok && len(r.recorded) >= deviceInfoRecorderMaxSessions {
+		// the sessions are forgotten all at once, which only means that their next refresh fetches the session data
+		r.recorded = map[string]sessmodels.DeviceInfo{}
+	}
+	r.recorded[sessionHandle] = *deviceInfo
+}
+
+// isDeviceInfoUpToDate returns true if recorded is of the same device as deviceInfo and was updated less than
+// deviceInfoUpdateInterval ago
+func isDeviceInfoUpToDate(recorded sessmodels.DeviceInfo, deviceInfo sessmodels.DeviceInfo) bool {
+	return recorded.UserAgent == deviceInfo.UserAgent && recorded.IPAddress == deviceInfo.IPAddress && recorded.LastSeen+uint64(deviceInfoUpdateInterval.Milliseconds()) > deviceInfo.LastSeen
+}
+
+// GetDeviceInfoFromSessionData returns the device info recorded in the session data in the database (see
+// RecordDeviceInfo in the session recipe config), or nil if there is none
+func GetDeviceInfoFromSessionData(sessionDataInDatabase map[string]interface{}) *sessmodels.DeviceInfo {
+	value, ok := sessionDataInDatabase[deviceInfoKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	deviceInfo := &sessmodels.DeviceInfo{}
+	deviceInfo.UserAgent, _ = value["userAgent"].(string)
+	deviceInfo.IPAddress, _ = value["ipAddress"].(string)
+	if firstSeen := sanitizeNumberInputAsUint64(value["firstSeen"]); firstSeen != nil {
+		deviceInfo.FirstSeen = *firstSeen
+	}
+	if lastSeen := sanitizeNumberInputAsUint64(value["lastSeen"]); lastSeen != nil {
+		deviceInfo.LastSeen = *lastSeen
+	}
+	return deviceInfo
+}
+
+func deviceInfoToSessionData(deviceInfo sessmodels.DeviceInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"userAgent": deviceInfo.UserAgent,
+		"ipAddress": deviceInfo.IPAddress,
+		"firstSeen": float64(deviceInfo.FirstSeen),
+		"lastSeen":  float64(deviceInfo.LastSeen),
+	}
+}
+
+// withDeviceInfo wraps the functions of recipeImpl that create and refresh sessions so that they record the
+// device of the request they are called for in the session data in the database
+func withDeviceInfo(recipeImpl sessmodels.RecipeInterface, config sessmodels.TypeNormalisedInput) sessmodels.RecipeInterface {
+	if !config.RecordDeviceInfo {
+		return recipeImpl
+	}
+	recorder := &deviceInfoRecorder{recorded: map[string]sessmodels.DeviceInfo{}}
+
+	oCreateNewSession := *recipeImpl.CreateNewSession
+	createNewSession := func(userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCsrf *bool, tenantId string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		req := supertokens.GetRequestFromUserContext(userContext)
+		if req == nil {
+			return oCreateNewSession(userID, accessTokenPayload, sessionDataInDatabase, disableAntiCsrf, tenantId, userContext)
+		}
+		now := GetCurrTimeInMS()
+		deviceInfo := sessmodels.DeviceInfo{
+			UserAgent: req.UserAgent(),
+			IPAddress: config.Events.GetIPAddress(req),
+			FirstSeen: now,
+			LastSeen:  now,
+		}
+		newSessionDataInDatabase := map[string]interface{}{}
+		for key, value := range sessionDataInDatabase {
+			newSessionDataInDatabase[key] = value
+		}
+		newSessionDataInDatabase[deviceInfoKey] = deviceInfoToSessionData(deviceInfo)
+		session, err := oCreateNewSession(userID, accessTokenPayload, newSessionDataInDatabase, disableAntiCsrf, tenantId, userContext)
+		if err != nil || session == nil {
+			return session, err
+		}
+		recorder.setRecorded(session.GetHandleWithContext(userContext), &deviceInfo)
+		return session, nil
+	}
+
+	oUpdateSessionDataInDatabase := *recipeImpl.UpdateSessionDataInDatabase
+	updateSessionDataInDatabase := func(sessionHandle string, newSessionData map[string]interface{}, userContext supertokens.UserContext) (bool, error) {
+		sessionLock := recorder.getSessionLock(sessionHandle)
+		sessionLock.Lock()
+		defer sessionLock.Unlock()
+		updated, err := oUpdateSessionDataInDatabase(sessionHandle, newSessionData, userContext)
+		if err == nil {
+			recorder.setRecorded(sessionHandle, GetDeviceInfoFromSessionData(newSessionData))
+		}
+		return updated, err
+	}
+
+	oRefreshSession := *recipeImpl.RefreshSession
+	getSessionInformation := *recipeImpl.GetSessionInformation
+	refreshSession := func(refreshToken string, antiCSRFToken *string, disableAntiCSRF bool, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		session, err := oRefreshSession(refreshToken, antiCSRFToken, disableAntiCSRF, userContext)
+		if err != nil || session == nil {
+			return session, err
+		}
+		req := supertokens.GetRequestFromUserContext(userContext)
+		if req == nil {
+			return session, nil
+		}
+
+		sessionHandle := session.GetHandleWithContext(userContext)
+		now := GetCurrTimeInMS()
+		deviceInfo := sessmodels.DeviceInfo{
+			UserAgent: req.UserAgent(),
+			IPAddress: config.Events.GetIPAddress(req),
+			FirstSeen: now,
+			LastSeen:  now,
+		}
+		if recorded, ok := recorder.getRecorded(sessionHandle); ok && isDeviceInfoUpToDate(recorded, deviceInfo) {
+			return session, nil
+		}
+
+		// the session data is fetched and replaced while holding the lock of the session, so that only the
+		// device info is changed by the update
+		sessionLock := recorder.getSessionLock(sessionHandle)
+		sessionLock.Lock()
+		defer sessionLock.Unlock()
+
+		// errors are only logged from here on, since the tokens have already been refreshed in the core and
+		// must be sent to the client
+		sessionInfo, err := getSessionInformation(sessionHandle, userContext)
+		if err != nil {
+			supertokens.LogMessage(supertokens.LogLevelWarn, userContext, "refreshSession: could not fetch the session to record the device info: "+err.Error(), "recipeId", RECIPE_ID, "session", supertokens.HashForLogging(sessionHandle))
+			return session, nil
+		}
+		if sessionInfo == nil {
+			// the session was revoked after it was refreshed
+			return session, nil
+		}
+
+		if existingDeviceInfo := GetDeviceInfoFromSessionData(sessionInfo.SessionDataInDatabase); existingDeviceInfo != nil {
+			if isDeviceInfoUpToDate(*existingDeviceInfo, deviceInfo) {
+				recorder.setRecorded(sessionHandle, existingDeviceInfo)
+				return session, nil
+			}
+			deviceInfo.FirstSeen = existingDeviceInfo.FirstSeen
+		}
+
+		sessionDataInDatabase := map[string]interface{}{}
+		for key, value := range sessionInfo.SessionDataInDatabase {
+			sessionDataInDatabase[key] = value
+		}
+		sessionDataInDatabase[deviceInfoKey] = deviceInfoToSessionData(deviceInfo)
+		_, err = oUpdateSessionDataInDatabase(sessionHandle, sessionDataInDatabase, userContext)
+		if err != nil {
+			supertokens.LogMessage(supertokens.LogLevelWarn, userContext, "refreshSession: could not record the device info: "+err.Error(), "recipeId", RECIPE_ID, "session", supertokens.HashForLogging(sessionHandle))
+			return session, nil
+		}
+		recorder.setRecorded(sessionHandle, &deviceInfo)
+		return session, nil
+	}
+
+	recipeImpl.CreateNewSession = &createNewSession
+	recipeImpl.UpdateSessionDataInDatabase = &updateSessionDataInDatabase
+	recipeImpl.RefreshSession = &refreshSession
+	return recipeImpl
+}
@@ -0,0 +1,324 @@
+package session
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// makeRecipeImplementationForDeviceInfoTest returns a recipe implementation that keeps the session data in
+// sessionData instead of calling the core
+func makeRecipeImplementationForDeviceInfoTest(sessionData map[string]map[string]interface{}) sessmodels.RecipeInterface {
+	createNewSession := func(userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCsrf *bool, tenantId string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		sessionData["handle"] = sessionDataInDatabase
+		return makeSessionContainerForEventsTest(userID, tenantId, "handle"), nil
+	}
+	refreshSession := func(refreshToken string, antiCSRFToken *string, disableAntiCSRF bool, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		return makeSessionContainerForEventsTest("userId", "public", "handle"), nil
+	}
+	getSessionInformation := func(sessionHandle string, userContext supertokens.UserContext) (*sessmodels.SessionInformation, error) {
+		data, ok := sessionData[sessionHandle]
+		if !ok {
+			return nil, nil
+		}
+		return &sessmodels.SessionInformation{SessionHandle: sessionHandle, UserId: "userId", TenantId: "public", SessionDataInDatabase: data}, nil
+	}
+	updateSessionDataInDatabase := func(sessionHandle string, newSessionData map[string]interface{}, userContext supertokens.UserContext) (bool, error) {
+		sessionData[sessionHandle] = newSessionData
+		return true, nil
+	}
+	getAllSessionHandlesForUser := func(userID string, tenantId string, fetchAcrossAllTenants *bool, userContext supertokens.UserContext) ([]string, error) {
+		sessionHandles := []string{}
+		for sessionHandle := range sessionData {
+			sessionHandles = append(sessionHandles, sessionHandle)
+		}
+		return sessionHandles, nil
+	}
+	revokeSession := func(sessionHandle string, userContext supertokens.UserContext) (bool, error) {
+		_, ok := sessionData[sessionHandle]
+		delete(sessionData, sessionHandle)
+		return ok, nil
+	}
+	revokeMultipleSessions := func(sessionHandles []string, userContext supertokens.UserContext) ([]string, error) {
+		for _, sessionHandle := range sessionHandles {
+			delete(sessionData, sessionHandle)
+		}
+		return sessionHandles, nil
+	}
+	return sessmodels.RecipeInterface{
+		CreateNewSession:            &createNewSession,
+		RefreshSession:              &refreshSession,
+		GetSessionInformation:       &getSessionInformation,
+		UpdateSessionDataInDatabase: &updateSessionDataInDatabase,
+		GetAllSessionHandlesForUser: &getAllSessionHandlesForUser,
+		RevokeSession:               &revokeSession,
+		RevokeMultipleSessions:      &revokeMultipleSessions,
+	}
+}
+
+func TestThatDeviceInfoIsRecordedOnCreateAndRefresh(t *testing.T) {
+	config, err := ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{
+		RecordDeviceInfo: true,
+	})
+	assert.NoError(t, err)
+
+	sessionData := map[string]map[string]interface{}{}
+	recipeImpl := withDeviceInfo(makeRecipeImplementationForDeviceInfoTest(sessionData), config)
+
+	req := httptest.NewRequest("POST", "/auth/signin", nil)
+	req.RemoteAddr = "1.2.3.4:5678"
+	req.Header.Set("User-Agent", "browser")
+	_, err = (*recipeImpl.CreateNewSession)("userId", map[string]interface{}{}, map[string]interface{}{"key": "value"}, nil, "public", supertokens.MakeDefaultUserContextFromAPI(req))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "value", sessionData["handle"]["key"])
+	deviceInfo := GetDeviceInfoFromSessionData(sessionData["handle"])
+	assert.NotNil(t, deviceInfo)
+	assert.Equal(t, "browser", deviceInfo.UserAgent)
+	assert.Equal(t, "1.2.3.4", deviceInfo.IPAddress)
+	assert.Equal(t, deviceInfo.FirstSeen, deviceInfo.LastSeen)
+	firstSeen := deviceInfo.FirstSeen
+
+	req = httptest.NewRequest("POST", "/auth/session/refresh", nil)
+	req.RemoteAddr = "5.6.7.8:5678"
+	req.Header.Set("User-Agent", "other-browser")
+	_, err = (*recipeImpl.RefreshSession)("refreshToken", nil, false, supertokens.MakeDefaultUserContextFromAPI(req))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "value", sessionData["handle"]["key"])
+	deviceInfo = GetDeviceInfoFromSessionData(sessionData["handle"])
+	assert.NotNil(t, deviceInfo)
+	assert.Equal(t, "other-browser", deviceInfo.UserAgent)
+	assert.Equal(t, "5.6.7.8", deviceInfo.IPAddress)
+	assert.Equal(t, firstSeen, deviceInfo.FirstSeen)
+	assert.GreaterOrEqual(t, deviceInfo.LastSeen, firstSeen)
+}
+
+func TestThatDeviceInfoIsOnlyUpdatedWhenTheDeviceChangesOrAfterTheUpdateInterval(t *testing.T) {
+	config, err := ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{
+		RecordDeviceInfo: true,
+	})
+	assert.NoError(t, err)
+
+	now := GetCurrTimeInMS()
+	sessionData := map[string]map[string]interface{}{
+		"handle": {deviceInfoKey: deviceInfoToSessionData(sessmodels.DeviceInfo{UserAgent: "browser", IPAddress: "1.2.3.4", FirstSeen: now, LastSeen: now})},
+	}
+	recipeImpl := makeRecipeImplementationForDeviceInfoTest(sessionData)
+	updateSessionDataInDatabase := *recipeImpl.UpdateSessionDataInDatabase
+	updates := 0
+	countingUpdateSessionDataInDatabase := func(sessionHandle string, newSessionData map[string]interface{}, userContext supertokens.UserContext) (bool, error) {
+		updates++
+		return updateSessionDataInDatabase(sessionHandle, newSessionData, userContext)
+	}
+	recipeImpl.UpdateSessionDataInDatabase = &countingUpdateSessionDataInDatabase
+	recipeImpl = withDeviceInfo(recipeImpl, config)
+
+	refresh := func(userAgent string) {
+		req := httptest.NewRequest("POST", "/auth/session/refresh", nil)
+		req.RemoteAddr = "1.2.3.4:5678"
+		req.Header.Set("User-Agent", userAgent)
+		_, err := (*recipeImpl.RefreshSession)("refreshToken", nil, false, supertokens.MakeDefaultUserContextFromAPI(req))
+		assert.NoError(t, err)
+	}
+
+	refresh("browser")
+	assert.Equal(t, 0, updates)
+
+	refresh("other-browser")
+	assert.Equal(t, 1, updates)
+	assert.Equal(t, "other-browser", GetDeviceInfoFromSessionData(sessionData["handle"]).UserAgent)
+
+	_, err = (*recipeImpl.UpdateSessionDataInDatabase)("handle", map[string]interface{}{
+		deviceInfoKey: deviceInfoToSessionData(sessmodels.DeviceInfo{UserAgent: "other-browser", IPAddress: "1.2.3.4", FirstSeen: 1, LastSeen: now - uint64(deviceInfoUpdateInterval.Milliseconds())}),
+	}, nil)
+	assert.NoError(t, err)
+	refresh("other-browser")
+	assert.Equal(t, 3, updates)
+	assert.GreaterOrEqual(t, GetDeviceInfoFromSessionData(sessionData["handle"]).LastSeen, now)
+	assert.Equal(t, uint64(1), GetDeviceInfoFromSessionData(sessionData["handle"]).FirstSeen)
+}
+
+func TestThatRefreshesFromTheRecordedDeviceDontFetchTheSession(t *testing.T) {
+	config, err := ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{
+		RecordDeviceInfo: true,
+	})
+	assert.NoError(t, err)
+
+	sessionData := map[string]map[string]interface{}{}
+	recipeImpl := makeRecipeImplementationForDeviceInfoTest(sessionData)
+	getSessionInformation := *recipeImpl.GetSessionInformation
+	fetches := 0
+	countingGetSessionInformation := func(sessionHandle string, userContext supertokens.UserContext) (*sessmodels.SessionInformation, error) {
+		fetches++
+		return getSessionInformation(sessionHandle, userContext)
+	}
+	recipeImpl.GetSessionInformation = &countingGetSessionInformation
+	recipeImpl = withDeviceInfo(recipeImpl, config)
+
+	makeUserContext := func(userAgent string) supertokens.UserContext {
+		req := httptest.NewRequest("POST", "/auth/session/refresh", nil)
+		req.RemoteAddr = "1.2.3.4:5678"
+		req.Header.Set("User-Agent", userAgent)
+		return supertokens.MakeDefaultUserContextFromAPI(req)
+	}
+	_, err = (*recipeImpl.CreateNewSession)("userId", map[string]interface{}{}, map[string]interface{}{}, nil, "public", makeUserContext("browser"))
+	assert.NoError(t, err)
+
+	_, err = (*recipeImpl.RefreshSession)("refreshToken", nil, false, makeUserContext("browser"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, fetches)
+
+	// the session data changed by the app is not overwritten when the device changes
+	_, err = (*recipeImpl.UpdateSessionDataInDatabase)("handle", map[string]interface{}{"key": "value", deviceInfoKey: sessionData["handle"][deviceInfoKey]}, nil)
+	assert.NoError(t, err)
+	_, err = (*recipeImpl.RefreshSession)("refreshToken", nil, false, makeUserContext("other-browser"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fetches)
+	assert.Equal(t, "value", sessionData["handle"]["key"])
+	assert.Equal(t, "other-browser", GetDeviceInfoFromSessionData(sessionData["handle"]).UserAgent)
+
+	_, err = (*recipeImpl.RefreshSession)("refreshToken", nil, false, makeUserContext("other-browser"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fetches)
+}
+
+func TestThatSessionManagementAPIsAreOnlyExposedIfDeviceInfoIsRecorded(t *testing.T) {
+	for _, recordDeviceInfo := range []bool{false, true} {
+		_, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{RecordDeviceInfo: recordDeviceInfo})
+		recipe, err := getRecipeInstanceOrThrowError()
+		assert.NoError(t, err)
+		apis, err := recipe.RecipeModule.GetAPIsHandled()
+		assert.NoError(t, err)
+		for _, api := range apis {
+			if api.ID == SessionsAPIPath || api.ID == RevokeSessionAPIPath || api.ID == RevokeOtherSessionsAPIPath {
+				assert.Equal(t, !recordDeviceInfo, api.Disabled, api.ID)
+			}
+		}
+		cleanup()
+	}
+}
+
+func TestThatDeviceInfoIsNotRecordedByDefault(t *testing.T) {
+	config, err := ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{})
+	assert.NoError(t, err)
+
+	sessionData := map[string]map[string]interface{}{}
+	recipeImpl := withDeviceInfo(makeRecipeImplementationForDeviceInfoTest(sessionData), config)
+
+	req := httptest.NewRequest("POST", "/auth/signin", nil)
+	_, err = (*recipeImpl.CreateNewSession)("userId", map[string]interface{}{}, map[string]interface{}{}, nil, "public", supertokens.MakeDefaultUserContextFromAPI(req))
+	assert.NoError(t, err)
+
+	assert.Nil(t, GetDeviceInfoFromSessionData(sessionData["handle"]))
+}
+
+func TestSessionManagementAPIImplementation(t *testing.T) {
+	sessionData := map[string]map[string]interface{}{
+		"handle": {deviceInfoKey: deviceInfoToSessionData(sessmodels.DeviceInfo{UserAgent: "browser", IPAddress: "1.2.3.4", FirstSeen: 1, LastSeen: 2})},
+		"other1": {},
+		"other2": {},
+	}
+	options := sessmodels.APIOptions{RecipeImplementation: makeRecipeImplementationForDeviceInfoTest(sessionData)}
+	apiImpl := MakeAPIImplementation()
+	revokedCurrentSession := false
+	sessionContainer := makeSessionContainerForEventsTest("userId", "public", "handle")
+	sessionContainer.RevokeSessionWithContext = func(userContext supertokens.UserContext) error {
+		revokedCurrentSession = true
+		return nil
+	}
+	userContext := &map[string]interface{}{}
+
+	sessionsResp, err := (*apiImpl.SessionsGET)(sessionContainer, options, userContext)
+	assert.NoError(t, err)
+	assert.NotNil(t, sessionsResp.OK)
+	assert.Len(t, sessionsResp.OK.Sessions, 3)
+	for _, session := range sessionsResp.OK.Sessions {
+		assert.Equal(t, session.SessionHandle == "handle", session.IsCurrent)
+		if session.SessionHandle == "handle" {
+			assert.Equal(t, &sessmodels.DeviceInfo{UserAgent: "browser", IPAddress: "1.2.3.4", FirstSeen: 1, LastSeen: 2}, session.Device)
+		} else {
+			assert.Nil(t, session.Device)
+		}
+	}
+
+	revokeResp, err := (*apiImpl.RevokeSessionPOST)("unknown", sessionContainer, options, userContext)
+	assert.NoError(t, err)
+	assert.NotNil(t, revokeResp.UnknownSessionError)
+
+	revokeResp, err = (*apiImpl.RevokeSessionPOST)("other1", sessionContainer, options, userContext)
+	assert.NoError(t, err)
+	assert.NotNil(t, revokeResp.OK)
+	assert.NotContains(t, sessionData, "other1")
+
+	revokeOthersResp, err := (*apiImpl.RevokeOtherSessionsPOST)(sessionContainer, options, userContext)
+	assert.NoError(t, err)
+	assert.NotNil(t, revokeOthersResp.OK)
+	assert.Equal(t, []string{"other2"}, revokeOthersResp.OK.RevokedSessionHandles)
+	assert.Contains(t, sessionData, "handle")
+
+	revokeResp, err = (*apiImpl.RevokeSessionPOST)("handle", sessionContainer, options, userContext)
+	assert.NoError(t, err)
+	assert.NotNil(t, revokeResp.OK)
+	assert.True(t, revokedCurrentSession)
+}
+
+func TestThatSessionsOfOtherUsersCannotBeRevoked(t *testing.T) {
+	sessionData := map[string]map[string]interface{}{
+		"handle": {},
+		"other":  {},
+	}
+	options := sessmodels.APIOptions{RecipeImplementation: makeRecipeImplementationForDeviceInfoTest(sessionData)}
+	apiImpl := MakeAPIImplementation()
+	sessionContainer := makeSessionContainerForEventsTest("otherUserId", "public", "handle")
+
+	revokeResp, err := (*apiImpl.RevokeSessionPOST)("other", sessionContainer, options, &map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.NotNil(t, revokeResp.UnknownSessionError)
+	assert.Contains(t, sessionData, "other")
+}
+
+func TestThatSessionsGETFetchesTheSessionsConcurrently(t *testing.T) {
+	sessionHandles := []string{}
+	for i := 0; i < 3*sessionsGETMaxConcurrentFetches; i++ {
+		sessionHandles = append(sessionHandles, "handle"+strconv.Itoa(i))
+	}
+	var lock sync.Mutex
+	fetching, maxFetching := 0, 0
+	getAllSessionHandlesForUser := func(userID string, tenantId string, fetchAcrossAllTenants *bool, userContext supertokens.UserContext) ([]string, error) {
+		return sessionHandles, nil
+	}
+	getSessionInformation := func(sessionHandle string, userContext supertokens.UserContext) (*sessmodels.SessionInformation, error) {
+		lock.Lock()
+		fetching++
+		if fetching > maxFetching {
+			maxFetching = fetching
+		}
+		lock.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		lock.Lock()
+		fetching--
+		lock.Unlock()
+		return &sessmodels.SessionInformation{SessionHandle: sessionHandle, UserId: "userId", TenantId: "public", SessionDataInDatabase: map[string]interface{}{}}, nil
+	}
+	options := sessmodels.APIOptions{RecipeImplementation: sessmodels.RecipeInterface{
+		GetAllSessionHandlesForUser: &getAllSessionHandlesForUser,
+		GetSessionInformation:       &getSessionInformation,
+	}}
+	apiImpl := MakeAPIImplementation()
+
+	sessionsResp, err := (*apiImpl.SessionsGET)(makeSessionContainerForEventsTest("userId", "public", "handle0"), options, &map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Len(t, sessionsResp.OK.Sessions, len(sessionHandles))
+	for i, session := range sessionsResp.OK.Sessions {
+		assert.Equal(t, sessionHandles[i], session.SessionHandle)
+	}
+	assert.Greater(t, maxFetching, 1)
+	assert.LessOrEqual(t, maxFetching, sessionsGETMaxConcurrentFetches)
+}
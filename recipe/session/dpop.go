@@ -0,0 +1,356 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	"container/heap"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	defaultErrors "errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// the algorithms DPoP proofs can be signed with. Symmetric algorithms and "none" are not allowed.
+var dpopSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// the minimum size of the RSA keys DPoP proofs can be signed with
+const dpopMinRSAKeySize = 2048
+
+type inMemoryDPoPJTIStore struct {
+	lock      sync.Mutex
+	expiresAt map[string]time.Time
+	// the stored jtis, ordered by when they expire, so that expired ones are removed without scanning the map
+	expiryQueue dpopJTIExpiryQueue
+}
+
+type dpopJTIExpiry struct {
+	jti       string
+	expiresAt time.Time
+}
+
+type dpopJTIExpiryQueue []dpopJTIExpiry
+
+func (q dpopJTIExpiryQueue) Len() int            { return len(q) }
+func (q dpopJTIExpiryQueue) Less(i, j int) bool  { return q[i].expiresAt.Before(q[j].expiresAt) }
+func (q dpopJTIExpiryQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *dpopJTIExpiryQueue) Push(x interface{}) { *q = append(*q, x.(dpopJTIExpiry)) }
+func (q *dpopJTIExpiryQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// NewInMemoryDPoPJTIStore returns a DPoPJTIStore that keeps the jti of proofs in memory
+func NewInMemoryDPoPJTIStore() sessmodels.DPoPJTIStore {
+	return &inMemoryDPoPJTIStore{
+		expiresAt: map[string]time.Time{},
+	}
+}
+
+func (s *inMemoryDPoPJTIStore) Add(jti string, expiresAt time.Time, userContext supertokens.UserContext) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	for s.expiryQueue.Len() > 0 && !s.expiryQueue[0].expiresAt.After(now) {
+		expired := heap.Pop(&s.expiryQueue).(dpopJTIExpiry)
+		// the jti may have been added again after it expired
+		if s.expiresAt[expired.jti].Equal(expired.expiresAt) {
+			delete(s.expiresAt, expired.jti)
+		}
+	}
+	if existingExpiresAt, ok := s.expiresAt[jti]; ok && existingExpiresAt.After(now) {
+		return false, nil
+	}
+	s.expiresAt[jti] = expiresAt
+	heap.Push(&s.expiryQueue, dpopJTIExpiry{jti: jti, expiresAt: expiresAt})
+	return true, nil
+}
+
+func getDPoPThumbprint(accessTokenPayload map[string]interface{}) *string {
+	confirmation, ok := accessTokenPayload[dpopConfirmationKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	thumbprint, ok := confirmation["jkt"].(string)
+	if !ok {
+		return nil
+	}
+	return &thumbprint
+}
+
+// verifyDPoPProof verifies the DPoP proof in the headers of req and returns the thumbprint of its key. If
+// accessToken is not nil, the proof must be bound to it using the ath claim.
+func verifyDPoPProof(config sessmodels.TypeNormalisedInput, req *http.Request, accessToken *string, userContext supertokens.UserContext) (string, error) {
+	proofs := req.Header.Values(dpopHeaderKey)
+	if len(proofs) != 1 {
+		return "", defaultErrors.New("the request must have exactly one DPoP header")
+	}
+
+	// the proof can be verified more than once in the same request (for example if a session is fetched
+	// before a new one is created while signing in), but its jti can only be used once.
+	if verifiedProof, ok := getVerifiedDPoPProof(proofs[0], userContext); ok {
+		if accessToken != nil && verifiedProof.ath != getDPoPAccessTokenHash(*accessToken) {
+			return "", defaultErrors.New("the ath claim does not match the access token")
+		}
+		return verifiedProof.thumbprint, nil
+	}
+
+	thumbprint := ""
+	token, err := jwt.Parse(proofs[0], func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != "dpop+jwt" {
+			return nil, defaultErrors.New("the typ header must be dpop+jwt")
+		}
+		jwk, ok := token.Header["jwk"].(map[string]interface{})
+		if !ok {
+			return nil, defaultErrors.New("the jwk header is missing")
+		}
+		publicKey, err := parseDPoPPublicKey(jwk)
+		if err != nil {
+			return nil, err
+		}
+		thumbprint, err = computeJWKThumbprint(jwk)
+		if err != nil {
+			return nil, err
+		}
+		return publicKey, nil
+	}, jwt.WithValidMethods(dpopSigningMethods), jwt.WithoutClaimsValidation())
+	if err != nil {
+		return "", err
+	}
+
+	proofClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", defaultErrors.New("the claims of the proof can't be read")
+	}
+
+	if htm, _ := proofClaims["htm"].(string); htm != req.Method {
+		return "", defaultErrors.New("the htm claim does not match the method of the request")
+	}
+	htu, _ := proofClaims["htu"].(string)
+	if i := strings.IndexAny(htu, "?#"); i != -1 {
+		htu = htu[:i]
+	}
+	if htu != config.DPoP.GetRequestURL(req, userContext) {
+		return "", defaultErrors.New("the htu claim does not match the URL of the request")
+	}
+
+	iat, ok := proofClaims["iat"].(float64)
+	if !ok {
+		return "", defaultErrors.New("the iat claim is missing")
+	}
+	issuedAt := time.Unix(int64(iat), 0)
+	if time.Since(issuedAt) > config.DPoP.ProofMaxAge || time.Until(issuedAt) > config.DPoP.ProofMaxAge {
+		return "", defaultErrors.New("the iat claim is too far from the current time")
+	}
+
+	ath, _ := proofClaims["ath"].(string)
+	if accessToken != nil && ath != getDPoPAccessTokenHash(*accessToken) {
+		return "", defaultErrors.New("the ath claim does not match the access token")
+	}
+
+	jti, _ := proofClaims["jti"].(string)
+	if jti == "" {
+		return "", defaultErrors.New("the jti claim is missing")
+	}
+	// the jti only needs to be kept until the proof is too old to be accepted
+	added, err := config.DPoP.JTIStore.Add(thumbprint+":"+jti, issuedAt.Add(config.DPoP.ProofMaxAge), userContext)
+	if err != nil {
+		return "", err
+	}
+	if !added {
+		return "", defaultErrors.New("the proof has already been used")
+	}
+
+	setVerifiedDPoPProof(proofs[0], dpopVerifiedProof{thumbprint: thumbprint, ath: ath}, userContext)
+	return thumbprint, nil
+}
+
+type dpopVerifiedProof struct {
+	thumbprint string
+	ath        string
+}
+
+func getDPoPAccessTokenHash(accessToken string) string {
+	accessTokenHash := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(accessTokenHash[:])
+}
+
+// getVerifiedDPoPProof returns the result of verifying proof earlier in the request userContext was made for
+func getVerifiedDPoPProof(proof string, userContext supertokens.UserContext) (dpopVerifiedProof, bool) {
+	if userContext == nil {
+		return dpopVerifiedProof{}, false
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		return dpopVerifiedProof{}, false
+	}
+	verifiedProofs, ok := defaultObj["dpopVerifiedProofs"].(map[string]dpopVerifiedProof)
+	if !ok {
+		return dpopVerifiedProof{}, false
+	}
+	verifiedProof, ok := verifiedProofs[proof]
+	return verifiedProof, ok
+}
+
+func setVerifiedDPoPProof(proof string, verifiedProof dpopVerifiedProof, userContext supertokens.UserContext) {
+	if userContext == nil {
+		return
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		defaultObj = map[string]interface{}{}
+		(*userContext)["_default"] = defaultObj
+	}
+	verifiedProofs, ok := defaultObj["dpopVerifiedProofs"].(map[string]dpopVerifiedProof)
+	if !ok {
+		verifiedProofs = map[string]dpopVerifiedProof{}
+		defaultObj["dpopVerifiedProofs"] = verifiedProofs
+	}
+	verifiedProofs[proof] = verifiedProof
+}
+
+// verifyDPoPProofForSession verifies the DPoP proof in the headers of req for a session bound to thumbprint
+func verifyDPoPProofForSession(config sessmodels.TypeNormalisedInput, req *http.Request, accessToken *string, thumbprint string, userContext supertokens.UserContext) error {
+	proofThumbprint, err := verifyDPoPProof(config, req, accessToken, userContext)
+	if err != nil {
+		return err
+	}
+	if proofThumbprint != thumbprint {
+		return defaultErrors.New("the proof is not signed with the key the session is bound to")
+	}
+	return nil
+}
+
+func parseDPoPPublicKey(jwk map[string]interface{}) (interface{}, error) {
+	if _, ok := jwk["d"]; ok {
+		return nil, defaultErrors.New("the jwk header must not contain a private key")
+	}
+	getBytes := func(name string) ([]byte, error) {
+		value, _ := jwk[name].(string)
+		if value == "" {
+			return nil, fmt.Errorf("the %s member of the jwk is missing", name)
+		}
+		return base64.RawURLEncoding.DecodeString(value)
+	}
+
+	kty, _ := jwk["kty"].(string)
+	crv, _ := jwk["crv"].(string)
+	switch kty {
+	case "RSA":
+		n, err := getBytes("n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := getBytes("e")
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		// the exponent must fit in an int on all platforms, and be odd and at least 3
+		if exponent.BitLen() > 31 || exponent.Int64() < 3 || exponent.Bit(0) == 0 {
+			return nil, defaultErrors.New("the exponent of the RSA key is invalid")
+		}
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if publicKey.N.BitLen() < dpopMinRSAKeySize {
+			return nil, fmt.Errorf("RSA keys must have at least %d bits", dpopMinRSAKeySize)
+		}
+		return publicKey, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", crv)
+		}
+		x, err := getBytes("x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := getBytes("y")
+		if err != nil {
+			return nil, err
+		}
+		publicKey := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, defaultErrors.New("the jwk is not a point on its curve")
+		}
+		return publicKey, nil
+	case "OKP":
+		if crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", crv)
+		}
+		x, err := getBytes("x")
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, defaultErrors.New("the jwk is not an Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", kty)
+}
+
+// computeJWKThumbprint returns the SHA-256 thumbprint of a public key, as defined by RFC 7638
+func computeJWKThumbprint(jwk map[string]interface{}) (string, error) {
+	var members []string
+	switch jwk["kty"] {
+	case "RSA":
+		members = []string{"e", "kty", "n"}
+	case "EC":
+		members = []string{"crv", "kty", "x", "y"}
+	case "OKP":
+		members = []string{"crv", "kty", "x"}
+	default:
+		return "", fmt.Errorf("unsupported key type %v", jwk["kty"])
+	}
+
+	// the members are in lexicographic order, and the values are strings, so this is the canonical JSON
+	parts := []string{}
+	for _, member := range members {
+		value, ok := jwk[member].(string)
+		if !ok {
+			return "", fmt.Errorf("the %s member of the jwk is missing", member)
+		}
+		encodedValue, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, fmt.Sprintf("%q:%s", member, encodedValue))
+	}
+	hash := sha256.Sum256([]byte("{" + strings.Join(parts, ",") + "}"))
+	return base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
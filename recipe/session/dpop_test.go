@@ -0,0 +1,369 @@
+package session
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	defaultErrors "errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func makeDPoPKeyForTest(t *testing.T) (*ecdsa.PrivateKey, map[string]interface{}) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	jwk := map[string]interface{}{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(privateKey.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(privateKey.Y.FillBytes(make([]byte, 32))),
+	}
+	return privateKey, jwk
+}
+
+func makeDPoPProofForTest(t *testing.T, privateKey *ecdsa.PrivateKey, jwk map[string]interface{}, claims jwt.MapClaims) string {
+	proofClaims := jwt.MapClaims{
+		"htm": "GET",
+		"htu": "https://api.supertokens.io/",
+		"iat": time.Now().Unix(),
+		"jti": base64.RawURLEncoding.EncodeToString(make([]byte, 8)) + time.Now().String(),
+	}
+	for key, value := range claims {
+		proofClaims[key] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, proofClaims)
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = jwk
+	proof, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return proof
+}
+
+func makeRequestForDPoPTest(accessToken string, proof string) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	if accessToken != "" {
+		req.Header.Set("Authorization", "DPoP "+accessToken)
+	}
+	if proof != "" {
+		req.Header.Set("DPoP", proof)
+	}
+	return req
+}
+
+func TestComputeJWKThumbprint(t *testing.T) {
+	// the example from RFC 7638 section 3.1
+	thumbprint, err := computeJWKThumbprint(map[string]interface{}{
+		"kty": "RSA",
+		"n":   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		"e":   "AQAB",
+		"alg": "RS256",
+		"kid": "2011-04-29",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
+
+func TestThatTheInMemoryDPoPJTIStoreRejectsReplays(t *testing.T) {
+	store := NewInMemoryDPoPJTIStore()
+	added, err := store.Add("jti", time.Now().Add(time.Minute), nil)
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = store.Add("jti", time.Now().Add(time.Minute), nil)
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	// expired entries can be added again
+	added, err = store.Add("expired", time.Now().Add(-time.Second), nil)
+	assert.NoError(t, err)
+	assert.True(t, added)
+	added, err = store.Add("expired", time.Now().Add(time.Minute), nil)
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	// expired entries are removed when others are added
+	_, err = store.Add("expiring", time.Now().Add(-time.Second), nil)
+	assert.NoError(t, err)
+	_, err = store.Add("other", time.Now().Add(time.Minute), nil)
+	assert.NoError(t, err)
+	inMemoryStore := store.(*inMemoryDPoPJTIStore)
+	assert.Len(t, inMemoryStore.expiresAt, 3)
+	assert.NotContains(t, inMemoryStore.expiresAt, "expiring")
+	assert.Contains(t, inMemoryStore.expiresAt, "expired")
+}
+
+func TestThatRSAKeysOfDPoPProofsMustHaveAtLeast2048Bits(t *testing.T) {
+	for bits, isAllowed := range map[int]bool{1024: false, 2048: true} {
+		privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+		assert.NoError(t, err)
+		_, err = parseDPoPPublicKey(map[string]interface{}{
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		})
+		if isAllowed {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, "RSA keys must have at least 2048 bits")
+		}
+	}
+}
+
+func TestThatTheExponentOfRSAKeysOfDPoPProofsIsValidated(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	for exponent, isAllowed := range map[int64]bool{65537: true, 3: true, 1: false, 65536: false, 1<<40 + 1: false} {
+		_, err = parseDPoPPublicKey(map[string]interface{}{
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(exponent).Bytes()),
+		})
+		if isAllowed {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, "the exponent of the RSA key is invalid")
+		}
+	}
+}
+
+func TestVerifyDPoPProof(t *testing.T) {
+	appInfo, err := supertokens.NormaliseInputAppInfoOrThrowError(supertokens.AppInfo{
+		AppName:       "SuperTokens",
+		WebsiteDomain: "supertokens.io",
+		APIDomain:     "api.supertokens.io",
+	})
+	assert.NoError(t, err)
+	config, err := ValidateAndNormaliseUserInput(appInfo, &sessmodels.TypeInput{DPoP: &sessmodels.DPoPConfig{}})
+	assert.NoError(t, err)
+	assert.Equal(t, 60*time.Second, config.DPoP.ProofMaxAge)
+
+	privateKey, jwk := makeDPoPKeyForTest(t)
+	expectedThumbprint, err := computeJWKThumbprint(jwk)
+	assert.NoError(t, err)
+
+	proof := makeDPoPProofForTest(t, privateKey, jwk, nil)
+	thumbprint, err := verifyDPoPProof(config, makeRequestForDPoPTest("", proof), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedThumbprint, thumbprint)
+
+	_, err = verifyDPoPProof(config, makeRequestForDPoPTest("", proof), nil, nil)
+	assert.EqualError(t, err, "the proof has already been used")
+
+	_, err = verifyDPoPProof(config, makeRequestForDPoPTest("", ""), nil, nil)
+	assert.Error(t, err)
+
+	_, err = verifyDPoPProof(config, makeRequestForDPoPTest("", makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{"htm": "POST"})), nil, nil)
+	assert.EqualError(t, err, "the htm claim does not match the method of the request")
+
+	_, err = verifyDPoPProof(config, makeRequestForDPoPTest("", makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{"htu": "https://api.supertokens.io/other"})), nil, nil)
+	assert.EqualError(t, err, "the htu claim does not match the URL of the request")
+
+	_, err = verifyDPoPProof(config, makeRequestForDPoPTest("", makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{"htu": "https://api.supertokens.io/?a=b"})), nil, nil)
+	assert.NoError(t, err)
+
+	_, err = verifyDPoPProof(config, makeRequestForDPoPTest("", makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{"iat": time.Now().Add(-2 * time.Minute).Unix()})), nil, nil)
+	assert.EqualError(t, err, "the iat claim is too far from the current time")
+
+	accessToken := "token"
+	accessTokenHash := sha256.Sum256([]byte(accessToken))
+	ath := base64.RawURLEncoding.EncodeToString(accessTokenHash[:])
+	_, err = verifyDPoPProof(config, makeRequestForDPoPTest("", makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{"ath": ath})), &accessToken, nil)
+	assert.NoError(t, err)
+
+	otherAccessToken := "other-token"
+	_, err = verifyDPoPProof(config, makeRequestForDPoPTest("", makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{"ath": ath})), &otherAccessToken, nil)
+	assert.EqualError(t, err, "the ath claim does not match the access token")
+
+	// proofs must be signed with an asymmetric key
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"htm": "GET", "htu": "https://api.supertokens.io/", "iat": time.Now().Unix(), "jti": "hmac"})
+	hmacToken.Header["typ"] = "dpop+jwt"
+	hmacToken.Header["jwk"] = map[string]interface{}{"kty": "oct", "k": "c2VjcmV0"}
+	hmacProof, err := hmacToken.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+	_, err = verifyDPoPProof(config, makeRequestForDPoPTest("", hmacProof), nil, nil)
+	assert.Error(t, err)
+}
+
+func TestGetSessionWithDPoPBoundAccessToken(t *testing.T) {
+	signingKey, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+		DPoP: &sessmodels.DPoPConfig{},
+	})
+	defer cleanup()
+
+	privateKey, jwk := makeDPoPKeyForTest(t)
+	thumbprint, err := computeJWKThumbprint(jwk)
+	assert.NoError(t, err)
+	accessToken := makeAccessTokenForTest(t, signingKey, map[string]interface{}{
+		dpopConfirmationKey: map[string]interface{}{"jkt": thumbprint},
+	})
+	accessTokenHash := sha256.Sum256([]byte(accessToken))
+	ath := base64.RawURLEncoding.EncodeToString(accessTokenHash[:])
+
+	sessionContainer, err := GetSession(makeRequestForDPoPTest(accessToken, makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{"ath": ath})), httptest.NewRecorder(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, thumbprint, *sessionContainer.GetDPoPThumbprint())
+
+	// a stolen token can't be used without the key
+	_, err = GetSession(makeRequestForDPoPTest(accessToken, ""), httptest.NewRecorder(), nil)
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+
+	otherPrivateKey, otherJwk := makeDPoPKeyForTest(t)
+	_, err = GetSession(makeRequestForDPoPTest(accessToken, makeDPoPProofForTest(t, otherPrivateKey, otherJwk, jwt.MapClaims{"ath": ath})), httptest.NewRecorder(), nil)
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+
+	// the proof can't be sent along with the token outside of the request
+	_, err = GetSessionWithoutRequestResponse(accessToken, nil, nil)
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+	sessionContainer, err = GetSessionWithoutRequestResponse(accessToken, nil, &sessmodels.VerifySessionOptions{VerifiesDPoPProof: true})
+	assert.NoError(t, err)
+	assert.Equal(t, thumbprint, *sessionContainer.GetDPoPThumbprint())
+
+	_, err = VerifyConnection(httptest.NewRequest("GET", "/ws?token="+accessToken, nil), httptest.NewRecorder(), &sessmodels.VerifyConnectionOptions{AccessTokenQueryParam: "token"})
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+
+	// tokens that are not bound to a key don't need a proof
+	sessionContainer, err = GetSession(makeRequestForDPoPTest(makeAccessTokenForTest(t, signingKey, nil), ""), httptest.NewRecorder(), nil)
+	assert.NoError(t, err)
+	assert.Nil(t, sessionContainer.GetDPoPThumbprint())
+}
+
+func TestThatAccessTokensBoundToADPoPKeyAreRejectedIfDPoPIsNotEnabled(t *testing.T) {
+	signingKey, cleanup := initWithSigningKeyForTest(t)
+	defer cleanup()
+
+	accessToken := makeAccessTokenForTest(t, signingKey, map[string]interface{}{
+		dpopConfirmationKey: map[string]interface{}{"jkt": "thumbprint"},
+	})
+	_, err := GetSession(makeRequestForDPoPTest(accessToken, ""), httptest.NewRecorder(), nil)
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+}
+
+func TestThatRefreshVerifiesTheDPoPProofBeforeRotatingTheTokens(t *testing.T) {
+	privateKey, jwk := makeDPoPKeyForTest(t)
+	thumbprint, err := computeJWKThumbprint(jwk)
+	assert.NoError(t, err)
+
+	refreshCount := 0
+	var revokedSessionHandles []string
+	_, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+		DPoP: &sessmodels.DPoPConfig{},
+		Override: &sessmodels.OverrideStruct{
+			Functions: func(originalImplementation sessmodels.RecipeInterface) sessmodels.RecipeInterface {
+				(*originalImplementation.RefreshSession) = func(refreshToken string, antiCSRFToken *string, disableAntiCSRF bool, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+					refreshCount++
+					sessionContainer := makeSessionContainerForEventsTest("userId", "public", "handle")
+					sessionContainer.GetAccessTokenPayloadWithContext = func(userContext supertokens.UserContext) map[string]interface{} {
+						return map[string]interface{}{dpopConfirmationKey: map[string]interface{}{"jkt": thumbprint}}
+					}
+					sessionContainer.AttachToRequestResponseWithContext = func(info sessmodels.RequestResponseInfo, userContext supertokens.UserContext) error {
+						return nil
+					}
+					return sessionContainer, nil
+				}
+				(*originalImplementation.RevokeSession) = func(sessionHandle string, userContext supertokens.UserContext) (bool, error) {
+					revokedSessionHandles = append(revokedSessionHandles, sessionHandle)
+					return true, nil
+				}
+				return originalImplementation
+			},
+		},
+	})
+	defer cleanup()
+
+	makeRefreshRequest := func(proof string) *http.Request {
+		req := httptest.NewRequest("POST", "/auth/session/refresh", nil)
+		req.Header.Set("Authorization", "Bearer refresh-token")
+		if proof != "" {
+			req.Header.Set("DPoP", proof)
+		}
+		return req
+	}
+	refreshProofClaims := jwt.MapClaims{"htm": "POST", "htu": "https://api.supertokens.io/auth/session/refresh"}
+
+	// the refresh token is not used if the proof is invalid
+	_, err = RefreshSession(makeRefreshRequest(makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{"htm": "GET"})), httptest.NewRecorder())
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+	assert.Equal(t, 0, refreshCount)
+
+	sessionContainer, err := RefreshSession(makeRefreshRequest(makeDPoPProofForTest(t, privateKey, jwk, refreshProofClaims)), httptest.NewRecorder())
+	assert.NoError(t, err)
+	assert.Equal(t, "handle", sessionContainer.GetHandleWithContext(nil))
+	assert.Equal(t, 1, refreshCount)
+	assert.Empty(t, revokedSessionHandles)
+
+	// the session is revoked if the refresh token is used with another key, or without a key
+	otherPrivateKey, otherJwk := makeDPoPKeyForTest(t)
+	_, err = RefreshSession(makeRefreshRequest(makeDPoPProofForTest(t, otherPrivateKey, otherJwk, refreshProofClaims)), httptest.NewRecorder())
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+	assert.Equal(t, 2, refreshCount)
+	assert.Equal(t, []string{"handle"}, revokedSessionHandles)
+
+	cookieReq := httptest.NewRequest("POST", "/auth/session/refresh", nil)
+	cookieReq.Header.Set("Cookie", "sRefreshToken=refresh-token")
+	cookieReq.Header.Set("rid", "session")
+	_, err = RefreshSession(cookieReq, httptest.NewRecorder())
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+	assert.Equal(t, 3, refreshCount)
+	assert.Equal(t, []string{"handle", "handle"}, revokedSessionHandles)
+}
+
+func TestThatADPoPProofIsOnlyUsedOnceInARequest(t *testing.T) {
+	var regenerated bool
+	signingKey, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+		DPoP:                   &sessmodels.DPoPConfig{},
+		ReauthenticateOnSignIn: true,
+		Override: &sessmodels.OverrideStruct{
+			Functions: func(originalImplementation sessmodels.RecipeInterface) sessmodels.RecipeInterface {
+				(*originalImplementation.RegenerateAccessToken) = func(accessToken string, newAccessTokenPayload *map[string]interface{}, userContext supertokens.UserContext) (*sessmodels.RegenerateAccessTokenResponse, error) {
+					regenerated = true
+					return &sessmodels.RegenerateAccessTokenResponse{}, nil
+				}
+				return originalImplementation
+			},
+		},
+	})
+	defer cleanup()
+
+	privateKey, jwk := makeDPoPKeyForTest(t)
+	thumbprint, err := computeJWKThumbprint(jwk)
+	assert.NoError(t, err)
+	accessToken := makeAccessTokenForTest(t, signingKey, map[string]interface{}{
+		dpopConfirmationKey: map[string]interface{}{"jkt": thumbprint},
+	})
+	req := httptest.NewRequest("POST", "/auth/signin", nil)
+	req.Header.Set("Authorization", "DPoP "+accessToken)
+	req.Header.Set("DPoP", makeDPoPProofForTest(t, privateKey, jwk, jwt.MapClaims{
+		"htm": "POST",
+		"htu": "https://api.supertokens.io/auth/signin",
+		"ath": getDPoPAccessTokenHash(accessToken),
+	}))
+
+	// the session is fetched, and the proof is verified, twice in the same request
+	userContext := &map[string]interface{}{}
+	_, err = GetSession(req, httptest.NewRecorder(), nil, userContext)
+	assert.NoError(t, err)
+	sessionContainer, err := CreateNewSessionForSignIn(req, httptest.NewRecorder(), "public", "userId", nil, nil, userContext)
+	assert.NoError(t, err)
+	assert.Equal(t, thumbprint, *sessionContainer.GetDPoPThumbprint())
+	assert.True(t, regenerated)
+
+	// the proof can't be used in another request
+	_, err = GetSession(req, httptest.NewRecorder(), nil, &map[string]interface{}{})
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+}
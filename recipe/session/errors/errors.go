@@ -25,6 +25,13 @@ const (
 	ClearDuplicateSessionCookiesErrorStr = "CLEAR_DUPLICATE_SESSION_COOKIES"
 )
 
+// Reasons why the session of a long-lived connection ends (see SessionEndedError)
+const (
+	SessionEndedRevoked            = "SESSION_REVOKED"
+	SessionEndedAccessTokenExpired = "ACCESS_TOKEN_EXPIRED"
+	SessionEndedInvalidClaims      = "INVALID_CLAIMS"
+)
+
 // TryRefreshTokenError used for when the refresh API needs to be called
 type TryRefreshTokenError struct {
 	Msg string
@@ -43,6 +50,7 @@ type TokenTheftDetectedError struct {
 type TokenTheftDetectedErrorPayload struct {
 	SessionHandle string
 	UserID        string
+	TenantId      string
 }
 
 func (err TokenTheftDetectedError) Error() string {
@@ -75,3 +83,25 @@ type ClearDuplicateSessionCookiesError struct {
 func (err ClearDuplicateSessionCookiesError) Error() string {
 	return err.Msg
 }
+
+// SessionEndedError is used when the session of a long-lived connection (see session.VerifyConnection) ends.
+// Reason is one of SessionEndedRevoked, SessionEndedAccessTokenExpired or SessionEndedInvalidClaims.
+type SessionEndedError struct {
+	Msg           string
+	Reason        string
+	InvalidClaims []claims.ClaimValidationError
+}
+
+func (err SessionEndedError) Error() string {
+	return err.Msg
+}
+
+// WebSocketCloseCode returns the code to close a WebSocket with when its session ends. 4401 tells the client to
+// refresh the session (which fails if it has been revoked) and reconnect, and 4403 that the session doesn't have
+// the claims that the connection requires.
+func (err SessionEndedError) WebSocketCloseCode() int {
+	if err.Reason == SessionEndedInvalidClaims {
+		return 4403
+	}
+	return 4401
+}
@@ -0,0 +1,289 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	"context"
+	defaultErrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// sessionEventDispatcher passes session events to the configured handler on a separate goroutine, through a
+// bounded queue
+type sessionEventDispatcher struct {
+	config sessmodels.SessionEventsConfig
+	lock   sync.Mutex
+	closed bool
+	queue  chan sessmodels.SessionEvent
+	done   chan struct{}
+}
+
+// newSessionEventDispatcher returns nil if no event handler is configured
+func newSessionEventDispatcher(config sessmodels.SessionEventsConfig) *sessionEventDispatcher {
+	if config.Handler == nil {
+		return nil
+	}
+	d := &sessionEventDispatcher{
+		config: config,
+		queue:  make(chan sessmodels.SessionEvent, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+func (d *sessionEventDispatcher) run() {
+	defer close(d.done)
+	for event := range d.queue {
+		d.handle(event)
+	}
+}
+
+func (d *sessionEventDispatcher) handle(event sessmodels.SessionEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			supertokens.LogMessage(supertokens.LogLevelError, nil, fmt.Sprint("session event handler panicked: ", r), "recipeId", RECIPE_ID, "event", string(event.Type))
+		}
+	}()
+	d.config.Handler(event)
+}
+
+// dispatch adds an event to the queue without blocking. The event is dropped if the queue is full or the
+// dispatcher has been shut down.
+func (d *sessionEventDispatcher) dispatch(eventType sessmodels.SessionEventType, userID string, tenantId string, sessionHandle string, impersonatorUserID string, reason string, userContext supertokens.UserContext) {
+	if d == nil {
+		return
+	}
+	event := sessmodels.SessionEvent{
+		Type:               eventType,
+		Time:               time.Now(),
+		UserID:             userID,
+		TenantId:           tenantId,
+		SessionHandle:      sessionHandle,
+		ImpersonatorUserID: impersonatorUserID,
+		Reason:             reason,
+	}
+	if req := supertokens.GetRequestFromUserContext(userContext); req != nil {
+		event.IPAddress = d.config.GetIPAddress(req)
+		event.UserAgent = req.UserAgent()
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.closed {
+		return
+	}
+	select {
+	case d.queue <- event:
+	default:
+		supertokens.LogMessage(supertokens.LogLevelWarn, userContext, "session event dropped because the queue is full", "recipeId", RECIPE_ID, "event", string(eventType), "session", supertokens.HashForLogging(sessionHandle))
+	}
+}
+
+// shutdown stops accepting events and waits until the queued events have been handled
+func (d *sessionEventDispatcher) shutdown(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	d.lock.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.lock.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func defaultGetIPAddress(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// withSessionEvents wraps the functions of recipeImpl that create, refresh and revoke sessions so that they
+// dispatch events. It wraps the overridden implementation, so that each call results in one event no matter
+// how the functions are overridden.
+func withSessionEvents(recipeImpl sessmodels.RecipeInterface, dispatcher *sessionEventDispatcher) sessmodels.RecipeInterface {
+	if dispatcher == nil {
+		return recipeImpl
+	}
+
+	oCreateNewSession := *recipeImpl.CreateNewSession
+	createNewSession := func(userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCsrf *bool, tenantId string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		session, err := oCreateNewSession(userID, accessTokenPayload, sessionDataInDatabase, disableAntiCsrf, tenantId, userContext)
+		if err == nil && session != nil {
+			dispatcher.dispatch(sessmodels.SessionCreatedEvent, session.GetUserIDWithContext(userContext), session.GetTenantIdWithContext(userContext), session.GetHandleWithContext(userContext), getImpersonatorUserID(accessTokenPayload), "", userContext)
+		}
+		return session, err
+	}
+
+	oRefreshSession := *recipeImpl.RefreshSession
+	refreshSession := func(refreshToken string, antiCSRFToken *string, disableAntiCSRF bool, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		session, err := oRefreshSession(refreshToken, antiCSRFToken, disableAntiCSRF, userContext)
+		if isSharedRefreshInUserContext(userContext) {
+			// the events are dispatched by the concurrent refresh that called the core
+			return session, err
+		}
+		if err == nil && session != nil {
+			dispatcher.dispatch(sessmodels.SessionRefreshedEvent, session.GetUserIDWithContext(userContext), session.GetTenantIdWithContext(userContext), session.GetHandleWithContext(userContext), getImpersonatorUserID(session.GetAccessTokenPayloadWithContext(userContext)), "", userContext)
+		}
+		var tokenTheftErr errors.TokenTheftDetectedError
+		if defaultErrors.As(err, &tokenTheftErr) {
+			dispatcher.dispatch(sessmodels.SessionTokenTheftDetectedEvent, tokenTheftErr.Payload.UserID, tokenTheftErr.Payload.TenantId, tokenTheftErr.Payload.SessionHandle, "", "", userContext)
+		}
+		return session, err
+	}
+
+	oRevokeSession := *recipeImpl.RevokeSession
+	revokeSession := func(sessionHandle string, userContext supertokens.UserContext) (bool, error) {
+		revoked, err := oRevokeSession(sessionHandle, userContext)
+		if err == nil && revoked {
+			// the user and tenant are only known if the caller had the session container, otherwise they are
+			// left empty rather than fetching the information of the session first
+			revokedSession := getSessionToRevokeFromUserContext(sessionHandle, userContext)
+			dispatcher.dispatch(sessmodels.SessionRevokedEvent, revokedSession.userID, revokedSession.tenantId, sessionHandle, revokedSession.impersonatorUserID, "REVOKE_SESSION", userContext)
+		}
+		return revoked, err
+	}
+
+	oRevokeAllSessionsForUser := *recipeImpl.RevokeAllSessionsForUser
+	revokeAllSessionsForUser := func(userID string, tenantId string, revokeAcrossAllTenants *bool, userContext supertokens.UserContext) ([]string, error) {
+		sessionHandles, err := oRevokeAllSessionsForUser(userID, tenantId, revokeAcrossAllTenants, userContext)
+		if err == nil {
+			for _, sessionHandle := range sessionHandles {
+				dispatcher.dispatch(sessmodels.SessionRevokedEvent, userID, tenantId, sessionHandle, "", "REVOKE_ALL_SESSIONS_FOR_USER", userContext)
+			}
+		}
+		return sessionHandles, err
+	}
+
+	oRevokeMultipleSessions := *recipeImpl.RevokeMultipleSessions
+	revokeMultipleSessions := func(sessionHandles []string, userContext supertokens.UserContext) ([]string, error) {
+		revokedSessionHandles, err := oRevokeMultipleSessions(sessionHandles, userContext)
+		// the core only returns the handles of the revoked sessions, so the user and tenant of these events
+		// are left empty rather than fetching the information of every session first
+		if err == nil {
+			for _, sessionHandle := range revokedSessionHandles {
+				dispatcher.dispatch(sessmodels.SessionRevokedEvent, "", "", sessionHandle, "", "REVOKE_MULTIPLE_SESSIONS", userContext)
+			}
+		}
+		return revokedSessionHandles, err
+	}
+
+	recipeImpl.CreateNewSession = &createNewSession
+	recipeImpl.RefreshSession = &refreshSession
+	recipeImpl.RevokeSession = &revokeSession
+	recipeImpl.RevokeAllSessionsForUser = &revokeAllSessionsForUser
+	recipeImpl.RevokeMultipleSessions = &revokeMultipleSessions
+	return recipeImpl
+}
+
+type sessionToRevoke struct {
+	userID             string
+	tenantId           string
+	impersonatorUserID string
+}
+
+// setSessionToRevokeInUserContext records the user and tenant of sessionContainer in userContext before it is
+// revoked, so that the session revoked event can include them without fetching the session from the core
+func setSessionToRevokeInUserContext(sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) {
+	setSessionInfoToRevokeInUserContext(sessionContainer.GetHandleWithContext(userContext), sessionToRevoke{
+		userID:             sessionContainer.GetUserIDWithContext(userContext),
+		tenantId:           sessionContainer.GetTenantIdWithContext(userContext),
+		impersonatorUserID: getImpersonatorUserID(sessionContainer.GetAccessTokenPayloadWithContext(userContext)),
+	}, userContext)
+}
+
+func setSessionInfoToRevokeInUserContext(sessionHandle string, session sessionToRevoke, userContext supertokens.UserContext) {
+	if userContext == nil {
+		return
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		defaultObj = map[string]interface{}{}
+		(*userContext)["_default"] = defaultObj
+	}
+	sessionsToRevoke, ok := defaultObj["sessionsToRevoke"].(map[string]sessionToRevoke)
+	if !ok {
+		sessionsToRevoke = map[string]sessionToRevoke{}
+		defaultObj["sessionsToRevoke"] = sessionsToRevoke
+	}
+	sessionsToRevoke[sessionHandle] = session
+}
+
+// getSessionToRevokeFromUserContext returns what setSessionToRevokeInUserContext recorded for sessionHandle, or
+// an empty sessionToRevoke if nothing was recorded
+func getSessionToRevokeFromUserContext(sessionHandle string, userContext supertokens.UserContext) sessionToRevoke {
+	if userContext == nil {
+		return sessionToRevoke{}
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		return sessionToRevoke{}
+	}
+	sessionsToRevoke, ok := defaultObj["sessionsToRevoke"].(map[string]sessionToRevoke)
+	if !ok {
+		return sessionToRevoke{}
+	}
+	result := sessionsToRevoke[sessionHandle]
+	delete(sessionsToRevoke, sessionHandle)
+	return result
+}
+
+// setSharedRefreshInUserContext records in userContext that a refresh used the call to the core of a concurrent
+// refresh of the same session, so that only the refresh that made the call dispatches events
+func setSharedRefreshInUserContext(userContext supertokens.UserContext) {
+	if userContext == nil {
+		return
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		defaultObj = map[string]interface{}{}
+		(*userContext)["_default"] = defaultObj
+	}
+	defaultObj["sharedRefresh"] = true
+}
+
+// isSharedRefreshInUserContext returns true if setSharedRefreshInUserContext was called with userContext, and
+// resets it for the next refresh made with it
+func isSharedRefreshInUserContext(userContext supertokens.UserContext) bool {
+	if userContext == nil {
+		return false
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	sharedRefresh, _ := defaultObj["sharedRefresh"].(bool)
+	delete(defaultObj, "sharedRefresh")
+	return sharedRefresh
+}
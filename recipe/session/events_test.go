@@ -0,0 +1,224 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+type sessionEventRecorder struct {
+	lock   sync.Mutex
+	events []sessmodels.SessionEvent
+}
+
+func (r *sessionEventRecorder) handle(event sessmodels.SessionEvent) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *sessionEventRecorder) getEvents() []sessmodels.SessionEvent {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]sessmodels.SessionEvent{}, r.events...)
+}
+
+func makeSessionContainerForEventsTest(userID string, tenantId string, sessionHandle string) sessmodels.SessionContainer {
+	return &sessmodels.TypeSessionContainer{
+		GetUserIDWithContext: func(userContext supertokens.UserContext) string {
+			return userID
+		},
+		GetTenantIdWithContext: func(userContext supertokens.UserContext) string {
+			return tenantId
+		},
+		GetHandleWithContext: func(userContext supertokens.UserContext) string {
+			return sessionHandle
+		},
+		GetAccessTokenPayloadWithContext: func(userContext supertokens.UserContext) map[string]interface{} {
+			return map[string]interface{}{}
+		},
+	}
+}
+
+// makeRecipeImplementationForEventsTest returns a recipe implementation that doesn't call the core
+func makeRecipeImplementationForEventsTest() sessmodels.RecipeInterface {
+	createNewSession := func(userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCsrf *bool, tenantId string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		return makeSessionContainerForEventsTest(userID, tenantId, "handle"), nil
+	}
+	refreshSession := func(refreshToken string, antiCSRFToken *string, disableAntiCSRF bool, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		if refreshToken == "stolen" {
+			return nil, errors.TokenTheftDetectedError{
+				Msg:     "Token theft detected",
+				Payload: errors.TokenTheftDetectedErrorPayload{SessionHandle: "handle", UserID: "userId", TenantId: "tenant"},
+			}
+		}
+		return makeSessionContainerForEventsTest("userId", "tenant", "handle"), nil
+	}
+	revokeSession := func(sessionHandle string, userContext supertokens.UserContext) (bool, error) {
+		return sessionHandle != "unknown", nil
+	}
+	revokeAllSessionsForUser := func(userID string, tenantId string, revokeAcrossAllTenants *bool, userContext supertokens.UserContext) ([]string, error) {
+		return []string{"handle-1", "handle-2"}, nil
+	}
+	revokeMultipleSessions := func(sessionHandles []string, userContext supertokens.UserContext) ([]string, error) {
+		return sessionHandles[:1], nil
+	}
+	return sessmodels.RecipeInterface{
+		CreateNewSession:         &createNewSession,
+		RefreshSession:           &refreshSession,
+		RevokeSession:            &revokeSession,
+		RevokeAllSessionsForUser: &revokeAllSessionsForUser,
+		RevokeMultipleSessions:   &revokeMultipleSessions,
+	}
+}
+
+func TestThatSessionEventsAreDispatched(t *testing.T) {
+	recorder := &sessionEventRecorder{}
+	dispatcher := newSessionEventDispatcher(sessmodels.SessionEventsConfig{
+		Handler:      recorder.handle,
+		QueueSize:    10,
+		GetIPAddress: defaultGetIPAddress,
+	})
+	recipeImpl := withSessionEvents(makeRecipeImplementationForEventsTest(), dispatcher)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/signin", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "test-agent")
+	userContext := supertokens.MakeDefaultUserContextFromAPI(req)
+
+	_, err := (*recipeImpl.CreateNewSession)("userId", nil, nil, nil, "tenant", userContext)
+	assert.NoError(t, err)
+	_, err = (*recipeImpl.RefreshSession)("refresh", nil, false, &map[string]interface{}{})
+	assert.NoError(t, err)
+	_, err = (*recipeImpl.RefreshSession)("stolen", nil, false, &map[string]interface{}{})
+	assert.Error(t, err)
+	// the session container is known when a session is revoked by signing out
+	revokeUserContext := &map[string]interface{}{}
+	setSessionToRevokeInUserContext(makeSessionContainerForEventsTest("userId", "tenant", "handle"), revokeUserContext)
+	revoked, err := (*recipeImpl.RevokeSession)("handle", revokeUserContext)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = (*recipeImpl.RevokeSession)("unknown", &map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	// the session isn't fetched to fill in the user and tenant if only the handle is known
+	revoked, err = (*recipeImpl.RevokeSession)("other", &map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	_, err = (*recipeImpl.RevokeAllSessionsForUser)("userId", "tenant", nil, &map[string]interface{}{})
+	assert.NoError(t, err)
+	_, err = (*recipeImpl.RevokeMultipleSessions)([]string{"handle-3", "handle-4"}, &map[string]interface{}{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, dispatcher.shutdown(context.Background()))
+
+	events := recorder.getEvents()
+	assert.Equal(t, 8, len(events))
+	assert.Equal(t, sessmodels.SessionCreatedEvent, events[0].Type)
+	assert.Equal(t, "userId", events[0].UserID)
+	assert.Equal(t, "tenant", events[0].TenantId)
+	assert.Equal(t, "handle", events[0].SessionHandle)
+	assert.Equal(t, "10.0.0.1", events[0].IPAddress)
+	assert.Equal(t, "test-agent", events[0].UserAgent)
+	assert.False(t, events[0].Time.IsZero())
+
+	assert.Equal(t, sessmodels.SessionRefreshedEvent, events[1].Type)
+	assert.Equal(t, "", events[1].IPAddress)
+	assert.Equal(t, sessmodels.SessionTokenTheftDetectedEvent, events[2].Type)
+	assert.Equal(t, "userId", events[2].UserID)
+	assert.Equal(t, "tenant", events[2].TenantId)
+	assert.Equal(t, "handle", events[2].SessionHandle)
+
+	assert.Equal(t, sessmodels.SessionEvent{
+		Type: sessmodels.SessionRevokedEvent, Time: events[3].Time, UserID: "userId", TenantId: "tenant", SessionHandle: "handle", Reason: "REVOKE_SESSION",
+	}, events[3])
+	assert.Equal(t, sessmodels.SessionEvent{
+		Type: sessmodels.SessionRevokedEvent, Time: events[4].Time, SessionHandle: "other", Reason: "REVOKE_SESSION",
+	}, events[4])
+	assert.Equal(t, "handle-1", events[5].SessionHandle)
+	assert.Equal(t, "REVOKE_ALL_SESSIONS_FOR_USER", events[5].Reason)
+	assert.Equal(t, "handle-2", events[6].SessionHandle)
+	assert.Equal(t, sessmodels.SessionEvent{
+		Type: sessmodels.SessionRevokedEvent, Time: events[7].Time, SessionHandle: "handle-3", Reason: "REVOKE_MULTIPLE_SESSIONS",
+	}, events[7])
+}
+
+func TestThatSessionEventsAreDroppedWhenTheQueueIsFull(t *testing.T) {
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	recorder := &sessionEventRecorder{}
+	dispatcher := newSessionEventDispatcher(sessmodels.SessionEventsConfig{
+		Handler: func(event sessmodels.SessionEvent) {
+			if event.SessionHandle == "handle-0" {
+				close(handling)
+				<-release
+			}
+			if event.SessionHandle == "handle-1" {
+				panic("handler failed")
+			}
+			recorder.handle(event)
+		},
+		QueueSize:    2,
+		GetIPAddress: defaultGetIPAddress,
+	})
+
+	dispatcher.dispatch(sessmodels.SessionCreatedEvent, "userId", "public", "handle-0", "", "", nil)
+	<-handling
+	// the handler is busy with the first event, so only 2 more events fit in the queue, and dispatching
+	// doesn't block
+	start := time.Now()
+	for _, sessionHandle := range []string{"handle-1", "handle-2", "handle-3", "handle-4"} {
+		dispatcher.dispatch(sessmodels.SessionCreatedEvent, "userId", "public", sessionHandle, "", "", nil)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	close(release)
+
+	assert.NoError(t, dispatcher.shutdown(context.Background()))
+	// events dispatched after shutdown are ignored
+	dispatcher.dispatch(sessmodels.SessionCreatedEvent, "userId", "public", "handle-5", "", "", nil)
+
+	handles := []string{}
+	for _, event := range recorder.getEvents() {
+		handles = append(handles, event.SessionHandle)
+	}
+	// handle-1 made the handler panic, which doesn't stop the events after it from being handled
+	assert.Equal(t, []string{"handle-0", "handle-2"}, handles)
+}
+
+func TestThatShutdownStopsWaitingForSessionEventsWhenTheContextIsDone(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	dispatcher := newSessionEventDispatcher(sessmodels.SessionEventsConfig{
+		Handler: func(event sessmodels.SessionEvent) {
+			<-release
+		},
+		QueueSize:    1,
+		GetIPAddress: defaultGetIPAddress,
+	})
+	dispatcher.dispatch(sessmodels.SessionCreatedEvent, "userId", "public", "handle", "", "", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, dispatcher.shutdown(ctx))
+}
+
+func TestSessionEventsConfigDefaults(t *testing.T) {
+	assert.Nil(t, newSessionEventDispatcher(sessmodels.SessionEventsConfig{}))
+
+	config, err := ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{Events: &sessmodels.SessionEventsConfig{Handler: func(event sessmodels.SessionEvent) {}}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, config.Events.QueueSize)
+	assert.NotNil(t, config.Events.Handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "[::1]:80"
+	assert.Equal(t, "::1", config.Events.GetIPAddress(req))
+}
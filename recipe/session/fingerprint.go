@@ -0,0 +1,184 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	defaultErrors "errors"
+	"net"
+	"net/http"
+	"sort"
+
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// GetIPAddressPrefix returns the network of ipAddress, using the first ipv4PrefixLength bits of IPv4
+// addresses and the first ipv6PrefixLength bits of IPv6 addresses, or ipAddress itself if it can't be parsed
+func GetIPAddressPrefix(ipAddress string, ipv4PrefixLength int, ipv6PrefixLength int) string {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return ipAddress
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return (&net.IPNet{IP: ipv4.Mask(net.CIDRMask(ipv4PrefixLength, 32)), Mask: net.CIDRMask(ipv4PrefixLength, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(ipv6PrefixLength, 128)), Mask: net.CIDRMask(ipv6PrefixLength, 128)}).String()
+}
+
+func makeDefaultGetFingerprint(getIPAddress func(req *http.Request) string) func(req *http.Request, userContext supertokens.UserContext) map[string]string {
+	return func(req *http.Request, userContext supertokens.UserContext) map[string]string {
+		return map[string]string{
+			"ip": GetIPAddressPrefix(getIPAddress(req), 24, 64),
+			"ua": req.UserAgent(),
+		}
+	}
+}
+
+// getFingerprintForPayload returns the fingerprint of req as it is stored in the access token payload, which
+// the frontend can read. The parts are hashed using HMAC-SHA256 with FingerprintBinding.HashKey, so that
+// someone who has the access token can't find out values like the IP address prefix by trying all of them.
+func getFingerprintForPayload(config sessmodels.TypeNormalisedInput, req *http.Request, userContext supertokens.UserContext) map[string]interface{} {
+	fingerprint := map[string]interface{}{}
+	for name, value := range config.FingerprintBinding.GetFingerprint(req, userContext) {
+		mac := hmac.New(sha256.New, []byte(config.FingerprintBinding.HashKey))
+		mac.Write([]byte(value))
+		fingerprint[name] = base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+	}
+	return fingerprint
+}
+
+// getMismatchedFingerprintParts compares the fingerprint in an access token payload with the fingerprint of
+// req. It returns nil if the payload has no fingerprint, which is the case for sessions created without a
+// request or before FingerprintBinding was enabled.
+func getMismatchedFingerprintParts(config sessmodels.TypeNormalisedInput, payload map[string]interface{}, req *http.Request, userContext supertokens.UserContext) []string {
+	sessionFingerprint, ok := payload[fingerprintKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	requestFingerprint := getFingerprintForPayload(config, req, userContext)
+	mismatchedParts := []string{}
+	for name, value := range sessionFingerprint {
+		if requestFingerprint[name] != value {
+			mismatchedParts = append(mismatchedParts, name)
+		}
+	}
+	for name := range requestFingerprint {
+		if _, ok := sessionFingerprint[name]; !ok {
+			mismatchedParts = append(mismatchedParts, name)
+		}
+	}
+	sort.Strings(mismatchedParts)
+	return mismatchedParts
+}
+
+// isFingerprintMismatchAllowed returns true if the request whose fingerprint differs in mismatchedParts must
+// be accepted anyway, and logs the mismatch
+func isFingerprintMismatchAllowed(config sessmodels.TypeNormalisedInput, mismatchedParts []string, sessionHandle string, req *http.Request, userContext supertokens.UserContext) bool {
+	if len(mismatchedParts) == 0 {
+		return true
+	}
+	if config.FingerprintBinding.AllowMismatch != nil && config.FingerprintBinding.AllowMismatch(mismatchedParts, req, userContext) {
+		return true
+	}
+	supertokens.LogMessage(supertokens.LogLevelWarn, userContext, "fingerprint of the request does not match the session", "recipeId", RECIPE_ID, "session", supertokens.HashForLogging(sessionHandle), "mismatchedParts", mismatchedParts, "mode", string(config.FingerprintBinding.Mode))
+	return config.FingerprintBinding.Mode == sessmodels.FingerprintLogOnly
+}
+
+// makeFingerprintValidator returns the claim validator that compares the fingerprint of the session with the
+// fingerprint of req
+func makeFingerprintValidator(config sessmodels.TypeNormalisedInput, req *http.Request, sessionHandle string) claims.SessionClaimValidator {
+	return claims.SessionClaimValidator{
+		ID: fingerprintKey,
+		Validate: func(payload map[string]interface{}, userContext supertokens.UserContext) claims.ClaimValidationResult {
+			mismatchedParts := getMismatchedFingerprintParts(config, payload, req, userContext)
+			if isFingerprintMismatchAllowed(config, mismatchedParts, sessionHandle, req, userContext) {
+				return claims.ClaimValidationResult{IsValid: true}
+			}
+			return claims.ClaimValidationResult{
+				IsValid: false,
+				Reason: map[string]interface{}{
+					"message":         "fingerprint mismatch",
+					"mismatchedParts": mismatchedParts,
+				},
+			}
+		},
+	}
+}
+
+// handleFingerprintValidationError applies the FingerprintBinding mode to an error returned while asserting the
+// claims of a session in GetSessionFromRequest or VerifyConnection
+func handleFingerprintValidationError(err error, config sessmodels.TypeNormalisedInput, sessionContainer sessmodels.SessionContainer, recipeImpl sessmodels.RecipeInterface, userContext supertokens.UserContext) error {
+	invalidClaimError := errors.InvalidClaimError{}
+	if !defaultErrors.As(err, &invalidClaimError) {
+		return err
+	}
+	fingerprintFailed := false
+	for _, invalidClaim := range invalidClaimError.InvalidClaims {
+		if invalidClaim.ID == fingerprintKey {
+			fingerprintFailed = true
+		}
+	}
+	if !fingerprintFailed {
+		return err
+	}
+
+	if config.FingerprintBinding.Mode == sessmodels.FingerprintRevoke {
+		setSessionToRevokeInUserContext(sessionContainer, userContext)
+		_, revokeErr := (*recipeImpl.RevokeSession)(sessionContainer.GetHandleWithContext(userContext), userContext)
+		if revokeErr != nil {
+			return revokeErr
+		}
+	} else if config.FingerprintBinding.Mode == sessmodels.FingerprintForceRefresh && len(invalidClaimError.InvalidClaims) == 1 {
+		return errors.TryRefreshTokenError{
+			Msg: "fingerprint of the request does not match the session, please call the refresh API",
+		}
+	}
+	return err
+}
+
+// bindRefreshedSessionToFingerprint stores the fingerprint of req in the access token payload of a session that
+// was just refreshed, or revokes it if the fingerprint doesn't match in the FingerprintRevoke mode
+func bindRefreshedSessionToFingerprint(config sessmodels.TypeNormalisedInput, sessionContainer sessmodels.SessionContainer, req *http.Request, recipeImpl sessmodels.RecipeInterface, userContext supertokens.UserContext) error {
+	payload := sessionContainer.GetAccessTokenPayloadWithContext(userContext)
+	sessionHandle := sessionContainer.GetHandleWithContext(userContext)
+	if _, ok := payload[fingerprintKey]; ok {
+		mismatchedParts := getMismatchedFingerprintParts(config, payload, req, userContext)
+		if len(mismatchedParts) == 0 {
+			return nil
+		}
+		if !isFingerprintMismatchAllowed(config, mismatchedParts, sessionHandle, req, userContext) && config.FingerprintBinding.Mode == sessmodels.FingerprintRevoke {
+			setSessionToRevokeInUserContext(sessionContainer, userContext)
+			_, err := (*recipeImpl.RevokeSession)(sessionHandle, userContext)
+			if err != nil {
+				return err
+			}
+			clearTokens := true
+			return errors.UnauthorizedError{
+				Msg:         "fingerprint of the request does not match the session",
+				ClearTokens: &clearTokens,
+			}
+		}
+	}
+	setSessionManagedPropsInUserContext(map[string]interface{}{
+		fingerprintKey: getFingerprintForPayload(config, req, userContext),
+	}, userContext)
+	return sessionContainer.MergeIntoAccessTokenPayloadWithContext(map[string]interface{}{}, userContext)
+}
@@ -0,0 +1,201 @@
+package session
+
+import (
+	defaultErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func TestGetIPAddressPrefix(t *testing.T) {
+	assert.Equal(t, "203.0.113.0/24", GetIPAddressPrefix("203.0.113.77", 24, 64))
+	assert.Equal(t, "203.0.0.0/16", GetIPAddressPrefix("203.0.113.77", 16, 64))
+	assert.Equal(t, "2001:db8:1:2::/64", GetIPAddressPrefix("2001:db8:1:2:3::1", 24, 64))
+	assert.Equal(t, "unknown", GetIPAddressPrefix("unknown", 24, 64))
+}
+
+func TestThatFingerprintBindingModeIsValidated(t *testing.T) {
+	config, err := ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{FingerprintBinding: &sessmodels.FingerprintBindingConfig{HashKey: "secret"}})
+	assert.NoError(t, err)
+	assert.Equal(t, sessmodels.FingerprintLogOnly, config.FingerprintBinding.Mode)
+	assert.NotNil(t, config.FingerprintBinding.GetFingerprint)
+
+	_, err = ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{FingerprintBinding: &sessmodels.FingerprintBindingConfig{Mode: "BLOCK", HashKey: "secret"}})
+	assert.Error(t, err)
+
+	_, err = ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{FingerprintBinding: &sessmodels.FingerprintBindingConfig{}})
+	assert.EqualError(t, err, "please provide a HashKey in FingerprintBinding")
+
+	config, err = ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{})
+	assert.NoError(t, err)
+	assert.Nil(t, config.FingerprintBinding)
+}
+
+func makeRequestForFingerprintTest(accessToken string, remoteAddr string, userAgent string) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("User-Agent", userAgent)
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return req
+}
+
+func TestFingerprintBindingModes(t *testing.T) {
+	for _, mode := range []sessmodels.FingerprintMismatchMode{sessmodels.FingerprintLogOnly, sessmodels.FingerprintForceRefresh, sessmodels.FingerprintRevoke} {
+		t.Run(string(mode), func(t *testing.T) {
+			revokedSessionHandles := []string{}
+			privateKey, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+				FingerprintBinding: &sessmodels.FingerprintBindingConfig{
+					Mode:    mode,
+					HashKey: "secret",
+					AllowMismatch: func(mismatchedParts []string, req *http.Request, userContext supertokens.UserContext) bool {
+						// mobile clients can change networks
+						return len(mismatchedParts) == 1 && mismatchedParts[0] == "ip" && req.UserAgent() == "mobile-app"
+					},
+				},
+				Override: &sessmodels.OverrideStruct{
+					Functions: func(originalImplementation sessmodels.RecipeInterface) sessmodels.RecipeInterface {
+						(*originalImplementation.RevokeSession) = func(sessionHandle string, userContext supertokens.UserContext) (bool, error) {
+							revokedSessionHandles = append(revokedSessionHandles, sessionHandle)
+							return true, nil
+						}
+						return originalImplementation
+					},
+				},
+			})
+			defer cleanup()
+
+			instance, err := getRecipeInstanceOrThrowError()
+			assert.NoError(t, err)
+
+			fingerprint := getFingerprintForPayload(instance.Config, makeRequestForFingerprintTest("", "203.0.113.1:1234", "browser"), &map[string]interface{}{})
+			accessToken := makeAccessTokenForTest(t, privateKey, map[string]interface{}{fingerprintKey: fingerprint})
+
+			// same network and user agent
+			_, err = GetSession(makeRequestForFingerprintTest(accessToken, "203.0.113.200:1234", "browser"), httptest.NewRecorder(), nil)
+			assert.NoError(t, err)
+
+			// sessions without a fingerprint are accepted
+			_, err = GetSession(makeRequestForFingerprintTest(makeAccessTokenForTest(t, privateKey, nil), "198.51.100.1:1234", "other-browser"), httptest.NewRecorder(), nil)
+			assert.NoError(t, err)
+
+			// allowed by AllowMismatch
+			mobileFingerprint := getFingerprintForPayload(instance.Config, makeRequestForFingerprintTest("", "203.0.113.1:1234", "mobile-app"), &map[string]interface{}{})
+			mobileAccessToken := makeAccessTokenForTest(t, privateKey, map[string]interface{}{fingerprintKey: mobileFingerprint})
+			_, err = GetSession(makeRequestForFingerprintTest(mobileAccessToken, "198.51.100.1:1234", "mobile-app"), httptest.NewRecorder(), nil)
+			assert.NoError(t, err)
+
+			_, err = GetSession(makeRequestForFingerprintTest(accessToken, "198.51.100.1:1234", "browser"), httptest.NewRecorder(), nil)
+			switch mode {
+			case sessmodels.FingerprintLogOnly:
+				assert.NoError(t, err)
+				assert.Empty(t, revokedSessionHandles)
+			case sessmodels.FingerprintForceRefresh:
+				assert.True(t, defaultErrors.As(err, &errors.TryRefreshTokenError{}))
+				assert.Empty(t, revokedSessionHandles)
+			case sessmodels.FingerprintRevoke:
+				invalidClaimError := errors.InvalidClaimError{}
+				assert.True(t, defaultErrors.As(err, &invalidClaimError))
+				assert.Len(t, invalidClaimError.InvalidClaims, 1)
+				assert.Equal(t, fingerprintKey, invalidClaimError.InvalidClaims[0].ID)
+				assert.Equal(t, []string{"ip"}, invalidClaimError.InvalidClaims[0].Reason.(map[string]interface{})["mismatchedParts"])
+				assert.Equal(t, []string{"handle"}, revokedSessionHandles)
+			}
+		})
+	}
+}
+
+func TestThatRefreshBindsTheSessionToTheNewFingerprint(t *testing.T) {
+	config, err := ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{
+		FingerprintBinding: &sessmodels.FingerprintBindingConfig{Mode: sessmodels.FingerprintForceRefresh, HashKey: "secret"},
+	})
+	assert.NoError(t, err)
+	userContext := &map[string]interface{}{}
+
+	oldFingerprint := getFingerprintForPayload(config, makeRequestForFingerprintTest("", "203.0.113.1:1234", "browser"), userContext)
+	newReq := makeRequestForFingerprintTest("", "198.51.100.1:1234", "browser")
+	newFingerprint := getFingerprintForPayload(config, newReq, userContext)
+
+	var mergedPayload map[string]interface{}
+	sessionContainer := &sessmodels.TypeSessionContainer{
+		GetHandleWithContext: func(userContext supertokens.UserContext) string {
+			return "handle"
+		},
+		GetUserIDWithContext: func(userContext supertokens.UserContext) string {
+			return "userId"
+		},
+		GetTenantIdWithContext: func(userContext supertokens.UserContext) string {
+			return "public"
+		},
+		GetAccessTokenPayloadWithContext: func(userContext supertokens.UserContext) map[string]interface{} {
+			return map[string]interface{}{fingerprintKey: oldFingerprint}
+		},
+		MergeIntoAccessTokenPayloadWithContext: func(accessTokenPayloadUpdate map[string]interface{}, userContext supertokens.UserContext) error {
+			mergedPayload = map[string]interface{}{}
+			mergeIntoAccessTokenPayloadWithoutProtectedProps(mergedPayload, accessTokenPayloadUpdate, userContext)
+			return nil
+		},
+	}
+
+	err = bindRefreshedSessionToFingerprint(config, sessionContainer, newReq, sessmodels.RecipeInterface{}, userContext)
+	assert.NoError(t, err)
+	assert.Equal(t, newFingerprint, mergedPayload[fingerprintKey])
+
+	config.FingerprintBinding.Mode = sessmodels.FingerprintRevoke
+	revokedSessionHandles := []string{}
+	revokeSession := func(sessionHandle string, userContext supertokens.UserContext) (bool, error) {
+		revokedSessionHandles = append(revokedSessionHandles, sessionHandle)
+		return true, nil
+	}
+	mergedPayload = nil
+	err = bindRefreshedSessionToFingerprint(config, sessionContainer, newReq, sessmodels.RecipeInterface{RevokeSession: &revokeSession}, userContext)
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+	assert.Equal(t, []string{"handle"}, revokedSessionHandles)
+	assert.Nil(t, mergedPayload)
+}
+
+func TestThatFingerprintsAreHashedWithTheHashKey(t *testing.T) {
+	req := makeRequestForFingerprintTest("", "203.0.113.1:1234", "browser")
+	makeConfig := func(hashKey string) sessmodels.TypeNormalisedInput {
+		config, err := ValidateAndNormaliseUserInput(supertokens.NormalisedAppinfo{}, &sessmodels.TypeInput{
+			FingerprintBinding: &sessmodels.FingerprintBindingConfig{HashKey: hashKey},
+		})
+		assert.NoError(t, err)
+		return config
+	}
+
+	withKey := getFingerprintForPayload(makeConfig("secret"), req, &map[string]interface{}{})
+	assert.Len(t, withKey, 2)
+	assert.Equal(t, withKey, getFingerprintForPayload(makeConfig("secret"), req, &map[string]interface{}{}))
+	assert.NotEqual(t, withKey, getFingerprintForPayload(makeConfig("other-secret"), req, &map[string]interface{}{}))
+}
+
+func TestThatVerifyConnectionChecksTheFingerprintOfAccessTokensInQueryParams(t *testing.T) {
+	privateKey, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+		FingerprintBinding: &sessmodels.FingerprintBindingConfig{Mode: sessmodels.FingerprintForceRefresh, HashKey: "secret"},
+	})
+	defer cleanup()
+	instance, err := getRecipeInstanceOrThrowError()
+	assert.NoError(t, err)
+
+	fingerprint := getFingerprintForPayload(instance.Config, makeRequestForFingerprintTest("", "203.0.113.1:1234", "browser"), &map[string]interface{}{})
+	accessToken := makeAccessTokenForTest(t, privateKey, map[string]interface{}{fingerprintKey: fingerprint})
+	options := &sessmodels.VerifyConnectionOptions{AccessTokenQueryParam: "token"}
+
+	req := makeRequestForFingerprintTest("", "203.0.113.1:1234", "browser")
+	req.URL.RawQuery = "token=" + accessToken
+	connection, err := VerifyConnection(req, httptest.NewRecorder(), options)
+	assert.NoError(t, err)
+	connection.Close()
+
+	req = makeRequestForFingerprintTest("", "203.0.113.1:1234", "other-browser")
+	req.URL.RawQuery = "token=" + accessToken
+	_, err = VerifyConnection(req, httptest.NewRecorder(), options)
+	assert.True(t, defaultErrors.As(err, &errors.TryRefreshTokenError{}))
+}
@@ -0,0 +1,188 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	defaultErrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// CreateImpersonationSession creates a session for targetUserId on behalf of adminUserId, for example so that
+// support staff can see what a customer sees. The access token payload has an "act" claim naming the admin
+// (see GetImpersonatorUserID). The session expires after ttl and can't be refreshed, so ttl is also limited by
+// the validity of access tokens. Use RequireNoImpersonation to keep impersonation sessions away from sensitive
+// APIs.
+func CreateImpersonationSession(req *http.Request, res http.ResponseWriter, adminUserId string, targetUserId string, tenantId string, ttl time.Duration, userContext ...supertokens.UserContext) (sessmodels.SessionContainer, error) {
+	accessTokenPayload, err := makeImpersonationAccessTokenPayload(adminUserId, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if len(userContext) == 0 || userContext[0] == nil {
+		userContext = []supertokens.UserContext{supertokens.SetContextInUserContext(nil, req.Context())}
+	}
+	// the impersonation claims are protected, so they can't be passed as a part of the access token payload
+	setSessionManagedPropsInUserContext(accessTokenPayload, userContext[0])
+	sessionContainer, err := CreateNewSession(req, res, tenantId, targetUserId, map[string]interface{}{}, map[string]interface{}{}, userContext...)
+	if err != nil {
+		return nil, err
+	}
+	logImpersonationSessionCreated(sessionContainer, adminUserId, targetUserId, tenantId, userContext...)
+	return sessionContainer, nil
+}
+
+// CreateImpersonationSessionWithoutRequestResponse is like CreateImpersonationSession, for when the tokens are
+// not sent in an HTTP response
+func CreateImpersonationSessionWithoutRequestResponse(adminUserId string, targetUserId string, tenantId string, ttl time.Duration, userContext ...supertokens.UserContext) (sessmodels.SessionContainer, error) {
+	accessTokenPayload, err := makeImpersonationAccessTokenPayload(adminUserId, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if len(userContext) == 0 || userContext[0] == nil {
+		userContext = []supertokens.UserContext{&map[string]interface{}{}}
+	}
+	setSessionManagedPropsInUserContext(accessTokenPayload, userContext[0])
+	sessionContainer, err := CreateNewSessionWithoutRequestResponse(tenantId, targetUserId, map[string]interface{}{}, map[string]interface{}{}, nil, userContext...)
+	if err != nil {
+		return nil, err
+	}
+	logImpersonationSessionCreated(sessionContainer, adminUserId, targetUserId, tenantId, userContext...)
+	return sessionContainer, nil
+}
+
+// GetImpersonatorUserID returns the ID of the admin who created the impersonation session with
+// accessTokenPayload, or nil if it isn't an impersonation session
+func GetImpersonatorUserID(accessTokenPayload map[string]interface{}) *string {
+	impersonatorUserID := getImpersonatorUserID(accessTokenPayload)
+	if impersonatorUserID == "" {
+		return nil
+	}
+	return &impersonatorUserID
+}
+
+// RequireNoImpersonation returns a claim validator that fails for impersonation sessions. Add it to the
+// VerifySessionOptions of APIs that admins must not call on behalf of users, like changing the password.
+func RequireNoImpersonation() claims.SessionClaimValidator {
+	return claims.SessionClaimValidator{
+		ID: impersonationActorKey,
+		Validate: func(payload map[string]interface{}, userContext supertokens.UserContext) claims.ClaimValidationResult {
+			if impersonatorUserID := getImpersonatorUserID(payload); impersonatorUserID != "" {
+				return claims.ClaimValidationResult{
+					IsValid: false,
+					Reason: map[string]interface{}{
+						"message":            "not allowed in an impersonation session",
+						"impersonatorUserId": impersonatorUserID,
+					},
+				}
+			}
+			return claims.ClaimValidationResult{IsValid: true}
+		},
+	}
+}
+
+func makeImpersonationAccessTokenPayload(adminUserId string, ttl time.Duration) (map[string]interface{}, error) {
+	if adminUserId == "" {
+		return nil, defaultErrors.New("adminUserId must not be empty")
+	}
+	if ttl <= 0 {
+		return nil, defaultErrors.New("ttl must be positive")
+	}
+	return map[string]interface{}{
+		impersonationActorKey: map[string]interface{}{
+			"sub": adminUserId,
+		},
+		impersonationExpiryKey: float64(time.Now().Add(ttl).UnixNano() / 1000000),
+	}, nil
+}
+
+func logImpersonationSessionCreated(sessionContainer sessmodels.SessionContainer, adminUserId string, targetUserId string, tenantId string, userContext ...supertokens.UserContext) {
+	var _userContext supertokens.UserContext
+	if len(userContext) > 0 {
+		_userContext = userContext[0]
+	}
+	supertokens.LogMessage(supertokens.LogLevelInfo, _userContext, "impersonation session created", "recipeId", RECIPE_ID, "tenantId", tenantId, "session", supertokens.HashForLogging(sessionContainer.GetHandleWithContext(_userContext)), "impersonatorUserId", adminUserId, "userId", targetUserId)
+}
+
+func getImpersonatorUserID(accessTokenPayload map[string]interface{}) string {
+	actor, ok := accessTokenPayload[impersonationActorKey].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	impersonatorUserID, _ := actor["sub"].(string)
+	return impersonatorUserID
+}
+
+// isImpersonationSessionExpired returns true for impersonation sessions whose ttl has passed
+func isImpersonationSessionExpired(accessTokenPayload map[string]interface{}) bool {
+	if getImpersonatorUserID(accessTokenPayload) == "" {
+		return false
+	}
+	expiry := sanitizeNumberInputAsUint64(accessTokenPayload[impersonationExpiryKey])
+	return expiry == nil || *expiry <= GetCurrTimeInMS()
+}
+
+// withImpersonation wraps the functions of recipeImpl that get and refresh sessions so that impersonation
+// sessions are revoked when they expire or when they are refreshed
+func withImpersonation(recipeImpl sessmodels.RecipeInterface) sessmodels.RecipeInterface {
+	revokeSession := func(sessionContainer sessmodels.SessionContainer, msg string, userContext supertokens.UserContext) error {
+		sessionHandle := sessionContainer.GetHandleWithContext(userContext)
+		setSessionToRevokeInUserContext(sessionContainer, userContext)
+		_, err := (*recipeImpl.RevokeSession)(sessionHandle, userContext)
+		if err != nil {
+			return err
+		}
+		supertokens.LogMessage(supertokens.LogLevelInfo, userContext, msg, "recipeId", RECIPE_ID, "session", supertokens.HashForLogging(sessionHandle), "impersonatorUserId", getImpersonatorUserID(sessionContainer.GetAccessTokenPayloadWithContext(userContext)))
+		clearTokens := true
+		return errors.UnauthorizedError{
+			Msg:         msg,
+			ClearTokens: &clearTokens,
+		}
+	}
+
+	oGetSession := *recipeImpl.GetSession
+	getSession := func(accessToken *string, antiCSRFToken *string, options *sessmodels.VerifySessionOptions, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		session, err := oGetSession(accessToken, antiCSRFToken, options, userContext)
+		if err != nil || session == nil {
+			return session, err
+		}
+		if isImpersonationSessionExpired(session.GetAccessTokenPayloadWithContext(userContext)) {
+			return nil, revokeSession(session, "impersonation session has expired", userContext)
+		}
+		return session, nil
+	}
+
+	oRefreshSession := *recipeImpl.RefreshSession
+	refreshSession := func(refreshToken string, antiCSRFToken *string, disableAntiCSRF bool, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		session, err := oRefreshSession(refreshToken, antiCSRFToken, disableAntiCSRF, userContext)
+		if err != nil || session == nil {
+			return session, err
+		}
+		if getImpersonatorUserID(session.GetAccessTokenPayloadWithContext(userContext)) != "" {
+			// the refresh token can't be revoked before it is used, since it doesn't say which session it belongs to
+			return nil, revokeSession(session, "impersonation sessions can't be refreshed", userContext)
+		}
+		return session, nil
+	}
+
+	recipeImpl.GetSession = &getSession
+	recipeImpl.RefreshSession = &refreshSession
+	return recipeImpl
+}
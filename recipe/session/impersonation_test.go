@@ -0,0 +1,174 @@
+package session
+
+import (
+	defaultErrors "errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func TestImpersonationAccessTokenPayload(t *testing.T) {
+	_, err := makeImpersonationAccessTokenPayload("", time.Minute)
+	assert.Error(t, err)
+	_, err = makeImpersonationAccessTokenPayload("admin", 0)
+	assert.Error(t, err)
+
+	payload, err := makeImpersonationAccessTokenPayload("admin", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"sub": "admin"}, payload["act"])
+	assert.Equal(t, "admin", *GetImpersonatorUserID(payload))
+	assert.False(t, isImpersonationSessionExpired(payload))
+
+	payload[impersonationExpiryKey] = float64(time.Now().Add(-time.Second).UnixNano() / 1000000)
+	assert.True(t, isImpersonationSessionExpired(payload))
+
+	assert.Nil(t, GetImpersonatorUserID(map[string]interface{}{}))
+	assert.False(t, isImpersonationSessionExpired(map[string]interface{}{}))
+}
+
+func TestRequireNoImpersonation(t *testing.T) {
+	validator := RequireNoImpersonation()
+	assert.True(t, validator.Validate(map[string]interface{}{}, nil).IsValid)
+
+	result := validator.Validate(map[string]interface{}{"act": map[string]interface{}{"sub": "admin"}}, nil)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, "admin", result.Reason.(map[string]interface{})["impersonatorUserId"])
+}
+
+func TestThatExpiredImpersonationSessionsAreRevoked(t *testing.T) {
+	revokedSessionHandles := []string{}
+	privateKey, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+		Override: &sessmodels.OverrideStruct{
+			Functions: func(originalImplementation sessmodels.RecipeInterface) sessmodels.RecipeInterface {
+				(*originalImplementation.RevokeSession) = func(sessionHandle string, userContext supertokens.UserContext) (bool, error) {
+					revokedSessionHandles = append(revokedSessionHandles, sessionHandle)
+					return true, nil
+				}
+				return originalImplementation
+			},
+		},
+	})
+	defer cleanup()
+
+	payload, err := makeImpersonationAccessTokenPayload("admin", time.Minute)
+	assert.NoError(t, err)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessTokenForTest(t, privateKey, payload))
+	sessionContainer, err := GetSession(req, httptest.NewRecorder(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", *GetImpersonatorUserID(sessionContainer.GetAccessTokenPayload()))
+
+	// APIs protected by RequireNoImpersonation reject it
+	_, err = GetSession(req, httptest.NewRecorder(), WithClaimValidators(nil, RequireNoImpersonation()))
+	assert.True(t, defaultErrors.As(err, &errors.InvalidClaimError{}))
+
+	payload[impersonationExpiryKey] = time.Now().Add(-time.Second).UnixNano() / 1000000
+	req.Header.Set("Authorization", "Bearer "+makeAccessTokenForTest(t, privateKey, payload))
+	_, err = GetSession(req, httptest.NewRecorder(), nil)
+	assert.True(t, defaultErrors.As(err, &errors.UnauthorizedError{}))
+	assert.Equal(t, []string{"handle"}, revokedSessionHandles)
+}
+
+func TestThatImpersonationSessionsCannotBeRefreshed(t *testing.T) {
+	accessTokenPayload := map[string]interface{}{}
+	revokedSessionHandles := []string{}
+	getSession := func(accessToken *string, antiCSRFToken *string, options *sessmodels.VerifySessionOptions, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		return nil, nil
+	}
+	refreshSession := func(refreshToken string, antiCSRFToken *string, disableAntiCSRF bool, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+		return &sessmodels.TypeSessionContainer{
+			GetHandleWithContext: func(userContext supertokens.UserContext) string {
+				return "handle"
+			},
+			GetUserIDWithContext: func(userContext supertokens.UserContext) string {
+				return "userId"
+			},
+			GetTenantIdWithContext: func(userContext supertokens.UserContext) string {
+				return "public"
+			},
+			GetAccessTokenPayloadWithContext: func(userContext supertokens.UserContext) map[string]interface{} {
+				return accessTokenPayload
+			},
+		}, nil
+	}
+	revokeSession := func(sessionHandle string, userContext supertokens.UserContext) (bool, error) {
+		revokedSessionHandles = append(revokedSessionHandles, sessionHandle)
+		return true, nil
+	}
+	recipeImpl := withImpersonation(sessmodels.RecipeInterface{
+		GetSession:     &getSession,
+		RefreshSession: &refreshSession,
+		RevokeSession:  &revokeSession,
+	})
+
+	sessionContainer, err := (*recipeImpl.RefreshSession)("refreshToken", nil, true, &map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.NotNil(t, sessionContainer)
+
+	accessTokenPayload["act"] = map[string]interface{}{"sub": "admin"}
+	_, err = (*recipeImpl.RefreshSession)("refreshToken", nil, true, &map[string]interface{}{})
+	unauthorisedErr := errors.UnauthorizedError{}
+	assert.True(t, defaultErrors.As(err, &unauthorisedErr))
+	assert.True(t, *unauthorisedErr.ClearTokens)
+	assert.Equal(t, []string{"handle"}, revokedSessionHandles)
+}
+
+func TestThatTheClaimsSetByTheSessionRecipeAreProtected(t *testing.T) {
+	errCreateNewSession := defaultErrors.New("create new session")
+	var regeneratedPayload map[string]interface{}
+	var createdPayload map[string]interface{}
+	privateKey, cleanup := initWithSigningKeyAndConfigForTest(t, &sessmodels.TypeInput{
+		Override: &sessmodels.OverrideStruct{
+			Functions: func(originalImplementation sessmodels.RecipeInterface) sessmodels.RecipeInterface {
+				(*originalImplementation.RegenerateAccessToken) = func(accessToken string, newAccessTokenPayload *map[string]interface{}, userContext supertokens.UserContext) (*sessmodels.RegenerateAccessTokenResponse, error) {
+					regeneratedPayload = *newAccessTokenPayload
+					return &sessmodels.RegenerateAccessTokenResponse{}, nil
+				}
+				(*originalImplementation.CreateNewSession) = func(userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCsrf *bool, tenantId string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
+					createdPayload = accessTokenPayload
+					return nil, errCreateNewSession
+				}
+				return originalImplementation
+			},
+		},
+	})
+	defer cleanup()
+
+	_, err := CreateImpersonationSession(httptest.NewRequest("POST", "/", nil), httptest.NewRecorder(), "admin", "userId", "public", time.Minute)
+	assert.Equal(t, errCreateNewSession, err)
+	assert.Equal(t, map[string]interface{}{"sub": "admin"}, createdPayload[impersonationActorKey])
+	assert.NotNil(t, createdPayload[impersonationExpiryKey])
+
+	// the claims can't be set by the app
+	_, err = CreateNewSession(httptest.NewRequest("POST", "/", nil), httptest.NewRecorder(), "public", "userId", map[string]interface{}{
+		"custom":              "value",
+		impersonationActorKey: map[string]interface{}{"sub": "admin"},
+		dpopConfirmationKey:   map[string]interface{}{"jkt": "thumbprint"},
+		fingerprintKey:        map[string]interface{}{},
+		authTimeKey:           float64(time.Now().UnixNano() / 1000000),
+	}, nil)
+	assert.Equal(t, errCreateNewSession, err)
+	assert.Equal(t, map[string]interface{}{"custom": "value", "iss": "https://api.supertokens.io/auth"}, createdPayload)
+
+	// nor changed or removed when the payload is updated
+	impersonationPayload, err := makeImpersonationAccessTokenPayload("admin", time.Minute)
+	assert.NoError(t, err)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessTokenForTest(t, privateKey, impersonationPayload))
+	sessionContainer, err := GetSession(req, httptest.NewRecorder(), nil)
+	assert.NoError(t, err)
+	err = sessionContainer.MergeIntoAccessTokenPayload(map[string]interface{}{
+		"custom":               "value",
+		impersonationActorKey:  nil,
+		impersonationExpiryKey: float64(time.Now().Add(time.Hour).UnixNano() / 1000000),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "value", regeneratedPayload["custom"])
+	assert.Equal(t, impersonationPayload[impersonationActorKey], regeneratedPayload[impersonationActorKey])
+	assert.Equal(t, impersonationPayload[impersonationExpiryKey], regeneratedPayload[impersonationExpiryKey])
+}
@@ -0,0 +1,213 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+type fakeCore struct {
+	server  *httptest.Server
+	lock    sync.Mutex
+	apiKeys []string
+}
+
+// newFakeCore returns a core that answers every request with the given handle as the revoked session
+func newFakeCore(sessionHandle string) *fakeCore {
+	core := &fakeCore{}
+	core.server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/apiversion" {
+			json.NewEncoder(rw).Encode(map[string]interface{}{"versions": []string{"3.1"}})
+			return
+		}
+		if r.URL.Path == "/.well-known/jwks.json" {
+			json.NewEncoder(rw).Encode(map[string]interface{}{"keys": []interface{}{}})
+			return
+		}
+		core.lock.Lock()
+		core.apiKeys = append(core.apiKeys, r.Header.Get("api-key"))
+		core.lock.Unlock()
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"status":                "OK",
+			"sessionHandlesRevoked": []string{sessionHandle},
+		})
+	}))
+	return core
+}
+
+func (c *fakeCore) getAPIKeys() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string{}, c.apiKeys...)
+}
+
+func newInstanceForTest(t *testing.T, core *fakeCore, apiKey string, apiBasePath string, sessionExpiredStatusCode int) *supertokens.Instance {
+	instance, err := supertokens.New(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: core.server.URL,
+			APIKey:        apiKey,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+			APIBasePath:   &apiBasePath,
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(&sessmodels.TypeInput{
+				SessionExpiredStatusCode: &sessionExpiredStatusCode,
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return instance
+}
+
+func TestThatInstancesUseTheirOwnCoreAndRecipes(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	coreA := newFakeCore("handle-a")
+	defer coreA.server.Close()
+	coreB := newFakeCore("handle-b")
+	defer coreB.server.Close()
+
+	instanceA := newInstanceForTest(t, coreA, "key-a", "/a/auth", 440)
+	defer instanceA.Close()
+	instanceB := newInstanceForTest(t, coreB, "key-b", "/b/auth", 441)
+	defer instanceB.Close()
+
+	// creating instances does not initialise the default instance
+	_, err := supertokens.GetInstanceOrThrowError()
+	assert.Error(t, err)
+	_, err = GetRecipeInstanceOrThrowError()
+	assert.Error(t, err)
+
+	recipeA, err := GetRecipeInstanceOrThrowError(instanceA.BindUserContext(nil))
+	assert.NoError(t, err)
+	recipeB, err := GetRecipeInstanceOrThrowError(instanceB.BindUserContext(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 440, recipeA.Config.SessionExpiredStatusCode)
+	assert.Equal(t, 441, recipeB.Config.SessionExpiredStatusCode)
+
+	revokedA, err := RevokeAllSessionsForUser("user", nil, instanceA.BindUserContext(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"handle-a"}, revokedA)
+	revokedB, err := RevokeAllSessionsForUser("user", nil, instanceB.BindUserContext(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"handle-b"}, revokedB)
+
+	assert.Equal(t, []string{"key-a"}, coreA.getAPIKeys())
+	assert.Equal(t, []string{"key-b"}, coreB.getAPIKeys())
+}
+
+func TestThatInstanceMiddlewaresOnlyHandleTheirOwnAPIs(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	coreA := newFakeCore("handle-a")
+	defer coreA.server.Close()
+	coreB := newFakeCore("handle-b")
+	defer coreB.server.Close()
+
+	instanceA := newInstanceForTest(t, coreA, "key-a", "/a/auth", 440)
+	defer instanceA.Close()
+	instanceB := newInstanceForTest(t, coreB, "key-b", "/b/auth", 441)
+	defer instanceB.Close()
+
+	notHandled := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	})
+	handler := instanceA.Middleware(instanceB.Middleware(notHandled))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// the refresh API of each instance responds with its own session expired status code
+	res, err := http.Post(server.URL+"/a/auth/session/refresh", "application/json", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 440, res.StatusCode)
+
+	res, err = http.Post(server.URL+"/b/auth/session/refresh", "application/json", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 441, res.StatusCode)
+
+	res, err = http.Post(server.URL+"/c/auth/session/refresh", "application/json", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusTeapot, res.StatusCode)
+}
+
+func TestThatVerifySessionUsesTheInstanceThatHandledTheRequest(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	coreA := newFakeCore("handle-a")
+	defer coreA.server.Close()
+
+	instanceA := newInstanceForTest(t, coreA, "key-a", "/a/auth", 440)
+	defer instanceA.Close()
+
+	server := httptest.NewServer(instanceA.Middleware(VerifySession(nil, func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})))
+	defer server.Close()
+
+	// the unauthorised response uses the session expired status code configured for instance A
+	res, err := http.Get(server.URL + "/protected")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 440, res.StatusCode)
+}
+
+func TestThatRenderAPIResponseIsCalledForErrorsSentByTheMiddleware(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	core := newFakeCore("handle")
+	defer core.server.Close()
+
+	apiBasePath := "/auth"
+	var rendered supertokens.APIResponse
+	instance, err := supertokens.New(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: core.server.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+			APIBasePath:   &apiBasePath,
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(nil),
+		},
+		RenderAPIResponse: func(response supertokens.APIResponse, userContext supertokens.UserContext) (supertokens.APIResponse, error) {
+			rendered = response
+			response.ContentType = "application/problem+json"
+			return response, nil
+		},
+	})
+	assert.NoError(t, err)
+	defer instance.Close()
+
+	server := httptest.NewServer(instance.Middleware(nil))
+	defer server.Close()
+
+	res, err := http.Post(server.URL+"/auth/session/refresh", "application/json", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 401, res.StatusCode)
+	assert.Equal(t, "application/problem+json", res.Header.Get("Content-Type"))
+	assert.Equal(t, RECIPE_ID, rendered.RecipeID)
+	assert.Equal(t, RefreshAPIPath, rendered.APIID)
+	assert.Equal(t, "public", rendered.TenantID)
+	assert.Nil(t, rendered.Result)
+}
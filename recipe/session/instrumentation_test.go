@@ -0,0 +1,233 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	name       string
+	parent     *testSpan
+	attributes map[string]interface{}
+	errors     []error
+	ended      bool
+}
+
+func (s *testSpan) SetAttributes(attributes map[string]interface{}) {
+	for k, v := range attributes {
+		s.attributes[k] = v
+	}
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.errors = append(s.errors, err)
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+type testMetric struct {
+	name       string
+	value      float64
+	attributes map[string]interface{}
+}
+
+// inMemoryInstrumentation records all spans and metrics so that they can be asserted on
+type inMemoryInstrumentation struct {
+	lock    sync.Mutex
+	spans   []*testSpan
+	metrics []testMetric
+}
+
+func (i *inMemoryInstrumentation) StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, supertokens.Span) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	parent, _ := ctx.Value(testSpanKey{}).(*testSpan)
+	span := &testSpan{name: name, parent: parent, attributes: map[string]interface{}{}}
+	span.SetAttributes(attributes)
+	i.spans = append(i.spans, span)
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func (i *inMemoryInstrumentation) AddToCounter(ctx context.Context, name string, value int64, attributes map[string]interface{}) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.metrics = append(i.metrics, testMetric{name: name, value: float64(value), attributes: attributes})
+}
+
+func (i *inMemoryInstrumentation) RecordHistogram(ctx context.Context, name string, value float64, attributes map[string]interface{}) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.metrics = append(i.metrics, testMetric{name: name, value: value, attributes: attributes})
+}
+
+func (i *inMemoryInstrumentation) spansWithName(name string) []*testSpan {
+	result := []*testSpan{}
+	for _, span := range i.spans {
+		if span.name == name {
+			result = append(result, span)
+		}
+	}
+	return result
+}
+
+func (i *inMemoryInstrumentation) metricsWithName(name string) []testMetric {
+	result := []testMetric{}
+	for _, metric := range i.metrics {
+		if metric.name == name {
+			result = append(result, metric)
+		}
+	}
+	return result
+}
+
+func initWithInstrumentation(t *testing.T, connectionURI string, instrumentation supertokens.Instrumentation) {
+	config := supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: connectionURI,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(nil),
+		},
+		Instrumentation: instrumentation,
+	}
+
+	err := supertokens.Init(config)
+	if err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestThatMiddlewareAndAPISpansAreEmitted(t *testing.T) {
+	resetAll()
+	defer resetAll()
+
+	instrumentation := &inMemoryInstrumentation{}
+	initWithInstrumentation(t, "http://localhost:8080", instrumentation)
+
+	testServer := httptest.NewServer(supertokens.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(200)
+	})))
+	defer testServer.Close()
+
+	res, err := http.Post(testServer.URL+"/auth/session/refresh", "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, res.StatusCode)
+
+	res, err = http.Get(testServer.URL + "/some/other/path")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+
+	instrumentation.lock.Lock()
+	defer instrumentation.lock.Unlock()
+
+	middlewareSpans := instrumentation.spansWithName(supertokens.SpanMiddleware)
+	assert.Equal(t, 2, len(middlewareSpans))
+	assert.Equal(t, "/auth/session/refresh", middlewareSpans[0].attributes["path"])
+	assert.Equal(t, "/some/other/path", middlewareSpans[1].attributes["path"])
+	for _, span := range middlewareSpans {
+		assert.True(t, span.ended)
+		assert.Nil(t, span.parent)
+	}
+
+	apiSpans := instrumentation.spansWithName(supertokens.SpanAPIRequest)
+	assert.Equal(t, 1, len(apiSpans))
+	assert.Equal(t, middlewareSpans[0], apiSpans[0].parent)
+	assert.Equal(t, "session", apiSpans[0].attributes["recipeId"])
+	assert.Equal(t, "/session/refresh", apiSpans[0].attributes["apiId"])
+	assert.Equal(t, "public", apiSpans[0].attributes["tenantId"])
+	assert.Equal(t, 1, len(apiSpans[0].errors))
+	assert.True(t, apiSpans[0].ended)
+
+	assert.Equal(t, 1, len(instrumentation.metricsWithName(supertokens.MetricAPIRequestDuration)))
+}
+
+func TestThatCoreRequestSpansAndMetricsAreEmitted(t *testing.T) {
+	resetAll()
+	defer resetAll()
+
+	numberOfTimesCalled := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/testing", func(rw http.ResponseWriter, r *http.Request) {
+		numberOfTimesCalled++
+		rw.Header().Set("Content-Type", "application/json")
+		if numberOfTimesCalled == 1 {
+			rw.WriteHeader(supertokens.RateLimitStatusCode)
+		} else {
+			rw.WriteHeader(200)
+		}
+		rw.Write([]byte("{}"))
+	})
+	mux.HandleFunc("/failing", func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(500)
+		rw.Write([]byte("{}"))
+	})
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+
+	instrumentation := &inMemoryInstrumentation{}
+	initWithInstrumentation(t, testServer.URL, instrumentation)
+
+	q, err := supertokens.GetNewQuerierInstanceOrThrowError("")
+	supertokens.SetQuerierApiVersionForTests("3.0")
+	defer resetQuerier()
+	assert.NoError(t, err)
+
+	parentCtx, parentSpan := instrumentation.StartSpan(context.Background(), "parent", nil)
+	userContext := supertokens.SetContextInUserContext(nil, parentCtx)
+
+	_, err = q.SendGetRequest("/testing", map[string]string{}, userContext)
+	assert.NoError(t, err)
+
+	// this should be served from the cache
+	_, err = q.SendGetRequest("/testing", map[string]string{}, userContext)
+	assert.NoError(t, err)
+
+	_, err = q.SendPostRequest("/failing", map[string]interface{}{}, userContext)
+	assert.Error(t, err)
+
+	// the context of the parent is restored once the core request span ends
+	assert.Equal(t, parentCtx, supertokens.GetContextFromUserContext(userContext))
+
+	instrumentation.lock.Lock()
+	defer instrumentation.lock.Unlock()
+
+	coreSpans := instrumentation.spansWithName(supertokens.SpanCoreRequest)
+	assert.Equal(t, 3, len(coreSpans))
+	for _, span := range coreSpans {
+		assert.Equal(t, parentSpan, span.parent)
+		assert.True(t, span.ended)
+	}
+
+	assert.Equal(t, "/testing", coreSpans[0].attributes["path"])
+	assert.Equal(t, "GET", coreSpans[0].attributes["method"])
+	assert.Equal(t, 200, coreSpans[0].attributes["status"])
+	assert.Equal(t, 1, coreSpans[0].attributes["retries"])
+	assert.Equal(t, false, coreSpans[0].attributes["cacheHit"])
+
+	assert.Equal(t, true, coreSpans[1].attributes["cacheHit"])
+	assert.Equal(t, 0, coreSpans[1].attributes["retries"])
+
+	assert.Equal(t, "POST", coreSpans[2].attributes["method"])
+	assert.Equal(t, 500, coreSpans[2].attributes["status"])
+	assert.Equal(t, 1, len(coreSpans[2].errors))
+
+	coreErrors := instrumentation.metricsWithName(supertokens.MetricCoreErrors)
+	assert.Equal(t, 1, len(coreErrors))
+	assert.Equal(t, "/failing", coreErrors[0].attributes["path"])
+	assert.Equal(t, 3, len(instrumentation.metricsWithName(supertokens.MetricCoreRequestDuration)))
+}
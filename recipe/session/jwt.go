@@ -47,7 +47,7 @@ func ParseJWTWithoutSignatureVerification(token string) (sessmodels.ParsedJWTInf
 	latestAccessTokenVersion := 3
 	var kid *string
 	if len(splittedInput) != 3 {
-		errors.New("Invalid JWT")
+		return sessmodels.ParsedJWTInfo{}, errors.New("Invalid JWT")
 	}
 
 	// V1&V2 is functionally identical, plus all legacy tokens should be V2 now.
@@ -90,7 +90,8 @@ func ParseJWTWithoutSignatureVerification(token string) (sessmodels.ParsedJWTInf
 		kidString := kidInHeader.(string)
 		kid = &kidString
 
-		if parsedHeader["typ"].(string) != "JWT" || parseError != nil || versionNumber < 3 || parsedHeader["kid"] == nil {
+		typ, _ := parsedHeader["typ"].(string)
+		if typ != "JWT" || parseError != nil || versionNumber < 3 || parsedHeader["kid"] == nil {
 			return sessmodels.ParsedJWTInfo{}, errors.New("JWT header mismatch")
 		}
 
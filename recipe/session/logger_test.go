@@ -3,6 +3,7 @@ package session
 import (
 	"bytes"
 	"log"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -165,3 +166,91 @@ func TestLogDebugMessageWithEnvVar(t *testing.T) {
 	supertokens.LogDebugMessage(logMessage)
 	assert.Contains(t, buf.String(), logMessage, "checking log message in logs")
 }
+
+type testLogRecord struct {
+	level   string
+	message string
+	fields  []interface{}
+}
+
+type testStructuredLogger struct {
+	records []testLogRecord
+}
+
+func (l *testStructuredLogger) log(level string, msg string, args ...interface{}) {
+	l.records = append(l.records, testLogRecord{level: level, message: msg, fields: args})
+}
+
+func (l *testStructuredLogger) Debug(msg string, args ...interface{}) { l.log("debug", msg, args...) }
+func (l *testStructuredLogger) Info(msg string, args ...interface{})  { l.log("info", msg, args...) }
+func (l *testStructuredLogger) Warn(msg string, args ...interface{})  { l.log("warn", msg, args...) }
+func (l *testStructuredLogger) Error(msg string, args ...interface{}) { l.log("error", msg, args...) }
+
+func TestStructuredLoggerReceivesLevelsAndFields(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	var buf bytes.Buffer
+	supertokens.Logger = log.New(&buf, "test", 0)
+	defer resetLogger()
+
+	logger := &testStructuredLogger{}
+	configValue := supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: "http://localhost:8080",
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			APIDomain:     "api.supertokens.io",
+			WebsiteDomain: "supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(nil),
+		},
+		Logger: logger,
+	}
+
+	err := supertokens.Init(configValue)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	userContext := supertokens.MakeDefaultUserContextFromAPI(req)
+
+	logger.records = nil
+	supertokens.LogMessage(supertokens.LogLevelWarn, userContext, "test message", "recipeId", "session", "tenantId", "public")
+	supertokens.LogDebugMessage("debug message")
+
+	assert.Equal(t, 2, len(logger.records))
+	assert.Equal(t, "warn", logger.records[0].level)
+	assert.Equal(t, "test message", logger.records[0].message)
+	assert.Equal(t, []interface{}{"sdkVer", supertokens.VERSION, "requestId", "req-1", "recipeId", "session", "tenantId", "public"}, logger.records[0].fields)
+	assert.Equal(t, "debug", logger.records[1].level)
+	assert.Equal(t, "debug message", logger.records[1].message)
+
+	// nothing is written to the default logger when a structured logger is used
+	assert.Equal(t, "", buf.String())
+}
+
+func TestLogMessageWithoutStructuredLoggerUsesDebugLogger(t *testing.T) {
+	resetAll()
+	var buf bytes.Buffer
+	supertokens.Logger = log.New(&buf, "test", 0)
+	defer resetLogger()
+
+	supertokens.LogMessage(supertokens.LogLevelError, nil, "test message", "recipeId", "session")
+	assert.Equal(t, "", buf.String())
+
+	supertokens.DebugEnabled = true
+	supertokens.LogMessage(supertokens.LogLevelError, nil, "test message", "recipeId", "session")
+	assert.Contains(t, buf.String(), "test message (recipeId=session)")
+}
+
+func TestHashForLoggingDoesNotLeakTheValue(t *testing.T) {
+	hash := supertokens.HashForLogging("some-session-handle")
+	assert.Equal(t, 16, len(hash))
+	assert.NotContains(t, hash, "session")
+	assert.Equal(t, hash, supertokens.HashForLogging("some-session-handle"))
+	assert.NotEqual(t, hash, supertokens.HashForLogging("other-session-handle"))
+}
@@ -31,12 +31,12 @@ func Init(config *sessmodels.TypeInput) supertokens.Recipe {
 }
 
 func CreateNewSession(req *http.Request, res http.ResponseWriter, tenantId string, userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, userContext ...supertokens.UserContext) (sessmodels.SessionContainer, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
 	if len(userContext) == 0 {
-		userContext = append(userContext, &map[string]interface{}{})
+		userContext = append(userContext, supertokens.SetContextInUserContext(nil, req.Context()))
 	}
 	config := instance.Config
 	appInfo := instance.RecipeModule.GetAppInfo()
@@ -45,7 +45,7 @@ func CreateNewSession(req *http.Request, res http.ResponseWriter, tenantId strin
 }
 
 func CreateNewSessionWithoutRequestResponse(tenantId string, userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCSRF *bool, userContext ...supertokens.UserContext) (sessmodels.SessionContainer, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +75,12 @@ func CreateNewSessionWithoutRequestResponse(tenantId string, userID string, acce
 		}
 	}
 
+	for k, v := range getSessionManagedPropsFromUserContext(userContext[0]) {
+		if v != nil {
+			finalAccessTokenPayload[k] = v
+		}
+	}
+
 	_disableAntiCSRF := false
 
 	if disableAntiCSRF != nil {
@@ -85,12 +91,12 @@ func CreateNewSessionWithoutRequestResponse(tenantId string, userID string, acce
 }
 
 func GetSession(req *http.Request, res http.ResponseWriter, options *sessmodels.VerifySessionOptions, userContext ...supertokens.UserContext) (sessmodels.SessionContainer, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
 	if len(userContext) == 0 {
-		userContext = append(userContext, &map[string]interface{}{})
+		userContext = append(userContext, supertokens.SetContextInUserContext(nil, req.Context()))
 	}
 	config := instance.Config
 
@@ -98,7 +104,7 @@ func GetSession(req *http.Request, res http.ResponseWriter, options *sessmodels.
 }
 
 func GetSessionWithoutRequestResponse(accessToken string, antiCSRFToken *string, options *sessmodels.VerifySessionOptions, userContext ...supertokens.UserContext) (sessmodels.SessionContainer, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +145,7 @@ func GetSessionWithoutRequestResponse(accessToken string, antiCSRFToken *string,
 }
 
 func GetSessionInformation(sessionHandle string, userContext ...supertokens.UserContext) (*sessmodels.SessionInformation, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -150,18 +156,18 @@ func GetSessionInformation(sessionHandle string, userContext ...supertokens.User
 }
 
 func RefreshSession(req *http.Request, res http.ResponseWriter, userContext ...supertokens.UserContext) (sessmodels.SessionContainer, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
 	if len(userContext) == 0 {
-		userContext = append(userContext, &map[string]interface{}{})
+		userContext = append(userContext, supertokens.SetContextInUserContext(nil, req.Context()))
 	}
 	return RefreshSessionInRequest(req, res, instance.Config, instance.RecipeImpl, userContext[0])
 }
 
 func RefreshSessionWithoutRequestResponse(refreshToken string, disableAntiCSRF *bool, antiCSRFToken *string, userContext ...supertokens.UserContext) (sessmodels.SessionContainer, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -179,7 +185,7 @@ func RefreshSessionWithoutRequestResponse(refreshToken string, disableAntiCSRF *
 }
 
 func RevokeAllSessionsForUser(userID string, tenantId *string, userContext ...supertokens.UserContext) ([]string, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -198,7 +204,7 @@ func RevokeAllSessionsForUser(userID string, tenantId *string, userContext ...su
 }
 
 func GetAllSessionHandlesForUser(userID string, tenantId *string, userContext ...supertokens.UserContext) ([]string, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -218,7 +224,7 @@ func GetAllSessionHandlesForUser(userID string, tenantId *string, userContext ..
 }
 
 func RevokeSession(sessionHandle string, userContext ...supertokens.UserContext) (bool, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return false, err
 	}
@@ -229,7 +235,7 @@ func RevokeSession(sessionHandle string, userContext ...supertokens.UserContext)
 }
 
 func RevokeMultipleSessions(sessionHandles []string, userContext ...supertokens.UserContext) ([]string, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -240,7 +246,7 @@ func RevokeMultipleSessions(sessionHandles []string, userContext ...supertokens.
 }
 
 func UpdateSessionDataInDatabase(sessionHandle string, newSessionData map[string]interface{}, userContext ...supertokens.UserContext) (bool, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return false, err
 	}
@@ -251,7 +257,7 @@ func UpdateSessionDataInDatabase(sessionHandle string, newSessionData map[string
 }
 
 func CreateJWT(payload map[string]interface{}, validitySecondsPointer *uint64, useStaticSigningKey *bool, userContext ...supertokens.UserContext) (jwtmodels.CreateJWTResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return jwtmodels.CreateJWTResponse{}, err
 	}
@@ -262,7 +268,7 @@ func CreateJWT(payload map[string]interface{}, validitySecondsPointer *uint64, u
 }
 
 func GetJWKS(userContext ...supertokens.UserContext) (jwtmodels.GetJWKSResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return jwtmodels.GetJWKSResponse{}, err
 	}
@@ -273,7 +279,7 @@ func GetJWKS(userContext ...supertokens.UserContext) (jwtmodels.GetJWKSResponse,
 }
 
 func GetOpenIdDiscoveryConfiguration(userContext ...supertokens.UserContext) (openidmodels.GetOpenIdDiscoveryConfigurationResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return openidmodels.GetOpenIdDiscoveryConfigurationResponse{}, err
 	}
@@ -283,13 +289,23 @@ func GetOpenIdDiscoveryConfiguration(userContext ...supertokens.UserContext) (op
 	return (*instance.OpenIdRecipe.RecipeImpl.GetOpenIdDiscoveryConfiguration)(userContext[0])
 }
 
+// GetCookieName returns the name of the cookie that holds the given session token, which depends on the
+// CookieNamePrefix and CookieSecurityPrefix configs
+func GetCookieName(tokenType sessmodels.TokenType, userContext ...supertokens.UserContext) (string, error) {
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
+	if err != nil {
+		return "", err
+	}
+	return getCookieNameFromTokenType(instance.Config, tokenType)
+}
+
 func ValidateClaimsForSessionHandle(
 	sessionHandle string,
 	overrideGlobalClaimValidators func([]claims.SessionClaimValidator, sessmodels.SessionInformation, supertokens.UserContext) []claims.SessionClaimValidator,
 	userContext ...supertokens.UserContext,
 ) (sessmodels.ValidateClaimsResponse, error) {
 
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return sessmodels.ValidateClaimsResponse{}, err
 	}
@@ -350,7 +366,7 @@ func ValidateClaimsInJWTPayload(
 	userContext ...supertokens.UserContext,
 ) ([]claims.ClaimValidationError, error) {
 
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return nil, err
 	}
@@ -376,7 +392,7 @@ func ValidateClaimsInJWTPayload(
 }
 
 func MergeIntoAccessTokenPayload(sessionHandle string, accessTokenPayloadUpdate map[string]interface{}, userContext ...supertokens.UserContext) (bool, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return false, err
 	}
@@ -387,7 +403,7 @@ func MergeIntoAccessTokenPayload(sessionHandle string, accessTokenPayloadUpdate
 }
 
 func FetchAndSetClaim(sessionHandle string, claim *claims.TypeSessionClaim, userContext ...supertokens.UserContext) (bool, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return false, err
 	}
@@ -398,7 +414,7 @@ func FetchAndSetClaim(sessionHandle string, claim *claims.TypeSessionClaim, user
 }
 
 func SetClaimValue(sessionHandle string, claim *claims.TypeSessionClaim, value interface{}, userContext ...supertokens.UserContext) (bool, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return false, err
 	}
@@ -409,7 +425,7 @@ func SetClaimValue(sessionHandle string, claim *claims.TypeSessionClaim, value i
 }
 
 func GetClaimValue(sessionHandle string, claim *claims.TypeSessionClaim, userContext ...supertokens.UserContext) (sessmodels.GetClaimValueResult, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return sessmodels.GetClaimValueResult{}, err
 	}
@@ -420,7 +436,7 @@ func GetClaimValue(sessionHandle string, claim *claims.TypeSessionClaim, userCon
 }
 
 func RemoveClaim(sessionHandle string, claim *claims.TypeSessionClaim, userContext ...supertokens.UserContext) (bool, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return false, err
 	}
@@ -431,11 +447,18 @@ func RemoveClaim(sessionHandle string, claim *claims.TypeSessionClaim, userConte
 }
 
 func VerifySession(options *sessmodels.VerifySessionOptions, otherHandler http.HandlerFunc) http.HandlerFunc {
-	instance, err := getRecipeInstanceOrThrowError()
-	if err != nil {
+	if !supertokens.IsInitialised() {
 		panic("can't fetch supertokens instance. You should call the supertokens.Init function before using the VerifySession function.")
 	}
-	return VerifySessionHelper(*instance, options, otherHandler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the recipe is resolved for each request since it depends on the instance that handled it
+		instance, err := getRecipeInstanceForRequestOrThrowError(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		VerifySessionHelper(*instance, options, otherHandler).ServeHTTP(w, r)
+	})
 }
 
 func GetSessionFromRequestContext(ctx context.Context) sessmodels.SessionContainer {
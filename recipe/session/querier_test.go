@@ -1,13 +1,16 @@
 package session
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/supertokens/supertokens-golang/supertokens"
@@ -284,3 +287,720 @@ func TestThatParallelCallsHaveIndependentRetryCounters(t *testing.T) {
 	assert.Equal(t, numberOfTimesFirstCalled, 6)
 	assert.Equal(t, numberOfTimesSecondCalled, 6)
 }
+
+func TestThatCoreRequestsRespectTheConfiguredTimeout(t *testing.T) {
+	resetAll()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/slow", func(rw http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(200)
+		rw.Write([]byte("{}"))
+	})
+
+	testServer := httptest.NewServer(mux)
+
+	defer func() {
+		testServer.Close()
+	}()
+
+	config := supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI:  testServer.URL,
+			RequestTimeout: 50 * time.Millisecond,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(nil),
+		},
+	}
+
+	err := supertokens.Init(config)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	q, err := supertokens.GetNewQuerierInstanceOrThrowError("")
+	supertokens.SetQuerierApiVersionForTests("3.0")
+	defer resetQuerier()
+
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	start := time.Now()
+	_, err = q.SendGetRequest("/slow", map[string]string{}, nil)
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "Client.Timeout exceeded"))
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestThatCoreRequestsReuseConnections(t *testing.T) {
+	resetAll()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/testing", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(200)
+		rw.Write([]byte("{}"))
+	})
+
+	var lock sync.Mutex
+	newConnections := 0
+	testServer := httptest.NewUnstartedServer(mux)
+	testServer.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			lock.Lock()
+			newConnections++
+			lock.Unlock()
+		}
+	}
+	testServer.Start()
+
+	defer func() {
+		testServer.Close()
+	}()
+
+	config := supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: testServer.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(nil),
+		},
+	}
+
+	err := supertokens.Init(config)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	q, err := supertokens.GetNewQuerierInstanceOrThrowError("")
+	supertokens.SetQuerierApiVersionForTests("3.0")
+	defer resetQuerier()
+
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err = q.SendPostRequest("/testing", map[string]interface{}{}, nil)
+		assert.NoError(t, err)
+		_, err = q.SendGetRequest("/testing", map[string]string{}, nil)
+		assert.NoError(t, err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 1, newConnections)
+}
+
+type countingRoundTripper struct {
+	lock  sync.Mutex
+	count int
+}
+
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.lock.Lock()
+	c.count++
+	c.lock.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestThatCustomTransportIsUsedForCoreRequests(t *testing.T) {
+	resetAll()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/testing", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(200)
+		rw.Write([]byte("{}"))
+	})
+
+	testServer := httptest.NewServer(mux)
+
+	defer func() {
+		testServer.Close()
+	}()
+
+	transport := &countingRoundTripper{}
+	config := supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: testServer.URL,
+			Transport:     transport,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(nil),
+		},
+	}
+
+	err := supertokens.Init(config)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	q, err := supertokens.GetNewQuerierInstanceOrThrowError("")
+	supertokens.SetQuerierApiVersionForTests("3.0")
+	defer resetQuerier()
+
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	_, err = q.SendGetRequest("/testing", map[string]string{}, nil)
+	assert.NoError(t, err)
+	_, err = q.SendPutRequest("/testing", map[string]interface{}{}, nil)
+	assert.NoError(t, err)
+
+	transport.lock.Lock()
+	defer transport.lock.Unlock()
+	assert.Equal(t, 2, transport.count)
+}
+
+func TestThatCancellingTheContextAbortsTheCoreRequest(t *testing.T) {
+	resetAll()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/slow", func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(200)
+		rw.Write([]byte("{}"))
+	})
+
+	testServer := httptest.NewServer(mux)
+
+	defer func() {
+		testServer.Close()
+	}()
+
+	config := supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: testServer.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(nil),
+		},
+	}
+
+	err := supertokens.Init(config)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	q, err := supertokens.GetNewQuerierInstanceOrThrowError("")
+	supertokens.SetQuerierApiVersionForTests("3.0")
+	defer resetQuerier()
+
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = q.SendGetRequest("/slow", map[string]string{}, supertokens.SetContextInUserContext(nil, ctx))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the context of the request in the user context is used if none is set explicitly
+	reqCtx, cancelReq := context.WithCancel(context.Background())
+	cancelReq()
+	req := httptest.NewRequest("GET", "/", nil).WithContext(reqCtx)
+	_, err = q.SendPostRequest("/slow", map[string]interface{}{}, supertokens.MakeDefaultUserContextFromAPI(req))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+type failingCore struct {
+	lock        sync.Mutex
+	calls       int
+	failures    int
+	failureCode int
+	server      *httptest.Server
+}
+
+// newFailingCore starts a core stand-in that fails the first `failures` requests (or all of them if
+// failures is -1) with failureCode.
+func newFailingCore(failures int, failureCode int) *failingCore {
+	core := &failingCore{
+		failures:    failures,
+		failureCode: failureCode,
+	}
+	core.server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		core.lock.Lock()
+		core.calls++
+		shouldFail := core.failures == -1 || core.calls <= core.failures
+		failureCode := core.failureCode
+		core.lock.Unlock()
+
+		rw.Header().Set("Content-Type", "application/json")
+		if shouldFail {
+			rw.WriteHeader(failureCode)
+		} else {
+			rw.WriteHeader(200)
+		}
+		rw.Write([]byte("{}"))
+	}))
+	return core
+}
+
+func (c *failingCore) getCalls() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.calls
+}
+
+func (c *failingCore) setFailures(failures int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.failures = failures
+	c.calls = 0
+}
+
+func (c *failingCore) setFailureCode(failureCode int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.failureCode = failureCode
+}
+
+func initWithConnectionInfo(t *testing.T, connectionInfo *supertokens.ConnectionInfo) *supertokens.Querier {
+	config := supertokens.TypeInput{
+		Supertokens: connectionInfo,
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(nil),
+		},
+	}
+
+	err := supertokens.Init(config)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	q, err := supertokens.GetNewQuerierInstanceOrThrowError("")
+	if err != nil {
+		t.Error(err.Error())
+	}
+	supertokens.SetQuerierApiVersionForTests("3.0")
+	return q
+}
+
+func TestThatServiceUnavailableIsRetriedWithBackoff(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	core := newFailingCore(2, 503)
+	defer core.server.Close()
+
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI:  core.server.URL,
+		RetryBaseDelay: 40 * time.Millisecond,
+	})
+	defer resetQuerier()
+
+	start := time.Now()
+	_, err := q.SendGetRequest("/testing", map[string]string{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, core.getCalls())
+	// the backoff is at least half of 40ms for the first retry and half of 80ms for the second
+	assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+
+	// requests that are not idempotent are not retried, since a gateway can return 503 after the core
+	// processed the request
+	core.setFailures(1)
+	_, err = q.SendPostRequest("/testing", map[string]interface{}{}, nil)
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "with status code: 503"))
+	assert.Equal(t, 1, core.getCalls())
+
+	// 500 is not retried since the core might have processed the request
+	core.setFailures(1)
+	core.setFailureCode(500)
+	_, err = q.SendGetRequest("/testing", map[string]string{}, nil)
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "with status code: 500"))
+	assert.Equal(t, 1, core.getCalls())
+}
+
+func TestThatTheRetryBudgetIsConfigurable(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	core := newFailingCore(-1, 503)
+	defer core.server.Close()
+
+	maxRetries := 2
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI:  core.server.URL,
+		MaxRetries:     &maxRetries,
+		RetryBaseDelay: time.Millisecond,
+	})
+	defer resetQuerier()
+
+	_, err := q.SendGetRequest("/testing", map[string]string{}, nil)
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "with status code: 503"))
+	assert.Equal(t, 3, core.getCalls())
+
+	maxRetries = 0
+	resetAll()
+	core.setFailures(-1)
+	q = initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI: core.server.URL,
+		MaxRetries:    &maxRetries,
+	})
+
+	_, err = q.SendGetRequest("/testing", map[string]string{}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, core.getCalls())
+}
+
+func TestThatBackoffIsCancelledWithTheContext(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	core := newFailingCore(-1, supertokens.RateLimitStatusCode)
+	defer core.server.Close()
+
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI:  core.server.URL,
+		RetryBaseDelay: time.Second,
+	})
+	defer resetQuerier()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := q.SendGetRequest("/testing", map[string]string{}, supertokens.SetContextInUserContext(nil, ctx))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, 1, core.getCalls())
+}
+
+func TestThatCircuitBreakerSkipsFailingCoreHost(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	failingHost := newFailingCore(-1, 503)
+	defer failingHost.server.Close()
+	healthyHost := newFailingCore(0, 503)
+	defer healthyHost.server.Close()
+
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI:                  failingHost.server.URL + ";" + healthyHost.server.URL,
+		RetryBaseDelay:                 time.Millisecond,
+		CircuitBreakerFailureThreshold: 2,
+		CircuitBreakerCooldown:         200 * time.Millisecond,
+	})
+	defer resetQuerier()
+
+	for i := 0; i < 10; i++ {
+		_, err := q.SendGetRequest("/testing", map[string]string{}, nil)
+		assert.NoError(t, err)
+	}
+
+	// the failing host is only tried till its circuit opens
+	assert.Equal(t, 2, failingHost.getCalls())
+	assert.Equal(t, 10, healthyHost.getCalls())
+
+	// once the cooldown is over, the failing host is tried again
+	time.Sleep(250 * time.Millisecond)
+	failingHost.setFailures(0)
+	for i := 0; i < 4; i++ {
+		_, err := q.SendGetRequest("/testing", map[string]string{}, nil)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, failingHost.getCalls())
+}
+
+func TestThatCircuitBreakerSkipsUnreachableCoreHost(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	deadHost := newFailingCore(0, 503)
+	deadHostURL := deadHost.server.URL
+	deadHost.server.Close()
+	healthyHost := newFailingCore(0, 503)
+	defer healthyHost.server.Close()
+
+	var lock sync.Mutex
+	hostsTried := map[string]int{}
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI:                  deadHostURL + ";" + healthyHost.server.URL,
+		CircuitBreakerFailureThreshold: 1,
+		NetworkInterceptor: func(r *http.Request, uc supertokens.UserContext) (*http.Request, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			hostsTried[r.URL.Host]++
+			return r, nil
+		},
+	})
+	defer resetQuerier()
+
+	for i := 0; i < 6; i++ {
+		_, err := q.SendPutRequest("/testing", map[string]interface{}{}, nil)
+		assert.NoError(t, err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 1, hostsTried[strings.TrimPrefix(deadHostURL, "http://")])
+	assert.Equal(t, 6, healthyHost.getCalls())
+}
+
+func TestThatTimedOutCoreHostsAreFailedOver(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	slowHost := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slowHost.Close()
+	healthyHost := newFailingCore(0, 503)
+	defer healthyHost.server.Close()
+
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI:  slowHost.URL + ";" + healthyHost.server.URL,
+		RequestTimeout: 50 * time.Millisecond,
+	})
+	defer resetQuerier()
+
+	for i := 0; i < 2; i++ {
+		_, err := q.SendGetRequest("/testing", map[string]string{}, nil)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, healthyHost.getCalls())
+
+	// a request that is not idempotent may have been processed by the core, so it is not sent again
+	_, err := q.SendPostRequest("/testing", map[string]interface{}{}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 2, healthyHost.getCalls())
+}
+
+func TestThatErrorsOfBuildingCoreRequestsAreNotHostFailures(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	firstHost := newFailingCore(0, 503)
+	defer firstHost.server.Close()
+	secondHost := newFailingCore(0, 503)
+	defer secondHost.server.Close()
+
+	interceptorCalls := 0
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI:                  firstHost.server.URL + ";" + secondHost.server.URL,
+		CircuitBreakerFailureThreshold: 1,
+		NetworkInterceptor: func(r *http.Request, uc supertokens.UserContext) (*http.Request, error) {
+			interceptorCalls++
+			if r.URL.Path == "/fail" {
+				return nil, errors.New("interceptor error")
+			}
+			return r, nil
+		},
+	})
+	defer resetQuerier()
+
+	_, err := q.SendGetRequest("/fail", map[string]string{}, nil)
+	assert.EqualError(t, err, "interceptor error")
+	assert.Equal(t, 1, interceptorCalls)
+
+	// the circuit of the first host is still closed
+	for i := 0; i < 4; i++ {
+		_, err = q.SendGetRequest("/testing", map[string]string{}, nil)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, firstHost.getCalls())
+	assert.Equal(t, 2, secondHost.getCalls())
+}
+
+func TestThatCallerCancellationsDoNotOpenTheCircuit(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	var lock sync.Mutex
+	calls := 0
+	core := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		calls++
+		lock.Unlock()
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write([]byte("{}"))
+	}))
+	defer core.Close()
+	otherHost := newFailingCore(0, 503)
+	defer otherHost.server.Close()
+
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI:                  core.URL + ";" + otherHost.server.URL,
+		CircuitBreakerFailureThreshold: 1,
+	})
+	defer resetQuerier()
+
+	// the first call goes to the first host, and is not failed over when the caller gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := q.SendGetRequest("/slow", map[string]string{}, supertokens.SetContextInUserContext(nil, ctx))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 0, otherHost.getCalls())
+
+	for i := 0; i < 4; i++ {
+		_, err = q.SendPostRequest("/testing", map[string]interface{}{}, nil)
+		assert.NoError(t, err)
+	}
+
+	// the circuit of the first host is still closed
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 2, otherHost.getCalls())
+}
+
+func TestThatSharedCoreCallCacheIsUsedAcrossRequests(t *testing.T) {
+	resetAll()
+	defer resetAll()
+
+	var lock sync.Mutex
+	numberOfTimesGetCalled := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/recipe/role/permissions", func(rw http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		numberOfTimesGetCalled++
+		lock.Unlock()
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(200)
+		rw.Write([]byte(`{"status":"OK","permissions":["read"]}`))
+	})
+	mux.HandleFunc("/recipe/role/permissions/remove", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(200)
+		rw.Write([]byte(`{"status":"OK"}`))
+	})
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+
+	getCalls := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return numberOfTimesGetCalled
+	}
+
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI: testServer.URL,
+		SharedCoreCallCache: &supertokens.SharedCoreCallCacheConfig{
+			Rules: []supertokens.SharedCoreCallCacheRule{
+				supertokens.SharedCacheRuleForRolePermissions(200 * time.Millisecond),
+			},
+		},
+	})
+	defer resetQuerier()
+
+	for i := 0; i < 3; i++ {
+		// every iteration uses a new user context, like different requests would
+		response, err := q.SendGetRequest("/recipe/role/permissions", map[string]string{"role": "admin"}, &map[string]interface{}{})
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"read"}, response["permissions"])
+	}
+	assert.Equal(t, 1, getCalls())
+
+	// different query params are cached separately
+	_, err := q.SendGetRequest("/recipe/role/permissions", map[string]string{"role": "user"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, getCalls())
+
+	// writes to related paths invalidate the cache
+	_, err = q.SendPostRequest("/recipe/role/permissions/remove", map[string]interface{}{}, nil)
+	assert.NoError(t, err)
+	_, err = q.SendGetRequest("/recipe/role/permissions", map[string]string{"role": "admin"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, getCalls())
+
+	// the cache can be invalidated manually
+	path := "/recipe/role/permissions"
+	assert.NoError(t, supertokens.InvalidateSharedCoreCallCache(&path))
+	_, err = q.SendGetRequest("/recipe/role/permissions", map[string]string{"role": "admin"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, getCalls())
+
+	// entries expire after the TTL
+	time.Sleep(250 * time.Millisecond)
+	_, err = q.SendGetRequest("/recipe/role/permissions", map[string]string{"role": "admin"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, getCalls())
+}
+
+func TestThatSharedCoreCallCacheIsNotUsedWithANetworkInterceptor(t *testing.T) {
+	resetAll()
+	defer resetAll()
+
+	var lock sync.Mutex
+	roles := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/recipe/role/permissions", func(rw http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		roles = append(roles, r.URL.Query().Get("role"))
+		lock.Unlock()
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(200)
+		rw.Write([]byte(`{"status":"OK","permissions":["read"]}`))
+	})
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+
+	q := initWithConnectionInfo(t, &supertokens.ConnectionInfo{
+		ConnectionURI: testServer.URL,
+		SharedCoreCallCache: &supertokens.SharedCoreCallCacheConfig{
+			Rules: []supertokens.SharedCoreCallCacheRule{
+				supertokens.SharedCacheRuleForRolePermissions(time.Minute),
+			},
+		},
+		// the interceptor changes the request in ways that are not part of the cache key
+		NetworkInterceptor: func(r *http.Request, userContext supertokens.UserContext) (*http.Request, error) {
+			query := r.URL.Query()
+			query.Set("role", (*userContext)["role"].(string))
+			r.URL.RawQuery = query.Encode()
+			return r, nil
+		},
+	})
+	defer resetQuerier()
+
+	for _, role := range []string{"admin", "user"} {
+		_, err := q.SendGetRequest("/recipe/role/permissions", map[string]string{"role": "admin"}, &map[string]interface{}{"role": role})
+		assert.NoError(t, err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"admin", "user"}, roles)
+}
@@ -16,6 +16,7 @@
 package session
 
 import (
+	"context"
 	defaultErrors "errors"
 	"net/http"
 	"strconv"
@@ -37,6 +38,17 @@ type Recipe struct {
 
 	claimsAddedByOtherRecipes          []*claims.TypeSessionClaim
 	claimValidatorsAddedByOtherRecipes []claims.SessionClaimValidator
+
+	// scopedJWKSCache is used instead of jwksCache by recipes of instances created using supertokens.New,
+	// since their cores may use a different key set. It is a pointer so that copies of the recipe share it.
+	scopedJWKSCache *scopedJWKSCache
+
+	// events is nil if no session event handler is configured
+	events *sessionEventDispatcher
+}
+
+type scopedJWKSCache struct {
+	result *sessmodels.GetJWKSResult
 }
 
 const RECIPE_ID = "session"
@@ -47,6 +59,7 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 	r := &Recipe{
 		claimsAddedByOtherRecipes:          []*claims.TypeSessionClaim{},
 		claimValidatorsAddedByOtherRecipes: []claims.SessionClaimValidator{},
+		scopedJWKSCache:                    &scopedJWKSCache{},
 	}
 
 	r.RecipeModule = supertokens.MakeRecipeModule(recipeId, appInfo, r.handleAPIRequest, r.getAllCORSHeaders, r.getAPIsHandled, nil, r.handleError, onSuperTokensAPIError)
@@ -78,9 +91,16 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 	supertokens.LogDebugMessage("session init: SessionExpiredStatusCode: " + strconv.Itoa(verifiedConfig.SessionExpiredStatusCode))
 
 	r.Config = verifiedConfig
-	r.APIImpl = verifiedConfig.Override.APIs(MakeAPIImplementation())
+	apiImplementation := MakeAPIImplementation()
+	if !verifiedConfig.RecordDeviceInfo {
+		// the session management APIs are only exposed if the devices of the sessions are recorded
+		apiImplementation.SessionsGET = nil
+		apiImplementation.RevokeSessionPOST = nil
+		apiImplementation.RevokeOtherSessionsPOST = nil
+	}
+	r.APIImpl = verifiedConfig.Override.APIs(apiImplementation)
 
-	querierInstance, err := supertokens.GetNewQuerierInstanceOrThrowError(recipeId)
+	querierInstance, err := supertokens.GetNewQuerierInstanceForAppInfoOrThrowError(appInfo, recipeId)
 	if err != nil {
 		return Recipe{}, err
 	}
@@ -94,34 +114,52 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 		return Recipe{}, err
 	}
 
-	r.RecipeImpl = verifiedConfig.Override.Functions(recipeImplementation)
+	r.events = newSessionEventDispatcher(verifiedConfig.Events)
+	r.RecipeImpl = withImpersonation(withSessionEvents(withDeviceInfo(verifiedConfig.Override.Functions(recipeImplementation), verifiedConfig), r.events))
 	r.OpenIdRecipe = openIdRecipe
 
 	r.RecipeModule.ResetForTest = ResetForTest
+	r.RecipeModule.Shutdown = r.shutdown
 
 	return *r, nil
 }
 
-func getRecipeInstanceOrThrowError() (*Recipe, error) {
-	if singletonInstance != nil {
+func getRecipeInstanceOrThrowError(userContext ...supertokens.UserContext) (*Recipe, error) {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		if recipe, ok := instance.(*Recipe); ok {
+			return recipe, nil
+		}
+	} else if singletonInstance != nil {
 		return singletonInstance, nil
 	}
 	return nil, defaultErrors.New("Initialisation not done. Did you forget to call the init function?")
 }
 
-func GetRecipeInstanceOrThrowError() (*Recipe, error) {
-	return getRecipeInstanceOrThrowError()
+func GetRecipeInstanceOrThrowError(userContext ...supertokens.UserContext) (*Recipe, error) {
+	return getRecipeInstanceOrThrowError(userContext...)
+}
+
+// getRecipeInstanceForRequestOrThrowError returns the recipe of the instance that handled the request
+func getRecipeInstanceForRequestOrThrowError(req *http.Request) (*Recipe, error) {
+	return getRecipeInstanceOrThrowError(supertokens.SetContextInUserContext(nil, req.Context()))
 }
 
 func recipeInit(config *sessmodels.TypeInput) supertokens.Recipe {
 	return func(appInfo supertokens.NormalisedAppinfo, onSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)) (*supertokens.RecipeModule, error) {
-		if singletonInstance == nil {
+		if singletonInstance == nil || !supertokens.IsDefaultInstance(appInfo) {
 			recipe, err := MakeRecipe(RECIPE_ID, appInfo, config, onSuperTokensAPIError)
 			if err != nil {
 				return nil, err
 			}
-			singletonInstance = &recipe
-			return &singletonInstance.RecipeModule, nil
+			if supertokens.IsDefaultInstance(appInfo) {
+				singletonInstance = &recipe
+			} else {
+				err = supertokens.RegisterRecipeInstance(appInfo, RECIPE_ID, &recipe)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return &recipe.RecipeModule, nil
 		}
 		return nil, defaultErrors.New("Session recipe has already been initialised. Please check your code for bugs.")
 	}
@@ -138,6 +176,18 @@ func (r *Recipe) getAPIsHandled() ([]supertokens.APIHandled, error) {
 	if err != nil {
 		return nil, err
 	}
+	sessionsAPIPathNormalised, err := supertokens.NewNormalisedURLPath(SessionsAPIPath)
+	if err != nil {
+		return nil, err
+	}
+	revokeSessionAPIPathNormalised, err := supertokens.NewNormalisedURLPath(RevokeSessionAPIPath)
+	if err != nil {
+		return nil, err
+	}
+	revokeOtherSessionsAPIPathNormalised, err := supertokens.NewNormalisedURLPath(RevokeOtherSessionsAPIPath)
+	if err != nil {
+		return nil, err
+	}
 	resp := []supertokens.APIHandled{{
 		Method:                 http.MethodPost,
 		PathWithoutAPIBasePath: refreshAPIPathNormalised,
@@ -148,6 +198,21 @@ func (r *Recipe) getAPIsHandled() ([]supertokens.APIHandled, error) {
 		PathWithoutAPIBasePath: signoutAPIPathNormalised,
 		ID:                     SignoutAPIPath,
 		Disabled:               r.APIImpl.SignOutPOST == nil,
+	}, {
+		Method:                 http.MethodGet,
+		PathWithoutAPIBasePath: sessionsAPIPathNormalised,
+		ID:                     SessionsAPIPath,
+		Disabled:               r.APIImpl.SessionsGET == nil,
+	}, {
+		Method:                 http.MethodPost,
+		PathWithoutAPIBasePath: revokeSessionAPIPathNormalised,
+		ID:                     RevokeSessionAPIPath,
+		Disabled:               r.APIImpl.RevokeSessionPOST == nil,
+	}, {
+		Method:                 http.MethodPost,
+		PathWithoutAPIBasePath: revokeOtherSessionsAPIPathNormalised,
+		ID:                     RevokeOtherSessionsAPIPath,
+		Disabled:               r.APIImpl.RevokeOtherSessionsPOST == nil,
 	}}
 
 	jwtAPIs, err := r.OpenIdRecipe.RecipeModule.GetAPIsHandled()
@@ -174,6 +239,12 @@ func (r *Recipe) handleAPIRequest(id string, tenantId string, req *http.Request,
 		return HandleRefreshAPI(r.APIImpl, options, userContext)
 	} else if id == SignoutAPIPath {
 		return SignOutAPI(r.APIImpl, options, userContext)
+	} else if id == SessionsAPIPath {
+		return SessionsAPI(r.APIImpl, options, userContext)
+	} else if id == RevokeSessionAPIPath {
+		return RevokeSessionAPI(r.APIImpl, options, userContext)
+	} else if id == RevokeOtherSessionsAPIPath {
+		return RevokeOtherSessionsAPI(r.APIImpl, options, userContext)
 	} else {
 		return r.OpenIdRecipe.RecipeModule.HandleAPIRequest(id, tenantId, req, res, theirhandler, path, method, userContext)
 	}
@@ -181,6 +252,9 @@ func (r *Recipe) handleAPIRequest(id string, tenantId string, req *http.Request,
 
 func (r *Recipe) getAllCORSHeaders() []string {
 	resp := GetCORSAllowedHeaders()
+	if r.Config.DPoP != nil {
+		resp = append(resp, dpopHeaderKey)
+	}
 	resp = append(resp, r.OpenIdRecipe.RecipeModule.GetAllCORSHeaders()...)
 	return resp
 }
@@ -243,6 +317,9 @@ func (r *Recipe) getClaimValidatorsAddedByOtherRecipes() []claims.SessionClaimVa
 }
 
 func ResetForTest() {
+	if singletonInstance != nil {
+		singletonInstance.events.shutdown(context.Background())
+	}
 	singletonInstance = nil
 	openid.ResetForTest()
 }
@@ -17,11 +17,15 @@ package session
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	defaultErrors "errors"
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MicahParks/keyfunc/v2"
@@ -34,17 +38,41 @@ import (
 
 var jwksCache *sessmodels.GetJWKSResult = nil
 var mutex sync.RWMutex
+var jwksFetchGroup singleFlightGroup
 
-func getJWKSFromCacheIfPresent() *sessmodels.GetJWKSResult {
+// getJWKSCache returns where the JWKS of the core(s) used by the recipe are cached
+func (r *Recipe) getJWKSCache() **sessmodels.GetJWKSResult {
+	if supertokens.IsDefaultInstance(r.RecipeModule.GetAppInfo()) {
+		return &jwksCache
+	}
+	return &r.scopedJWKSCache.result
+}
+
+// shutdown stops the background refresh of the cached JWKS and removes it from the cache, and waits for the
+// queued session events to be handled
+func (r *Recipe) shutdown(ctx context.Context) error {
+	mutex.Lock()
+	cacheRef := r.getJWKSCache()
+	if *cacheRef != nil && (*cacheRef).JWKS != nil {
+		(*cacheRef).JWKS.EndBackground()
+	}
+	*cacheRef = nil
+	mutex.Unlock()
+
+	return r.events.shutdown(ctx)
+}
+
+func getJWKSFromCacheIfPresent(userContext supertokens.UserContext) *sessmodels.GetJWKSResult {
 	mutex.RLock()
 	defer mutex.RUnlock()
 
-	sessionInstance, err := getRecipeInstanceOrThrowError()
+	sessionInstance, err := getRecipeInstanceOrThrowError(userContext)
 	if err != nil {
 		return nil
 	}
 
-	if jwksCache != nil {
+	cachedJWKS := *sessionInstance.getJWKSCache()
+	if cachedJWKS != nil {
 		// This means that we have valid JWKs for the given core path
 		// We check if we need to refresh before returning
 		currentTime := time.Now().UnixNano() / int64(time.Millisecond)
@@ -54,7 +82,7 @@ func getJWKSFromCacheIfPresent() *sessmodels.GetJWKSResult {
 		// Note that this also means that the SDK will not try to query any other Core (if there are multiple)
 		// if it has a valid cache entry from one of the core URLs. It will only attempt to fetch
 		// from the cores again after the entry in the cache is expired
-		if (currentTime - jwksCache.LastFetched) < int64(sessionInstance.Config.JWKSRefreshIntervalSec*1000) {
+		if (currentTime - cachedJWKS.LastFetched) < int64(sessionInstance.Config.JWKSRefreshIntervalSec*1000) {
 			if supertokens.IsRunningInTestMode() {
 				if len(returnedFromCache) == cap(returnedFromCache) { // need to clear the channel if full because it's not being consumed in the test
 					close(returnedFromCache)
@@ -63,21 +91,22 @@ func getJWKSFromCacheIfPresent() *sessmodels.GetJWKSResult {
 				returnedFromCache <- true
 			}
 
-			return jwksCache
+			return cachedJWKS
 		}
 	}
 
 	return nil
 }
 
-func getJWKS() (*keyfunc.JWKS, error) {
-	corePaths := supertokens.GetAllCoreUrlsForPath("/.well-known/jwks.json")
+func getJWKS(userContext supertokens.UserContext) (*keyfunc.JWKS, error) {
+	corePaths := supertokens.GetAllCoreUrlsForPath("/.well-known/jwks.json", userContext)
 
 	if len(corePaths) == 0 {
 		return nil, defaultErrors.New("No SuperTokens core available to query. Please pass supertokens > connectionURI to the init function, or override all the functions of the recipe you are using.")
 	}
 
-	resultFromCache := getJWKSFromCacheIfPresent()
+	cacheCheckedAt := time.Now().UnixNano() / int64(time.Millisecond)
+	resultFromCache := getJWKSFromCacheIfPresent(userContext)
 
 	if resultFromCache != nil {
 		return resultFromCache.JWKS, nil
@@ -85,42 +114,21 @@ func getJWKS() (*keyfunc.JWKS, error) {
 
 	var lastError error
 
-	mutex.Lock()
-	defer mutex.Unlock()
-	for _, path := range corePaths {
-		if supertokens.IsRunningInTestMode() {
-			urlsAttemptedForJWKSFetch = append(urlsAttemptedForJWKSFetch, path)
-		}
+	cacheRef := &jwksCache
+	sessionInstance, err := getRecipeInstanceOrThrowError(userContext)
+	if err == nil {
+		cacheRef = sessionInstance.getJWKSCache()
+	}
 
-		// RefreshUnknownKID - Fetch JWKS again if the kid in the header of the JWT does not match any in
-		// the keyfunc library's cache
-		jwks, jwksError := keyfunc.Get(path, keyfunc.Options{
-			RefreshUnknownKID: true,
+	for _, path := range corePaths {
+		// Concurrent fetches from the same core for the same cache are coalesced, so that when the cache
+		// expires only one request is made no matter how many sessions are being verified
+		jwks, jwksError := jwksFetchGroup.do(supertokens.GetContextFromUserContext(userContext), fmt.Sprintf("%p %s", cacheRef, path), func(ctx context.Context) (interface{}, error) {
+			return fetchJWKSIntoCache(path, cacheRef, cacheCheckedAt)
 		})
 
 		if jwksError == nil {
-			jwksResult := sessmodels.GetJWKSResult{
-				JWKS:        jwks,
-				Error:       jwksError,
-				LastFetched: time.Now().UnixNano() / int64(time.Millisecond),
-			}
-
-			// Close any existing JWKS in the cache before replacing it
-			if jwksCache != nil && jwksCache.JWKS != nil {
-				jwksCache.JWKS.EndBackground()
-			}
-
-			jwksCache = &jwksResult
-
-			if supertokens.IsRunningInTestMode() {
-				if len(returnedFromCache) == cap(returnedFromCache) { // need to clear the channel if full because it's not being consumed in the test
-					close(returnedFromCache)
-					returnedFromCache = make(chan bool, 1000)
-				}
-				returnedFromCache <- false
-			}
-
-			return jwksResult.JWKS, nil
+			return jwks.(*keyfunc.JWKS), nil
 		}
 
 		lastError = jwksError
@@ -130,6 +138,58 @@ func getJWKS() (*keyfunc.JWKS, error) {
 	return nil, lastError
 }
 
+// fetchJWKSIntoCache fetches the JWKS from path and stores it in cacheRef, unless it has been fetched since
+// the cache was checked (at cacheCheckedAt), which happens if another fetch finished in the meantime
+func fetchJWKSIntoCache(path string, cacheRef **sessmodels.GetJWKSResult, cacheCheckedAt int64) (*keyfunc.JWKS, error) {
+	mutex.RLock()
+	cachedJWKS := *cacheRef
+	mutex.RUnlock()
+	if cachedJWKS != nil && cachedJWKS.JWKS != nil && cachedJWKS.LastFetched >= cacheCheckedAt {
+		return cachedJWKS.JWKS, nil
+	}
+
+	if supertokens.IsRunningInTestMode() {
+		mutex.Lock()
+		urlsAttemptedForJWKSFetch = append(urlsAttemptedForJWKSFetch, path)
+		mutex.Unlock()
+	}
+
+	// RefreshUnknownKID - Fetch JWKS again if the kid in the header of the JWT does not match any in
+	// the keyfunc library's cache
+	jwks, err := keyfunc.Get(path, keyfunc.Options{
+		RefreshUnknownKID: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	jwksResult := sessmodels.GetJWKSResult{
+		JWKS:        jwks,
+		Error:       err,
+		LastFetched: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+
+	// Close any existing JWKS in the cache before replacing it
+	if *cacheRef != nil && (*cacheRef).JWKS != nil {
+		(*cacheRef).JWKS.EndBackground()
+	}
+
+	*cacheRef = &jwksResult
+
+	if supertokens.IsRunningInTestMode() {
+		if len(returnedFromCache) == cap(returnedFromCache) { // need to clear the channel if full because it's not being consumed in the test
+			close(returnedFromCache)
+			returnedFromCache = make(chan bool, 1000)
+		}
+		returnedFromCache <- false
+	}
+
+	return jwksResult.JWKS, nil
+}
+
 /*
 *
 This function fetches all JWKs from the first available core instance. This combines the other JWKS functions to become
@@ -138,12 +198,19 @@ error resistant.
 Every core instance a backend is connected to is expected to connect to the same database and use the same key set for
 token verification. Otherwise, the result of session verification would depend on which core is currently available.
 */
-func GetCombinedJWKS() (*keyfunc.JWKS, error) {
+func GetCombinedJWKS(userContext ...supertokens.UserContext) (*keyfunc.JWKS, error) {
 	if supertokens.IsRunningInTestMode() {
+		mutex.Lock()
 		urlsAttemptedForJWKSFetch = []string{}
+		mutex.Unlock()
+	}
+
+	var userContextToUse supertokens.UserContext = nil
+	if len(userContext) > 0 {
+		userContextToUse = userContext[0]
 	}
 
-	jwksResult, err := getJWKS()
+	jwksResult, err := getJWKS(userContextToUse)
 
 	if err != nil {
 		return nil, err
@@ -152,8 +219,20 @@ func GetCombinedJWKS() (*keyfunc.JWKS, error) {
 	return jwksResult, nil
 }
 
+// getRefreshSingleFlightKey returns the key used to coalesce concurrent refreshes. The refresh token is hashed
+// so that it isn't kept as a key while the refresh is running.
+func getRefreshSingleFlightKey(refreshToken string, antiCsrfToken *string, disableAntiCsrf bool) string {
+	antiCsrf := ""
+	if antiCsrfToken != nil {
+		antiCsrf = *antiCsrfToken
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprint(refreshToken, "\x00", antiCsrf, "\x00", disableAntiCsrf)))
+	return hex.EncodeToString(hash[:])
+}
+
 func MakeRecipeImplementation(querier supertokens.Querier, config sessmodels.TypeNormalisedInput, appInfo supertokens.NormalisedAppinfo) sessmodels.RecipeInterface {
 	var result sessmodels.RecipeInterface
+	var refreshGroup singleFlightGroup
 
 	createNewSession := func(userID string, accessTokenPayload map[string]interface{}, sessionDataInDatabase map[string]interface{}, disableAntiCsrf *bool, tenantId string, userContext supertokens.UserContext) (sessmodels.SessionContainer, error) {
 		supertokens.LogDebugMessage("createNewSession: Started")
@@ -236,6 +315,15 @@ func MakeRecipeImplementation(querier supertokens.Querier, config sessmodels.Typ
 			}
 		}
 
+		if _, isBound := accessTokenResponse.Payload[dpopConfirmationKey]; isBound && (options == nil || !options.VerifiesDPoPProof) {
+			supertokens.LogDebugMessage("getSession: Returning UNAUTHORISED because the access token is bound to a DPoP key and no proof is verified")
+			False := false
+			return nil, errors.UnauthorizedError{
+				Msg:         "access tokens bound to a DPoP key need a DPoP proof",
+				ClearTokens: &False,
+			}
+		}
+
 		alwaysCheckCore := false
 
 		if options != nil && options.CheckDatabase != nil {
@@ -300,10 +388,24 @@ func MakeRecipeImplementation(querier supertokens.Querier, config sessmodels.Typ
 
 		supertokens.LogDebugMessage("refreshSession: Started")
 
-		response, err := refreshSessionHelper(config, querier, refreshToken, antiCsrfToken, disableAntiCsrf, config.UseDynamicAccessTokenSigningKey, userContext)
+		// A frontend with several tabs can refresh the same session in parallel. Those refreshes share a
+		// single call to the core, which is made with the user context of the first one, but isn't cancelled
+		// with its request.
+		refreshKey := getRefreshSingleFlightKey(refreshToken, antiCsrfToken, disableAntiCsrf)
+		// the first caller can stop waiting and keep using its user context while the call is running
+		sharedUserContext := copyUserContextWithoutCoreCallCache(userContext)
+		calledCore := int32(0)
+		refreshResult, err := refreshGroup.do(supertokens.GetContextFromUserContext(userContext), refreshKey, func(ctx context.Context) (interface{}, error) {
+			atomic.StoreInt32(&calledCore, 1)
+			return refreshSessionHelper(config, querier, refreshToken, antiCsrfToken, disableAntiCsrf, config.UseDynamicAccessTokenSigningKey, supertokens.SetContextInUserContext(sharedUserContext, ctx))
+		})
+		if atomic.LoadInt32(&calledCore) == 0 {
+			setSharedRefreshInUserContext(userContext)
+		}
 		if err != nil {
 			return nil, err
 		}
+		response := refreshResult.(sessmodels.CreateOrRefreshAPIResponse)
 		supertokens.LogDebugMessage("refreshSession: Success!")
 
 		responseToken, parseErr := ParseJWTWithoutSignatureVerification(response.AccessToken.Token)
@@ -357,19 +459,7 @@ func MakeRecipeImplementation(querier supertokens.Querier, config sessmodels.Typ
 			newAccessTokenPayload[k] = v
 		}
 
-		for k, _ := range newAccessTokenPayload {
-			if supertokens.DoesSliceContainString(k, protectedProps) {
-				delete(newAccessTokenPayload, k)
-			}
-		}
-
-		for k, v := range accessTokenPayloadUpdate {
-			if v == nil {
-				delete(newAccessTokenPayload, k)
-			} else {
-				newAccessTokenPayload[k] = v
-			}
-		}
+		mergeIntoAccessTokenPayloadWithoutProtectedProps(newAccessTokenPayload, accessTokenPayloadUpdate, userContext)
 
 		return updateAccessTokenPayloadHelper(querier, sessionHandle, newAccessTokenPayload, userContext)
 	}
@@ -385,7 +475,7 @@ func MakeRecipeImplementation(querier supertokens.Querier, config sessmodels.Typ
 			return sessmodels.ValidateClaimsResult{}, err
 		}
 
-		for _, validator := range claimValidators {
+		for _, validator := range claims.GetValidatorsToRefetch(claimValidators) {
 			supertokens.LogDebugMessage("updateClaimsInPayloadIfNeeded checking shouldRefetch for " + validator.ID)
 			claim := validator.Claim
 			if claim != nil && validator.ShouldRefetch != nil {
@@ -72,6 +72,7 @@ func newSessionContainer(config sessmodels.TypeNormalisedInput, session *Session
 	sessionContainer := &sessmodels.TypeSessionContainer{}
 
 	sessionContainer.RevokeSessionWithContext = func(userContext supertokens.UserContext) error {
+		setSessionToRevokeInUserContext(sessionContainer, userContext)
 		_, err := (*session.recipeImpl.RevokeSession)(session.sessionHandle, userContext)
 		if err != nil {
 			return err
@@ -146,23 +147,14 @@ func newSessionContainer(config sessmodels.TypeNormalisedInput, session *Session
 	sessionContainer.GetAccessTokenPayloadWithContext = func(userContext supertokens.UserContext) map[string]interface{} {
 		return session.userDataInAccessToken
 	}
+	sessionContainer.GetDPoPThumbprintWithContext = func(userContext supertokens.UserContext) *string {
+		return getDPoPThumbprint(session.userDataInAccessToken)
+	}
 
 	sessionContainer.MergeIntoAccessTokenPayloadWithContext = func(accessTokenPayloadUpdate map[string]interface{}, userContext supertokens.UserContext) error {
 		accessTokenPayload := sessionContainer.GetAccessTokenPayloadWithContext(userContext)
 
-		for k, _ := range accessTokenPayload {
-			if supertokens.DoesSliceContainString(k, protectedProps) {
-				delete(accessTokenPayload, k)
-			}
-		}
-
-		for k, v := range accessTokenPayloadUpdate {
-			if v == nil {
-				delete(accessTokenPayload, k)
-			} else {
-				accessTokenPayload[k] = v
-			}
-		}
+		mergeIntoAccessTokenPayloadWithoutProtectedProps(accessTokenPayload, accessTokenPayloadUpdate, userContext)
 
 		response, err := (*session.recipeImpl.RegenerateAccessToken)(sessionContainer.GetAccessToken(), &accessTokenPayload, userContext)
 
@@ -337,6 +329,10 @@ func newSessionContainer(config sessmodels.TypeNormalisedInput, session *Session
 		return sessionContainer.GetExpiryWithContext(&map[string]interface{}{})
 	}
 
+	sessionContainer.GetDPoPThumbprint = func() *string {
+		return sessionContainer.GetDPoPThumbprintWithContext(&map[string]interface{}{})
+	}
+
 	sessionContainer.MergeIntoAccessTokenPayload = func(accessTokenPayloadUpdate map[string]interface{}) error {
 		return sessionContainer.MergeIntoAccessTokenPayloadWithContext(accessTokenPayloadUpdate, &map[string]interface{}{})
 	}
@@ -62,7 +62,7 @@ func createNewSessionHelper(config sessmodels.TypeNormalisedInput, querier super
 func getSessionHelper(config sessmodels.TypeNormalisedInput, querier supertokens.Querier, parsedAccessToken sessmodels.ParsedJWTInfo, antiCsrfToken *string, doAntiCsrfCheck, alwaysCheckCore bool, userContext supertokens.UserContext) (sessmodels.GetSessionResponse, error) {
 	var accessTokenInfo *AccessTokenInfoStruct = nil
 	var err error = nil
-	combinedJwks, jwksError := GetCombinedJWKS()
+	combinedJwks, jwksError := GetCombinedJWKS(userContext)
 	if jwksError != nil {
 		supertokens.LogDebugMessage(fmt.Sprintf("getSessionHelper: Returning TryRefreshTokenError because there was an error fetching JWKs - %s", jwksError))
 		if !defaultErrors.As(jwksError, &errors.TryRefreshTokenError{}) {
@@ -262,8 +262,12 @@ func refreshSessionHelper(config sessmodels.TypeNormalisedInput, querier superto
 			SessionHandle: (response["session"].(map[string]interface{}))["handle"].(string),
 			UserID:        (response["session"].(map[string]interface{}))["userId"].(string),
 		}
+		sessionInfo.TenantId, _ = (response["session"].(map[string]interface{}))["tenantId"].(string)
 
-		supertokens.LogDebugMessage("refreshSession: Returning TOKEN_THEFT_DETECTED because of core response")
+		supertokens.AddToCounter(userContext, supertokens.MetricTokenTheftDetections, 1, map[string]interface{}{
+			"recipeId": RECIPE_ID,
+		})
+		supertokens.LogMessage(supertokens.LogLevelWarn, userContext, "refreshSession: Returning TOKEN_THEFT_DETECTED because of core response", "recipeId", RECIPE_ID, "session", supertokens.HashForLogging(sessionInfo.SessionHandle))
 		return sessmodels.CreateOrRefreshAPIResponse{}, errors.TokenTheftDetectedError{
 			Msg:     "Token theft detected",
 			Payload: sessionInfo,
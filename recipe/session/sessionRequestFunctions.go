@@ -56,6 +56,16 @@ func CreateNewSessionInRequest(req *http.Request, res http.ResponseWriter, tenan
 		finalAccessTokenPayload = _finalAccessTokenPayload
 	}
 
+	for k, v := range getSessionManagedPropsFromUserContext(userContext) {
+		if v != nil {
+			finalAccessTokenPayload[k] = v
+		}
+	}
+
+	if config.FingerprintBinding != nil {
+		finalAccessTokenPayload[fingerprintKey] = getFingerprintForPayload(config, req, userContext)
+	}
+
 	supertokens.LogDebugMessage("createNewSession: Access token payload built")
 
 	outputTokenTransferMethod := config.GetTokenTransferMethod(req, true, userContext)
@@ -70,6 +80,14 @@ func CreateNewSessionInRequest(req *http.Request, res http.ResponseWriter, tenan
 
 	supertokens.LogDebugMessage(fmt.Sprintf("createNewSession: using transfer method %s", outputTokenTransferMethod))
 
+	if config.DPoP != nil && outputTokenTransferMethod == sessmodels.HeaderTransferMethod && (config.DPoP.Required || len(req.Header.Values(dpopHeaderKey)) > 0) {
+		thumbprint, err := verifyDPoPProof(config, req, nil, userContext)
+		if err != nil {
+			return nil, supertokens.BadInputError{Msg: "invalid DPoP proof: " + err.Error()}
+		}
+		finalAccessTokenPayload[dpopConfirmationKey] = map[string]interface{}{"jkt": thumbprint}
+	}
+
 	isTopLevelAPIDomainIPAddress, err := supertokens.IsAnIPAddress(appInfo.TopLevelAPIDomain)
 	if err != nil {
 		return nil, err
@@ -108,11 +126,11 @@ func CreateNewSessionInRequest(req *http.Request, res http.ResponseWriter, tenan
 		return nil, err
 	}
 
-	supertokens.LogDebugMessage("createNewSession: Session created in core built")
+	supertokens.LogMessage(supertokens.LogLevelDebug, userContext, "createNewSession: Session created in core built", "recipeId", RECIPE_ID, "tenantId", tenantId, "session", supertokens.HashForLogging(sessionResponse.GetHandleWithContext(userContext)))
 
 	for _, tokenTransferMethod := range AvailableTokenTransferMethods {
 		if tokenTransferMethod != outputTokenTransferMethod {
-			token, err := GetToken(req, sessmodels.AccessToken, tokenTransferMethod)
+			token, err := GetTokenWithConfig(config, req, sessmodels.AccessToken, tokenTransferMethod)
 			if err != nil {
 				return nil, err
 			}
@@ -150,7 +168,7 @@ func GetSessionFromRequest(req *http.Request, res http.ResponseWriter, config se
 
 	// We check all token transfer methods for available access tokens
 	for _, tokenTransferMethod := range AvailableTokenTransferMethods {
-		token, err := GetToken(req, sessmodels.AccessToken, tokenTransferMethod)
+		token, err := GetTokenWithConfig(config, req, sessmodels.AccessToken, tokenTransferMethod)
 		if err != nil {
 			return nil, err
 		}
@@ -186,7 +204,7 @@ func GetSessionFromRequest(req *http.Request, res http.ResponseWriter, config se
 		// If multiple access tokens exist in the request cookie, throw TRY_REFRESH_TOKEN.
 		// This prompts the client to call the refresh endpoint, clearing olderCookieDomain cookies (if set).
 		// ensuring outdated token payload isn't used.
-		if hasMultipleCookiesForTokenType(req, sessmodels.AccessToken) {
+		if hasMultipleCookiesForTokenType(config, req, sessmodels.AccessToken) {
 			supertokens.LogDebugMessage("getSession: Throwing TRY_REFRESH_TOKEN because multiple access tokens are present in request cookies")
 
 			return nil, errors.TryRefreshTokenError{
@@ -259,6 +277,9 @@ func GetSessionFromRequest(req *http.Request, res http.ResponseWriter, config se
 		}
 	}
 
+	// the proof is verified below
+	_verifySessionOptionsToPass.VerifiesDPoPProof = config.DPoP != nil
+
 	var rawTokenString *string
 
 	if accessToken != nil {
@@ -287,8 +308,33 @@ func GetSessionFromRequest(req *http.Request, res http.ResponseWriter, config se
 			return nil, err
 		}
 
+		if thumbprint := getDPoPThumbprint((*sessionResult).GetAccessTokenPayloadWithContext(userContext)); config.DPoP != nil && thumbprint != nil {
+			// the proof is bound to the token that was sent in the request, which may have been replaced by the core
+			accessTokenString := rawTokenString
+			if accessTokenString == nil {
+				sessionAccessToken := (*sessionResult).GetAccessTokenWithContext(userContext)
+				accessTokenString = &sessionAccessToken
+			}
+			err = verifyDPoPProofForSession(config, req, accessTokenString, *thumbprint, userContext)
+			if err != nil {
+				supertokens.LogDebugMessage("getSession: Returning UnauthorizedError because of an invalid DPoP proof: " + err.Error())
+				clearTokens := false
+				return nil, errors.UnauthorizedError{
+					Msg:         "invalid DPoP proof: " + err.Error(),
+					ClearTokens: &clearTokens,
+				}
+			}
+		}
+
+		if config.FingerprintBinding != nil {
+			claimValidators = append(claimValidators, makeFingerprintValidator(config, req, sessionResult.GetHandleWithContext(userContext)))
+		}
+
 		err = (*sessionResult).AssertClaimsWithContext(claimValidators, userContext)
 		if err != nil {
+			if config.FingerprintBinding != nil {
+				return nil, handleFingerprintValidationError(err, config, sessionResult, recipeImpl, userContext)
+			}
 			return nil, err
 		}
 
@@ -331,7 +377,7 @@ func RefreshSessionInRequest(req *http.Request, res http.ResponseWriter, config
 	// We check all token transfer methods for available refresh tokens
 	// We do this so that we can later clear all we are not overwriting
 	for _, tokenTransferMethod := range AvailableTokenTransferMethods {
-		token, err := GetToken(req, sessmodels.RefreshToken, tokenTransferMethod)
+		token, err := GetTokenWithConfig(config, req, sessmodels.RefreshToken, tokenTransferMethod)
 		if err != nil {
 			return nil, err
 		}
@@ -366,7 +412,7 @@ func RefreshSessionInRequest(req *http.Request, res http.ResponseWriter, config
 		// - the allowedTransferMethod is 'cookie' or 'any', and
 		// - an access token cookie exists (otherwise it'd be a no-op)
 		// See: https://github.com/supertokens/supertokens-node/issues/790
-		token, err := GetToken(req, sessmodels.AccessToken, sessmodels.CookieTransferMethod)
+		token, err := GetTokenWithConfig(config, req, sessmodels.AccessToken, sessmodels.CookieTransferMethod)
 		if err != nil {
 			return nil, err
 		}
@@ -416,6 +462,21 @@ func RefreshSessionInRequest(req *http.Request, res http.ResponseWriter, config
 		disableAntiCSRF = true
 	}
 
+	// the proof is verified before the tokens are rotated, so that an invalid proof doesn't use up the refresh token
+	var proofThumbprint *string
+	if config.DPoP != nil && (len(req.Header.Values(dpopHeaderKey)) > 0 || (config.DPoP.Required && requestTokenTransferMethod == sessmodels.HeaderTransferMethod)) {
+		thumbprint, err := verifyDPoPProof(config, req, nil, userContext)
+		if err != nil {
+			supertokens.LogDebugMessage("refreshSession: Returning UnauthorizedError because of an invalid DPoP proof: " + err.Error())
+			clearTokens := false
+			return nil, errors.UnauthorizedError{
+				Msg:         "invalid DPoP proof: " + err.Error(),
+				ClearTokens: &clearTokens,
+			}
+		}
+		proofThumbprint = &thumbprint
+	}
+
 	result, err := (*recipeImpl.RefreshSession)(*refreshToken, antiCsrfToken, disableAntiCSRF, userContext)
 
 	if err != nil {
@@ -438,6 +499,28 @@ func RefreshSessionInRequest(req *http.Request, res http.ResponseWriter, config
 		return nil, err
 	}
 
+	if thumbprint := getDPoPThumbprint((*result).GetAccessTokenPayloadWithContext(userContext)); config.DPoP != nil && thumbprint != nil && (proofThumbprint == nil || *proofThumbprint != *thumbprint) {
+		// the refresh token was used without the key of the session, so it has probably been stolen
+		setSessionToRevokeInUserContext(result, userContext)
+		_, revokeErr := (*recipeImpl.RevokeSession)((*result).GetHandleWithContext(userContext), userContext)
+		if revokeErr != nil {
+			return nil, revokeErr
+		}
+		supertokens.LogDebugMessage("refreshSession: Returning UnauthorizedError because the DPoP proof is not signed with the key of the session")
+		clearTokens := true
+		return nil, errors.UnauthorizedError{
+			Msg:         "invalid DPoP proof: the proof is not signed with the key the session is bound to",
+			ClearTokens: &clearTokens,
+		}
+	}
+
+	if config.FingerprintBinding != nil {
+		err = bindRefreshedSessionToFingerprint(config, result, req, recipeImpl, userContext)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	supertokens.LogDebugMessage("refreshSession: Attaching refreshed session info as " + string(requestTokenTransferMethod))
 
 	for _, tokenTransferMethod := range AvailableTokenTransferMethods {
@@ -452,7 +535,11 @@ func RefreshSessionInRequest(req *http.Request, res http.ResponseWriter, config
 		TokenTransferMethod: requestTokenTransferMethod,
 	}, userContext)
 
-	supertokens.LogDebugMessage("refreshSession: Success!")
+	supertokens.AddToCounter(userContext, supertokens.MetricSessionRefreshes, 1, map[string]interface{}{
+		"recipeId": RECIPE_ID,
+		"tenantId": (*result).GetTenantIdWithContext(userContext),
+	})
+	supertokens.LogMessage(supertokens.LogLevelDebug, userContext, "refreshSession: Success!", "recipeId", RECIPE_ID, "tenantId", (*result).GetTenantIdWithContext(userContext), "session", supertokens.HashForLogging((*result).GetHandleWithContext(userContext)))
 
 	if GetCookieValue(req, legacyIdRefreshTokenCookieName) != nil {
 		supertokens.LogDebugMessage("refreshSession: cleared legacy id refresh token after successful refresh")
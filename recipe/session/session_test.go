@@ -1607,7 +1607,7 @@ func TestThatJWKSResultIsRefreshedProperly(t *testing.T) {
 		t.Error(err.Error())
 	}
 
-	jwksBefore, err := getJWKS()
+	jwksBefore, err := getJWKS(nil)
 
 	if err != nil {
 		t.Error(err.Error())
@@ -1617,7 +1617,7 @@ func TestThatJWKSResultIsRefreshedProperly(t *testing.T) {
 
 	time.Sleep(3 * time.Second)
 
-	jwksAfter, err := getJWKS()
+	jwksAfter, err := getJWKS(nil)
 
 	if err != nil {
 		t.Error(err.Error())
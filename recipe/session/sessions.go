@@ -0,0 +1,124 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func SessionsAPI(apiImplementation sessmodels.APIInterface, options sessmodels.APIOptions, userContext supertokens.UserContext) error {
+	if apiImplementation.SessionsGET == nil || (*apiImplementation.SessionsGET == nil) {
+		options.OtherHandler.ServeHTTP(options.Res, options.Req)
+		return nil
+	}
+
+	sessionContainer, err := GetSessionFromRequest(options.Req, options.Res, options.Config, nil, options.RecipeImplementation, userContext)
+	if err != nil {
+		return err
+	}
+
+	resp, err := (*apiImplementation.SessionsGET)(sessionContainer, options, userContext)
+	if err != nil {
+		return err
+	}
+
+	if resp.OK != nil {
+		sessions := resp.OK.Sessions
+		if sessions == nil {
+			sessions = []sessmodels.SessionWithDeviceInfo{}
+		}
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, map[string]interface{}{
+			"status":   "OK",
+			"sessions": sessions,
+		})
+	} else if resp.GeneralError != nil {
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, supertokens.ConvertGeneralErrorToJsonResponse(*resp.GeneralError))
+	}
+	return supertokens.ErrorIfNoResponse(options.Res)
+}
+
+func RevokeSessionAPI(apiImplementation sessmodels.APIInterface, options sessmodels.APIOptions, userContext supertokens.UserContext) error {
+	if apiImplementation.RevokeSessionPOST == nil || (*apiImplementation.RevokeSessionPOST == nil) {
+		options.OtherHandler.ServeHTTP(options.Res, options.Req)
+		return nil
+	}
+
+	readBody, err := supertokens.ReadJSONObjectFromRequest(options.Req, "sessionHandle")
+	if err != nil {
+		return err
+	}
+	sessionHandle, ok := readBody["sessionHandle"].(string)
+	if !ok || sessionHandle == "" {
+		return supertokens.BadInputError{Msg: "Please provide the sessionHandle as a string"}
+	}
+
+	// admins must not revoke the sessions of the users they impersonate
+	sessionContainer, err := GetSessionFromRequest(options.Req, options.Res, options.Config, WithClaimValidators(nil, RequireNoImpersonation()), options.RecipeImplementation, userContext)
+	if err != nil {
+		return err
+	}
+
+	resp, err := (*apiImplementation.RevokeSessionPOST)(sessionHandle, sessionContainer, options, userContext)
+	if err != nil {
+		return err
+	}
+
+	if resp.OK != nil {
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, map[string]interface{}{
+			"status": "OK",
+		})
+	} else if resp.UnknownSessionError != nil {
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, map[string]interface{}{
+			"status": "UNKNOWN_SESSION_ERROR",
+		})
+	} else if resp.GeneralError != nil {
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, supertokens.ConvertGeneralErrorToJsonResponse(*resp.GeneralError))
+	}
+	return supertokens.ErrorIfNoResponse(options.Res)
+}
+
+func RevokeOtherSessionsAPI(apiImplementation sessmodels.APIInterface, options sessmodels.APIOptions, userContext supertokens.UserContext) error {
+	if apiImplementation.RevokeOtherSessionsPOST == nil || (*apiImplementation.RevokeOtherSessionsPOST == nil) {
+		options.OtherHandler.ServeHTTP(options.Res, options.Req)
+		return nil
+	}
+
+	// admins must not revoke the sessions of the users they impersonate
+	sessionContainer, err := GetSessionFromRequest(options.Req, options.Res, options.Config, WithClaimValidators(nil, RequireNoImpersonation()), options.RecipeImplementation, userContext)
+	if err != nil {
+		return err
+	}
+
+	resp, err := (*apiImplementation.RevokeOtherSessionsPOST)(sessionContainer, options, userContext)
+	if err != nil {
+		return err
+	}
+
+	if resp.OK != nil {
+		revokedSessionHandles := resp.OK.RevokedSessionHandles
+		if revokedSessionHandles == nil {
+			revokedSessionHandles = []string{}
+		}
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, map[string]interface{}{
+			"status":                "OK",
+			"revokedSessionHandles": revokedSessionHandles,
+		})
+	} else if resp.GeneralError != nil {
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, supertokens.ConvertGeneralErrorToJsonResponse(*resp.GeneralError))
+	}
+	return supertokens.ErrorIfNoResponse(options.Res)
+}
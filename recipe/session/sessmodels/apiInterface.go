@@ -26,9 +26,43 @@ type APIInterface struct {
 	RefreshPOST   *func(options APIOptions, userContext supertokens.UserContext) (SessionContainer, error)
 	SignOutPOST   *func(sessionContainer SessionContainer, options APIOptions, userContext supertokens.UserContext) (SignOutPOSTResponse, error)
 	VerifySession *func(verifySessionOptions *VerifySessionOptions, options APIOptions, userContext supertokens.UserContext) (SessionContainer, error)
+
+	SessionsGET             *func(sessionContainer SessionContainer, options APIOptions, userContext supertokens.UserContext) (SessionsGETResponse, error)
+	RevokeSessionPOST       *func(sessionHandle string, sessionContainer SessionContainer, options APIOptions, userContext supertokens.UserContext) (RevokeSessionPOSTResponse, error)
+	RevokeOtherSessionsPOST *func(sessionContainer SessionContainer, options APIOptions, userContext supertokens.UserContext) (RevokeOtherSessionsPOSTResponse, error)
 }
 
 type SignOutPOSTResponse struct {
 	OK           *struct{}
 	GeneralError *supertokens.GeneralErrorResponse
 }
+
+type SessionsGETResponse struct {
+	OK *struct {
+		Sessions []SessionWithDeviceInfo
+	}
+	GeneralError *supertokens.GeneralErrorResponse
+}
+
+type SessionWithDeviceInfo struct {
+	SessionHandle string      `json:"sessionHandle"`
+	TenantId      string      `json:"tenantId"`
+	IsCurrent     bool        `json:"current"`
+	TimeCreated   uint64      `json:"timeCreated"`
+	Expiry        uint64      `json:"expiry"`
+	Device        *DeviceInfo `json:"device,omitempty"`
+}
+
+type RevokeSessionPOSTResponse struct {
+	OK *struct{}
+	// UnknownSessionError is returned if the session does not exist, or does not belong to the user
+	UnknownSessionError *struct{}
+	GeneralError        *supertokens.GeneralErrorResponse
+}
+
+type RevokeOtherSessionsPOSTResponse struct {
+	OK *struct {
+		RevokedSessionHandles []string
+	}
+	GeneralError *supertokens.GeneralErrorResponse
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/supertokens/supertokens-golang/recipe/openid/openidmodels"
 	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	"github.com/supertokens/supertokens-golang/recipe/session/errors"
 	"github.com/supertokens/supertokens-golang/supertokens"
 )
 
@@ -112,6 +113,37 @@ type TypeInput struct {
 	ExposeAccessTokenToFrontendInCookieBasedAuth bool
 	UseDynamicAccessTokenSigningKey              *bool
 	JWKSRefreshIntervalSec                       *uint64
+	Events                                       *SessionEventsConfig
+	// RecordDeviceInfo stores the user agent and IP address of the device that uses a session, and when it
+	// was first and last seen, in the session data in the database. It also enables the APIs that list the
+	// sessions of the user and revoke them (see SessionsGET, RevokeSessionPOST and RevokeOtherSessionsPOST).
+	// If the device changed or was last seen more than an hour ago, a refresh fetches the session data and
+	// replaces it with one that only has the device info changed. Calls to UpdateSessionDataInDatabase in the
+	// same process wait for this, but calls in other processes made at the same time can be overwritten.
+	RecordDeviceInfo bool
+	// ReauthenticateOnSignIn makes the sign in APIs update the auth time of the existing session of the
+	// request, instead of creating a new session, if it belongs to the user who signs in. See
+	// session.CreateNewSessionForSignIn.
+	ReauthenticateOnSignIn bool
+	// FingerprintBinding binds sessions to a fingerprint of the client that created them. It is disabled if
+	// nil.
+	FingerprintBinding *FingerprintBindingConfig
+	// DPoP enables sender-constrained access tokens (RFC 9449) for sessions that use the header transfer
+	// method. It is disabled if nil.
+	DPoP *DPoPConfig
+	// CookieNamePrefix is prepended to the names of the session cookies (sAccessToken and sRefreshToken), so
+	// that apps on sibling subdomains don't overwrite each other's cookies.
+	CookieNamePrefix *string
+	// CookieSecurityPrefix is "__Host-" or "__Secure-", and is prepended to the names of the session cookies so
+	// that browsers enforce their attributes. Both require CookieSecure, and "__Host-" also requires that
+	// CookieDomain and OlderCookieDomain are not set. Since "__Host-" cookies must have the path "/" and the
+	// refresh token cookie only has the path of the refresh API, the refresh token cookie is named with
+	// "__Secure-" instead when "__Host-" is set, so it doesn't get the guarantees of "__Host-" (subdomains
+	// can still set it).
+	CookieSecurityPrefix *string
+	// CookiePartitioned adds the Partitioned attribute (CHIPS) to the session cookies, which lets browsers that
+	// block third party cookies keep them when the app is embedded in an iframe. It requires CookieSecure.
+	CookiePartitioned bool
 }
 
 type OverrideStruct struct {
@@ -132,6 +164,9 @@ type TypeNormalisedInput struct {
 	RefreshTokenPath                             supertokens.NormalisedURLPath
 	CookieDomain                                 *string
 	OlderCookieDomain                            *string
+	CookieNamePrefix                             string
+	CookieSecurityPrefix                         string
+	CookiePartitioned                            bool
 	GetCookieSameSite                            func(request *http.Request, userContext supertokens.UserContext) (string, error)
 	CookieSecure                                 bool
 	SessionExpiredStatusCode                     int
@@ -143,6 +178,150 @@ type TypeNormalisedInput struct {
 	ExposeAccessTokenToFrontendInCookieBasedAuth bool
 	UseDynamicAccessTokenSigningKey              bool
 	JWKSRefreshIntervalSec                       uint64
+	Events                                       SessionEventsConfig
+	RecordDeviceInfo                             bool
+	ReauthenticateOnSignIn                       bool
+	FingerprintBinding                           *FingerprintBindingConfig
+	DPoP                                         *DPoPConfig
+}
+
+// VerifyConnectionOptions configures session.VerifyConnection
+type VerifyConnectionOptions struct {
+	// VerifySessionOptions are used to verify the session when the connection is opened. Its claim validators
+	// are checked again whenever the session is rechecked.
+	VerifySessionOptions *VerifySessionOptions
+	// AccessTokenQueryParam is the name of a query parameter that the access token can be sent in, for clients
+	// that can't send it in a header or a cookie. It is disabled if empty, since URLs are often logged.
+	AccessTokenQueryParam string
+	// AllowAccessTokenInSubprotocol lets browsers send the access token in the Sec-WebSocket-Protocol header
+	// of the handshake, as a subprotocol named "st-access-token.<access token>". The server must not select
+	// this subprotocol in its response.
+	AllowAccessTokenInSubprotocol bool
+	// RecheckInterval is how often the session is checked for revocation and its claims are validated.
+	// Defaults to 1 minute, and periodic rechecks are disabled if it is negative.
+	RecheckInterval time.Duration
+	// OnSessionEnded is called once when the session of the connection ends, and should close the connection
+	// (for example with the close code returned by err.WebSocketCloseCode()).
+	OnSessionEnded func(err errors.SessionEndedError)
+}
+
+// DPoPConfig configures DPoP (RFC 9449). A session that is created with a DPoP proof in the request is bound to
+// the thumbprint of the public key of the proof, which is stored in the "cnf" claim of the access token payload.
+// Requests that use the session (and refresh it) must then include a DPoP proof signed with the same key. Bound
+// access tokens are rejected where no proof can be verified (GetSessionWithoutRequestResponse, unless
+// VerifySessionOptions.VerifiesDPoPProof is set, tokens of VerifyConnection sent in a query parameter or a
+// subprotocol, and the gRPC and Twirp adapters). Proofs must be signed with an EC, Ed25519, or RSA key of at
+// least 2048 bits.
+type DPoPConfig struct {
+	// Required rejects the creation of sessions that use the header transfer method without a valid DPoP
+	// proof. Otherwise, only sessions created with a proof are bound to a key.
+	Required bool
+	// ProofMaxAge is how far the iat of a proof can be from the current time. Defaults to 60 seconds.
+	ProofMaxAge time.Duration
+	// JTIStore keeps the jti of recent proofs, so that proofs can't be replayed. Defaults to an in-memory
+	// store (see session.NewInMemoryDPoPJTIStore), which only works if a single process serves the APIs.
+	JTIStore DPoPJTIStore
+	// GetRequestURL returns the URL that proofs for req must be made for (the htu claim), without the query
+	// and fragment. Defaults to the API domain followed by the path of req.
+	GetRequestURL func(req *http.Request, userContext supertokens.UserContext) string
+}
+
+// DPoPJTIStore keeps the jti of DPoP proofs until they expire
+type DPoPJTIStore interface {
+	// Add stores jti until expiresAt. It returns false if jti is already stored.
+	Add(jti string, expiresAt time.Time, userContext supertokens.UserContext) (bool, error)
+}
+
+type FingerprintMismatchMode string
+
+const (
+	// FingerprintLogOnly logs requests whose fingerprint doesn't match their session, and accepts them
+	FingerprintLogOnly FingerprintMismatchMode = "LOG_ONLY"
+	// FingerprintForceRefresh rejects requests whose fingerprint doesn't match their session with a try
+	// refresh token error. Refreshing the session binds it to the new fingerprint.
+	FingerprintForceRefresh FingerprintMismatchMode = "FORCE_REFRESH"
+	// FingerprintRevoke revokes sessions that are used by a request whose fingerprint doesn't match, and
+	// rejects the request with an invalid claim error (or an unauthorised error for the refresh API)
+	FingerprintRevoke FingerprintMismatchMode = "REVOKE"
+)
+
+// FingerprintBindingConfig configures how sessions are bound to the client that created them. The fingerprint of
+// the request that creates a session is stored, hashed, in its access token payload, and compared with the
+// fingerprint of the requests that use the session in session.GetSession, session.VerifySession,
+// session.VerifyConnection and the refresh API. A mismatch fails the "st-fp" claim validator.
+//
+// The fingerprint is not checked by session.GetSessionWithoutRequestResponse or the gRPC and Twirp adapters,
+// since they don't have the request the fingerprint is computed from. Add the check to the claim validators
+// of these calls if they need it.
+type FingerprintBindingConfig struct {
+	// Mode decides what happens when the fingerprints don't match. Defaults to FingerprintLogOnly.
+	Mode FingerprintMismatchMode
+	// GetFingerprint returns the parts of the fingerprint of a request, by name. Defaults to the IP address
+	// prefix (see session.GetIPAddressPrefix) as "ip" and the user agent as "ua".
+	GetFingerprint func(req *http.Request, userContext supertokens.UserContext) map[string]string
+	// AllowMismatch is called with the names of the parts of the fingerprint that don't match. The request
+	// is accepted if it returns true, for example to allow a mobile client to change networks.
+	AllowMismatch func(mismatchedParts []string, req *http.Request, userContext supertokens.UserContext) bool
+	// HashKey is a secret used to hash the parts of the fingerprint (using HMAC-SHA256) before they are
+	// stored in the access token payload, which the frontend can read, so that values with few possibilities
+	// (like the IP address prefix) stay secret. It is required. Changing it makes the fingerprints of existing
+	// sessions mismatch.
+	HashKey string
+}
+
+type SessionEventType string
+
+const (
+	SessionCreatedEvent            SessionEventType = "SESSION_CREATED"
+	SessionRefreshedEvent          SessionEventType = "SESSION_REFRESHED"
+	SessionRevokedEvent            SessionEventType = "SESSION_REVOKED"
+	SessionTokenTheftDetectedEvent SessionEventType = "TOKEN_THEFT_DETECTED"
+)
+
+// SessionEvent describes a change in the lifecycle of a session. IPAddress and UserAgent are only set if the
+// change was made while handling a request, and UserID and TenantId are empty for some revoked sessions.
+// Concurrent refreshes of the same session that share a call to the core result in one SessionRefreshedEvent.
+type SessionEvent struct {
+	Type SessionEventType
+	Time time.Time
+	// UserID and TenantId are empty for the events of RevokeMultipleSessions, since the core only returns
+	// the handles of the revoked sessions. They are also empty for the events of RevokeSession if it is
+	// called with only the handle of the session (they are set if the session is revoked through its
+	// session container, for example when signing out), since the session is not fetched to fill them in.
+	UserID        string
+	TenantId      string
+	SessionHandle string
+	IPAddress     string
+	UserAgent     string
+	// ImpersonatorUserID is the ID of the admin who acts as UserID in an impersonation session (see
+	// session.CreateImpersonationSession). It is empty for other sessions, and for the revoked events whose
+	// UserID is empty or that are dispatched by RevokeAllSessionsForUser.
+	ImpersonatorUserID string
+	// Reason is the function that revoked the session ("REVOKE_SESSION", "REVOKE_ALL_SESSIONS_FOR_USER" or
+	// "REVOKE_MULTIPLE_SESSIONS") for SessionRevokedEvent, and empty for the other events
+	Reason string
+}
+
+type SessionEventsConfig struct {
+	// Handler is called with each event, one at a time, on a separate goroutine
+	Handler func(event SessionEvent)
+	// QueueSize is the number of events that can wait for Handler. Events that arrive while the queue is full
+	// are dropped (and logged), so that a slow Handler never slows down requests. Defaults to 1000.
+	QueueSize int
+	// GetIPAddress returns the IP address for events created while handling req, for the device info
+	// recorded if RecordDeviceInfo is set, and for the default fingerprint of FingerprintBinding. Defaults
+	// to the host of req.RemoteAddr. Override it to read headers like X-Forwarded-For when running behind a
+	// trusted proxy.
+	GetIPAddress func(req *http.Request) string
+}
+
+// DeviceInfo is stored in the session data in the database if RecordDeviceInfo is set. The times are in
+// milliseconds since epoch.
+type DeviceInfo struct {
+	UserAgent string `json:"userAgent"`
+	IPAddress string `json:"ipAddress"`
+	FirstSeen uint64 `json:"firstSeen"`
+	LastSeen  uint64 `json:"lastSeen"`
 }
 
 type AntiCsrfFunctionOrString struct {
@@ -161,6 +340,26 @@ type VerifySessionOptions struct {
 	SessionRequired               *bool
 	CheckDatabase                 *bool
 	OverrideGlobalClaimValidators func(globalClaimValidators []claims.SessionClaimValidator, sessionContainer SessionContainer, userContext supertokens.UserContext) ([]claims.SessionClaimValidator, error)
+	// VerifiesDPoPProof has to be set by callers that verify the DPoP proof of access tokens bound to a DPoP key
+	// themselves. Otherwise, such tokens are rejected, since they must not be accepted as plain bearer tokens.
+	// GetSession sets it when DPoP is enabled.
+	VerifiesDPoPProof bool
+}
+
+// AuthorizationRule requires a session, and that ClaimValidators pass, for requests that match Method and
+// Path, unless Public is set. See session.VerifySessionWithAuthorizationRules.
+type AuthorizationRule struct {
+	// Method is the HTTP method of the requests the rule applies to. It matches every method if empty or "*".
+	Method string
+	// Path is a pattern for the paths of the requests the rule applies to. A segment that is "*" or starts
+	// with ":" (like "/users/:userId") matches any single segment, and a last segment that is "**" matches
+	// any number of segments (including none).
+	Path string
+	// ClaimValidators are checked in addition to the global claim validators
+	ClaimValidators []claims.SessionClaimValidator
+	// Public lets requests that match the rule through without a session. Since requests that match no rule
+	// are rejected, routes that don't need a session have to be listed with Public set.
+	Public bool
 }
 
 type APIOptions struct {
@@ -208,6 +407,7 @@ type TypeSessionContainer struct {
 	GetAccessToken                 func() string
 	GetTimeCreated                 func() (uint64, error)
 	GetExpiry                      func() (uint64, error)
+	GetDPoPThumbprint              func() *string
 
 	RevokeSessionWithContext               func(userContext supertokens.UserContext) error
 	GetSessionDataInDatabaseWithContext    func(userContext supertokens.UserContext) (map[string]interface{}, error)
@@ -219,6 +419,7 @@ type TypeSessionContainer struct {
 	GetAccessTokenWithContext              func(userContext supertokens.UserContext) string
 	GetTimeCreatedWithContext              func(userContext supertokens.UserContext) (uint64, error)
 	GetExpiryWithContext                   func(userContext supertokens.UserContext) (uint64, error)
+	GetDPoPThumbprintWithContext           func(userContext supertokens.UserContext) *string
 
 	MergeIntoAccessTokenPayloadWithContext func(accessTokenPayloadUpdate map[string]interface{}, userContext supertokens.UserContext) error
 
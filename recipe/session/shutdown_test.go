@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// isKeyfuncRefreshRunning checks the stacks of all goroutines for the background refresh started by keyfunc
+func isKeyfuncRefreshRunning() bool {
+	buf := make([]byte, 1<<20)
+	n := runtime.Stack(buf, true)
+	return strings.Contains(string(buf[:n]), "keyfunc")
+}
+
+func waitForKeyfuncRefreshToStop() bool {
+	for i := 0; i < 50; i++ {
+		if !isKeyfuncRefreshRunning() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestThatShutdownStopsJWKSRefresh(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	core := newFakeCore("handle")
+	defer core.server.Close()
+	assert.True(t, waitForKeyfuncRefreshToStop())
+
+	instance := newInstanceForTest(t, core, "key", "/auth", 401)
+	userContext := instance.BindUserContext(nil)
+
+	_, err := GetCombinedJWKS(userContext)
+	assert.NoError(t, err)
+	assert.True(t, isKeyfuncRefreshRunning())
+
+	assert.NoError(t, supertokens.Shutdown(context.Background()))
+	assert.True(t, waitForKeyfuncRefreshToStop())
+
+	recipe, err := GetRecipeInstanceOrThrowError(userContext)
+	assert.NoError(t, err)
+	assert.Nil(t, *recipe.getJWKSCache())
+}
+
+func TestThatInstanceShutdownOnlyStopsItsOwnJWKSRefresh(t *testing.T) {
+	resetAll()
+	defer resetAll()
+	coreA := newFakeCore("handle-a")
+	defer coreA.server.Close()
+	coreB := newFakeCore("handle-b")
+	defer coreB.server.Close()
+
+	instanceA := newInstanceForTest(t, coreA, "key-a", "/a/auth", 440)
+	instanceB := newInstanceForTest(t, coreB, "key-b", "/b/auth", 441)
+	defer supertokens.Shutdown(context.Background())
+
+	_, err := GetCombinedJWKS(instanceA.BindUserContext(nil))
+	assert.NoError(t, err)
+	_, err = GetCombinedJWKS(instanceB.BindUserContext(nil))
+	assert.NoError(t, err)
+
+	assert.NoError(t, instanceA.Shutdown(context.Background()))
+
+	recipeA, err := GetRecipeInstanceOrThrowError(instanceA.BindUserContext(nil))
+	assert.NoError(t, err)
+	recipeB, err := GetRecipeInstanceOrThrowError(instanceB.BindUserContext(nil))
+	assert.NoError(t, err)
+	assert.Nil(t, *recipeA.getJWKSCache())
+	assert.NotNil(t, *recipeB.getJWKSCache())
+}
@@ -45,11 +45,11 @@ func SignOutAPI(apiImplementation sessmodels.APIInterface, options sessmodels.AP
 	}
 
 	if resp.OK != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, map[string]interface{}{
 			"status": "OK",
 		})
 	} else if resp.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*resp.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, resp, supertokens.ConvertGeneralErrorToJsonResponse(*resp.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
@@ -0,0 +1,100 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package session
+
+import (
+	"context"
+	defaultErrors "errors"
+	"sync"
+	"time"
+)
+
+var errSingleFlightCallPanicked = defaultErrors.New("the call that this call was waiting for panicked")
+
+type singleFlightCall struct {
+	done   chan struct{}
+	result interface{}
+	err    error
+	// the value fn panicked with, which is re-raised in the caller that started the call
+	panicValue interface{}
+}
+
+// singleFlightGroup coalesces concurrent calls with the same key, so that only the first one runs and the
+// others wait for it and get the same result. Calls that start after it has returned run again.
+type singleFlightGroup struct {
+	lock  sync.Mutex
+	calls map[string]*singleFlightCall
+}
+
+// do runs fn, unless a call with the same key is already running, in which case it waits for that call
+// and returns its result. fn is given a context with the values of ctx that is never cancelled, so that the
+// caller that started it can't cancel it for the others. Each caller stops waiting when its own ctx is done.
+func (g *singleFlightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	g.lock.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*singleFlightCall{}
+	}
+	if call, ok := g.calls[key]; ok {
+		g.lock.Unlock()
+		return call.wait(ctx, false)
+	}
+	call := &singleFlightCall{
+		done: make(chan struct{}),
+	}
+	g.calls[key] = call
+	g.lock.Unlock()
+
+	go func() {
+		defer func() {
+			if panicValue := recover(); panicValue != nil {
+				call.panicValue = panicValue
+				call.result, call.err = nil, errSingleFlightCallPanicked
+			}
+			g.lock.Lock()
+			delete(g.calls, key)
+			g.lock.Unlock()
+			close(call.done)
+		}()
+		call.result, call.err = fn(detachedContext{parent: ctx})
+	}()
+
+	return call.wait(ctx, true)
+}
+
+func (call *singleFlightCall) wait(ctx context.Context, isFirstCaller bool) (interface{}, error) {
+	select {
+	case <-call.done:
+		if isFirstCaller && call.panicValue != nil {
+			panic(call.panicValue)
+		}
+		return call.result, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// detachedContext has the values of parent, but is never cancelled and has no deadline. It is the same as
+// context.WithoutCancel, which needs go 1.21.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
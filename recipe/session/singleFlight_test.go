@@ -0,0 +1,241 @@
+package session
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/sessmodels"
+)
+
+func TestThatSingleFlightGroupCoalescesConcurrentCalls(t *testing.T) {
+	var group singleFlightGroup
+	var calls int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := group.do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+				<-release
+				return fmt.Sprint("result ", atomic.AddInt32(&calls, 1)), nil
+			})
+			assert.NoError(t, err)
+			results[i] = result
+		}(i)
+	}
+	// gives the goroutines time to join the call before it returns
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, result := range results {
+		assert.Equal(t, "result 1", result)
+	}
+
+	// calls made after the call returned, or with another key, are not coalesced
+	result, err := group.do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+		return "again", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "again", result)
+	result, err = group.do(context.Background(), "other", func(ctx context.Context) (interface{}, error) {
+		return "other", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "other", result)
+}
+
+func TestThatSingleFlightGroupReturnsAnErrorToWaitersIfTheCallPanics(t *testing.T) {
+	var group singleFlightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	panicked := make(chan interface{})
+	go func() {
+		defer func() {
+			panicked <- recover()
+		}()
+		group.do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			panic("failed")
+		})
+	}()
+
+	<-started
+	waiterErr := make(chan error)
+	go func() {
+		_, err := group.do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+			return "not coalesced", nil
+		})
+		waiterErr <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	assert.Equal(t, "failed", <-panicked)
+	assert.Equal(t, errSingleFlightCallPanicked, <-waiterErr)
+}
+
+type singleFlightTestKey struct{}
+
+func TestThatSingleFlightGroupCallsAreNotCancelledByTheFirstCaller(t *testing.T) {
+	var group singleFlightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	callErr := make(chan error, 1)
+
+	firstCtx, cancelFirst := context.WithCancel(context.WithValue(context.Background(), singleFlightTestKey{}, "value"))
+	firstErr := make(chan error)
+	go func() {
+		_, err := group.do(firstCtx, "key", func(ctx context.Context) (interface{}, error) {
+			// the call keeps the values of the context of the first caller
+			assert.Equal(t, "value", ctx.Value(singleFlightTestKey{}))
+			close(started)
+			<-release
+			callErr <- ctx.Err()
+			return "result", nil
+		})
+		firstErr <- err
+	}()
+	<-started
+
+	waiterResult := make(chan interface{})
+	go func() {
+		result, err := group.do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+			return "not coalesced", nil
+		})
+		assert.NoError(t, err)
+		waiterResult <- result
+	}()
+
+	// a waiter stops waiting when its own context is done
+	cancelledCtx, cancelWaiter := context.WithCancel(context.Background())
+	cancelWaiter()
+	_, err := group.do(cancelledCtx, "key", func(ctx context.Context) (interface{}, error) {
+		return "not coalesced", nil
+	})
+	assert.Equal(t, context.Canceled, err)
+
+	// the first caller stops waiting when it is cancelled, but the call goes on for the other waiters
+	time.Sleep(50 * time.Millisecond)
+	cancelFirst()
+	assert.Equal(t, context.Canceled, <-firstErr)
+	close(release)
+	assert.Equal(t, "result", <-waiterResult)
+	assert.NoError(t, <-callErr)
+}
+
+func TestThatConcurrentRefreshesOfTheSameSessionAreCoalesced(t *testing.T) {
+	var refreshCalls int32
+	// the signing key is only known after the core has been started
+	var signingKey atomic.Value
+	recorder := &sessionEventRecorder{}
+	privateKey, cleanup := initWithSigningKeyAndCoreHandlerForTest(t, &sessmodels.TypeInput{
+		Events: &sessmodels.SessionEventsConfig{Handler: recorder.handle},
+	}, func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/recipe/session/refresh" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		call := atomic.AddInt32(&refreshCalls, 1)
+		// a slow core, so that the refreshes overlap
+		time.Sleep(100 * time.Millisecond)
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"status": "OK",
+			"session": map[string]interface{}{
+				"handle":        "handle",
+				"userId":        "userId",
+				"userDataInJWT": map[string]interface{}{},
+				"tenantId":      "public",
+			},
+			"accessToken": map[string]interface{}{
+				"token":       makeAccessTokenForTest(t, signingKey.Load().(*rsa.PrivateKey), map[string]interface{}{"call": call}),
+				"expiry":      time.Now().Add(time.Hour).UnixMilli(),
+				"createdTime": time.Now().UnixMilli(),
+			},
+			"refreshToken": map[string]interface{}{
+				"token":       fmt.Sprint("refresh-token-", call),
+				"expiry":      time.Now().Add(24 * time.Hour).UnixMilli(),
+				"createdTime": time.Now().UnixMilli(),
+			},
+		})
+	})
+	defer cleanup()
+	signingKey.Store(privateKey)
+
+	disableAntiCSRF := true
+	var wg sync.WaitGroup
+	refreshTokens := make([]string, 10)
+	for i := range refreshTokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sessionContainer, err := RefreshSessionWithoutRequestResponse("refresh-token", &disableAntiCSRF, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+			refreshTokens[i] = *sessionContainer.GetAllSessionTokensDangerously().RefreshToken
+			assert.Equal(t, float64(1), sessionContainer.GetAccessTokenPayload()["call"])
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshCalls))
+	for _, refreshToken := range refreshTokens {
+		assert.Equal(t, "refresh-token-1", refreshToken)
+	}
+
+	// refreshes of other sessions are not coalesced
+	sessionContainer, err := RefreshSessionWithoutRequestResponse("other-refresh-token", &disableAntiCSRF, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "refresh-token-2", *sessionContainer.GetAllSessionTokensDangerously().RefreshToken)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&refreshCalls))
+
+	// one event is dispatched per call to the core
+	instance, err := getRecipeInstanceOrThrowError()
+	assert.NoError(t, err)
+	assert.NoError(t, instance.events.shutdown(context.Background()))
+	assert.Len(t, recorder.getEvents(), 2)
+}
+
+func TestThatConcurrentJWKSFetchesAreCoalesced(t *testing.T) {
+	_, cleanup := initWithSigningKeyForTest(t)
+	defer cleanup()
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			jwks, err := GetCombinedJWKS()
+			assert.NoError(t, err)
+			assert.NotNil(t, jwks)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// returnedFromCache gets false for every fetch from the core
+	fetches := 0
+	for len(returnedFromCache) > 0 {
+		if !<-returnedFromCache {
+			fetches++
+		}
+	}
+	assert.Equal(t, 1, fetches)
+}
@@ -0,0 +1,91 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package tokenverifier
+
+import (
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	sterrors "github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// Session is a read-only view of a verified access token. Unlike sessmodels.SessionContainer, it can't be
+// used to change or revoke the session, since that needs the core.
+type Session struct {
+	accessToken string
+	info        session.AccessTokenInfoStruct
+}
+
+func (s *Session) GetUserID() string {
+	return s.info.UserID
+}
+
+func (s *Session) GetTenantId() string {
+	return s.info.TenantId
+}
+
+func (s *Session) GetHandle() string {
+	return s.info.SessionHandle
+}
+
+// GetAccessTokenPayload returns a copy of the payload of the access token
+func (s *Session) GetAccessTokenPayload() map[string]interface{} {
+	payload := map[string]interface{}{}
+	for key, value := range s.info.UserData {
+		payload[key] = value
+	}
+	return payload
+}
+
+func (s *Session) GetAccessToken() string {
+	return s.accessToken
+}
+
+// GetExpiry returns the time (in milliseconds since epoch) at which the access token expires
+func (s *Session) GetExpiry() uint64 {
+	return s.info.ExpiryTime
+}
+
+// GetTimeCreated returns the time (in milliseconds since epoch) at which the access token was created
+func (s *Session) GetTimeCreated() uint64 {
+	return s.info.TimeCreated
+}
+
+// GetClaimValue returns the value of claim in the access token, or nil if it is not set
+func (s *Session) GetClaimValue(claim *claims.TypeSessionClaim, userContext ...supertokens.UserContext) interface{} {
+	var userContextToUse supertokens.UserContext = &map[string]interface{}{}
+	if len(userContext) > 0 && userContext[0] != nil {
+		userContextToUse = userContext[0]
+	}
+	return claim.GetValueFromPayload(s.info.UserData, userContextToUse)
+}
+
+// AssertClaims returns an InvalidClaimError if any of the claimValidators fails for the access token. Claims
+// are never refetched, since the payload can't be updated without the core.
+func (s *Session) AssertClaims(claimValidators []claims.SessionClaimValidator, userContext ...supertokens.UserContext) error {
+	var userContextToUse supertokens.UserContext = &map[string]interface{}{}
+	if len(userContext) > 0 && userContext[0] != nil {
+		userContextToUse = userContext[0]
+	}
+	invalidClaims := session.ValidateClaimsInPayload(claimValidators, s.info.UserData, userContextToUse)
+	if len(invalidClaims) > 0 {
+		return sterrors.InvalidClaimError{
+			Msg:           "invalid claims",
+			InvalidClaims: invalidClaims,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package tokenverifier verifies SuperTokens access tokens without a connection to the core, and without
+// calling supertokens.Init. It is meant for services that only need to check who is calling them, and
+// leave creating and refreshing sessions to the API that runs the full SDK.
+package tokenverifier
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	sterrors "github.com/supertokens/supertokens-golang/recipe/session/errors"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+// DefaultJWKSRefreshInterval is how often the keys are fetched from Config.JWKSURL if
+// Config.JWKSRefreshInterval is not set. It matches the default of the session recipe.
+const DefaultJWKSRefreshInterval = 4 * time.Hour
+
+// keys of the access token payload that are set by the session recipe for impersonation and DPoP sessions
+const (
+	impersonationActorKey  = "act"
+	impersonationExpiryKey = "st-imp-exp"
+	dpopConfirmationKey    = "cnf"
+)
+
+type Config struct {
+	// JWKSURL is the URL the signing keys are fetched from. This is usually
+	// <connectionURI>/.well-known/jwks.json of the core, or <apiDomain><apiBasePath>/jwt/jwks.json of an API
+	// that runs the full SDK. Exactly one of JWKSURL and JWKS must be set.
+	JWKSURL string
+	// JWKS is a static key set (in the JSON format of a JWKS endpoint) to verify tokens with.
+	JWKS json.RawMessage
+	// JWKSRefreshInterval is how often the keys are fetched from JWKSURL. Keys are also fetched when a token
+	// is signed with a key that is not known yet. Defaults to DefaultJWKSRefreshInterval.
+	JWKSRefreshInterval time.Duration
+	// HTTPClient is used to fetch the keys from JWKSURL. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// TenantIds restricts the tenants that tokens can belong to. If empty, tokens of every tenant are accepted.
+	TenantIds []string
+	// ClaimValidators are checked for every token that is verified, unless VerifyOptions.ClaimValidators
+	// is set.
+	ClaimValidators []claims.SessionClaimValidator
+}
+
+type VerifyOptions struct {
+	// AntiCsrfToken is checked against the token if it is not nil
+	AntiCsrfToken *string
+	// ClaimValidators replaces the Config.ClaimValidators for this verification if it is not nil
+	ClaimValidators *[]claims.SessionClaimValidator
+}
+
+type Verifier struct {
+	jwks   *keyfunc.JWKS
+	config Config
+}
+
+// New creates a Verifier. If Config.JWKSURL is set, the keys are fetched before New returns, and are then
+// refreshed in the background until Close is called.
+func New(config Config) (*Verifier, error) {
+	if (config.JWKSURL == "") == (len(config.JWKS) == 0) {
+		return nil, errors.New("please provide exactly one of JWKSURL or JWKS")
+	}
+
+	var jwks *keyfunc.JWKS
+	var err error
+	if config.JWKSURL != "" {
+		refreshInterval := config.JWKSRefreshInterval
+		if refreshInterval <= 0 {
+			refreshInterval = DefaultJWKSRefreshInterval
+		}
+		jwks, err = keyfunc.Get(config.JWKSURL, keyfunc.Options{
+			Client:            config.HTTPClient,
+			RefreshInterval:   refreshInterval,
+			RefreshUnknownKID: true,
+			RefreshRateLimit:  time.Minute,
+		})
+	} else {
+		jwks, err = keyfunc.NewJSON(config.JWKS)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verifier{
+		jwks:   jwks,
+		config: config,
+	}, nil
+}
+
+// Close stops refreshing the keys from Config.JWKSURL in the background
+func (v *Verifier) Close() {
+	v.jwks.EndBackground()
+}
+
+// Verify checks the signature, structure, expiry and tenant of accessToken, and the claim validators. A
+// TryRefreshTokenError is returned if the token is invalid or expired, an UnauthorizedError if it belongs
+// to a tenant that is not in Config.TenantIds, and an InvalidClaimError if a claim validator fails.
+//
+// An UnauthorizedError is also returned for impersonation sessions whose ttl has passed, and for tokens
+// that are bound to a DPoP key (these need a DPoP proof, which the Verifier can't check). Since the Verifier
+// has no connection to the core, impersonation sessions are not revoked when they expire.
+func (v *Verifier) Verify(accessToken string, options *VerifyOptions, userContext ...supertokens.UserContext) (*Session, error) {
+	var userContextToUse supertokens.UserContext = &map[string]interface{}{}
+	if len(userContext) > 0 && userContext[0] != nil {
+		userContextToUse = userContext[0]
+	}
+
+	jwtInfo, err := session.ParseJWTWithoutSignatureVerification(accessToken)
+	if err != nil {
+		return nil, sterrors.TryRefreshTokenError{Msg: err.Error()}
+	}
+
+	doAntiCsrfCheck := options != nil && options.AntiCsrfToken != nil
+	accessTokenInfo, err := session.GetInfoFromAccessToken(jwtInfo, v.jwks, doAntiCsrfCheck)
+	if err != nil {
+		return nil, err
+	}
+	if doAntiCsrfCheck && *accessTokenInfo.AntiCsrfToken != *options.AntiCsrfToken {
+		return nil, sterrors.TryRefreshTokenError{Msg: "anti-csrf check failed"}
+	}
+
+	if len(v.config.TenantIds) > 0 && !supertokens.DoesSliceContainString(accessTokenInfo.TenantId, v.config.TenantIds) {
+		return nil, sterrors.UnauthorizedError{Msg: "access token belongs to tenant " + accessTokenInfo.TenantId + ", which is not allowed"}
+	}
+
+	if isImpersonationSessionExpired(accessTokenInfo.UserData) {
+		return nil, sterrors.UnauthorizedError{Msg: "impersonation session has expired"}
+	}
+	if _, ok := accessTokenInfo.UserData[dpopConfirmationKey]; ok {
+		return nil, sterrors.UnauthorizedError{Msg: "access token is bound to a DPoP key"}
+	}
+
+	result := &Session{
+		accessToken: accessToken,
+		info:        *accessTokenInfo,
+	}
+
+	claimValidators := v.config.ClaimValidators
+	if options != nil && options.ClaimValidators != nil {
+		claimValidators = *options.ClaimValidators
+	}
+	err = result.AssertClaims(claimValidators, userContextToUse)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// isImpersonationSessionExpired returns true for impersonation sessions whose ttl has passed
+func isImpersonationSessionExpired(accessTokenPayload map[string]interface{}) bool {
+	actor, ok := accessTokenPayload[impersonationActorKey].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if impersonatorUserID, _ := actor["sub"].(string); impersonatorUserID == "" {
+		return false
+	}
+	expiry, ok := accessTokenPayload[impersonationExpiryKey].(float64)
+	return !ok || expiry <= float64(time.Now().UnixNano()/int64(time.Millisecond))
+}
@@ -0,0 +1,270 @@
+package tokenverifier
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/supertokens/supertokens-golang/recipe/session/claims"
+	sterrors "github.com/supertokens/supertokens-golang/recipe/session/errors"
+)
+
+type signingKey struct {
+	kid        string
+	privateKey *rsa.PrivateKey
+}
+
+func newSigningKey(t *testing.T, kid string) signingKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return signingKey{kid: kid, privateKey: privateKey}
+}
+
+func makeJWKS(keys ...signingKey) json.RawMessage {
+	jwks := []map[string]interface{}{}
+	for _, key := range keys {
+		jwks = append(jwks, map[string]interface{}{
+			"kty": "RSA",
+			"kid": key.kid,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.privateKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.privateKey.E)).Bytes()),
+		})
+	}
+	result, _ := json.Marshal(map[string]interface{}{"keys": jwks})
+	return result
+}
+
+// makeAccessToken creates an access token with the structure of a v3 (or newer) token created by the core
+func makeAccessToken(t *testing.T, key signingKey, version string, expiry time.Time, extraClaims map[string]interface{}) string {
+	payload := jwt.MapClaims{
+		"sub":               "user",
+		"sessionHandle":     "handle",
+		"refreshTokenHash1": "hash",
+		"exp":               expiry.Unix(),
+		"iat":               time.Now().Unix(),
+		"antiCsrfToken":     "csrf",
+	}
+	if version != "3" {
+		payload["tId"] = "tenant"
+	}
+	for claim, value := range extraClaims {
+		payload[claim] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, payload)
+	token.Header["kid"] = key.kid
+	token.Header["version"] = version
+	signed, err := token.SignedString(key.privateKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return signed
+}
+
+// makeLegacyAccessToken creates an access token with the structure of the v2 tokens created by older cores
+func makeLegacyAccessToken(t *testing.T, key signingKey, expiry time.Time) string {
+	header := "eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCIsInZlcnNpb24iOiIyIn0="
+	payload, _ := json.Marshal(map[string]interface{}{
+		"userId":            "user",
+		"sessionHandle":     "handle",
+		"refreshTokenHash1": "hash",
+		"userData":          map[string]interface{}{"role": "admin"},
+		"expiryTime":        expiry.UnixNano() / int64(time.Millisecond),
+		"timeCreated":       time.Now().UnixNano() / int64(time.Millisecond),
+	})
+	signingInput := header + "." + base64.StdEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key.privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return signingInput + "." + base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestVerifyWithStaticKeys(t *testing.T) {
+	key := newSigningKey(t, "s-key")
+	verifier, err := New(Config{JWKS: makeJWKS(key)})
+	assert.NoError(t, err)
+	defer verifier.Close()
+
+	session, err := verifier.Verify(makeAccessToken(t, key, "4", time.Now().Add(time.Hour), map[string]interface{}{"role": "admin"}), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "user", session.GetUserID())
+	assert.Equal(t, "tenant", session.GetTenantId())
+	assert.Equal(t, "handle", session.GetHandle())
+	assert.Equal(t, "admin", session.GetAccessTokenPayload()["role"])
+
+	// the payload can't be changed through the view
+	session.GetAccessTokenPayload()["role"] = "user"
+	assert.Equal(t, "admin", session.GetAccessTokenPayload()["role"])
+
+	// v3 tokens belong to the public tenant
+	session, err = verifier.Verify(makeAccessToken(t, key, "3", time.Now().Add(time.Hour), nil), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "public", session.GetTenantId())
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	key := newSigningKey(t, "s-key")
+	otherKey := newSigningKey(t, "s-other")
+	verifier, err := New(Config{JWKS: makeJWKS(key)})
+	assert.NoError(t, err)
+	defer verifier.Close()
+
+	invalidTokens := map[string]string{
+		"expired":             makeAccessToken(t, key, "4", time.Now().Add(-time.Minute), nil),
+		"unknown key":         makeAccessToken(t, otherKey, "4", time.Now().Add(time.Hour), nil),
+		"legacy, unknown key": makeLegacyAccessToken(t, otherKey, time.Now().Add(time.Hour)),
+		"legacy, expired":     makeLegacyAccessToken(t, key, time.Now().Add(-time.Minute)),
+		"missing tenant":      makeAccessToken(t, key, "4", time.Now().Add(time.Hour), map[string]interface{}{"tId": nil}),
+		"not a jwt":           "token",
+		"two parts":           "a.b",
+	}
+	for name, token := range invalidTokens {
+		_, err := verifier.Verify(token, nil)
+		assert.True(t, errors.As(err, &sterrors.TryRefreshTokenError{}), name)
+	}
+
+	// tampering with the payload invalidates the signature
+	token := makeAccessToken(t, key, "4", time.Now().Add(time.Hour), nil)
+	parts := strings.Split(token, ".")
+	tamperedPayload, _ := json.Marshal(map[string]interface{}{
+		"sub": "admin", "sessionHandle": "handle", "refreshTokenHash1": "hash", "tId": "tenant",
+		"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix(),
+	})
+	_, err = verifier.Verify(parts[0]+"."+base64.RawURLEncoding.EncodeToString(tamperedPayload)+"."+parts[2], nil)
+	assert.True(t, errors.As(err, &sterrors.TryRefreshTokenError{}))
+}
+
+func TestVerifyChecksTenantAntiCsrfAndClaims(t *testing.T) {
+	key := newSigningKey(t, "s-key")
+	emailVerifiedClaim, emailVerifiedValidators := claims.BooleanClaim("st-ev", nil, nil)
+	verifier, err := New(Config{
+		JWKS:            makeJWKS(key),
+		TenantIds:       []string{"tenant"},
+		ClaimValidators: []claims.SessionClaimValidator{emailVerifiedValidators.IsTrue(nil, nil)},
+	})
+	assert.NoError(t, err)
+	defer verifier.Close()
+
+	verifiedToken := makeAccessToken(t, key, "4", time.Now().Add(time.Hour), map[string]interface{}{
+		"st-ev": map[string]interface{}{"v": true, "t": float64(time.Now().UnixNano() / int64(time.Millisecond))},
+	})
+	session, err := verifier.Verify(verifiedToken, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, true, session.GetClaimValue(emailVerifiedClaim))
+
+	_, err = verifier.Verify(makeAccessToken(t, key, "4", time.Now().Add(time.Hour), nil), nil)
+	var invalidClaimErr sterrors.InvalidClaimError
+	assert.True(t, errors.As(err, &invalidClaimErr))
+	assert.Equal(t, "st-ev", invalidClaimErr.InvalidClaims[0].ID)
+
+	// the claim validators can be replaced for a single verification
+	_, err = verifier.Verify(makeAccessToken(t, key, "4", time.Now().Add(time.Hour), nil), &VerifyOptions{
+		ClaimValidators: &[]claims.SessionClaimValidator{},
+	})
+	assert.NoError(t, err)
+
+	_, err = verifier.Verify(makeAccessToken(t, key, "3", time.Now().Add(time.Hour), nil), &VerifyOptions{
+		ClaimValidators: &[]claims.SessionClaimValidator{},
+	})
+	assert.True(t, errors.As(err, &sterrors.UnauthorizedError{}))
+
+	antiCsrfToken := "csrf"
+	_, err = verifier.Verify(verifiedToken, &VerifyOptions{AntiCsrfToken: &antiCsrfToken})
+	assert.NoError(t, err)
+	antiCsrfToken = "other"
+	_, err = verifier.Verify(verifiedToken, &VerifyOptions{AntiCsrfToken: &antiCsrfToken})
+	assert.True(t, errors.As(err, &sterrors.TryRefreshTokenError{}))
+}
+
+func TestVerifyRejectsExpiredImpersonationAndDPoPBoundTokens(t *testing.T) {
+	key := newSigningKey(t, "s-key")
+	verifier, err := New(Config{JWKS: makeJWKS(key)})
+	assert.NoError(t, err)
+	defer verifier.Close()
+
+	nowInMS := float64(time.Now().UnixNano() / int64(time.Millisecond))
+	actor := map[string]interface{}{"sub": "admin"}
+
+	_, err = verifier.Verify(makeAccessToken(t, key, "4", time.Now().Add(time.Hour), map[string]interface{}{
+		"act": actor, "st-imp-exp": nowInMS + 60000,
+	}), nil)
+	assert.NoError(t, err)
+
+	rejectedTokens := map[string]string{
+		"expired impersonation": makeAccessToken(t, key, "4", time.Now().Add(time.Hour), map[string]interface{}{
+			"act": actor, "st-imp-exp": nowInMS - 1,
+		}),
+		"impersonation without expiry": makeAccessToken(t, key, "4", time.Now().Add(time.Hour), map[string]interface{}{
+			"act": actor,
+		}),
+		"dpop bound": makeAccessToken(t, key, "4", time.Now().Add(time.Hour), map[string]interface{}{
+			"cnf": map[string]interface{}{"jkt": "thumbprint"},
+		}),
+	}
+	for name, token := range rejectedTokens {
+		_, err := verifier.Verify(token, nil)
+		assert.True(t, errors.As(err, &sterrors.UnauthorizedError{}), name)
+	}
+}
+
+func TestVerifyWithJWKSURLFetchesRotatedKeys(t *testing.T) {
+	oldKey := newSigningKey(t, "d-old")
+	newKey := newSigningKey(t, "d-new")
+
+	var lock sync.Mutex
+	keys := []signingKey{oldKey}
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		fetches++
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(makeJWKS(keys...))
+	}))
+	defer server.Close()
+
+	verifier, err := New(Config{JWKSURL: server.URL})
+	assert.NoError(t, err)
+	defer verifier.Close()
+
+	_, err = verifier.Verify(makeAccessToken(t, oldKey, "4", time.Now().Add(time.Hour), nil), nil)
+	assert.NoError(t, err)
+
+	lock.Lock()
+	keys = []signingKey{oldKey, newKey}
+	lock.Unlock()
+
+	// a token signed with a key that is not known yet makes the verifier fetch the keys again
+	_, err = verifier.Verify(makeAccessToken(t, newKey, "4", time.Now().Add(time.Hour), nil), nil)
+	assert.NoError(t, err)
+
+	lock.Lock()
+	assert.Equal(t, 2, fetches)
+	lock.Unlock()
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	_, err := New(Config{})
+	assert.Error(t, err)
+
+	_, err = New(Config{JWKSURL: "http://localhost", JWKS: makeJWKS()})
+	assert.Error(t, err)
+}
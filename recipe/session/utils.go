@@ -26,7 +26,7 @@ func GetRequiredClaimValidators(
 	overrideGlobalClaimValidators func(globalClaimValidators []claims.SessionClaimValidator, sessionContainer sessmodels.SessionContainer, userContext supertokens.UserContext) ([]claims.SessionClaimValidator, error),
 	userContext supertokens.UserContext,
 ) ([]claims.SessionClaimValidator, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +109,33 @@ func ValidateAndNormaliseUserInput(appInfo supertokens.NormalisedAppinfo, config
 		cookieSecure = *config.CookieSecure
 	}
 
+	cookieNamePrefix := ""
+	if config != nil && config.CookieNamePrefix != nil {
+		cookieNamePrefix = strings.TrimSpace(*config.CookieNamePrefix)
+		if !isValidCookieNamePrefix(cookieNamePrefix) {
+			return sessmodels.TypeNormalisedInput{}, errors.New("CookieNamePrefix can only contain letters, digits and the characters !#$%&'*+-.^_`|~, and can't start with __")
+		}
+	}
+
+	cookieSecurityPrefix := ""
+	if config != nil && config.CookieSecurityPrefix != nil {
+		cookieSecurityPrefix = *config.CookieSecurityPrefix
+		if cookieSecurityPrefix != CookiePrefix_HOST && cookieSecurityPrefix != CookiePrefix_SECURE {
+			return sessmodels.TypeNormalisedInput{}, errors.New("CookieSecurityPrefix must be one of '__Host-' or '__Secure-'")
+		}
+		if !cookieSecure {
+			return sessmodels.TypeNormalisedInput{}, errors.New("CookieSecurityPrefix can only be used if CookieSecure is true")
+		}
+		if cookieSecurityPrefix == CookiePrefix_HOST && (cookieDomain != nil || olderCookieDomain != nil) {
+			return sessmodels.TypeNormalisedInput{}, errors.New("the '__Host-' CookieSecurityPrefix can't be used if CookieDomain or OlderCookieDomain is set")
+		}
+	}
+
+	cookiePartitioned := config != nil && config.CookiePartitioned
+	if cookiePartitioned && !cookieSecure {
+		return sessmodels.TypeNormalisedInput{}, errors.New("CookiePartitioned can only be used if CookieSecure is true")
+	}
+
 	sessionExpiredStatusCode := 401
 	if config != nil && config.SessionExpiredStatusCode != nil {
 		sessionExpiredStatusCode = *config.SessionExpiredStatusCode
@@ -153,28 +180,28 @@ func ValidateAndNormaliseUserInput(appInfo supertokens.NormalisedAppinfo, config
 
 	errorHandlers := sessmodels.NormalisedErrorHandlers{
 		OnTokenTheftDetected: func(sessionHandle string, userID string, req *http.Request, res http.ResponseWriter) error {
-			recipeInstance, err := getRecipeInstanceOrThrowError()
+			recipeInstance, err := getRecipeInstanceForRequestOrThrowError(req)
 			if err != nil {
 				return err
 			}
 			return sendTokenTheftDetectedResponse(*recipeInstance, sessionHandle, userID, req, res)
 		},
 		OnTryRefreshToken: func(message string, req *http.Request, res http.ResponseWriter) error {
-			recipeInstance, err := getRecipeInstanceOrThrowError()
+			recipeInstance, err := getRecipeInstanceForRequestOrThrowError(req)
 			if err != nil {
 				return err
 			}
 			return sendTryRefreshTokenResponse(*recipeInstance, message, req, res)
 		},
 		OnUnauthorised: func(message string, req *http.Request, res http.ResponseWriter) error {
-			recipeInstance, err := getRecipeInstanceOrThrowError()
+			recipeInstance, err := getRecipeInstanceForRequestOrThrowError(req)
 			if err != nil {
 				return err
 			}
 			return sendUnauthorisedResponse(*recipeInstance, message, req, res)
 		},
 		OnInvalidClaim: func(validationErrors []claims.ClaimValidationError, req *http.Request, res http.ResponseWriter) error {
-			recipeInstance, err := getRecipeInstanceOrThrowError()
+			recipeInstance, err := getRecipeInstanceForRequestOrThrowError(req)
 			if err != nil {
 				return err
 			}
@@ -227,10 +254,71 @@ func ValidateAndNormaliseUserInput(appInfo supertokens.NormalisedAppinfo, config
 		jwksRefreshIntervalSec = *config.JWKSRefreshIntervalSec
 	}
 
+	events := sessmodels.SessionEventsConfig{
+		QueueSize:    1000,
+		GetIPAddress: defaultGetIPAddress,
+	}
+	if config.Events != nil {
+		events.Handler = config.Events.Handler
+		if config.Events.QueueSize > 0 {
+			events.QueueSize = config.Events.QueueSize
+		}
+		if config.Events.GetIPAddress != nil {
+			events.GetIPAddress = config.Events.GetIPAddress
+		}
+	}
+
+	var fingerprintBinding *sessmodels.FingerprintBindingConfig
+	if config.FingerprintBinding != nil {
+		fingerprintBinding = &sessmodels.FingerprintBindingConfig{
+			Mode:           config.FingerprintBinding.Mode,
+			GetFingerprint: config.FingerprintBinding.GetFingerprint,
+			AllowMismatch:  config.FingerprintBinding.AllowMismatch,
+			HashKey:        config.FingerprintBinding.HashKey,
+		}
+		if fingerprintBinding.Mode == "" {
+			fingerprintBinding.Mode = sessmodels.FingerprintLogOnly
+		}
+		if fingerprintBinding.Mode != sessmodels.FingerprintLogOnly && fingerprintBinding.Mode != sessmodels.FingerprintForceRefresh && fingerprintBinding.Mode != sessmodels.FingerprintRevoke {
+			return sessmodels.TypeNormalisedInput{}, errors.New("FingerprintBinding.Mode must be one of LOG_ONLY, FORCE_REFRESH or REVOKE")
+		}
+		if fingerprintBinding.GetFingerprint == nil {
+			fingerprintBinding.GetFingerprint = makeDefaultGetFingerprint(events.GetIPAddress)
+		}
+		if fingerprintBinding.HashKey == "" {
+			return sessmodels.TypeNormalisedInput{}, errors.New("please provide a HashKey in FingerprintBinding")
+		}
+	}
+
+	var dpop *sessmodels.DPoPConfig
+	if config.DPoP != nil {
+		dpop = &sessmodels.DPoPConfig{
+			Required:      config.DPoP.Required,
+			ProofMaxAge:   config.DPoP.ProofMaxAge,
+			JTIStore:      config.DPoP.JTIStore,
+			GetRequestURL: config.DPoP.GetRequestURL,
+		}
+		if dpop.ProofMaxAge <= 0 {
+			dpop.ProofMaxAge = 60 * time.Second
+		}
+		if dpop.JTIStore == nil {
+			dpop.JTIStore = NewInMemoryDPoPJTIStore()
+		}
+		if dpop.GetRequestURL == nil {
+			apiDomain := appInfo.APIDomain.GetAsStringDangerous()
+			dpop.GetRequestURL = func(req *http.Request, userContext supertokens.UserContext) string {
+				return apiDomain + req.URL.Path
+			}
+		}
+	}
+
 	typeNormalisedInput := sessmodels.TypeNormalisedInput{
 		RefreshTokenPath:         appInfo.APIBasePath.AppendPath(refreshAPIPath),
 		CookieDomain:             cookieDomain,
 		OlderCookieDomain:        olderCookieDomain,
+		CookieNamePrefix:         cookieNamePrefix,
+		CookieSecurityPrefix:     cookieSecurityPrefix,
+		CookiePartitioned:        cookiePartitioned,
 		GetCookieSameSite:        cookieSameSite,
 		CookieSecure:             cookieSecure,
 		SessionExpiredStatusCode: sessionExpiredStatusCode,
@@ -239,6 +327,11 @@ func ValidateAndNormaliseUserInput(appInfo supertokens.NormalisedAppinfo, config
 		ExposeAccessTokenToFrontendInCookieBasedAuth: config.ExposeAccessTokenToFrontendInCookieBasedAuth,
 		UseDynamicAccessTokenSigningKey:              useDynamicSigningKey,
 		JWKSRefreshIntervalSec:                       jwksRefreshIntervalSec,
+		Events:                                       events,
+		RecordDeviceInfo:                             config.RecordDeviceInfo,
+		ReauthenticateOnSignIn:                       config.ReauthenticateOnSignIn,
+		FingerprintBinding:                           fingerprintBinding,
+		DPoP:                                         dpop,
 		ErrorHandlers:                                errorHandlers,
 		GetTokenTransferMethod:                       config.GetTokenTransferMethod,
 		Override: sessmodels.OverrideStruct{
@@ -265,6 +358,21 @@ func ValidateAndNormaliseUserInput(appInfo supertokens.NormalisedAppinfo, config
 
 var accessTokenCookiesExpiryDurationMillis uint64 = 31536000000
 
+// isValidCookieNamePrefix checks that prefix only has the characters allowed in cookie names (RFC 6265). Prefixes
+// that start with "__" are rejected, since browsers give those a special meaning.
+func isValidCookieNamePrefix(prefix string) bool {
+	if strings.HasPrefix(prefix, "__") {
+		return false
+	}
+	for _, c := range prefix {
+		isAlphanumeric := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphanumeric && !strings.ContainsRune("!#$%&'*+-.^_`|~", c) {
+			return false
+		}
+	}
+	return true
+}
+
 func normaliseSameSiteOrThrowError(sameSite string) (string, error) {
 	sameSite = strings.TrimSpace(sameSite)
 	sameSite = strings.ToLower(sameSite)
@@ -407,3 +515,72 @@ func defaultGetTokenTransferMethod(req *http.Request, forCreateNewSession bool,
 		return sessmodels.AnyTransferMethod
 	}
 }
+
+// setSessionManagedPropsInUserContext keeps props, which must be sessionManagedProps, in userContext so that they
+// are set in the next access token payload that is created or updated with userContext. A nil value removes the
+// prop from the payload.
+func setSessionManagedPropsInUserContext(props map[string]interface{}, userContext supertokens.UserContext) {
+	if userContext == nil {
+		return
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		defaultObj = map[string]interface{}{}
+		(*userContext)["_default"] = defaultObj
+	}
+	sessionManagedPropsToSet, ok := defaultObj["sessionManagedProps"].(map[string]interface{})
+	if !ok {
+		sessionManagedPropsToSet = map[string]interface{}{}
+		defaultObj["sessionManagedProps"] = sessionManagedPropsToSet
+	}
+	for k, v := range props {
+		sessionManagedPropsToSet[k] = v
+	}
+}
+
+// getSessionManagedPropsFromUserContext returns and removes the props kept by setSessionManagedPropsInUserContext
+func getSessionManagedPropsFromUserContext(userContext supertokens.UserContext) map[string]interface{} {
+	if userContext == nil {
+		return map[string]interface{}{}
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+	sessionManagedPropsToSet, ok := defaultObj["sessionManagedProps"].(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+	delete(defaultObj, "sessionManagedProps")
+	return sessionManagedPropsToSet
+}
+
+// mergeIntoAccessTokenPayloadWithoutProtectedProps applies accessTokenPayloadUpdate to the custom claims of an
+// access token payload. The props set by the core are removed, while the sessionManagedProps are kept and only
+// changed by what setSessionManagedPropsInUserContext kept in userContext.
+func mergeIntoAccessTokenPayloadWithoutProtectedProps(accessTokenPayload map[string]interface{}, accessTokenPayloadUpdate map[string]interface{}, userContext supertokens.UserContext) {
+	for k := range accessTokenPayload {
+		if supertokens.DoesSliceContainString(k, protectedProps) && !supertokens.DoesSliceContainString(k, sessionManagedProps) {
+			delete(accessTokenPayload, k)
+		}
+	}
+
+	for k, v := range accessTokenPayloadUpdate {
+		if supertokens.DoesSliceContainString(k, sessionManagedProps) {
+			continue
+		}
+		if v == nil {
+			delete(accessTokenPayload, k)
+		} else {
+			accessTokenPayload[k] = v
+		}
+	}
+
+	for k, v := range getSessionManagedPropsFromUserContext(userContext) {
+		if v == nil {
+			delete(accessTokenPayload, k)
+		} else {
+			accessTokenPayload[k] = v
+		}
+	}
+}
@@ -62,9 +62,9 @@ func AuthorisationUrlAPI(apiImplementation tpmodels.APIInterface, tenantId strin
 		if result.OK.PKCECodeVerifier != nil {
 			respBody["pkceCodeVerifier"] = *result.OK.PKCECodeVerifier
 		}
-		return supertokens.Send200Response(options.Res, respBody)
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, respBody)
 	} else if result.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
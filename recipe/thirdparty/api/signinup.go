@@ -16,8 +16,6 @@
 package api
 
 import (
-	"encoding/json"
-
 	"github.com/supertokens/supertokens-golang/recipe/thirdparty/tpmodels"
 	"github.com/supertokens/supertokens-golang/supertokens"
 )
@@ -36,12 +34,8 @@ func SignInUpAPI(apiImplementation tpmodels.APIInterface, tenantId string, optio
 		return nil
 	}
 
-	body, err := supertokens.ReadFromRequest(options.Req)
-	if err != nil {
-		return err
-	}
 	var bodyParams bodyParams
-	err = json.Unmarshal(body, &bodyParams)
+	err := supertokens.ReadJSONFromRequest(options.Req, &bodyParams)
 	if err != nil {
 		return err
 	}
@@ -86,17 +80,22 @@ func SignInUpAPI(apiImplementation tpmodels.APIInterface, tenantId string, optio
 	}
 
 	if result.OK != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		supertokens.AddToCounter(userContext, supertokens.MetricSignIns, 1, map[string]interface{}{
+			"recipeId":       options.RecipeID,
+			"tenantId":       tenantId,
+			"createdNewUser": result.OK.CreatedNewUser,
+		})
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, map[string]interface{}{
 			"status":         "OK",
 			"user":           result.OK.User,
 			"createdNewUser": result.OK.CreatedNewUser,
 		})
 	} else if result.NoEmailGivenByProviderError != nil {
-		return supertokens.Send200Response(options.Res, map[string]interface{}{
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, map[string]interface{}{
 			"status": "NO_EMAIL_GIVEN_BY_PROVIDER",
 		})
 	} else if result.GeneralError != nil {
-		return supertokens.Send200Response(options.Res, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
+		return supertokens.Send200ResponseForAPIResult(options.Res, result, supertokens.ConvertGeneralErrorToJsonResponse(*result.GeneralError))
 	}
 	return supertokens.ErrorIfNoResponse(options.Res)
 }
@@ -136,10 +136,13 @@ func doPostRequest(url string, params map[string]interface{}, headers map[string
 
 // JWKS utils
 var jwksKeys = map[string]*keyfunc.JWKS{}
-var jwksKeysLock = sync.Mutex{}
+var jwksKeysLock = sync.RWMutex{}
 
 func getJWKSFromURL(url string) (*keyfunc.JWKS, error) {
-	if jwks, ok := jwksKeys[url]; ok {
+	jwksKeysLock.RLock()
+	jwks, ok := jwksKeys[url]
+	jwksKeysLock.RUnlock()
+	if ok {
 		return jwks, nil
 	}
 
@@ -162,6 +165,18 @@ func getJWKSFromURL(url string) (*keyfunc.JWKS, error) {
 	return jwks, nil
 }
 
+// ReleaseJWKSCache stops the background refresh of the JWKS fetched from providers and removes them from
+// the cache. They are fetched again the next time they are needed.
+func ReleaseJWKSCache() {
+	jwksKeysLock.Lock()
+	defer jwksKeysLock.Unlock()
+
+	for url, jwks := range jwksKeys {
+		jwks.EndBackground()
+		delete(jwksKeys, url)
+	}
+}
+
 // User map utils
 func accessField(obj interface{}, key string) (interface{}, bool) {
 	keyParts := strings.Split(key, ".")
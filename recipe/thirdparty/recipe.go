@@ -16,6 +16,7 @@
 package thirdparty
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/supertokens/supertokens-golang/recipe/emailverification/evmodels"
 	"github.com/supertokens/supertokens-golang/recipe/multitenancy"
 	"github.com/supertokens/supertokens-golang/recipe/thirdparty/api"
+	"github.com/supertokens/supertokens-golang/recipe/thirdparty/providers"
 	"github.com/supertokens/supertokens-golang/recipe/thirdparty/tperrors"
 	"github.com/supertokens/supertokens-golang/recipe/thirdparty/tpmodels"
 	"github.com/supertokens/supertokens-golang/supertokens"
@@ -46,7 +48,7 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 
 	r.RecipeModule = supertokens.MakeRecipeModule(recipeId, appInfo, r.handleAPIRequest, r.getAllCORSHeaders, r.getAPIsHandled, nil, r.handleError, onSuperTokensAPIError)
 
-	querierInstance, err := supertokens.GetNewQuerierInstanceOrThrowError(recipeId)
+	querierInstance, err := supertokens.GetNewQuerierInstanceForAppInfoOrThrowError(appInfo, recipeId)
 	if err != nil {
 		return Recipe{}, err
 	}
@@ -59,13 +61,13 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 	r.RecipeImpl = verifiedConfig.Override.Functions(MakeRecipeImplementation(*querierInstance, verifiedConfig.SignInAndUpFeature.Providers))
 	r.Providers = verifiedConfig.SignInAndUpFeature.Providers
 
-	supertokens.AddPostInitCallback(func() error {
-		evRecipe := emailverification.GetRecipeInstance()
+	supertokens.AddPostInitCallbackForAppInfo(appInfo, func(userContext supertokens.UserContext) error {
+		evRecipe := emailverification.GetRecipeInstance(userContext)
 		if evRecipe != nil {
 			evRecipe.AddGetEmailForUserIdFunc(r.getEmailForUserId)
 		}
 
-		mtRecipe := multitenancy.GetRecipeInstance()
+		mtRecipe := multitenancy.GetRecipeInstance(userContext)
 		if mtRecipe != nil {
 			mtRecipe.SetStaticThirdPartyProviders(verifiedConfig.SignInAndUpFeature.Providers)
 		}
@@ -74,26 +76,41 @@ func MakeRecipe(recipeId string, appInfo supertokens.NormalisedAppinfo, config *
 	})
 
 	r.RecipeModule.ResetForTest = ResetForTest
+	r.RecipeModule.Shutdown = func(ctx context.Context) error {
+		providers.ReleaseJWKSCache()
+		return nil
+	}
 
 	return *r, nil
 }
 
 func recipeInit(config *tpmodels.TypeInput) supertokens.Recipe {
 	return func(appInfo supertokens.NormalisedAppinfo, onSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)) (*supertokens.RecipeModule, error) {
-		if singletonInstance == nil {
+		if singletonInstance == nil || !supertokens.IsDefaultInstance(appInfo) {
 			recipe, err := MakeRecipe(RECIPE_ID, appInfo, config, nil, onSuperTokensAPIError)
 			if err != nil {
 				return nil, err
 			}
-			singletonInstance = &recipe
-			return &singletonInstance.RecipeModule, nil
+			if supertokens.IsDefaultInstance(appInfo) {
+				singletonInstance = &recipe
+			} else {
+				err = supertokens.RegisterRecipeInstance(appInfo, RECIPE_ID, &recipe)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return &recipe.RecipeModule, nil
 		}
 		return nil, errors.New("ThirdParty recipe has already been initialised. Please check your code for bugs.")
 	}
 }
 
-func GetRecipeInstanceOrThrowError() (*Recipe, error) {
-	if singletonInstance != nil {
+func GetRecipeInstanceOrThrowError(userContext ...supertokens.UserContext) (*Recipe, error) {
+	if instance, isBound := supertokens.GetRecipeInstanceFromUserContext(RECIPE_ID, userContext...); isBound {
+		if recipe, ok := instance.(*Recipe); ok {
+			return recipe, nil
+		}
+	} else if singletonInstance != nil {
 		return singletonInstance, nil
 	}
 	return nil, errors.New("Initialisation not done. Did you forget to call the init function?")
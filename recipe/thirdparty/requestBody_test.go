@@ -0,0 +1,79 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package thirdparty
+
+import (
+	"testing"
+
+	"github.com/supertokens/supertokens-golang/internal/testingutils"
+	"github.com/supertokens/supertokens-golang/recipe/thirdparty/tpmodels"
+	"github.com/supertokens/supertokens-golang/supertokens"
+)
+
+func FuzzThirdPartySignInUpRequestBodies(f *testing.F) {
+	testingutils.AddRequestBodySeeds(f,
+		`{"thirdPartyId":"custom","redirectURIInfo":{"redirectURIOnProviderDashboard":"http://127.0.0.1/callback","redirectURIQueryParams":{"code":"abcdefghj"}}}`,
+		`{"thirdPartyId":"custom","clientType":"web","oAuthTokens":{"access_token":"token"}}`,
+		`{"thirdPartyId":"unknown","oAuthTokens":{}}`,
+	)
+	core := testingutils.StartFakeCore(nil)
+	defer core.Close()
+
+	apiBasePath := "/auth"
+	instance, err := supertokens.New(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: core.URL,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       "SuperTokens",
+			WebsiteDomain: "supertokens.io",
+			APIDomain:     "api.supertokens.io",
+			APIBasePath:   &apiBasePath,
+		},
+		RecipeList: []supertokens.Recipe{
+			Init(&tpmodels.TypeInput{
+				Override: &tpmodels.OverrideStruct{
+					Functions: func(originalImplementation tpmodels.RecipeInterface) tpmodels.RecipeInterface {
+						getProvider := func(thirdPartyID string, clientType *string, tenantId string, userContext supertokens.UserContext) (*tpmodels.TypeProvider, error) {
+							if thirdPartyID != "custom" {
+								return nil, nil
+							}
+							return &tpmodels.TypeProvider{ID: thirdPartyID}, nil
+						}
+						originalImplementation.GetProvider = &getProvider
+						return originalImplementation
+					},
+					APIs: func(originalImplementation tpmodels.APIInterface) tpmodels.APIInterface {
+						signInUpPOST := func(provider *tpmodels.TypeProvider, input tpmodels.TypeSignInUpInput, tenantId string, options tpmodels.APIOptions, userContext supertokens.UserContext) (tpmodels.SignInUpPOSTResponse, error) {
+							return tpmodels.SignInUpPOSTResponse{NoEmailGivenByProviderError: &struct{}{}}, nil
+						}
+						originalImplementation.SignInUpPOST = &signInUpPOST
+						return originalImplementation
+					},
+				},
+			}),
+		},
+	})
+	if err != nil {
+		f.Fatal(err.Error())
+	}
+	defer instance.Close()
+	handler := instance.Middleware(nil)
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		testingutils.AssertAPIHandlesRequestBody(t, handler, "/auth/signinup", body)
+	})
+}
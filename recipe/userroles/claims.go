@@ -14,7 +14,7 @@ func init() {
 
 func NewUserRoleClaim() (*claims.TypeSessionClaim, claims.PrimitiveArrayClaimValidators) {
 	fetchValue := func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
-		recipe, err := getRecipeInstanceOrThrowError()
+		recipe, err := getRecipeInstanceOrThrowError(userContext)
 		if err != nil {
 			return nil, err
 		}
@@ -38,7 +38,7 @@ func NewUserRoleClaim() (*claims.TypeSessionClaim, claims.PrimitiveArrayClaimVal
 
 func NewPermissionClaim() (*claims.TypeSessionClaim, claims.PrimitiveArrayClaimValidators) {
 	fetchValue := func(userId string, tenantId string, userContext supertokens.UserContext) (interface{}, error) {
-		recipe, err := getRecipeInstanceOrThrowError()
+		recipe, err := getRecipeInstanceOrThrowError(userContext)
 		if err != nil {
 			return nil, err
 		}
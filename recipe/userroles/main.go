@@ -25,7 +25,7 @@ func Init(config *userrolesmodels.TypeInput) supertokens.Recipe {
 }
 
 func AddRoleToUser(tenantId string, userID string, role string, userContext ...supertokens.UserContext) (userrolesmodels.AddRoleToUserResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.AddRoleToUserResponse{}, err
 	}
@@ -36,7 +36,7 @@ func AddRoleToUser(tenantId string, userID string, role string, userContext ...s
 }
 
 func RemoveUserRole(tenantId string, userID string, role string, userContext ...supertokens.UserContext) (userrolesmodels.RemoveUserRoleResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.RemoveUserRoleResponse{}, err
 	}
@@ -47,7 +47,7 @@ func RemoveUserRole(tenantId string, userID string, role string, userContext ...
 }
 
 func GetRolesForUser(tenantId string, userID string, userContext ...supertokens.UserContext) (userrolesmodels.GetRolesForUserResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.GetRolesForUserResponse{}, err
 	}
@@ -58,7 +58,7 @@ func GetRolesForUser(tenantId string, userID string, userContext ...supertokens.
 }
 
 func GetUsersThatHaveRole(tenantId string, role string, userContext ...supertokens.UserContext) (userrolesmodels.GetUsersThatHaveRoleResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.GetUsersThatHaveRoleResponse{}, err
 	}
@@ -69,7 +69,7 @@ func GetUsersThatHaveRole(tenantId string, role string, userContext ...supertoke
 }
 
 func CreateNewRoleOrAddPermissions(role string, permissions []string, userContext ...supertokens.UserContext) (userrolesmodels.CreateNewRoleOrAddPermissionsResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.CreateNewRoleOrAddPermissionsResponse{}, err
 	}
@@ -80,7 +80,7 @@ func CreateNewRoleOrAddPermissions(role string, permissions []string, userContex
 }
 
 func GetPermissionsForRole(role string, userContext ...supertokens.UserContext) (userrolesmodels.GetPermissionsForRoleResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.GetPermissionsForRoleResponse{}, err
 	}
@@ -91,7 +91,7 @@ func GetPermissionsForRole(role string, userContext ...supertokens.UserContext)
 }
 
 func RemovePermissionsFromRole(role string, permissions []string, userContext ...supertokens.UserContext) (userrolesmodels.RemovePermissionsFromRoleResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.RemovePermissionsFromRoleResponse{}, err
 	}
@@ -102,7 +102,7 @@ func RemovePermissionsFromRole(role string, permissions []string, userContext ..
 }
 
 func GetRolesThatHavePermission(permission string, userContext ...supertokens.UserContext) (userrolesmodels.GetRolesThatHavePermissionResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.GetRolesThatHavePermissionResponse{}, err
 	}
@@ -113,7 +113,7 @@ func GetRolesThatHavePermission(permission string, userContext ...supertokens.Us
 }
 
 func DeleteRole(role string, userContext ...supertokens.UserContext) (userrolesmodels.DeleteRoleResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.DeleteRoleResponse{}, err
 	}
@@ -121,7 +121,7 @@ func DeleteRole(role string, userContext ...supertokens.UserContext) (userrolesm
 }
 
 func GetAllRoles(userContext ...supertokens.UserContext) (userrolesmodels.GetAllRolesResponse, error) {
-	instance, err := getRecipeInstanceOrThrowError()
+	instance, err := getRecipeInstanceOrThrowError(userContext...)
 	if err != nil {
 		return userrolesmodels.GetAllRolesResponse{}, err
 	}
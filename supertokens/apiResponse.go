@@ -0,0 +1,120 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package supertokens
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const defaultAPIResponseContentType = "application/json; charset=utf-8"
+
+// APIResponse is a response about to be sent by one of the APIs exposed by SuperTokens. It is passed to
+// RenderAPIResponse in TypeInput.
+type APIResponse struct {
+	RecipeID string
+	APIID    string
+	TenantID string
+	// Result is the typed response returned by the API function, like epmodels.SignInPOSTResponse. It is nil
+	// if the response was not built from such a result, for example for errors sent by the error handler.
+	Result     interface{}
+	StatusCode int
+	// ContentType defaults to "application/json; charset=utf-8"
+	ContentType string
+	// Body is marshalled to JSON. It is usually a map[string]interface{}.
+	Body interface{}
+}
+
+// RenderAPIResponseFunc is called before each response of the APIs exposed by SuperTokens is sent. The
+// returned response is sent instead, so it can be used to add fields to the body, localise messages,
+// or change the format (and content type) of the body.
+type RenderAPIResponseFunc func(response APIResponse, userContext UserContext) (APIResponse, error)
+
+type apiResponseInfo struct {
+	recipeID    string
+	apiID       string
+	tenantID    string
+	render      RenderAPIResponseFunc
+	userContext UserContext
+}
+
+// apiResponseInfoHolder is implemented by the writers created using MakeDoneWriter, so that the
+// middleware can attach the API being handled to the writer that recipes send their responses to.
+type apiResponseInfoHolder interface {
+	setAPIResponseInfo(info *apiResponseInfo)
+	getAPIResponseInfo() *apiResponseInfo
+}
+
+func (w *basicWriter) setAPIResponseInfo(info *apiResponseInfo) {
+	w.apiResponseInfo = info
+}
+
+func (w *basicWriter) getAPIResponseInfo() *apiResponseInfo {
+	return w.apiResponseInfo
+}
+
+func setAPIResponseInfo(dw DoneWriter, info *apiResponseInfo) {
+	if holder, ok := dw.(apiResponseInfoHolder); ok {
+		holder.setAPIResponseInfo(info)
+	}
+}
+
+// Send200ResponseForAPIResult is like Send200Response, but also passes result (the typed response returned
+// by the API function) to the RenderAPIResponse hook.
+func Send200ResponseForAPIResult(res http.ResponseWriter, result interface{}, responseJson interface{}) error {
+	return sendAPIResponse(res, result, 200, responseJson)
+}
+
+func sendAPIResponse(res http.ResponseWriter, result interface{}, statusCode int, body interface{}) error {
+	dw := MakeDoneWriter(res)
+	if dw.IsDone() {
+		return nil
+	}
+
+	response := APIResponse{
+		Result:      result,
+		StatusCode:  statusCode,
+		ContentType: defaultAPIResponseContentType,
+		Body:        body,
+	}
+	if holder, ok := res.(apiResponseInfoHolder); ok {
+		info := holder.getAPIResponseInfo()
+		if info != nil && info.render != nil {
+			response.RecipeID = info.recipeID
+			response.APIID = info.apiID
+			response.TenantID = info.tenantID
+			var err error
+			response, err = info.render(response, info.userContext)
+			if err != nil {
+				return err
+			}
+			if response.ContentType == "" {
+				response.ContentType = defaultAPIResponseContentType
+			}
+		}
+	}
+
+	bytes, err := json.Marshal(response.Body)
+	if err != nil {
+		return err
+	}
+	LogDebugMessage("Sending response to client with status code: " + strconv.Itoa(response.StatusCode))
+	res.Header().Set("Content-Type", response.ContentType)
+	res.WriteHeader(response.StatusCode)
+	res.Write(bytes)
+	return nil
+}
@@ -0,0 +1,85 @@
+package supertokens
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type signInResponseForTest struct {
+	UserID string
+}
+
+func TestResponsesAreSentAsIsWithoutRenderAPIResponse(t *testing.T) {
+	res := httptest.NewRecorder()
+	dw := MakeDoneWriter(res)
+	setAPIResponseInfo(dw, &apiResponseInfo{recipeID: "emailpassword", apiID: "/signin", tenantID: "public"})
+
+	err := Send200ResponseForAPIResult(dw, signInResponseForTest{UserID: "user"}, map[string]interface{}{"status": "OK"})
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, "application/json; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"status":"OK"}`, res.Body.String())
+}
+
+func TestRenderAPIResponseReceivesTheAPIAndTypedResult(t *testing.T) {
+	var rendered APIResponse
+	res := httptest.NewRecorder()
+	dw := MakeDoneWriter(res)
+	setAPIResponseInfo(dw, &apiResponseInfo{
+		recipeID: "emailpassword",
+		apiID:    "/signin",
+		tenantID: "public",
+		render: func(response APIResponse, userContext UserContext) (APIResponse, error) {
+			rendered = response
+			body := response.Body.(map[string]interface{})
+			body["requestId"] = "abc"
+			response.Body = body
+			return response, nil
+		},
+	})
+
+	err := Send200ResponseForAPIResult(dw, signInResponseForTest{UserID: "user"}, map[string]interface{}{"status": "OK"})
+	assert.NoError(t, err)
+	assert.Equal(t, "emailpassword", rendered.RecipeID)
+	assert.Equal(t, "/signin", rendered.APIID)
+	assert.Equal(t, "public", rendered.TenantID)
+	assert.Equal(t, signInResponseForTest{UserID: "user"}, rendered.Result)
+	assert.JSONEq(t, `{"status":"OK","requestId":"abc"}`, res.Body.String())
+}
+
+func TestRenderAPIResponseCanChangeTheFormatOfErrors(t *testing.T) {
+	res := httptest.NewRecorder()
+	dw := MakeDoneWriter(res)
+	setAPIResponseInfo(dw, &apiResponseInfo{
+		recipeID: "session",
+		apiID:    "/session/refresh",
+		tenantID: "public",
+		render: func(response APIResponse, userContext UserContext) (APIResponse, error) {
+			if response.StatusCode < 300 {
+				return response, nil
+			}
+			body := response.Body.(map[string]interface{})
+			return APIResponse{
+				StatusCode:  response.StatusCode,
+				ContentType: "application/problem+json",
+				Body: map[string]interface{}{
+					"title":  body["message"],
+					"status": response.StatusCode,
+				},
+			}, nil
+		},
+	})
+
+	err := SendNon200ResponseWithMessage(dw, "unauthorised", 401)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, res.Code)
+	assert.Equal(t, "application/problem+json", res.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"title":"unauthorised","status":401}`, res.Body.String())
+
+	// nothing is sent once a response has been sent
+	err = SendNon200ResponseWithMessage(dw, "unauthorised", 401)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"title":"unauthorised","status":401}`, res.Body.String())
+}
@@ -18,6 +18,8 @@ package supertokens
 const (
 	HeaderRID = "rid"
 	HeaderFDI = "fdi-version"
+
+	HeaderRequestId = "X-Request-Id"
 )
 
 // VERSION current version of the lib
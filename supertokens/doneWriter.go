@@ -61,7 +61,8 @@ func MakeDoneWriter(w http.ResponseWriter) DoneWriter {
 
 type basicWriter struct {
 	http.ResponseWriter
-	done bool
+	done            bool
+	apiResponseInfo *apiResponseInfo
 }
 
 func (w *basicWriter) Write(b []byte) (int, error) {
@@ -0,0 +1,216 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package supertokens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Instance is a SuperTokens instance created using New. Unlike the instance initialised using Init, it
+// has its own core connection and recipe list, so several instances can be used in one process (for
+// example to serve two apps that use different cores).
+//
+// Recipe functions (like session.GetSession) resolve the instance to use from the userContext. All
+// APIs handled by Instance.Middleware pass a userContext that is bound to the instance. When calling
+// recipe functions outside of those APIs, pass a userContext returned by Instance.BindUserContext.
+type Instance struct {
+	st *superTokens
+}
+
+var numberOfInstancesCreated int32 = 0
+
+// createdInstances are the instances that Shutdown releases the resources of
+var createdInstances []*superTokens
+var instancesLock sync.Mutex
+
+// New creates an independent SuperTokens instance. It does not affect the instance initialised using
+// Init, and can be called any number of times. Debug, Logger and Instrumentation are process wide
+// settings, so they are only changed if they are set in config.
+func New(config TypeInput) (*Instance, error) {
+	st, err := newSuperTokens(config, true)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt32(&numberOfInstancesCreated, 1)
+	userContext := st.bindUserContext(nil)
+	for _, cb := range st.postInitCallbacks {
+		err := cb(userContext)
+		if err != nil {
+			return nil, err
+		}
+	}
+	st.postInitCallbacks = nil
+	instancesLock.Lock()
+	createdInstances = append(createdInstances, st)
+	instancesLock.Unlock()
+	return &Instance{st: st}, nil
+}
+
+func (i *Instance) Middleware(theirHandler http.Handler) http.Handler {
+	return i.st.middleware(theirHandler)
+}
+
+func (i *Instance) ErrorHandler(err error, req *http.Request, res http.ResponseWriter, userContext ...UserContext) error {
+	var userContextToUse UserContext = nil
+	if len(userContext) > 0 {
+		userContextToUse = userContext[0]
+	}
+	return i.st.errorHandler(err, req, res, i.st.bindUserContext(userContextToUse))
+}
+
+func (i *Instance) GetAllCORSHeaders() []string {
+	return i.st.getAllCORSHeaders()
+}
+
+// BindUserContext binds the userContext to this instance, so that recipe functions called with it use
+// the recipes and core of this instance. If userContext is nil, a new one is created.
+func (i *Instance) BindUserContext(userContext UserContext) UserContext {
+	return i.st.bindUserContext(userContext)
+}
+
+// BindContext returns a copy of ctx that is bound to this instance. A userContext that has ctx attached
+// (see SetContextInUserContext) resolves to this instance, just like requests handled by Instance.Middleware.
+func (i *Instance) BindContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, instanceContextKey{}, i.st)
+}
+
+// GetQuerier returns a querier for the core of this instance
+func (i *Instance) GetQuerier(rIDToCore string) (*Querier, error) {
+	return GetNewQuerierInstanceForAppInfoOrThrowError(i.st.AppInfo, rIDToCore)
+}
+
+// InvalidateSharedCoreCallCache is the same as the package level InvalidateSharedCoreCallCache, but for
+// the core of this instance.
+func (i *Instance) InvalidateSharedCoreCallCache(path *string) error {
+	if i.st.querier == nil {
+		return nil
+	}
+	return i.st.querier.invalidateSharedCache(path)
+}
+
+// Close releases the idle connections to the core held by this instance
+func (i *Instance) Close() {
+	if i.st.querier != nil {
+		i.st.querier.close()
+	}
+}
+
+// Shutdown stops the background work started by the recipes of this instance and closes its idle
+// connections to the core. Unlike supertokens.Shutdown, it does not wait for in flight email / SMS sends.
+func (i *Instance) Shutdown(ctx context.Context) error {
+	instancesLock.Lock()
+	for index, instance := range createdInstances {
+		if instance == i.st {
+			createdInstances = append(createdInstances[:index], createdInstances[index+1:]...)
+			break
+		}
+	}
+	instancesLock.Unlock()
+	return i.st.shutdown(ctx)
+}
+
+func (s *superTokens) bindUserContext(userContext UserContext) UserContext {
+	if userContext == nil {
+		userContext = &map[string]interface{}{}
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		defaultObj = map[string]interface{}{}
+		(*userContext)["_default"] = defaultObj
+	}
+	defaultObj["instance"] = s
+	return userContext
+}
+
+type instanceContextKey struct{}
+
+// getInstanceFromUserContext returns the instance created using New that the userContext is bound to,
+// or nil if it is not bound to any. Requests that went through Instance.Middleware carry the instance
+// in their context, so a userContext made from such a request (or its context) is bound as well.
+func getInstanceFromUserContext(userContext UserContext) *superTokens {
+	if userContext != nil {
+		defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+		if ok {
+			instance, ok := defaultObj["instance"].(*superTokens)
+			if ok {
+				return instance
+			}
+		}
+	}
+	instance, _ := getContextFromUserContext(userContext).Value(instanceContextKey{}).(*superTokens)
+	return instance
+}
+
+// IsInitialised returns true if Init has been called, or if at least one instance has been created using New
+func IsInitialised() bool {
+	return superTokensInstance != nil || atomic.LoadInt32(&numberOfInstancesCreated) > 0
+}
+
+// IsDefaultInstance returns true if appInfo belongs to the instance initialised using Init. Recipes
+// store themselves in their package level singleton for that instance, and use RegisterRecipeInstance
+// for instances created using New.
+func IsDefaultInstance(appInfo NormalisedAppinfo) bool {
+	return appInfo.instance == nil
+}
+
+// RegisterRecipeInstance stores recipe as the instance of recipeId in the instance created using New
+// that appInfo belongs to.
+func RegisterRecipeInstance(appInfo NormalisedAppinfo, recipeId string, recipe interface{}) error {
+	if appInfo.instance == nil {
+		return errors.New("RegisterRecipeInstance should only be used for instances created using supertokens.New")
+	}
+	appInfo.instance.recipeLock.Lock()
+	defer appInfo.instance.recipeLock.Unlock()
+	if _, ok := appInfo.instance.recipeInstances[recipeId]; ok {
+		return errors.New(recipeId + " recipe has already been initialised. Please check your code for bugs.")
+	}
+	appInfo.instance.recipeInstances[recipeId] = recipe
+	return nil
+}
+
+// GetRecipeInstanceFromUserContext returns the recipe registered for recipeId in the instance that the
+// userContext is bound to. isBound is false if the userContext is not bound to an instance created
+// using New, in which case the recipe's singleton should be used instead. recipe is nil if the instance
+// does not have the recipe.
+func GetRecipeInstanceFromUserContext(recipeId string, userContext ...UserContext) (recipe interface{}, isBound bool) {
+	if len(userContext) == 0 {
+		return nil, false
+	}
+	instance := getInstanceFromUserContext(userContext[0])
+	if instance == nil {
+		return nil, false
+	}
+	instance.recipeLock.RLock()
+	defer instance.recipeLock.RUnlock()
+	return instance.recipeInstances[recipeId], true
+}
+
+// AddPostInitCallbackForAppInfo is like AddPostInitCallback, but the callback is run after the instance
+// that appInfo belongs to is initialised. The userContext passed to the callback is bound to that
+// instance, so it should be used to get the instances of other recipes.
+func AddPostInitCallbackForAppInfo(appInfo NormalisedAppinfo, cb func(userContext UserContext) error) {
+	if appInfo.instance == nil {
+		AddPostInitCallback(func() error {
+			return cb(nil)
+		})
+		return
+	}
+	appInfo.instance.postInitCallbacks = append(appInfo.instance.postInitCallbacks, cb)
+}
@@ -0,0 +1,127 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package supertokens
+
+import (
+	"context"
+	"time"
+)
+
+// Span names emitted by the SDK
+const (
+	SpanMiddleware  = "supertokens.middleware"
+	SpanAPIRequest  = "supertokens.api_request"
+	SpanCoreRequest = "supertokens.core_request"
+)
+
+// Metric names emitted by the SDK
+const (
+	MetricSignIns              = "supertokens.sign_ins"
+	MetricSessionRefreshes     = "supertokens.session_refreshes"
+	MetricTokenTheftDetections = "supertokens.token_theft_detections"
+	MetricCoreErrors           = "supertokens.core_errors"
+	MetricCoreRequestDuration  = "supertokens.core_request_duration_ms"
+	MetricAPIRequestDuration   = "supertokens.api_request_duration_ms"
+)
+
+// Instrumentation can be passed in TypeInput.Instrumentation to receive spans and metrics from the SDK.
+// It is intentionally independent of any tracing library so that it can be backed by OpenTelemetry
+// (or anything else) without the SDK depending on it.
+type Instrumentation interface {
+	// StartSpan starts a span as a child of any span in ctx and returns a context containing the new span.
+	StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, Span)
+	AddToCounter(ctx context.Context, name string, value int64, attributes map[string]interface{})
+	RecordHistogram(ctx context.Context, name string, value float64, attributes map[string]interface{})
+}
+
+type Span interface {
+	SetAttributes(attributes map[string]interface{})
+	RecordError(err error)
+	End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attributes map[string]interface{}) {}
+func (noopSpan) RecordError(err error)                           {}
+func (noopSpan) End()                                            {}
+
+var instrumentation Instrumentation = nil
+
+// userContextSpan makes the context of the span available through the userContext till the span ends,
+// so that spans started further down (for example for core requests) are nested under it.
+type userContextSpan struct {
+	Span
+	userContext     UserContext
+	previousContext interface{}
+}
+
+func (s *userContextSpan) End() {
+	s.Span.End()
+	defaultObj, ok := (*s.userContext)["_default"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if s.previousContext == nil {
+		delete(defaultObj, "context")
+	} else {
+		defaultObj["context"] = s.previousContext
+	}
+}
+
+// StartSpan starts a span using the configured instrumentation. Till the span is ended, the context of
+// the span is attached to the userContext. If no instrumentation is configured, this is a no-op.
+func StartSpan(userContext UserContext, name string, attributes map[string]interface{}) Span {
+	if instrumentation == nil {
+		return noopSpan{}
+	}
+	ctx, span := instrumentation.StartSpan(getContextFromUserContext(userContext), name, attributes)
+	if span == nil {
+		span = noopSpan{}
+	}
+	if userContext == nil {
+		return span
+	}
+	var previousContext interface{} = nil
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if ok {
+		previousContext = defaultObj["context"]
+	}
+	SetContextInUserContext(userContext, ctx)
+	return &userContextSpan{
+		Span:            span,
+		userContext:     userContext,
+		previousContext: previousContext,
+	}
+}
+
+func AddToCounter(userContext UserContext, name string, value int64, attributes map[string]interface{}) {
+	if instrumentation == nil {
+		return
+	}
+	instrumentation.AddToCounter(getContextFromUserContext(userContext), name, value, attributes)
+}
+
+func RecordHistogram(userContext UserContext, name string, value float64, attributes map[string]interface{}) {
+	if instrumentation == nil {
+		return
+	}
+	instrumentation.RecordHistogram(getContextFromUserContext(userContext), name, value, attributes)
+}
+
+func millisecondsSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
@@ -1,10 +1,14 @@
 package supertokens
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -20,14 +24,120 @@ var (
 	DebugEnabled = false
 )
 
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// StructuredLogger is a levelled logger that accepts alternating key / value pairs after the message.
+// *slog.Logger satisfies this interface, so it can be passed as is in TypeInput.Logger.
+type StructuredLogger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// contextStructuredLogger is implemented by loggers (like *slog.Logger) that can read values from a
+// context.Context. If the configured logger implements it, the context of the request being handled
+// is passed along so that SDK logs can be correlated with the rest of the request's logs.
+type contextStructuredLogger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+var structuredLogger StructuredLogger = nil
+
 func formatMessage(message string) string {
 	_, file, line, _ := runtime.Caller(2)
 	return fmt.Sprintf(" {t: \"%s\", message: \"%s\", file: \"%s:%d\" sdkVer: \"%s\"}\n\n", time.Now().Format(time.RFC3339), message, file, line, VERSION)
 }
 
-func LogDebugMessage(message string) {
+func isDebugLoggingEnabled() bool {
 	_, exists := os.LookupEnv("SUPERTOKENS_DEBUG")
-	if exists || DebugEnabled == true {
+	return exists || DebugEnabled == true
+}
+
+func LogDebugMessage(message string) {
+	if structuredLogger != nil {
+		structuredLogger.Debug(message, "sdkVer", VERSION)
+		return
+	}
+	if isDebugLoggingEnabled() {
 		Logger.Printf(formatMessage(message))
 	}
 }
+
+// LogMessage logs a message along with structured key / value pairs (for example "recipeId", "session").
+// If a logger was passed in TypeInput.Logger, it is used for all levels and the request ID stored in
+// the userContext (if any) is added to the fields. Otherwise, the message is written to the debug
+// logger only if debug logging is enabled.
+func LogMessage(level LogLevel, userContext UserContext, message string, keysAndValues ...interface{}) {
+	if structuredLogger == nil {
+		if isDebugLoggingEnabled() {
+			Logger.Printf(formatMessage(message + formatLogFields(keysAndValues)))
+		}
+		return
+	}
+
+	fields := []interface{}{"sdkVer", VERSION}
+	requestId := getRequestIdFromUserContext(userContext)
+	if requestId != "" {
+		fields = append(fields, "requestId", requestId)
+	}
+	fields = append(fields, keysAndValues...)
+
+	if ctxLogger, ok := structuredLogger.(contextStructuredLogger); ok {
+		ctx := getContextFromUserContext(userContext)
+		switch level {
+		case LogLevelDebug:
+			ctxLogger.DebugContext(ctx, message, fields...)
+		case LogLevelInfo:
+			ctxLogger.InfoContext(ctx, message, fields...)
+		case LogLevelWarn:
+			ctxLogger.WarnContext(ctx, message, fields...)
+		default:
+			ctxLogger.ErrorContext(ctx, message, fields...)
+		}
+		return
+	}
+
+	switch level {
+	case LogLevelDebug:
+		structuredLogger.Debug(message, fields...)
+	case LogLevelInfo:
+		structuredLogger.Info(message, fields...)
+	case LogLevelWarn:
+		structuredLogger.Warn(message, fields...)
+	default:
+		structuredLogger.Error(message, fields...)
+	}
+}
+
+func formatLogFields(keysAndValues []interface{}) string {
+	if len(keysAndValues) == 0 {
+		return ""
+	}
+	parts := []string{}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 < len(keysAndValues) {
+			parts = append(parts, fmt.Sprintf("%v=%v", keysAndValues[i], keysAndValues[i+1]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%v", keysAndValues[i]))
+		}
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// HashForLogging returns a short, non reversible representation of a sensitive value (like a session
+// handle) so that it can be used to correlate log lines without leaking the value itself.
+func HashForLogging(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])[:16]
+}
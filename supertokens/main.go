@@ -16,6 +16,7 @@
 package supertokens
 
 import (
+	"context"
 	"net/http"
 )
 
@@ -42,7 +43,7 @@ func Middleware(theirHandler http.Handler) http.Handler {
 }
 
 func ErrorHandler(err error, req *http.Request, res http.ResponseWriter, userContext ...UserContext) error {
-	instance, instanceErr := GetInstanceOrThrowError()
+	instance, instanceErr := GetInstanceOrThrowError(userContext...)
 	if instanceErr != nil {
 		return instanceErr
 	}
@@ -60,7 +61,7 @@ func GetAllCORSHeaders() []string {
 	return instance.getAllCORSHeaders()
 }
 
-func GetUserCount(includeRecipeIds *[]string, tenantId *string) (float64, error) {
+func GetUserCount(includeRecipeIds *[]string, tenantId *string, userContext ...UserContext) (float64, error) {
 	var includeAllTenants *bool
 	if tenantId == nil {
 		defaultTenantId := DefaultTenantId
@@ -68,21 +69,25 @@ func GetUserCount(includeRecipeIds *[]string, tenantId *string) (float64, error)
 		True := true
 		includeAllTenants = &True
 	}
-	return getUserCount(includeRecipeIds, *tenantId, includeAllTenants)
+	return getUserCount(includeRecipeIds, *tenantId, includeAllTenants, userContext...)
 }
 
-func GetUsersOldestFirst(tenantId string, paginationToken *string, limit *int, includeRecipeIds *[]string, query map[string]string) (UserPaginationResult, error) {
-	return GetUsersWithSearchParams(tenantId, "ASC", paginationToken, limit, includeRecipeIds, query)
+func GetUsersOldestFirst(tenantId string, paginationToken *string, limit *int, includeRecipeIds *[]string, query map[string]string, userContext ...UserContext) (UserPaginationResult, error) {
+	return GetUsersWithSearchParams(tenantId, "ASC", paginationToken, limit, includeRecipeIds, query, userContext...)
 }
 
-func GetUsersNewestFirst(tenantId string, paginationToken *string, limit *int, includeRecipeIds *[]string, query map[string]string) (UserPaginationResult, error) {
-	return GetUsersWithSearchParams(tenantId, "DESC", paginationToken, limit, includeRecipeIds, query)
+func GetUsersNewestFirst(tenantId string, paginationToken *string, limit *int, includeRecipeIds *[]string, query map[string]string, userContext ...UserContext) (UserPaginationResult, error) {
+	return GetUsersWithSearchParams(tenantId, "DESC", paginationToken, limit, includeRecipeIds, query, userContext...)
 }
 
-func DeleteUser(userId string) error {
-	return deleteUser(userId)
+func DeleteUser(userId string, userContext ...UserContext) error {
+	return deleteUser(userId, userContext...)
 }
 
 func GetRequestFromUserContext(userContext UserContext) *http.Request {
 	return getRequestFromUserContext(userContext)
 }
+
+func GetContextFromUserContext(userContext UserContext) context.Context {
+	return getContextFromUserContext(userContext)
+}
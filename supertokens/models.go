@@ -17,6 +17,7 @@ package supertokens
 
 import (
 	"net/http"
+	"time"
 )
 
 type NormalisedAppinfo struct {
@@ -28,6 +29,9 @@ type NormalisedAppinfo struct {
 	APIBasePath              NormalisedURLPath
 	APIGatewayPath           NormalisedURLPath
 	WebsiteBasePath          NormalisedURLPath
+
+	// instance is set if this app info belongs to an instance created using New
+	instance *superTokens
 }
 
 type AppInfo struct {
@@ -50,6 +54,15 @@ type TypeInput struct {
 	Telemetry             *bool
 	Debug                 bool
 	OnSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)
+	// Logger receives all SDK logs along with structured fields. *slog.Logger can be used here.
+	Logger StructuredLogger
+	// Instrumentation receives spans and metrics for the middleware, recipe APIs and core requests.
+	Instrumentation Instrumentation
+	// RenderAPIResponse is called before each JSON response of the APIs exposed by SuperTokens is sent, and
+	// can change its status code, content type or body.
+	RenderAPIResponse RenderAPIResponseFunc
+	// RequestBody configures how the request bodies of the APIs exposed by SuperTokens are read.
+	RequestBody *RequestBodyConfig
 }
 
 type ConnectionInfo struct {
@@ -57,6 +70,29 @@ type ConnectionInfo struct {
 	APIKey               string
 	NetworkInterceptor   func(*http.Request, UserContext) (*http.Request, error)
 	DisableCoreCallCache bool
+	// Transport is used for every request made to the core. This can be used to plug in
+	// mTLS or tracing transports. If nil, a shared transport with keep-alive pooling is used.
+	Transport http.RoundTripper
+	// RequestTimeout is the maximum time a single request to the core can take (including
+	// reading the response body). A value of 0 means no timeout.
+	RequestTimeout time.Duration
+	// MaxIdleConnsPerHost sets the size of the keep-alive pool per core host. It is ignored
+	// if Transport is provided. Defaults to 100.
+	MaxIdleConnsPerHost int
+	// MaxRetries is the number of times a request to a core host is retried if it fails with a 429,
+	// 502, 503 or 504 status code. Retries use jittered exponential backoff. Defaults to 5.
+	MaxRetries *int
+	// RetryBaseDelay is the backoff before the first retry. It doubles for every retry. Defaults to 50ms.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the backoff between retries. Defaults to 2s.
+	RetryMaxDelay time.Duration
+	// CircuitBreakerFailureThreshold is the number of consecutive failures (network errors or 5xx
+	// status codes) after which a core host is skipped for CircuitBreakerCooldown. Defaults to 5.
+	CircuitBreakerFailureThreshold int
+	// CircuitBreakerCooldown is how long a failing core host is skipped for. Defaults to 10s.
+	CircuitBreakerCooldown time.Duration
+	// SharedCoreCallCache enables a process wide cache for selected GET requests to the core.
+	SharedCoreCallCache *SharedCoreCallCacheConfig
 }
 
 type APIHandled struct {
@@ -31,6 +31,9 @@ import (
 
 type Querier struct {
 	RIDToCore string
+	// state is set for queriers of recipes that belong to an instance created using New. If it is
+	// nil, the state is picked based on the userContext of each request.
+	state *querierState
 }
 
 type QuerierHost struct {
@@ -38,36 +41,67 @@ type QuerierHost struct {
 	BasePath NormalisedURLPath
 }
 
+// querierState holds everything needed to query the core(s) of one SuperTokens instance.
+type querierState struct {
+	initCalled     bool
+	appInfo        NormalisedAppinfo
+	hosts          []QuerierHost
+	apiKey         *string
+	apiVersion     string
+	lastTriedIndex int
+	lock           sync.Mutex
+	hostLock       sync.Mutex
+	interceptor    func(*http.Request, UserContext) (*http.Request, error)
+	globalCacheTag uint64
+	disableCache   bool
+	httpClient     *http.Client
+	retrySettings  querierRetryConfig
+	hostHealthMap  map[string]*querierHostHealth
+	sharedCache    *sharedCoreCallCache
+}
+
 var (
-	querierInitCalled     bool          = false
-	QuerierHosts          []QuerierHost = nil
-	QuerierAPIKey         *string
-	querierAPIVersion     string
-	querierLastTriedIndex int
-	querierLock           sync.Mutex
-	querierHostLock       sync.Mutex
-	querierInterceptor    func(*http.Request, UserContext) (*http.Request, error)
-	querierGlobalCacheTag uint64
-	querierDisableCache   bool
+	// QuerierHosts and QuerierAPIKey are the hosts and API key of the instance initialised using Init
+	QuerierHosts  []QuerierHost = nil
+	QuerierAPIKey *string
+
+	defaultQuerierState = &querierState{
+		retrySettings: newQuerierRetryConfig(ConnectionInfo{}),
+	}
 )
 
+const defaultQuerierMaxIdleConnsPerHost = 100
+
 func SetQuerierApiVersionForTests(version string) {
-	querierAPIVersion = version
+	defaultQuerierState.apiVersion = version
 }
 
-func (q *Querier) GetQuerierAPIVersion(userContextIn ...UserContext) (string, error) {
-	querierLock.Lock()
-	defer querierLock.Unlock()
-	if querierAPIVersion != "" {
-		return querierAPIVersion, nil
+// getState returns the state of the instance that this querier should use for a request
+func (q *Querier) getState(userContext UserContext) *querierState {
+	if q.state != nil {
+		return q.state
+	}
+	instance := getInstanceFromUserContext(userContext)
+	if instance != nil && instance.querier != nil {
+		return instance.querier
 	}
+	return defaultQuerierState
+}
 
+func (q *Querier) GetQuerierAPIVersion(userContextIn ...UserContext) (string, error) {
 	var userContext UserContext = nil
 	if len(userContextIn) > 0 {
 		userContext = userContextIn[0]
 	}
 
-	appInfo := superTokensInstance.AppInfo
+	s := q.getState(userContext)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.apiVersion != "" {
+		return s.apiVersion, nil
+	}
+
+	appInfo := s.appInfo
 	req := getRequestFromUserContext(userContext)
 	websiteDomain, err := appInfo.GetOrigin(req, userContext)
 	if err != nil {
@@ -84,26 +118,26 @@ func (q *Querier) GetQuerierAPIVersion(userContextIn ...UserContext) (string, er
 	}
 	queryString := strings.Join(queryParams, "&")
 
-	response, _, err := q.sendRequestHelper(NormalisedURLPath{value: "/apiversion"}, func(url string) (*http.Response, []byte, error) {
-		req, err := http.NewRequest("GET", url, nil)
+	response, _, err := q.sendInstrumentedRequest("GET", NormalisedURLPath{value: "/apiversion"}, func(url string) (*http.Response, []byte, error) {
+		req, err := http.NewRequestWithContext(getContextFromUserContext(userContext), "GET", url, nil)
 		if err != nil {
 			return nil, nil, err
 		}
 
 		headers := make(http.Header)
-		if QuerierAPIKey != nil {
-			headers.Set("api-key", *QuerierAPIKey)
+		if s.apiKey != nil {
+			headers.Set("api-key", *s.apiKey)
 		}
 
 		// Apply network interceptor if available
-		if querierInterceptor != nil {
+		if s.interceptor != nil {
 			interceptedReq := &http.Request{
 				URL:    req.URL,
 				Method: req.Method,
 				Header: headers,
 			}
 			interceptedReq.URL.RawQuery = queryString
-			interceptedReq, err = querierInterceptor(interceptedReq, userContext)
+			interceptedReq, err = s.interceptor(interceptedReq, userContext)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -113,10 +147,9 @@ func (q *Querier) GetQuerierAPIVersion(userContextIn ...UserContext) (string, er
 			req.Header = headers
 		}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := s.doHTTPRequest(req)
 		return resp, nil, err
-	}, len(QuerierHosts), nil)
+	}, userContext)
 
 	if err != nil {
 		return "", err
@@ -138,40 +171,75 @@ func (q *Querier) GetQuerierAPIVersion(userContextIn ...UserContext) (string, er
 		return "", errors.New("the running SuperTokens core version is not compatible with this Golang SDK. Please visit https://supertokens.io/docs/community/compatibility-table to find the right version")
 	}
 
-	querierAPIVersion = *supportedVersion
+	s.apiVersion = *supportedVersion
 
-	return querierAPIVersion, nil
+	return s.apiVersion, nil
 }
 
-func GetNewQuerierInstanceOrThrowError(rIDToCore string) (*Querier, error) {
-	if !querierInitCalled {
+// GetNewQuerierInstanceOrThrowError returns a querier for the instance initialised using Init, or for
+// the instance that the userContext is bound to (see Instance.BindUserContext).
+func GetNewQuerierInstanceOrThrowError(rIDToCore string, userContext ...UserContext) (*Querier, error) {
+	if len(userContext) > 0 {
+		instance := getInstanceFromUserContext(userContext[0])
+		if instance != nil {
+			if instance.querier == nil {
+				return nil, errors.New("please pass the Supertokens config to supertokens.New before querying the core")
+			}
+			return &Querier{RIDToCore: rIDToCore, state: instance.querier}, nil
+		}
+	}
+	if !defaultQuerierState.initCalled {
 		return nil, errors.New("please call the supertokens.init function before using SuperTokens")
 	}
 	return &Querier{RIDToCore: rIDToCore}, nil
 }
 
-func initQuerier(hosts []QuerierHost, APIKey string, interceptor func(*http.Request, UserContext) (*http.Request, error), disableCache bool) {
-	if !querierInitCalled {
-		querierInitCalled = true
+// GetNewQuerierInstanceForAppInfoOrThrowError returns a querier for the instance that appInfo belongs to.
+// Recipes should use this while being initialised, so that they query the core of their own instance.
+func GetNewQuerierInstanceForAppInfoOrThrowError(appInfo NormalisedAppinfo, rIDToCore string) (*Querier, error) {
+	if appInfo.instance == nil {
+		return GetNewQuerierInstanceOrThrowError(rIDToCore)
+	}
+	if appInfo.instance.querier == nil {
+		return nil, errors.New("please pass the Supertokens config to supertokens.New before querying the core")
+	}
+	return &Querier{RIDToCore: rIDToCore, state: appInfo.instance.querier}, nil
+}
+
+func newQuerierState(appInfo NormalisedAppinfo, hosts []QuerierHost, APIKey string, interceptor func(*http.Request, UserContext) (*http.Request, error), disableCache bool, httpClient *http.Client, retrySettings querierRetryConfig, sharedCache *sharedCoreCallCache) *querierState {
+	s := &querierState{
+		initCalled:     true,
+		appInfo:        appInfo,
+		hosts:          hosts,
+		interceptor:    interceptor,
+		globalCacheTag: GetCurrTimeInMS(),
+		disableCache:   disableCache,
+		httpClient:     httpClient,
+		retrySettings:  retrySettings,
+		sharedCache:    sharedCache,
+	}
+	if APIKey != "" {
+		s.apiKey = &APIKey
+	}
+	return s
+}
+
+func initQuerier(appInfo NormalisedAppinfo, hosts []QuerierHost, APIKey string, interceptor func(*http.Request, UserContext) (*http.Request, error), disableCache bool, httpClient *http.Client, retrySettings querierRetryConfig, sharedCache *sharedCoreCallCache) {
+	if !defaultQuerierState.initCalled {
+		defaultQuerierState = newQuerierState(appInfo, hosts, APIKey, interceptor, disableCache, httpClient, retrySettings, sharedCache)
 		QuerierHosts = hosts
-		if APIKey != "" {
-			QuerierAPIKey = &APIKey
-		}
-		querierAPIVersion = ""
-		querierLastTriedIndex = 0
-		querierInterceptor = interceptor
-		querierGlobalCacheTag = GetCurrTimeInMS()
-		querierDisableCache = disableCache
+		QuerierAPIKey = defaultQuerierState.apiKey
 	}
 }
 
 func (q *Querier) SendPostRequest(path string, data map[string]interface{}, userContext UserContext) (map[string]interface{}, error) {
+	s := q.getState(userContext)
 	q.InvalidateCoreCallCache(userContext, true)
 	nP, err := NewNormalisedURLPath(path)
 	if err != nil {
 		return nil, err
 	}
-	resp, _, err := q.sendRequestHelper(nP, func(url string) (*http.Response, []byte, error) {
+	resp, _, err := q.sendInstrumentedRequest("POST", nP, func(url string) (*http.Response, []byte, error) {
 		if data == nil {
 			data = map[string]interface{}{}
 		}
@@ -179,7 +247,7 @@ func (q *Querier) SendPostRequest(path string, data map[string]interface{}, user
 		if err != nil {
 			return nil, nil, err
 		}
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+		req, err := http.NewRequestWithContext(getContextFromUserContext(userContext), "POST", url, bytes.NewBuffer(jsonData))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -191,39 +259,40 @@ func (q *Querier) SendPostRequest(path string, data map[string]interface{}, user
 
 		req.Header.Set("content-type", "application/json; charset=utf-8")
 		req.Header.Set("cdi-version", apiVersion)
-		if QuerierAPIKey != nil {
-			req.Header.Set("api-key", *QuerierAPIKey)
+		if s.apiKey != nil {
+			req.Header.Set("api-key", *s.apiKey)
 		}
 		if nP.IsARecipePath() && q.RIDToCore != "" {
 			req.Header.Set("rid", q.RIDToCore)
 		}
 
-		if querierInterceptor != nil {
-			req, err = querierInterceptor(req, userContext)
+		if s.interceptor != nil {
+			req, err = s.interceptor(req, userContext)
 			if err != nil {
 				return nil, nil, err
 			}
 		}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := s.doHTTPRequest(req)
 		return resp, nil, err
-	}, len(QuerierHosts), nil)
+	}, userContext)
+	s.invalidateSharedCacheForWrite(nP)
 	return resp, err
 }
 
 func (q *Querier) SendDeleteRequest(path string, data map[string]interface{}, params map[string]string, userContext UserContext) (map[string]interface{}, error) {
+	s := q.getState(userContext)
 	q.InvalidateCoreCallCache(userContext, true)
 	nP, err := NewNormalisedURLPath(path)
 	if err != nil {
 		return nil, err
 	}
-	resp, _, err := q.sendRequestHelper(nP, func(url string) (*http.Response, []byte, error) {
+	resp, _, err := q.sendInstrumentedRequest("DELETE", nP, func(url string) (*http.Response, []byte, error) {
 		jsonData, err := json.Marshal(data)
 		if err != nil {
 			return nil, nil, err
 		}
-		req, err := http.NewRequest("DELETE", url, bytes.NewBuffer(jsonData))
+		req, err := http.NewRequestWithContext(getContextFromUserContext(userContext), "DELETE", url, bytes.NewBuffer(jsonData))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -242,34 +311,35 @@ func (q *Querier) SendDeleteRequest(path string, data map[string]interface{}, pa
 
 		req.Header.Set("content-type", "application/json; charset=utf-8")
 		req.Header.Set("cdi-version", apiVersion)
-		if QuerierAPIKey != nil {
-			req.Header.Set("api-key", *QuerierAPIKey)
+		if s.apiKey != nil {
+			req.Header.Set("api-key", *s.apiKey)
 		}
 		if nP.IsARecipePath() && q.RIDToCore != "" {
 			req.Header.Set("rid", q.RIDToCore)
 		}
 
-		if querierInterceptor != nil {
-			req, err = querierInterceptor(req, userContext)
+		if s.interceptor != nil {
+			req, err = s.interceptor(req, userContext)
 			if err != nil {
 				return nil, nil, err
 			}
 		}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := s.doHTTPRequest(req)
 		return resp, nil, err
-	}, len(QuerierHosts), nil)
+	}, userContext)
+	s.invalidateSharedCacheForWrite(nP)
 	return resp, err
 }
 
 func (q *Querier) SendGetRequest(path string, params map[string]string, userContext UserContext) (map[string]interface{}, error) {
+	s := q.getState(userContext)
 	nP, err := NewNormalisedURLPath(path)
 	if err != nil {
 		return nil, err
 	}
-	resp, _, err := q.sendRequestHelper(nP, func(url string) (*http.Response, []byte, error) {
-		req, err := http.NewRequest("GET", url, nil)
+	resp, _, err := q.sendInstrumentedRequest("GET", nP, func(url string) (*http.Response, []byte, error) {
+		req, err := http.NewRequestWithContext(getContextFromUserContext(userContext), "GET", url, nil)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -304,8 +374,8 @@ func (q *Querier) SendGetRequest(path string, params map[string]string, userCont
 		}
 		headers["cdi-version"] = apiVersion
 
-		if QuerierAPIKey != nil {
-			headers["api-key"] = *QuerierAPIKey
+		if s.apiKey != nil {
+			headers["api-key"] = *s.apiKey
 		}
 
 		if nP.IsARecipePath() && q.RIDToCore != "" {
@@ -339,7 +409,7 @@ func (q *Querier) SendGetRequest(path string, params map[string]string, userCont
 			}
 
 			globalCacheTag, ok := defaultContext["globalCacheTag"].(uint64)
-			if !ok || globalCacheTag != querierGlobalCacheTag {
+			if !ok || globalCacheTag != s.globalCacheTag {
 				q.InvalidateCoreCallCache(userContext, false)
 			}
 
@@ -348,30 +418,54 @@ func (q *Querier) SendGetRequest(path string, params map[string]string, userCont
 				coreCallCache = make(map[string]interface{})
 			}
 
-			if !querierDisableCache && coreCallCache[uniqueKey] != nil {
+			if !s.disableCache && coreCallCache[uniqueKey] != nil {
 				return nil, coreCallCache[uniqueKey].([]byte), nil
 			}
 		}
 
-		if querierInterceptor != nil {
-			req, err = querierInterceptor(req, userContext)
+		// the interceptor can change the request in ways that are not part of the unique key, so the
+		// shared cache is only used without one.
+		sharedCache := s.sharedCache
+		if s.interceptor != nil {
+			sharedCache = nil
+		}
+		var sharedCacheGeneration uint64
+		if sharedCache != nil {
+			sharedCachedBody, ok := sharedCache.get(nP.GetAsStringDangerous(), url+";"+uniqueKey)
+			if ok {
+				return nil, sharedCachedBody, nil
+			}
+			sharedCacheGeneration = sharedCache.getGeneration(nP.GetAsStringDangerous())
+		}
+
+		if s.interceptor != nil {
+			req, err = s.interceptor(req, userContext)
 			if err != nil {
 				return nil, nil, err
 			}
 		}
 
-		client := &http.Client{}
-		response, err := client.Do(req)
+		response, err := s.doHTTPRequest(req)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		if response.StatusCode == 200 && !querierDisableCache && userContext != nil {
+		useRequestCache := !s.disableCache && userContext != nil
+		if response.StatusCode == 200 && (useRequestCache || sharedCache != nil) {
 			defer response.Body.Close()
 			body, err := ioutil.ReadAll(response.Body)
 			if err != nil {
 				return nil, nil, err
 			}
+
+			if sharedCache != nil {
+				sharedCache.set(nP.GetAsStringDangerous(), url+";"+uniqueKey, body, sharedCacheGeneration)
+			}
+
+			if !useRequestCache {
+				return response, body, nil
+			}
+
 			defaultContext, ok := (*userContext)["_default"].(map[string]interface{})
 			if !ok {
 				defaultContext = make(map[string]interface{})
@@ -383,7 +477,7 @@ func (q *Querier) SendGetRequest(path string, params map[string]string, userCont
 			}
 			coreCallCache[uniqueKey] = body
 			defaultContext["coreCallCache"] = coreCallCache
-			defaultContext["globalCacheTag"] = querierGlobalCacheTag
+			defaultContext["globalCacheTag"] = s.globalCacheTag
 
 			(*userContext)["_default"] = defaultContext
 
@@ -393,18 +487,19 @@ func (q *Querier) SendGetRequest(path string, params map[string]string, userCont
 		}
 
 		return response, nil, nil
-	}, len(QuerierHosts), nil)
+	}, userContext)
 	return resp, err
 }
 
 func (q *Querier) SendGetRequestWithResponseHeaders(path string, params map[string]string, userContext UserContext) (map[string]interface{}, http.Header, error) {
+	s := q.getState(userContext)
 	nP, err := NewNormalisedURLPath(path)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return q.sendRequestHelper(nP, func(url string) (*http.Response, []byte, error) {
-		req, err := http.NewRequest("GET", url, nil)
+	return q.sendInstrumentedRequest("GET", nP, func(url string) (*http.Response, []byte, error) {
+		req, err := http.NewRequestWithContext(getContextFromUserContext(userContext), "GET", url, nil)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -421,38 +516,38 @@ func (q *Querier) SendGetRequestWithResponseHeaders(path string, params map[stri
 			return nil, nil, querierAPIVersionError
 		}
 		req.Header.Set("cdi-version", apiVersion)
-		if QuerierAPIKey != nil {
-			req.Header.Set("api-key", *QuerierAPIKey)
+		if s.apiKey != nil {
+			req.Header.Set("api-key", *s.apiKey)
 		}
 		if nP.IsARecipePath() && q.RIDToCore != "" {
 			req.Header.Set("rid", q.RIDToCore)
 		}
 
-		if querierInterceptor != nil {
-			req, err = querierInterceptor(req, userContext)
+		if s.interceptor != nil {
+			req, err = s.interceptor(req, userContext)
 			if err != nil {
 				return nil, nil, err
 			}
 		}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := s.doHTTPRequest(req)
 		return resp, nil, err
-	}, len(QuerierHosts), nil)
+	}, userContext)
 }
 
 func (q *Querier) SendPutRequest(path string, data map[string]interface{}, userContext UserContext) (map[string]interface{}, error) {
+	s := q.getState(userContext)
 	q.InvalidateCoreCallCache(userContext, true)
 	nP, err := NewNormalisedURLPath(path)
 	if err != nil {
 		return nil, err
 	}
-	resp, _, err := q.sendRequestHelper(nP, func(url string) (*http.Response, []byte, error) {
+	resp, _, err := q.sendInstrumentedRequest("PUT", nP, func(url string) (*http.Response, []byte, error) {
 		jsonData, err := json.Marshal(data)
 		if err != nil {
 			return nil, nil, err
 		}
-		req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+		req, err := http.NewRequestWithContext(getContextFromUserContext(userContext), "PUT", url, bytes.NewBuffer(jsonData))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -464,28 +559,29 @@ func (q *Querier) SendPutRequest(path string, data map[string]interface{}, userC
 
 		req.Header.Set("content-type", "application/json; charset=utf-8")
 		req.Header.Set("cdi-version", apiVersion)
-		if QuerierAPIKey != nil {
-			req.Header.Set("api-key", *QuerierAPIKey)
+		if s.apiKey != nil {
+			req.Header.Set("api-key", *s.apiKey)
 		}
 		if nP.IsARecipePath() && q.RIDToCore != "" {
 			req.Header.Set("rid", q.RIDToCore)
 		}
 
-		if querierInterceptor != nil {
-			req, err = querierInterceptor(req, userContext)
+		if s.interceptor != nil {
+			req, err = s.interceptor(req, userContext)
 			if err != nil {
 				return nil, nil, err
 			}
 		}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := s.doHTTPRequest(req)
 		return resp, nil, err
-	}, len(QuerierHosts), nil)
+	}, userContext)
+	s.invalidateSharedCacheForWrite(nP)
 	return resp, err
 }
 
 func (q *Querier) InvalidateCoreCallCache(userContext UserContext, updGlobalCacheTagIfNecessary bool) {
+	s := q.getState(userContext)
 	if userContext == nil {
 		// Create an empty map to avoid nil pointer dereference
 		emptyMap := make(map[string]interface{})
@@ -501,7 +597,7 @@ func (q *Querier) InvalidateCoreCallCache(userContext UserContext, updGlobalCach
 		keepCacheAlive, ok := defaultContext["keepCacheAlive"].(bool)
 		if !ok || !keepCacheAlive {
 			// Update the global cache tag to invalidate the cache
-			querierGlobalCacheTag = GetCurrTimeInMS()
+			s.globalCacheTag = GetCurrTimeInMS()
 		}
 	}
 
@@ -516,18 +612,84 @@ func (q *Querier) InvalidateCoreCallCache(userContext UserContext, updGlobalCach
 	(*userContext)["_default"] = defaultContext
 }
 
+func (s *querierState) invalidateSharedCacheForWrite(path NormalisedURLPath) {
+	if s.sharedCache != nil {
+		s.sharedCache.invalidateForWrite(path.GetAsStringDangerous())
+	}
+}
+
+// sendInstrumentedRequest sends the request using sendRequestHelper and, if instrumentation is configured,
+// wraps it in a span that records the path, final status code, number of retries and whether the
+// response came from the cache.
+func (q *Querier) sendInstrumentedRequest(method string, path NormalisedURLPath, httpRequest httpRequestFunction, userContext UserContext) (map[string]interface{}, http.Header, error) {
+	s := q.getState(userContext)
+	if instrumentation == nil {
+		return q.sendRequestHelper(method, path, httpRequest, len(s.hosts), nil, userContext)
+	}
+
+	start := time.Now()
+	attributes := map[string]interface{}{
+		"method": method,
+		"path":   path.GetAsStringDangerous(),
+	}
+	span := StartSpan(userContext, SpanCoreRequest, attributes)
+	defer span.End()
+
+	attempts := 0
+	statusCode := 0
+	cacheHit := false
+	result, headers, err := q.sendRequestHelper(method, path, func(url string) (*http.Response, []byte, error) {
+		attempts++
+		resp, body, err := httpRequest(url)
+		if resp != nil {
+			statusCode = resp.StatusCode
+		} else if err == nil && body != nil {
+			cacheHit = true
+		}
+		return resp, body, err
+	}, len(s.hosts), nil, userContext)
+
+	retries := attempts - 1
+	if retries < 0 {
+		retries = 0
+	}
+	span.SetAttributes(map[string]interface{}{
+		"status":   statusCode,
+		"retries":  retries,
+		"cacheHit": cacheHit,
+	})
+	if err != nil {
+		span.RecordError(err)
+		AddToCounter(userContext, MetricCoreErrors, 1, map[string]interface{}{
+			"method": method,
+			"path":   path.GetAsStringDangerous(),
+			"status": statusCode,
+		})
+	}
+	RecordHistogram(userContext, MetricCoreRequestDuration, millisecondsSince(start), attributes)
+
+	return result, headers, err
+}
+
 // response, body, err - body will be present if its cache, else not
 type httpRequestFunction func(url string) (*http.Response, []byte, error)
 
-func GetAllCoreUrlsForPath(path string) []string {
-	if QuerierHosts == nil {
+// GetAllCoreUrlsForPath returns the URL of the path for each core of the instance initialised using Init,
+// or of the instance that the userContext is bound to.
+func GetAllCoreUrlsForPath(path string, userContext ...UserContext) []string {
+	var userContextToUse UserContext = nil
+	if len(userContext) > 0 {
+		userContextToUse = userContext[0]
+	}
+	hosts := (&Querier{}).getState(userContextToUse).hosts
+	if hosts == nil {
 		return []string{}
 	}
 
 	normalisedPath := NormalisedURLPath{value: path}
 	result := []string{}
 
-	for _, host := range QuerierHosts {
+	for _, host := range hosts {
 		currentDomain := host.Domain.GetAsStringDangerous()
 		currentBasePath := host.BasePath.GetAsStringDangerous()
 
@@ -537,17 +699,18 @@ func GetAllCoreUrlsForPath(path string) []string {
 	return result
 }
 
-func (q *Querier) sendRequestHelper(path NormalisedURLPath, httpRequest httpRequestFunction, numberOfTries int, retryInfoMap *map[string]int) (map[string]interface{}, http.Header, error) {
+func (q *Querier) sendRequestHelper(method string, path NormalisedURLPath, httpRequest httpRequestFunction, numberOfTries int, retryInfoMap *map[string]int, userContext UserContext) (map[string]interface{}, http.Header, error) {
+	s := q.getState(userContext)
 	if numberOfTries == 0 {
 		return nil, nil, errors.New("no SuperTokens core available to query")
 	}
 
-	querierHostLock.Lock()
-	currentDomain := QuerierHosts[querierLastTriedIndex].Domain.GetAsStringDangerous()
-	currentBasePath := QuerierHosts[querierLastTriedIndex].BasePath.GetAsStringDangerous()
-	url := currentDomain + currentBasePath + path.GetAsStringDangerous()
+	s.hostLock.Lock()
+	hostIndex := s.pickHostIndex(time.Now())
+	hostKey := getQuerierHostKey(s.hosts[hostIndex])
+	url := hostKey + path.GetAsStringDangerous()
 
-	maxRetries := 5
+	maxRetries := s.retrySettings.maxRetries
 	var _retryInfoMap map[string]int
 
 	if retryInfoMap != nil {
@@ -562,18 +725,29 @@ func (q *Querier) sendRequestHelper(path NormalisedURLPath, httpRequest httpRequ
 		_retryInfoMap[url] = maxRetries
 	}
 
-	querierLastTriedIndex = (querierLastTriedIndex + 1) % len(QuerierHosts)
-	querierHostLock.Unlock()
+	s.lastTriedIndex = (hostIndex + 1) % len(s.hosts)
+	s.hostLock.Unlock()
 
 	resp, cachedBody, err := httpRequest(url)
 
 	if err != nil {
-		if strings.Contains(err.Error(), "connection refused") {
-			return q.sendRequestHelper(path, httpRequest, numberOfTries-1, &_retryInfoMap)
-		}
 		if cachedBody == nil && resp != nil {
 			resp.Body.Close()
 		}
+		transportErr := querierTransportError{}
+		if !errors.As(err, &transportErr) {
+			return nil, nil, err
+		}
+		err = transportErr.err
+		if !isQuerierHostFailure(getContextFromUserContext(userContext), err) {
+			return nil, nil, err
+		}
+		s.recordHostFailure(hostKey)
+		// requests that may have reached the core (for example, that timed out) are only sent to the next
+		// host if they are idempotent, since the core may have processed them
+		if numberOfTries > 1 && (isConnectionRefusedError(err) || isIdempotentQuerierMethod(method)) {
+			return q.sendRequestHelper(method, path, httpRequest, numberOfTries-1, &_retryInfoMap, userContext)
+		}
 		return nil, nil, err
 	}
 
@@ -588,19 +762,30 @@ func (q *Querier) sendRequestHelper(path NormalisedURLPath, httpRequest httpRequ
 			return nil, nil, err
 		}
 	}
+
+	if resp != nil {
+		if isUnhealthyStatusCode(resp.StatusCode) {
+			s.recordHostFailure(hostKey)
+		} else {
+			s.recordHostSuccess(hostKey)
+		}
+	}
+
 	if resp != nil && resp.StatusCode != 200 {
-		if resp.StatusCode == RateLimitStatusCode {
+		if isRetryableStatusCode(method, resp.StatusCode) {
 			retriesLeft := _retryInfoMap[url]
 
 			if retriesLeft > 0 {
 				_retryInfoMap[url] = retriesLeft - 1
 
 				attemptsMade := maxRetries - retriesLeft
-				delay := 10 + (250 * attemptsMade)
 
-				time.Sleep(time.Millisecond * time.Duration(delay))
+				err = sleepWithContext(getContextFromUserContext(userContext), s.getRetryDelay(attemptsMade))
+				if err != nil {
+					return nil, nil, err
+				}
 
-				return q.sendRequestHelper(path, httpRequest, numberOfTries, &_retryInfoMap)
+				return q.sendRequestHelper(method, path, httpRequest, numberOfTries, &_retryInfoMap, userContext)
 			}
 		}
 
@@ -621,10 +806,59 @@ func (q *Querier) sendRequestHelper(path NormalisedURLPath, httpRequest httpRequ
 	return finalResult, headers, nil
 }
 
+// newQuerierHTTPClient builds the client that is shared by all requests to the core. A single
+// client (and hence a single transport) is used so that keep-alive connections are reused
+// across requests instead of a new client being created for each call.
+func newQuerierHTTPClient(config ConnectionInfo) *http.Client {
+	transport := config.Transport
+	if transport == nil {
+		defaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+		maxIdleConnsPerHost := defaultQuerierMaxIdleConnsPerHost
+		if config.MaxIdleConnsPerHost > 0 {
+			maxIdleConnsPerHost = config.MaxIdleConnsPerHost
+		}
+		defaultTransport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		if defaultTransport.MaxIdleConns < maxIdleConnsPerHost {
+			defaultTransport.MaxIdleConns = maxIdleConnsPerHost
+		}
+		transport = defaultTransport
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   config.RequestTimeout,
+	}
+}
+
+// doHTTPRequest sends req to the core, and marks the errors returned by the client as transport errors, so that
+// they can be told apart from the errors of building the request
+func (s *querierState) doHTTPRequest(req *http.Request) (*http.Response, error) {
+	resp, err := s.getHTTPClient().Do(req)
+	if err != nil {
+		return resp, querierTransportError{err: err}
+	}
+	return resp, nil
+}
+
+func (s *querierState) getHTTPClient() *http.Client {
+	if s.httpClient == nil {
+		return http.DefaultClient
+	}
+	return s.httpClient
+}
+
+func (s *querierState) close() {
+	if s.httpClient != nil {
+		s.httpClient.CloseIdleConnections()
+	}
+}
+
 func ResetQuerierForTest() {
-	querierInitCalled = false
+	defaultQuerierState.close()
+	defaultQuerierState = &querierState{
+		retrySettings: newQuerierRetryConfig(ConnectionInfo{}),
+	}
 }
 
 func (q *Querier) SetApiVersionForTests(apiVersion string) {
-	querierAPIVersion = apiVersion
+	q.getState(nil).apiVersion = apiVersion
 }
@@ -0,0 +1,211 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package supertokens
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultQuerierMaxRetries                     = 5
+	defaultQuerierRetryBaseDelay                 = 50 * time.Millisecond
+	defaultQuerierRetryMaxDelay                  = 2 * time.Second
+	defaultQuerierCircuitBreakerFailureThreshold = 5
+	defaultQuerierCircuitBreakerCooldown         = 10 * time.Second
+)
+
+type querierRetryConfig struct {
+	maxRetries                     int
+	retryBaseDelay                 time.Duration
+	retryMaxDelay                  time.Duration
+	circuitBreakerFailureThreshold int
+	circuitBreakerCooldown         time.Duration
+}
+
+func newQuerierRetryConfig(config ConnectionInfo) querierRetryConfig {
+	result := querierRetryConfig{
+		maxRetries:                     defaultQuerierMaxRetries,
+		retryBaseDelay:                 defaultQuerierRetryBaseDelay,
+		retryMaxDelay:                  defaultQuerierRetryMaxDelay,
+		circuitBreakerFailureThreshold: defaultQuerierCircuitBreakerFailureThreshold,
+		circuitBreakerCooldown:         defaultQuerierCircuitBreakerCooldown,
+	}
+	if config.MaxRetries != nil && *config.MaxRetries >= 0 {
+		result.maxRetries = *config.MaxRetries
+	}
+	if config.RetryBaseDelay > 0 {
+		result.retryBaseDelay = config.RetryBaseDelay
+	}
+	if config.RetryMaxDelay > 0 {
+		result.retryMaxDelay = config.RetryMaxDelay
+	}
+	if result.retryMaxDelay < result.retryBaseDelay {
+		result.retryMaxDelay = result.retryBaseDelay
+	}
+	if config.CircuitBreakerFailureThreshold > 0 {
+		result.circuitBreakerFailureThreshold = config.CircuitBreakerFailureThreshold
+	}
+	if config.CircuitBreakerCooldown > 0 {
+		result.circuitBreakerCooldown = config.CircuitBreakerCooldown
+	}
+	return result
+}
+
+// querierHostHealth tracks consecutive failures of a core host. Once the failures reach the
+// configured threshold, the circuit is opened and the host is skipped till openUntil. After that
+// the host is tried again, and a single failure re-opens the circuit.
+type querierHostHealth struct {
+	consecutiveFailures int
+	openUntil           time.Time
+}
+
+// should be called with s.hostLock held
+func (s *querierState) getHostHealth(host string) *querierHostHealth {
+	if s.hostHealthMap == nil {
+		s.hostHealthMap = map[string]*querierHostHealth{}
+	}
+	health, ok := s.hostHealthMap[host]
+	if !ok {
+		health = &querierHostHealth{}
+		s.hostHealthMap[host] = health
+	}
+	return health
+}
+
+// pickHostIndex returns the index of the next host to query in round robin order, skipping
+// hosts whose circuit is open. If the circuit of every host is open, the host that will be
+// available the soonest is used so that requests are still attempted. Should be called with
+// s.hostLock held.
+func (s *querierState) pickHostIndex(now time.Time) int {
+	numberOfHosts := len(s.hosts)
+	fallbackIndex := s.lastTriedIndex
+	var fallbackOpenUntil time.Time
+	for i := 0; i < numberOfHosts; i++ {
+		index := (s.lastTriedIndex + i) % numberOfHosts
+		health := s.getHostHealth(getQuerierHostKey(s.hosts[index]))
+		if !now.Before(health.openUntil) {
+			return index
+		}
+		if i == 0 || health.openUntil.Before(fallbackOpenUntil) {
+			fallbackIndex = index
+			fallbackOpenUntil = health.openUntil
+		}
+	}
+	return fallbackIndex
+}
+
+func getQuerierHostKey(host QuerierHost) string {
+	return host.Domain.GetAsStringDangerous() + host.BasePath.GetAsStringDangerous()
+}
+
+func (s *querierState) recordHostSuccess(host string) {
+	s.hostLock.Lock()
+	defer s.hostLock.Unlock()
+	health := s.getHostHealth(host)
+	health.consecutiveFailures = 0
+	health.openUntil = time.Time{}
+}
+
+func (s *querierState) recordHostFailure(host string) {
+	s.hostLock.Lock()
+	defer s.hostLock.Unlock()
+	health := s.getHostHealth(host)
+	health.consecutiveFailures++
+	if health.consecutiveFailures >= s.retrySettings.circuitBreakerFailureThreshold {
+		LogDebugMessage("querier: Opening circuit for core host " + host)
+		health.openUntil = time.Now().Add(s.retrySettings.circuitBreakerCooldown)
+	}
+}
+
+// querierTransportError wraps the errors returned by the HTTP client when sending a request to the core, as
+// opposed to the errors of building the request (like those of the NetworkInterceptor), which don't say anything
+// about the health of the core host
+type querierTransportError struct {
+	err error
+}
+
+func (e querierTransportError) Error() string {
+	return e.err.Error()
+}
+
+func (e querierTransportError) Unwrap() error {
+	return e.err
+}
+
+// isQuerierHostFailure returns true if the transport error err means that the core host is unhealthy, as
+// opposed to the request being cancelled, or running out of time, because of the context of the caller.
+func isQuerierHostFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// isConnectionRefusedError returns true if the request did not reach the core, so that it is safe to send it to
+// another host, even if it is not idempotent.
+func isConnectionRefusedError(err error) bool {
+	return strings.Contains(err.Error(), "connection refused")
+}
+
+// isIdempotentQuerierMethod returns true for the methods of the core APIs that can be sent again if they may
+// have been processed. Some PUT and DELETE APIs of the core are not idempotent, so only GET is.
+func isIdempotentQuerierMethod(method string) bool {
+	return method == http.MethodGet
+}
+
+// isRetryableStatusCode returns true for status codes that the request can be retried for. A 429 means that the
+// core did not process the request. A gateway in front of the core can return 502, 503 or 504 even if the core
+// processed the request, so they are only retried for idempotent requests.
+func isRetryableStatusCode(method string, statusCode int) bool {
+	if statusCode == RateLimitStatusCode {
+		return true
+	}
+	return isIdempotentQuerierMethod(method) && (statusCode == 502 || statusCode == 503 || statusCode == 504)
+}
+
+func isUnhealthyStatusCode(statusCode int) bool {
+	return statusCode >= 500
+}
+
+// getRetryDelay returns a jittered exponential backoff delay for the given (zero based) attempt.
+// The delay is chosen uniformly between half and all of min(retryMaxDelay, retryBaseDelay * 2^attempt).
+func (s *querierState) getRetryDelay(attempt int) time.Duration {
+	delay := s.retrySettings.retryMaxDelay
+	if attempt < 32 {
+		exponentialDelay := s.retrySettings.retryBaseDelay * time.Duration(1<<uint(attempt))
+		if exponentialDelay > 0 && exponentialDelay < delay {
+			delay = exponentialDelay
+		}
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func sleepWithContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -16,6 +16,7 @@
 package supertokens
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -31,6 +32,8 @@ type RecipeModule struct {
 	HandleError                   func(err error, req *http.Request, res http.ResponseWriter, userContext UserContext) (bool, error)
 	OnSuperTokensAPIError         func(err error, req *http.Request, res http.ResponseWriter)
 	ResetForTest                  func()
+	// Shutdown is optional, and is called by supertokens.Shutdown to stop any background work started by the recipe
+	Shutdown func(ctx context.Context) error
 }
 
 func MakeRecipeModule(
@@ -0,0 +1,224 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package supertokens
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxRequestBodySize is the maximum size (in bytes) of the request bodies read by the APIs exposed
+// by SuperTokens if RequestBodyConfig.MaxSize is not set
+const DefaultMaxRequestBodySize int64 = 1 << 20
+
+type RequestBodyConfig struct {
+	// MaxSize is the maximum size (in bytes) of the request bodies read by the APIs exposed by SuperTokens.
+	// Larger bodies are rejected with a BadInputError. Defaults to DefaultMaxRequestBodySize.
+	MaxSize int64
+	// RejectUnknownFields makes the APIs reject JSON request bodies that contain fields that they do not
+	// use. It is off by default, since frontend SDKs may send fields that older backend SDKs don't know
+	// about. The size, type and duplicate field checks are always done.
+	RejectUnknownFields bool
+}
+
+func normaliseRequestBodyConfig(config *RequestBodyConfig) RequestBodyConfig {
+	result := RequestBodyConfig{
+		MaxSize: DefaultMaxRequestBodySize,
+	}
+	if config != nil {
+		if config.MaxSize > 0 {
+			result.MaxSize = config.MaxSize
+		}
+		result.RejectUnknownFields = config.RejectUnknownFields
+	}
+	return result
+}
+
+// getRequestBodyConfig returns the config of the instance handling the request, or of the default instance
+func getRequestBodyConfig(r *http.Request) RequestBodyConfig {
+	if instance, ok := r.Context().Value(instanceContextKey{}).(*superTokens); ok {
+		return instance.requestBody
+	}
+	if superTokensInstance != nil {
+		return superTokensInstance.requestBody
+	}
+	return normaliseRequestBodyConfig(nil)
+}
+
+// ReadFromRequest reads the body of the request, and resets it so that it can be read again. A
+// BadInputError is returned if the body is larger than the configured RequestBodyConfig.MaxSize.
+func ReadFromRequest(r *http.Request) ([]byte, error) {
+	return readFromRequest(r, getRequestBodyConfig(r))
+}
+
+func readFromRequest(r *http.Request, config RequestBodyConfig) ([]byte, error) {
+	if r.Body == nil {
+		return []byte{}, nil
+	}
+	buf, err := ioutil.ReadAll(io.LimitReader(r.Body, config.MaxSize+1))
+	if err != nil {
+		return buf, err
+	}
+	if int64(len(buf)) > config.MaxSize {
+		return nil, BadInputError{Msg: "Request body must not be larger than " + strconv.FormatInt(config.MaxSize, 10) + " bytes"}
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(buf))
+
+	return buf, nil
+}
+
+// ReadJSONObjectFromRequest reads the body of the request as a JSON object. A BadInputError is returned if
+// the body is not a single JSON object, if it contains duplicate fields, or (if
+// RequestBodyConfig.RejectUnknownFields is set) if it contains top level fields that are not in allowedFields.
+func ReadJSONObjectFromRequest(r *http.Request, allowedFields ...string) (map[string]interface{}, error) {
+	config := getRequestBodyConfig(r)
+	body, err := readFromRequest(r, config)
+	if err != nil {
+		return nil, err
+	}
+	err = validateJSONObject(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, BadInputError{Msg: "Request body must be a valid JSON object"}
+	}
+
+	if config.RejectUnknownFields {
+		for field := range result {
+			if !DoesSliceContainString(field, allowedFields) {
+				return nil, BadInputError{Msg: "Unknown field '" + field + "' in request body"}
+			}
+		}
+	}
+	return result, nil
+}
+
+// ReadJSONFromRequest decodes the body of the request, which must be a single JSON object, into v. A
+// BadInputError is returned if the body is not valid, contains duplicate fields, has a value of the wrong
+// type or (if RequestBodyConfig.RejectUnknownFields is set) contains fields that v does not have.
+func ReadJSONFromRequest(r *http.Request, v interface{}) error {
+	config := getRequestBodyConfig(r)
+	body, err := readFromRequest(r, config)
+	if err != nil {
+		return err
+	}
+	err = validateJSONObject(body)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if config.RejectUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	err = decoder.Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return BadInputError{Msg: "Field '" + typeErr.Field + "' in request body must be of type " + jsonTypeName(typeErr.Type.Kind().String())}
+	}
+	if strings.HasPrefix(err.Error(), "json: unknown field ") {
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), "\"")
+		return BadInputError{Msg: "Unknown field '" + field + "' in request body"}
+	}
+	return BadInputError{Msg: "Request body must be a valid JSON object"}
+}
+
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "slice", "array":
+		return "array"
+	case "map", "struct":
+		return "object"
+	}
+	return "number"
+}
+
+type jsonContainer struct {
+	// fields is nil for arrays
+	fields    map[string]struct{}
+	expectKey bool
+}
+
+// validateJSONObject checks that body is a single JSON object, and that no object in it has duplicate fields
+func validateJSONObject(body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	first, err := decoder.Token()
+	if delim, ok := first.(json.Delim); err != nil || !ok || delim != '{' {
+		return BadInputError{Msg: "Request body must be a valid JSON object"}
+	}
+
+	stack := []jsonContainer{{fields: map[string]struct{}{}, expectKey: true}}
+	for len(stack) > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return BadInputError{Msg: "Request body must be a valid JSON object"}
+		}
+		top := &stack[len(stack)-1]
+
+		delim, isDelim := token.(json.Delim)
+		if isDelim && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if top.fields != nil {
+			if top.expectKey {
+				key, _ := token.(string)
+				if _, exists := top.fields[key]; exists {
+					return BadInputError{Msg: "Duplicate field '" + key + "' in request body"}
+				}
+				top.fields[key] = struct{}{}
+				top.expectKey = false
+				continue
+			}
+			top.expectKey = true
+		}
+
+		if isDelim {
+			if delim == '{' {
+				stack = append(stack, jsonContainer{fields: map[string]struct{}{}, expectKey: true})
+			} else {
+				stack = append(stack, jsonContainer{})
+			}
+		}
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return BadInputError{Msg: "Request body must contain a single JSON object"}
+	}
+	return nil
+}
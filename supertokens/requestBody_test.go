@@ -0,0 +1,120 @@
+package supertokens
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRequestWithBodyForTest(body string, config *RequestBodyConfig) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/auth/signin", strings.NewReader(body))
+	if config != nil {
+		instance := &superTokens{requestBody: normaliseRequestBodyConfig(config)}
+		req = req.WithContext(context.WithValue(req.Context(), instanceContextKey{}, instance))
+	}
+	return req
+}
+
+func TestReadFromRequestLimitsTheBodySize(t *testing.T) {
+	req := newRequestWithBodyForTest(strings.Repeat("a", 10), &RequestBodyConfig{MaxSize: 10})
+	body, err := ReadFromRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 10), string(body))
+
+	// the body can be read again
+	body, err = ReadFromRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 10), string(body))
+
+	req = newRequestWithBodyForTest(strings.Repeat("a", 11), &RequestBodyConfig{MaxSize: 10})
+	_, err = ReadFromRequest(req)
+	assert.Equal(t, BadInputError{Msg: "Request body must not be larger than 10 bytes"}, err)
+
+	assert.Equal(t, DefaultMaxRequestBodySize, normaliseRequestBodyConfig(nil).MaxSize)
+}
+
+func TestReadJSONObjectFromRequest(t *testing.T) {
+	result, err := ReadJSONObjectFromRequest(newRequestWithBodyForTest(`{"email": "test@example.com", "nested": {"a": [1, {"b": 2}]}}`, nil), "email", "nested")
+	assert.NoError(t, err)
+	assert.Equal(t, "test@example.com", result["email"])
+
+	invalidBodies := map[string]string{
+		``:                               "Request body must be a valid JSON object",
+		`null`:                           "Request body must be a valid JSON object",
+		`[{"email": 1}]`:                 "Request body must be a valid JSON object",
+		`{"email": }`:                    "Request body must be a valid JSON object",
+		`{"email": 1`:                    "Request body must be a valid JSON object",
+		`{"email": 1} {}`:                "Request body must contain a single JSON object",
+		`{"email": 1, "email": 2}`:       "Duplicate field 'email' in request body",
+		`{"nested": [{"a": 1, "a": 1}]}`: "Duplicate field 'a' in request body",
+	}
+	for body, message := range invalidBodies {
+		_, err := ReadJSONObjectFromRequest(newRequestWithBodyForTest(body, nil), "email", "nested")
+		assert.Equal(t, BadInputError{Msg: message}, err, body)
+	}
+
+	// the same key in different objects is not a duplicate
+	_, err = ReadJSONObjectFromRequest(newRequestWithBodyForTest(`{"email": {"email": 1}, "nested": [{"email": 1}, {"email": 2}]}`, nil), "email", "nested")
+	assert.NoError(t, err)
+
+	// unknown fields are only rejected if configured
+	result, err = ReadJSONObjectFromRequest(newRequestWithBodyForTest(`{"password": "password"}`, nil), "email")
+	assert.NoError(t, err)
+	assert.Equal(t, "password", result["password"])
+
+	_, err = ReadJSONObjectFromRequest(newRequestWithBodyForTest(`{"password": "password"}`, &RequestBodyConfig{RejectUnknownFields: true}), "email")
+	assert.Equal(t, BadInputError{Msg: "Unknown field 'password' in request body"}, err)
+}
+
+func TestReadJSONFromRequest(t *testing.T) {
+	type requestBody struct {
+		Email    *string   `json:"email"`
+		Verified *bool     `json:"verified"`
+		Handles  *[]string `json:"sessionHandles"`
+	}
+
+	var body requestBody
+	err := ReadJSONFromRequest(newRequestWithBodyForTest(`{"email": "test@example.com", "verified": true}`, nil), &body)
+	assert.NoError(t, err)
+	assert.Equal(t, "test@example.com", *body.Email)
+	assert.True(t, *body.Verified)
+
+	invalidBodies := map[string]string{
+		`"email"`:                      "Request body must be a valid JSON object",
+		`{"email": 1}`:                 "Field 'email' in request body must be of type string",
+		`{"verified": "true"}`:         "Field 'verified' in request body must be of type boolean",
+		`{"sessionHandles": "handle"}`: "Field 'sessionHandles' in request body must be of type array",
+		`{"email": "a", "email": "b"}`: "Duplicate field 'email' in request body",
+	}
+	for invalidBody, message := range invalidBodies {
+		err := ReadJSONFromRequest(newRequestWithBodyForTest(invalidBody, nil), &requestBody{})
+		assert.Equal(t, BadInputError{Msg: message}, err, invalidBody)
+	}
+
+	err = ReadJSONFromRequest(newRequestWithBodyForTest(`{"email": "a", "password": 1}`, nil), &requestBody{})
+	assert.NoError(t, err)
+
+	err = ReadJSONFromRequest(newRequestWithBodyForTest(`{"email": "a", "password": 1}`, &RequestBodyConfig{RejectUnknownFields: true}), &requestBody{})
+	assert.Equal(t, BadInputError{Msg: "Unknown field 'password' in request body"}, err)
+}
+
+func FuzzValidateJSONObject(f *testing.F) {
+	for _, seed := range []string{`{}`, `{"a": [1, {"b": null}]}`, `{"a": 1, "a": 1}`, `[]`, `{"a": {"b": 1}} x`, `{"a": "\u0000"}`} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		if validateJSONObject(body) != nil {
+			return
+		}
+		// every body that is accepted must be a single valid JSON object
+		var result map[string]interface{}
+		if err := json.Unmarshal(body, &result); err != nil || result == nil {
+			t.Fatalf("%q was accepted but is not a JSON object", body)
+		}
+	})
+}
@@ -0,0 +1,335 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package supertokens
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultSharedCoreCallCacheMaxEntries = 1000
+
+// SharedCoreCallCacheConfig enables a process wide cache for GET requests to the core. Unlike the
+// per request cache (see DisableCoreCallCache), entries are shared across requests till their TTL
+// expires or till they are invalidated. The shared cache is not used if a NetworkInterceptor is set,
+// since the interceptor can change the request in ways that are not part of the cache key.
+type SharedCoreCallCacheConfig struct {
+	// Rules decide which core paths are cached and for how long. GET requests to paths that do not
+	// match any rule are never cached.
+	Rules []SharedCoreCallCacheRule
+	// Backend stores the cached responses. Defaults to an in-memory LRU cache.
+	Backend SharedCoreCallCacheBackend
+	// MaxEntries is the size of the default in-memory backend. Defaults to 1000.
+	MaxEntries int
+}
+
+type SharedCoreCallCacheRule struct {
+	// PathPattern is a regex matched against the normalised path of the GET request
+	// (for example "/public/recipe/multitenancy/tenant").
+	PathPattern string
+	TTL         time.Duration
+	// InvalidatedBy is a list of regexes. A POST, PUT or DELETE request to a core path matching any of
+	// them clears all entries cached for this rule. GET requests of this process that were sent before
+	// the write do not store their responses; those of other processes sharing the backend may, till
+	// the TTL expires.
+	InvalidatedBy []string
+}
+
+// SharedCoreCallCacheBackend stores cached core responses. It can be implemented on top of a shared
+// store so that the cache is shared across processes.
+type SharedCoreCallCacheBackend interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	DeleteByPrefix(prefix string)
+}
+
+// SharedCacheRuleForTenantConfig caches the config of tenants (as returned by multitenancy.GetTenant)
+func SharedCacheRuleForTenantConfig(ttl time.Duration) SharedCoreCallCacheRule {
+	return SharedCoreCallCacheRule{
+		PathPattern:   `^(/[^/]+)?/recipe/multitenancy/tenant$`,
+		TTL:           ttl,
+		InvalidatedBy: []string{`/recipe/multitenancy/`},
+	}
+}
+
+// SharedCacheRuleForRolePermissions caches the permissions of roles (as returned by userroles.GetPermissionsForRole)
+func SharedCacheRuleForRolePermissions(ttl time.Duration) SharedCoreCallCacheRule {
+	return SharedCoreCallCacheRule{
+		PathPattern:   `^/recipe/role/permissions$`,
+		TTL:           ttl,
+		InvalidatedBy: []string{`^/recipe/role(/|$)`},
+	}
+}
+
+type sharedCoreCallCacheRule struct {
+	pathPattern   *regexp.Regexp
+	ttl           time.Duration
+	invalidatedBy []*regexp.Regexp
+	keyPrefix     string
+	// generation is incremented every time the entries of this rule are invalidated, so that a GET
+	// that was sent before the invalidation does not write its (possibly stale) response back.
+	generation uint64
+}
+
+type sharedCoreCallCache struct {
+	// lock is held for reading while an entry is written and for writing while entries are invalidated,
+	// so that an entry is never written after an invalidation that it does not know about.
+	lock    sync.RWMutex
+	rules   []sharedCoreCallCacheRule
+	backend SharedCoreCallCacheBackend
+}
+
+// getSharedCoreCallCacheNamespace returns the part of the cache keys that separates the entries of SuperTokens
+// instances that use different cores, API keys or apps, so that they can share a backend. It is the same for
+// all processes that run the same app, so that they can share the entries.
+func getSharedCoreCallCacheNamespace(appInfo NormalisedAppinfo, connectionInfo ConnectionInfo) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		appInfo.AppName,
+		appInfo.APIDomain.GetAsStringDangerous(),
+		appInfo.APIBasePath.GetAsStringDangerous(),
+		connectionInfo.ConnectionURI,
+		connectionInfo.APIKey,
+	}, "\n")))
+	return hex.EncodeToString(hash[:16])
+}
+
+func newSharedCoreCallCache(config *SharedCoreCallCacheConfig, namespace string) (*sharedCoreCallCache, error) {
+	if config == nil {
+		return nil, nil
+	}
+	result := &sharedCoreCallCache{
+		backend: config.Backend,
+	}
+	if result.backend == nil {
+		result.backend = NewInMemorySharedCoreCallCacheBackend(config.MaxEntries)
+	}
+	for i, rule := range config.Rules {
+		if rule.TTL <= 0 {
+			return nil, errors.New("please provide a TTL greater than 0 for all rules in SharedCoreCallCache")
+		}
+		pathPattern, err := regexp.Compile(rule.PathPattern)
+		if err != nil {
+			return nil, err
+		}
+		invalidatedBy := []*regexp.Regexp{}
+		for _, pattern := range rule.InvalidatedBy {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			invalidatedBy = append(invalidatedBy, compiled)
+		}
+		result.rules = append(result.rules, sharedCoreCallCacheRule{
+			pathPattern:   pathPattern,
+			ttl:           rule.TTL,
+			invalidatedBy: invalidatedBy,
+			keyPrefix:     "st-core-cache:" + namespace + ":" + strconv.Itoa(i) + ":",
+		})
+	}
+	return result, nil
+}
+
+func (c *sharedCoreCallCache) getRule(path string) *sharedCoreCallCacheRule {
+	for i := range c.rules {
+		if c.rules[i].pathPattern.MatchString(path) {
+			return &c.rules[i]
+		}
+	}
+	return nil
+}
+
+// the unique key contains the api key, so it is hashed before being sent to the backend. It also contains the
+// host of the core, since the namespace does not say which of the hosts in the connection URI was queried.
+func (c *sharedCoreCallCache) getKey(rule *sharedCoreCallCacheRule, uniqueKey string) string {
+	hash := sha256.Sum256([]byte(uniqueKey))
+	return rule.keyPrefix + hex.EncodeToString(hash[:])
+}
+
+func (c *sharedCoreCallCache) get(path string, uniqueKey string) ([]byte, bool) {
+	rule := c.getRule(path)
+	if rule == nil {
+		return nil, false
+	}
+	return c.backend.Get(c.getKey(rule, uniqueKey))
+}
+
+// getGeneration returns the generation of the rule matching path. It must be read before the request
+// is sent to the core and passed to set once the response is received.
+func (c *sharedCoreCallCache) getGeneration(path string) uint64 {
+	rule := c.getRule(path)
+	if rule == nil {
+		return 0
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return rule.generation
+}
+
+// set stores the response only if the entries of the rule have not been invalidated since generation
+// was read.
+func (c *sharedCoreCallCache) set(path string, uniqueKey string, body []byte, generation uint64) {
+	rule := c.getRule(path)
+	if rule == nil {
+		return
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if rule.generation != generation {
+		return
+	}
+	c.backend.Set(c.getKey(rule, uniqueKey), body, rule.ttl)
+}
+
+func (c *sharedCoreCallCache) invalidateForWrite(path string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i := range c.rules {
+		rule := &c.rules[i]
+		for _, pattern := range rule.invalidatedBy {
+			if pattern.MatchString(path) {
+				rule.generation++
+				c.backend.DeleteByPrefix(rule.keyPrefix)
+				break
+			}
+		}
+	}
+}
+
+func (c *sharedCoreCallCache) invalidate(path *string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i := range c.rules {
+		rule := &c.rules[i]
+		if path == nil || rule.pathPattern.MatchString(*path) {
+			rule.generation++
+			c.backend.DeleteByPrefix(rule.keyPrefix)
+		}
+	}
+}
+
+// InvalidateSharedCoreCallCache clears the entries of the shared core call cache for all rules that
+// match the given core path, or all entries if path is nil. This is a no-op if the shared cache is
+// not enabled.
+//
+// The cache of the instance bound to the userContext is cleared. If no userContext is passed, or if
+// no instance is bound to it, the cache of the instance created using Init is cleared. Use
+// Instance.InvalidateSharedCoreCallCache to clear the cache of a specific instance.
+func InvalidateSharedCoreCallCache(path *string, userContext ...UserContext) error {
+	var uc UserContext = nil
+	if len(userContext) > 0 {
+		uc = userContext[0]
+	}
+	return (&Querier{}).getState(uc).invalidateSharedCache(path)
+}
+
+func (s *querierState) invalidateSharedCache(path *string) error {
+	if s.sharedCache == nil {
+		return nil
+	}
+	if path != nil {
+		normalisedPath, err := NewNormalisedURLPath(*path)
+		if err != nil {
+			return err
+		}
+		pathStr := normalisedPath.GetAsStringDangerous()
+		path = &pathStr
+	}
+	s.sharedCache.invalidate(path)
+	return nil
+}
+
+type inMemoryCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+type inMemorySharedCoreCallCacheBackend struct {
+	lock       sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+// NewInMemorySharedCoreCallCacheBackend returns an LRU backend that evicts the least recently used
+// entry once maxEntries entries are stored.
+func NewInMemorySharedCoreCallCacheBackend(maxEntries int) SharedCoreCallCacheBackend {
+	if maxEntries <= 0 {
+		maxEntries = defaultSharedCoreCallCacheMaxEntries
+	}
+	return &inMemorySharedCoreCallCacheBackend{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+func (b *inMemorySharedCoreCallCacheBackend) Get(key string) ([]byte, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	element, ok := b.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*inMemoryCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		b.lru.Remove(element)
+		delete(b.entries, key)
+		return nil, false
+	}
+	b.lru.MoveToFront(element)
+	return entry.value, true
+}
+
+func (b *inMemorySharedCoreCallCacheBackend) Set(key string, value []byte, ttl time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if element, ok := b.entries[key]; ok {
+		entry := element.Value.(*inMemoryCacheEntry)
+		entry.value = value
+		entry.expiresAt = time.Now().Add(ttl)
+		b.lru.MoveToFront(element)
+		return
+	}
+	b.entries[key] = b.lru.PushFront(&inMemoryCacheEntry{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	})
+	for b.lru.Len() > b.maxEntries {
+		oldest := b.lru.Back()
+		b.lru.Remove(oldest)
+		delete(b.entries, oldest.Value.(*inMemoryCacheEntry).key)
+	}
+}
+
+func (b *inMemorySharedCoreCallCacheBackend) DeleteByPrefix(prefix string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for key, element := range b.entries {
+		if strings.HasPrefix(key, prefix) {
+			b.lru.Remove(element)
+			delete(b.entries, key)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package supertokens
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemorySharedCoreCallCacheBackendEvictsLeastRecentlyUsed(t *testing.T) {
+	backend := NewInMemorySharedCoreCallCacheBackend(2)
+
+	backend.Set("a", []byte("1"), time.Minute)
+	backend.Set("b", []byte("2"), time.Minute)
+
+	// using a makes b the least recently used entry
+	value, ok := backend.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	backend.Set("c", []byte("3"), time.Minute)
+
+	_, ok = backend.Get("b")
+	assert.False(t, ok)
+	_, ok = backend.Get("a")
+	assert.True(t, ok)
+	_, ok = backend.Get("c")
+	assert.True(t, ok)
+}
+
+func TestInMemorySharedCoreCallCacheBackendExpiresEntries(t *testing.T) {
+	backend := NewInMemorySharedCoreCallCacheBackend(0)
+
+	backend.Set("a", []byte("1"), 50*time.Millisecond)
+	_, ok := backend.Get("a")
+	assert.True(t, ok)
+
+	time.Sleep(100 * time.Millisecond)
+	_, ok = backend.Get("a")
+	assert.False(t, ok)
+}
+
+func TestInMemorySharedCoreCallCacheBackendDeleteByPrefix(t *testing.T) {
+	backend := NewInMemorySharedCoreCallCacheBackend(100)
+
+	for i := 0; i < 5; i++ {
+		backend.Set("rule:0:"+strconv.Itoa(i), []byte("value"), time.Minute)
+		backend.Set("rule:1:"+strconv.Itoa(i), []byte("value"), time.Minute)
+	}
+
+	backend.DeleteByPrefix("rule:0:")
+
+	for i := 0; i < 5; i++ {
+		_, ok := backend.Get("rule:0:" + strconv.Itoa(i))
+		assert.False(t, ok)
+		_, ok = backend.Get("rule:1:" + strconv.Itoa(i))
+		assert.True(t, ok)
+	}
+}
+
+func TestSharedCoreCallCacheRules(t *testing.T) {
+	cache, err := newSharedCoreCallCache(&SharedCoreCallCacheConfig{
+		Rules: []SharedCoreCallCacheRule{
+			SharedCacheRuleForTenantConfig(time.Minute),
+			SharedCacheRuleForRolePermissions(time.Minute),
+		},
+	}, "app")
+	assert.NoError(t, err)
+
+	cache.set("/public/recipe/multitenancy/tenant", "tenant-public", []byte("public"), 0)
+	cache.set("/recipe/role/permissions", "role-admin", []byte("admin"), 0)
+	cache.set("/recipe/user", "user", []byte("user"), 0)
+
+	_, ok := cache.get("/public/recipe/multitenancy/tenant", "tenant-public")
+	assert.True(t, ok)
+	_, ok = cache.get("/recipe/role/permissions", "role-admin")
+	assert.True(t, ok)
+	// paths that don't match any rule are not cached
+	_, ok = cache.get("/recipe/user", "user")
+	assert.False(t, ok)
+
+	cache.invalidateForWrite("/recipe/role/permissions/remove")
+	_, ok = cache.get("/recipe/role/permissions", "role-admin")
+	assert.False(t, ok)
+	_, ok = cache.get("/public/recipe/multitenancy/tenant", "tenant-public")
+	assert.True(t, ok)
+
+	cache.invalidateForWrite("/recipe/multitenancy/tenant")
+	_, ok = cache.get("/public/recipe/multitenancy/tenant", "tenant-public")
+	assert.False(t, ok)
+}
+
+func TestThatResponsesOfGetsSentBeforeAnInvalidationAreNotCached(t *testing.T) {
+	cache, err := newSharedCoreCallCache(&SharedCoreCallCacheConfig{
+		Rules: []SharedCoreCallCacheRule{
+			SharedCacheRuleForTenantConfig(time.Minute),
+			SharedCacheRuleForRolePermissions(time.Minute),
+		},
+	}, "app")
+	assert.NoError(t, err)
+
+	// a GET is sent, and the permissions are changed before its response is received
+	generation := cache.getGeneration("/recipe/role/permissions")
+	tenantGeneration := cache.getGeneration("/public/recipe/multitenancy/tenant")
+	cache.invalidateForWrite("/recipe/role/permissions/remove")
+
+	cache.set("/recipe/role/permissions", "role-admin", []byte("stale"), generation)
+	_, ok := cache.get("/recipe/role/permissions", "role-admin")
+	assert.False(t, ok)
+
+	// other rules are not affected
+	cache.set("/public/recipe/multitenancy/tenant", "tenant-public", []byte("public"), tenantGeneration)
+	_, ok = cache.get("/public/recipe/multitenancy/tenant", "tenant-public")
+	assert.True(t, ok)
+
+	cache.set("/recipe/role/permissions", "role-admin", []byte("admin"), cache.getGeneration("/recipe/role/permissions"))
+	value, ok := cache.get("/recipe/role/permissions", "role-admin")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("admin"), value)
+}
+
+func TestSharedCoreCallCacheConfigValidation(t *testing.T) {
+	_, err := newSharedCoreCallCache(&SharedCoreCallCacheConfig{
+		Rules: []SharedCoreCallCacheRule{{PathPattern: "^/recipe/roles$"}},
+	}, "app")
+	assert.Error(t, err)
+
+	_, err = newSharedCoreCallCache(&SharedCoreCallCacheConfig{
+		Rules: []SharedCoreCallCacheRule{{PathPattern: "(", TTL: time.Minute}},
+	}, "app")
+	assert.Error(t, err)
+
+	cache, err := newSharedCoreCallCache(nil, "")
+	assert.NoError(t, err)
+	assert.Nil(t, cache)
+}
+
+func TestThatSharedCoreCallCacheEntriesAreSeparatedByNamespace(t *testing.T) {
+	backend := NewInMemorySharedCoreCallCacheBackend(100)
+	config := &SharedCoreCallCacheConfig{
+		Rules:   []SharedCoreCallCacheRule{SharedCacheRuleForRolePermissions(time.Minute)},
+		Backend: backend,
+	}
+	appInfo, err := NormaliseInputAppInfoOrThrowError(AppInfo{
+		AppName:       "SuperTokens",
+		WebsiteDomain: "supertokens.io",
+		APIDomain:     "api.supertokens.io",
+	})
+	assert.NoError(t, err)
+	otherAppInfo := appInfo
+	otherAppInfo.AppName = "Other"
+
+	namespace := getSharedCoreCallCacheNamespace(appInfo, ConnectionInfo{ConnectionURI: "http://localhost:3567", APIKey: "key"})
+	assert.NotEqual(t, namespace, getSharedCoreCallCacheNamespace(appInfo, ConnectionInfo{ConnectionURI: "http://localhost:3567/appid-other", APIKey: "key"}))
+	assert.NotEqual(t, namespace, getSharedCoreCallCacheNamespace(appInfo, ConnectionInfo{ConnectionURI: "http://localhost:3567", APIKey: "other"}))
+	assert.NotEqual(t, namespace, getSharedCoreCallCacheNamespace(otherAppInfo, ConnectionInfo{ConnectionURI: "http://localhost:3567", APIKey: "key"}))
+	assert.Equal(t, namespace, getSharedCoreCallCacheNamespace(appInfo, ConnectionInfo{ConnectionURI: "http://localhost:3567", APIKey: "key"}))
+
+	cache, err := newSharedCoreCallCache(config, namespace)
+	assert.NoError(t, err)
+	otherCache, err := newSharedCoreCallCache(config, getSharedCoreCallCacheNamespace(otherAppInfo, ConnectionInfo{ConnectionURI: "http://localhost:3567", APIKey: "key"}))
+	assert.NoError(t, err)
+
+	cache.set("/recipe/role/permissions", "role-admin", []byte("admin"), 0)
+	_, ok := otherCache.get("/recipe/role/permissions", "role-admin")
+	assert.False(t, ok)
+
+	otherCache.set("/recipe/role/permissions", "role-admin", []byte("other admin"), 0)
+	otherCache.invalidateForWrite("/recipe/role")
+	value, ok := cache.get("/recipe/role/permissions", "role-admin")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("admin"), value)
+}
@@ -0,0 +1,138 @@
+/* Copyright (c) 2021, VRAI Labs and/or its affiliates. All rights reserved.
+ *
+ * This software is licensed under the Apache License, Version 2.0 (the
+ * "License") as published by the Apache Software Foundation.
+ *
+ * You may not use this file except in compliance with the License. You may
+ * obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ */
+
+package supertokens
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown is returned by operations (like sending emails or SMSes) that are started after Shutdown
+// has been called
+var ErrShuttingDown = errors.New("SuperTokens is shutting down")
+
+type inFlightTracker struct {
+	lock         sync.Mutex
+	count        int
+	shuttingDown bool
+	idle         chan struct{}
+}
+
+var inFlightOperations = &inFlightTracker{}
+
+// StartInFlightOperation marks the start of an operation that Shutdown should wait for, like sending an email.
+// The returned function must be called once the operation is done. ErrShuttingDown is returned if Shutdown
+// has already been called.
+func StartInFlightOperation() (func(), error) {
+	t := inFlightOperations
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.shuttingDown {
+		return nil, ErrShuttingDown
+	}
+	t.count++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.count--
+			if t.count == 0 && t.idle != nil {
+				close(t.idle)
+				t.idle = nil
+			}
+		})
+	}, nil
+}
+
+// drain stops new operations from starting and waits for the ones in flight to finish
+func (t *inFlightTracker) drain(ctx context.Context) error {
+	t.lock.Lock()
+	t.shuttingDown = true
+	if t.count == 0 {
+		t.lock.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.lock.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown stops accepting new email / SMS sends, waits for the ones in flight to finish, stops the
+// background JWKS refreshers started by recipes and closes idle connections to the core. If ctx is done
+// before the in flight operations finish, the remaining resources are still released and ctx's error is
+// returned.
+//
+// Shutdown also releases the resources of instances created using New. To release those of a single
+// instance, use Instance.Shutdown instead.
+func Shutdown(ctx context.Context) error {
+	drainErr := inFlightOperations.drain(ctx)
+
+	var err error
+	if superTokensInstance != nil {
+		err = superTokensInstance.shutdown(ctx)
+		defaultQuerierState.close()
+	}
+	instancesLock.Lock()
+	instances := createdInstances
+	createdInstances = nil
+	instancesLock.Unlock()
+	for _, instance := range instances {
+		instanceErr := instance.shutdown(ctx)
+		if err == nil {
+			err = instanceErr
+		}
+	}
+
+	if drainErr != nil {
+		return drainErr
+	}
+	return err
+}
+
+func (s *superTokens) shutdown(ctx context.Context) error {
+	var err error
+	for _, recipeModule := range s.RecipeModules {
+		if recipeModule.Shutdown == nil {
+			continue
+		}
+		recipeErr := recipeModule.Shutdown(ctx)
+		if err == nil {
+			err = recipeErr
+		}
+	}
+	if s.querier != nil {
+		s.querier.close()
+	}
+	return err
+}
+
+func resetShutdownForTest() {
+	inFlightOperations = &inFlightTracker{}
+	instancesLock.Lock()
+	createdInstances = nil
+	instancesLock.Unlock()
+}
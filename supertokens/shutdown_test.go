@@ -0,0 +1,62 @@
+package supertokens
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownWaitsForInFlightOperations(t *testing.T) {
+	ResetForTest()
+	defer ResetForTest()
+
+	done, err := StartInFlightOperation()
+	assert.NoError(t, err)
+
+	finished := make(chan error, 1)
+	go func() {
+		finished <- Shutdown(context.Background())
+	}()
+
+	select {
+	case <-finished:
+		t.Fatal("Shutdown returned before the in flight operation finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	done()
+	// calling done more than once has no effect
+	done()
+
+	select {
+	case err := <-finished:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return after the in flight operation finished")
+	}
+}
+
+func TestOperationsStartedAfterShutdownAreRejected(t *testing.T) {
+	ResetForTest()
+	defer ResetForTest()
+
+	assert.NoError(t, Shutdown(context.Background()))
+
+	_, err := StartInFlightOperation()
+	assert.Equal(t, ErrShuttingDown, err)
+}
+
+func TestShutdownReturnsContextErrorIfOperationsDoNotFinish(t *testing.T) {
+	ResetForTest()
+	defer ResetForTest()
+
+	done, err := StartInFlightOperation()
+	assert.NoError(t, err)
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, Shutdown(ctx))
+}
@@ -16,6 +16,7 @@
 package supertokens
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -23,6 +24,8 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // This function is required to be here because calling multitenancy recipe from this module causes cyclic dependency
@@ -35,6 +38,16 @@ type superTokens struct {
 	RecipeModules         []RecipeModule
 	OnSuperTokensAPIError func(err error, req *http.Request, res http.ResponseWriter)
 	Telemetry             *bool
+
+	// the fields below are only used by instances created using New
+	isScoped          bool
+	querier           *querierState
+	recipeLock        sync.RWMutex
+	recipeInstances   map[string]interface{}
+	postInitCallbacks []func(userContext UserContext) error
+
+	renderAPIResponse RenderAPIResponseFunc
+	requestBody       RequestBodyConfig
 }
 
 // this will be set to true if this is used in a test app environment
@@ -47,14 +60,41 @@ func supertokensInit(config TypeInput) error {
 		return nil
 	}
 
-	superTokens := &superTokens{}
+	superTokens, err := newSuperTokens(config, false)
+	if err != nil {
+		return err
+	}
+	superTokensInstance = superTokens
+
+	return nil
+}
+
+// newSuperTokens builds an instance from the config. If isScoped is false, the querier state and recipe
+// singletons are the package level ones used by Init, else they are stored in the returned instance.
+func newSuperTokens(config TypeInput, isScoped bool) (*superTokens, error) {
+	superTokens := &superTokens{
+		isScoped: isScoped,
+	}
+	if isScoped {
+		superTokens.recipeInstances = map[string]interface{}{}
+	}
 
 	superTokens.OnSuperTokensAPIError = defaultOnSuperTokensAPIError
 	if config.OnSuperTokensAPIError != nil {
 		superTokens.OnSuperTokensAPIError = config.OnSuperTokensAPIError
 	}
+	superTokens.renderAPIResponse = config.RenderAPIResponse
+	superTokens.requestBody = normaliseRequestBodyConfig(config.RequestBody)
 
-	DebugEnabled = config.Debug
+	if !isScoped || config.Debug {
+		DebugEnabled = config.Debug
+	}
+	if !isScoped || config.Logger != nil {
+		structuredLogger = config.Logger
+	}
+	if !isScoped || config.Instrumentation != nil {
+		instrumentation = config.Instrumentation
+	}
 
 	LogDebugMessage("Started SuperTokens with debug logging (supertokens.Init called)")
 
@@ -77,7 +117,10 @@ func supertokensInit(config TypeInput) error {
 	var err error
 	superTokens.AppInfo, err = NormaliseInputAppInfoOrThrowError(config.AppInfo)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if isScoped {
+		superTokens.AppInfo.instance = superTokens
 	}
 
 	if config.Supertokens != nil {
@@ -87,28 +130,36 @@ func supertokensInit(config TypeInput) error {
 			for _, h := range hostList {
 				domain, err := NewNormalisedURLDomain(h)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				basePath, err := NewNormalisedURLPath(h)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				hosts = append(hosts, QuerierHost{
 					Domain:   domain,
 					BasePath: basePath,
 				})
 			}
-			initQuerier(hosts, config.Supertokens.APIKey, config.Supertokens.NetworkInterceptor, config.Supertokens.DisableCoreCallCache)
+			sharedCache, err := newSharedCoreCallCache(config.Supertokens.SharedCoreCallCache, getSharedCoreCallCacheNamespace(superTokens.AppInfo, *config.Supertokens))
+			if err != nil {
+				return nil, err
+			}
+			if isScoped {
+				superTokens.querier = newQuerierState(superTokens.AppInfo, hosts, config.Supertokens.APIKey, config.Supertokens.NetworkInterceptor, config.Supertokens.DisableCoreCallCache, newQuerierHTTPClient(*config.Supertokens), newQuerierRetryConfig(*config.Supertokens), sharedCache)
+			} else {
+				initQuerier(superTokens.AppInfo, hosts, config.Supertokens.APIKey, config.Supertokens.NetworkInterceptor, config.Supertokens.DisableCoreCallCache, newQuerierHTTPClient(*config.Supertokens), newQuerierRetryConfig(*config.Supertokens), sharedCache)
+			}
 			superTokens.SuperTokens = *config.Supertokens
 		} else {
-			return errors.New("please provide 'ConnectionURI' value. If you do not want to provide a connection URI, then set config.Supertokens to nil")
+			return nil, errors.New("please provide 'ConnectionURI' value. If you do not want to provide a connection URI, then set config.Supertokens to nil")
 		}
 	} else {
 		// TODO: Add tests for init without supertokens core.
 	}
 
 	if config.RecipeList == nil || len(config.RecipeList) == 0 {
-		return errors.New("please provide at least one recipe to the supertokens.init function call")
+		return nil, errors.New("please provide at least one recipe to the supertokens.init function call")
 	}
 
 	multitenancyFound := false
@@ -116,7 +167,7 @@ func supertokensInit(config TypeInput) error {
 	for _, elem := range config.RecipeList {
 		recipeModule, err := elem(superTokens.AppInfo, superTokens.OnSuperTokensAPIError)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		superTokens.RecipeModules = append(superTokens.RecipeModules, *recipeModule)
 
@@ -128,22 +179,29 @@ func supertokensInit(config TypeInput) error {
 	if !multitenancyFound && DefaultMultitenancyRecipe != nil {
 		recipeModule, err := DefaultMultitenancyRecipe(superTokens.AppInfo, superTokens.OnSuperTokensAPIError)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		superTokens.RecipeModules = append(superTokens.RecipeModules, *recipeModule)
 	}
 
 	superTokens.Telemetry = config.Telemetry
-	superTokensInstance = superTokens
 
-	return nil
+	return superTokens, nil
 }
 
 func defaultOnSuperTokensAPIError(err error, req *http.Request, res http.ResponseWriter) {
 	http.Error(res, err.Error(), 500)
 }
 
-func GetInstanceOrThrowError() (*superTokens, error) {
+// GetInstanceOrThrowError returns the instance initialised using Init, or the instance that the userContext
+// is bound to (see Instance.BindUserContext).
+func GetInstanceOrThrowError(userContext ...UserContext) (*superTokens, error) {
+	if len(userContext) > 0 {
+		instance := getInstanceFromUserContext(userContext[0])
+		if instance != nil {
+			return instance, nil
+		}
+	}
 	if superTokensInstance != nil {
 		return superTokensInstance, nil
 	}
@@ -156,8 +214,16 @@ func (s *superTokens) middleware(theirHandler http.Handler) http.Handler {
 		theirHandler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.isScoped {
+			r = r.WithContext(context.WithValue(r.Context(), instanceContextKey{}, s))
+		}
 		dw := MakeDoneWriter(w)
 		userContext := MakeDefaultUserContextFromAPI(r)
+		span := StartSpan(userContext, SpanMiddleware, map[string]interface{}{
+			"method": r.Method,
+			"path":   r.URL.Path,
+		})
+		defer span.End()
 		reqURL, err := NewNormalisedURLPath(r.URL.Path)
 		if err != nil {
 			err = s.errorHandler(err, r, dw, userContext)
@@ -241,8 +307,6 @@ func (s *superTokens) middleware(theirHandler http.Handler) http.Handler {
 				return
 			}
 
-			LogDebugMessage("middleware: Request being handled by recipe. ID is: " + *id)
-
 			tenantId := "public"
 
 			if GetTenantIdFuncFromUsingMultitenancyRecipe != nil {
@@ -258,7 +322,9 @@ func (s *superTokens) middleware(theirHandler http.Handler) http.Handler {
 				}
 			}
 
-			apiErr := finalMatchedRecipe.HandleAPIRequest(*id, tenantId, r, dw, theirHandler.ServeHTTP, path, method, userContext)
+			LogMessage(LogLevelDebug, userContext, "middleware: Request being handled by recipe. ID is: "+*id, "recipeId", finalMatchedRecipe.GetRecipeID(), "apiId", *id, "tenantId", tenantId)
+
+			apiErr := s.handleAPIRequestWithInstrumentation(finalMatchedRecipe, *id, tenantId, r, dw, theirHandler.ServeHTTP, path, method, userContext)
 			if apiErr != nil {
 				apiErr = s.errorHandler(apiErr, r, dw, userContext)
 				if apiErr != nil && !dw.IsDone() {
@@ -286,8 +352,8 @@ func (s *superTokens) middlewareHelperHandleWithoutRid(path NormalisedURLPath, m
 		}
 
 		if id != nil {
-			LogDebugMessage("middleware: Request being handled by recipe. ID is: " + *id)
-			err := recipeModule.HandleAPIRequest(*id, tenantId, r, dw, theirHandler.ServeHTTP, path, method, userContext)
+			LogMessage(LogLevelDebug, userContext, "middleware: Request being handled by recipe. ID is: "+*id, "recipeId", recipeModule.GetRecipeID(), "apiId", *id, "tenantId", tenantId)
+			err := s.handleAPIRequestWithInstrumentation(recipeModule, *id, tenantId, r, dw, theirHandler.ServeHTTP, path, method, userContext)
 			if err != nil {
 				err = s.errorHandler(err, r, dw, userContext)
 				if err != nil && !dw.IsDone() {
@@ -304,6 +370,34 @@ func (s *superTokens) middlewareHelperHandleWithoutRid(path NormalisedURLPath, m
 	theirHandler.ServeHTTP(dw, r)
 }
 
+func (s *superTokens) handleAPIRequestWithInstrumentation(recipeModule RecipeModule, id string, tenantId string, r *http.Request, dw DoneWriter, theirHandler http.HandlerFunc, path NormalisedURLPath, method string, userContext UserContext) error {
+	start := time.Now()
+	attributes := map[string]interface{}{
+		"recipeId": recipeModule.GetRecipeID(),
+		"apiId":    id,
+		"tenantId": tenantId,
+		"method":   method,
+	}
+	span := StartSpan(userContext, SpanAPIRequest, attributes)
+	defer span.End()
+
+	// the info is kept on the writer after the API returns, so that errors sent by the error handler are rendered too
+	setAPIResponseInfo(dw, &apiResponseInfo{
+		recipeID:    recipeModule.GetRecipeID(),
+		apiID:       id,
+		tenantID:    tenantId,
+		render:      s.renderAPIResponse,
+		userContext: userContext,
+	})
+
+	err := recipeModule.HandleAPIRequest(id, tenantId, r, dw, theirHandler, path, method, userContext)
+	if err != nil {
+		span.RecordError(err)
+	}
+	RecordHistogram(userContext, MetricAPIRequestDuration, millisecondsSince(start), attributes)
+	return err
+}
+
 func (s *superTokens) getAllCORSHeaders() []string {
 	headerMap := map[string]bool{HeaderRID: true, HeaderFDI: true}
 	for _, recipe := range s.RecipeModules {
@@ -360,9 +454,9 @@ type UserPaginationResult struct {
 }
 
 // TODO: Add tests
-func GetUsersWithSearchParams(tenantId string, timeJoinedOrder string, paginationToken *string, limit *int, includeRecipeIds *[]string, searchParams map[string]string) (UserPaginationResult, error) {
+func GetUsersWithSearchParams(tenantId string, timeJoinedOrder string, paginationToken *string, limit *int, includeRecipeIds *[]string, searchParams map[string]string, userContext ...UserContext) (UserPaginationResult, error) {
 
-	querier, err := GetNewQuerierInstanceOrThrowError("")
+	querier, err := GetNewQuerierInstanceOrThrowError("", userContext...)
 	if err != nil {
 		return UserPaginationResult{}, err
 	}
@@ -406,9 +500,9 @@ func GetUsersWithSearchParams(tenantId string, timeJoinedOrder string, paginatio
 }
 
 // TODO: Add tests
-func getUserCount(includeRecipeIds *[]string, tenantId string, includeAllTenants *bool) (float64, error) {
+func getUserCount(includeRecipeIds *[]string, tenantId string, includeAllTenants *bool, userContext ...UserContext) (float64, error) {
 
-	querier, err := GetNewQuerierInstanceOrThrowError("")
+	querier, err := GetNewQuerierInstanceOrThrowError("", userContext...)
 	if err != nil {
 		return -1, err
 	}
@@ -431,8 +525,8 @@ func getUserCount(includeRecipeIds *[]string, tenantId string, includeAllTenants
 	return resp["count"].(float64), nil
 }
 
-func deleteUser(userId string) error {
-	querier, err := GetNewQuerierInstanceOrThrowError("")
+func deleteUser(userId string, userContext ...UserContext) error {
+	querier, err := GetNewQuerierInstanceOrThrowError("", userContext...)
 	if err != nil {
 		return err
 	}
@@ -459,7 +553,10 @@ func deleteUser(userId string) error {
 
 func ResetForTest() {
 	ResetQuerierForTest()
+	structuredLogger = nil
+	instrumentation = nil
 	resetPostInitCallbackForTest()
+	resetShutdownForTest()
 	if superTokensInstance != nil {
 		for _, recipeModule := range superTokensInstance.RecipeModules {
 			recipeModule.ResetForTest()
@@ -495,3 +592,25 @@ func getRequestFromUserContext(userContext UserContext) *http.Request {
 	}
 	return requestObj
 }
+
+// getContextFromUserContext returns the context that was attached using SetContextInUserContext. If there is
+// none, the context of the request in the userContext is used (this is the case for all APIs handled by the
+// middleware), else context.Background().
+func getContextFromUserContext(userContext UserContext) context.Context {
+	if userContext != nil {
+		defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+		if ok {
+			ctx, ok := defaultObj["context"].(context.Context)
+			if ok && ctx != nil {
+				return ctx
+			}
+		}
+	}
+
+	req := getRequestFromUserContext(userContext)
+	if req != nil {
+		return req.Context()
+	}
+
+	return context.Background()
+}
@@ -21,8 +21,8 @@ type CreateUserIdMappingResult struct {
 	}
 }
 
-func CreateUserIdMapping(supertokensUserId string, externalUserId string, externalUserIdInfo *string, force *bool) (CreateUserIdMappingResult, error) {
-	querier, err := GetNewQuerierInstanceOrThrowError("")
+func CreateUserIdMapping(supertokensUserId string, externalUserId string, externalUserIdInfo *string, force *bool, userContext ...UserContext) (CreateUserIdMappingResult, error) {
+	querier, err := GetNewQuerierInstanceOrThrowError("", userContext...)
 	if err != nil {
 		return CreateUserIdMappingResult{}, err
 	}
@@ -78,9 +78,9 @@ type GetUserIdMappingResult struct {
 	UnknownMappingError *struct{}
 }
 
-func GetUserIdMapping(userId string, userIdType *UserIdType) (GetUserIdMappingResult, error) {
+func GetUserIdMapping(userId string, userIdType *UserIdType, userContext ...UserContext) (GetUserIdMappingResult, error) {
 
-	querier, err := GetNewQuerierInstanceOrThrowError("")
+	querier, err := GetNewQuerierInstanceOrThrowError("", userContext...)
 	if err != nil {
 		return GetUserIdMappingResult{}, err
 	}
@@ -131,8 +131,8 @@ type DeleteUserIdMappingResult struct {
 	}
 }
 
-func DeleteUserIdMapping(userId string, userIdType *UserIdType, force *bool) (DeleteUserIdMappingResult, error) {
-	querier, err := GetNewQuerierInstanceOrThrowError("")
+func DeleteUserIdMapping(userId string, userIdType *UserIdType, force *bool, userContext ...UserContext) (DeleteUserIdMappingResult, error) {
+	querier, err := GetNewQuerierInstanceOrThrowError("", userContext...)
 	if err != nil {
 		return DeleteUserIdMappingResult{}, err
 	}
@@ -169,8 +169,8 @@ type UpdateOrDeleteUserIdMappingInfoResult struct {
 	UnknownMappingError *struct{}
 }
 
-func UpdateOrDeleteUserIdMappingInfo(userId string, userIdType *UserIdType, externalUserIdInfo *string) (UpdateOrDeleteUserIdMappingInfoResult, error) {
-	querier, err := GetNewQuerierInstanceOrThrowError("")
+func UpdateOrDeleteUserIdMappingInfo(userId string, userIdType *UserIdType, externalUserIdInfo *string, userContext ...UserContext) (UpdateOrDeleteUserIdMappingInfoResult, error) {
+	querier, err := GetNewQuerierInstanceOrThrowError("", userContext...)
 	if err != nil {
 		return UpdateOrDeleteUserIdMappingInfoResult{}, err
 	}
@@ -16,12 +16,12 @@
 package supertokens
 
 import (
-	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"math"
 	"net/http"
 	"net/url"
@@ -176,19 +176,7 @@ func getRIDFromRequest(r *http.Request) string {
 }
 
 func Send200Response(res http.ResponseWriter, responseJson interface{}) error {
-	LogDebugMessage("Sending response to client with status code: 200")
-	dw := MakeDoneWriter(res)
-	if !dw.IsDone() {
-		res.Header().Set("Content-Type", "application/json; charset=utf-8")
-		res.WriteHeader(200)
-		bytes, err := json.Marshal(responseJson)
-		if err != nil {
-			return err
-		} else {
-			res.Write(bytes)
-		}
-	}
-	return nil
+	return sendAPIResponse(res, nil, 200, responseJson)
 }
 
 func SendHTMLResponse(res http.ResponseWriter, statusCode int, htmlString string) error {
@@ -215,18 +203,7 @@ func SendNon200Response(res http.ResponseWriter, statusCode int, body map[string
 		if statusCode < 300 {
 			return errors.New("calling SendNon200Response with status code < 300")
 		}
-
-		LogDebugMessage("Sending response to client with status code: " + strconv.Itoa(statusCode))
-
-		res.Header().Set("Content-Type", "application/json; charset=utf-8")
-		res.WriteHeader(statusCode)
-
-		bytes, err := json.Marshal(body)
-		if err != nil {
-			return err
-		} else {
-			res.Write(bytes)
-		}
+		return sendAPIResponse(res, nil, statusCode, body)
 	}
 	return nil
 }
@@ -235,18 +212,6 @@ func SendUnauthorisedAccess(res http.ResponseWriter) error {
 	return SendNon200ResponseWithMessage(res, "unauthorised access", 401)
 }
 
-func ReadFromRequest(r *http.Request) ([]byte, error) {
-	f := r.Body
-	buf, err := ioutil.ReadAll(f)
-	if err != nil {
-		return buf, err
-	}
-
-	r.Body = io.NopCloser(bytes.NewReader(buf))
-
-	return buf, nil
-}
-
 func formatOneDecimalFloat(n float64) (string, string) {
 	n = math.Floor(n*10) / 10
 	if float64(int(n)) == n {
@@ -301,7 +266,41 @@ func ErrorIfNoResponse(res http.ResponseWriter) error {
 }
 
 func MakeDefaultUserContextFromAPI(r *http.Request) UserContext {
-	return SetRequestInUserContextIfNotDefined(nil, r)
+	userContext := SetRequestInUserContextIfNotDefined(nil, r)
+	(*userContext)["_default"].(map[string]interface{})["requestId"] = getRequestIdFromRequest(r)
+	return userContext
+}
+
+// getRequestIdFromRequest returns the value of the X-Request-Id header if it was set by an upstream
+// proxy, or a new random ID otherwise. This is used to correlate all log lines for one request.
+func getRequestIdFromRequest(r *http.Request) string {
+	if r != nil {
+		requestId := r.Header.Get(HeaderRequestId)
+		if requestId != "" {
+			return requestId
+		}
+	}
+	randomBytes := make([]byte, 8)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return strconv.FormatUint(GetCurrTimeInMS(), 16)
+	}
+	return hex.EncodeToString(randomBytes)
+}
+
+func getRequestIdFromUserContext(userContext UserContext) string {
+	if userContext == nil {
+		return ""
+	}
+	defaultObj, ok := (*userContext)["_default"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	requestId, ok := defaultObj["requestId"].(string)
+	if !ok {
+		return ""
+	}
+	return requestId
 }
 
 func SetRequestInUserContextIfNotDefined(userContext *map[string]interface{}, r *http.Request) UserContext {
@@ -331,6 +330,31 @@ func SetRequestInUserContextIfNotDefined(userContext *map[string]interface{}, r
 	return &_userContext
 }
 
+// SetContextInUserContext attaches ctx to the userContext. All requests to the core that are made using
+// the returned userContext are bound to ctx, so cancelling ctx (or hitting its deadline) aborts them.
+func SetContextInUserContext(userContext UserContext, ctx context.Context) UserContext {
+	var _userContext map[string]interface{}
+
+	if userContext == nil {
+		_userContext = map[string]interface{}{}
+	} else {
+		_userContext = *userContext
+	}
+
+	defaultObj, ok := _userContext["_default"]
+
+	if !ok {
+		_userContext["_default"] = map[string]interface{}{}
+		defaultObj = _userContext["_default"]
+	}
+
+	if defaultMap, ok := defaultObj.(map[string]interface{}); ok {
+		defaultMap["context"] = ctx
+	}
+
+	return &_userContext
+}
+
 func GetTopLevelDomainForSameSiteResolution(URL string) (string, error) {
 	urlObj, err := url.Parse(URL)
 	if err != nil {
@@ -1,6 +1,8 @@
 package supertokens
 
 import (
+	"context"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -54,3 +56,23 @@ func TestGetTopLevelDomainForSameSiteResolution(t *testing.T) {
 		assert.Equal(t, val.Output, domain, val.Input)
 	}
 }
+
+type contextTestKey struct{}
+
+func TestGetContextFromUserContext(t *testing.T) {
+	assert.Equal(t, context.Background(), GetContextFromUserContext(nil))
+	assert.Equal(t, context.Background(), GetContextFromUserContext(&map[string]interface{}{}))
+
+	reqCtx := context.WithValue(context.Background(), contextTestKey{}, "request")
+	req := httptest.NewRequest("GET", "/", nil).WithContext(reqCtx)
+	userContext := MakeDefaultUserContextFromAPI(req)
+	assert.Equal(t, "request", GetContextFromUserContext(userContext).Value(contextTestKey{}))
+
+	explicitCtx := context.WithValue(context.Background(), contextTestKey{}, "explicit")
+	userContext = SetContextInUserContext(userContext, explicitCtx)
+	assert.Equal(t, "explicit", GetContextFromUserContext(userContext).Value(contextTestKey{}))
+	assert.Equal(t, req, GetRequestFromUserContext(userContext))
+
+	userContext = SetContextInUserContext(nil, explicitCtx)
+	assert.Equal(t, "explicit", GetContextFromUserContext(userContext).Value(contextTestKey{}))
+}